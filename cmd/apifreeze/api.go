@@ -0,0 +1,185 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/types"
+)
+
+// features returns the features of the exported API of pkg, in no
+// particular order.  Each is a line of the form "pkg path, desc".
+func features(pkg *types.Package) []string {
+	var fs []string
+	add := func(format string, args ...interface{}) {
+		fs = append(fs, fmt.Sprintf("pkg %s, ", pkg.Path())+fmt.Sprintf(format, args...))
+	}
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Const:
+			add("%s = %s", types.ObjectString(pkg, obj), obj.Val())
+		case *types.Var:
+			add("%s", types.ObjectString(pkg, obj))
+		case *types.Func:
+			add("func %s%s", name, signature(pkg, obj.Type().(*types.Signature)))
+		case *types.TypeName:
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				add("%s", types.ObjectString(pkg, obj))
+				continue
+			}
+			switch u := named.Underlying().(type) {
+			case *types.Struct:
+				// Fields may be added compatibly, so each is a
+				// feature of its own.
+				add("type %s struct", name)
+				for i := 0; i < u.NumFields(); i++ {
+					if f := u.Field(i); f.Exported() {
+						if f.Anonymous() {
+							add("type %s struct, embedded %s", name, types.TypeString(pkg, f.Type()))
+						} else {
+							add("type %s struct, %s %s", name, f.Name(), types.TypeString(pkg, f.Type()))
+						}
+					}
+				}
+			case *types.Interface:
+				// Methods may be neither added nor removed
+				// compatibly, so an interface is one feature.
+				var methods []string
+				for i := 0; i < u.NumMethods(); i++ {
+					m := u.Method(i)
+					methods = append(methods, m.Name()+signature(pkg, m.Type().(*types.Signature)))
+				}
+				sort.Strings(methods)
+				add("type %s interface { %s }", name, strings.Join(methods, "; "))
+			default:
+				add("type %s %s", name, types.TypeString(pkg, u))
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				m := named.Method(i)
+				if !m.Exported() {
+					continue
+				}
+				sig := m.Type().(*types.Signature)
+				recv := name
+				if _, ok := sig.Recv().Type().(*types.Pointer); ok {
+					recv = "*" + name
+				}
+				add("method (%s) %s%s", recv, m.Name(), signature(pkg, sig))
+			}
+		}
+	}
+	return fs
+}
+
+// signature returns the parameter and result types of sig, without
+// names, as in "(string, ...int) (int, error)".
+func signature(pkg *types.Package, sig *types.Signature) string {
+	var buf bytes.Buffer
+	tuple := func(t *types.Tuple, variadic bool) {
+		for i := 0; i < t.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			T := t.At(i).Type()
+			if variadic && i == t.Len()-1 {
+				buf.WriteString("...")
+				T = T.(*types.Slice).Elem()
+			}
+			types.WriteType(&buf, pkg, T)
+		}
+	}
+	buf.WriteByte('(')
+	tuple(sig.Params(), sig.Variadic())
+	buf.WriteByte(')')
+	switch res := sig.Results(); res.Len() {
+	case 0:
+	case 1:
+		buf.WriteByte(' ')
+		tuple(res, false)
+	default:
+		buf.WriteString(" (")
+		tuple(res, false)
+		buf.WriteByte(')')
+	}
+	return buf.String()
+}
+
+// featurePackage returns the import path of the package of the feature.
+func featurePackage(feature string) string {
+	feature = strings.TrimPrefix(feature, "pkg ")
+	if i := strings.Index(feature, ","); i >= 0 {
+		return feature[:i]
+	}
+	return ""
+}
+
+// sortedFeatures returns the features, sorted and without duplicates.
+func sortedFeatures(fs []string) []string {
+	fs = append([]string(nil), fs...)
+	sort.Strings(fs)
+	var result []string
+	for i, f := range fs {
+		if i == 0 || f != fs[i-1] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// compare prints to w the features of golden missing from current,
+// preceded by "-", and the features of current missing from golden,
+// preceded by "+", in order, and reports whether any of golden were
+// missing: whether current is incompatible with golden.
+func compare(w io.Writer, golden, current []string) bool {
+	have := make(map[string]bool)
+	for _, f := range current {
+		have[f] = true
+	}
+	frozen := make(map[string]bool)
+	for _, f := range golden {
+		frozen[f] = true
+	}
+	incompatible := false
+	var lines []string
+	for _, f := range sortedFeatures(golden) {
+		if !have[f] {
+			lines = append(lines, "- "+f)
+			incompatible = true
+		}
+	}
+	for _, f := range sortedFeatures(current) {
+		if !frozen[f] {
+			lines = append(lines, "+ "+f)
+		}
+	}
+	sort.Sort(byFeature(lines))
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	return incompatible
+}
+
+// byFeature orders "-" and "+" lines by feature, removals first.
+type byFeature []string
+
+func (b byFeature) Len() int { return len(b) }
+func (b byFeature) Less(i, j int) bool {
+	if b[i][2:] != b[j][2:] {
+		return b[i][2:] < b[j][2:]
+	}
+	return b[i][0] == '-'
+}
+func (b byFeature) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
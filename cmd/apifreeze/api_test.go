@@ -0,0 +1,99 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+)
+
+func check(t *testing.T, src string) *types.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("example.com/p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestFeatures(t *testing.T) {
+	pkg := check(t, `package p
+const C = 1
+var V, v int
+type File struct {
+	Name string
+	fd int
+	*Embedded
+}
+type Embedded struct{}
+func Open(name string, flags ...int) (f *File, err error)
+func (f *File) Close() error
+func (File) String() string
+func (File) private()
+type Reader interface {
+	Read(p []byte) (int, error)
+	Close() error
+}
+type Mode uint32
+func private()
+`)
+	got := strings.Join(sortedFeatures(features(pkg)), "\n")
+	const want = `pkg example.com/p, const C untyped int = 1
+pkg example.com/p, func Open(string, ...int) (*File, error)
+pkg example.com/p, method (*File) Close() error
+pkg example.com/p, method (File) String() string
+pkg example.com/p, type Embedded struct
+pkg example.com/p, type File struct
+pkg example.com/p, type File struct, Name string
+pkg example.com/p, type File struct, embedded *Embedded
+pkg example.com/p, type Mode uint32
+pkg example.com/p, type Reader interface { Close() error; Read([]byte) (int, error) }
+pkg example.com/p, var V int`
+	if got != want {
+		t.Errorf("features:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	golden := features(check(t, `package p
+type T struct{ A int }
+func F(x int) int
+func G()
+`))
+	current := features(check(t, `package p
+type T struct{ A, B int }
+func F(y int) int
+func G(...int)
+func H()
+`))
+	var buf bytes.Buffer
+	if !compare(&buf, golden, current) {
+		t.Errorf("compare reported no incompatibilities")
+	}
+	const want = `- pkg example.com/p, func G()
++ pkg example.com/p, func G(...int)
++ pkg example.com/p, func H()
++ pkg example.com/p, type T struct, B int
+`
+	if got := buf.String(); got != want {
+		t.Errorf("compare printed:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Additions alone are compatible.
+	buf.Reset()
+	if compare(&buf, current[:0], current) {
+		t.Errorf("compare reported incompatibilities for additions:\n%s", buf.String())
+	}
+}
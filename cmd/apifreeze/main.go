@@ -0,0 +1,104 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The apifreeze command records the exported API of Go packages in a
+// golden file and verifies that later versions of the packages remain
+// compatible with it.  See the Usage constant for details.
+package main // import "golang.org/x/tools/cmd/apifreeze"
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/loader"
+)
+
+var (
+	apiFile = flag.String("api", "api.txt", "the golden API file")
+	write   = flag.Bool("w", false, "write the API of the packages to the golden file instead of verifying it")
+)
+
+const Usage = `apifreeze: records and verifies the exported API of Go packages.
+
+Usage: apifreeze [-api file] [-w] package...
+
+apifreeze describes the exported API of the specified packages as a
+sorted list of features, one per line: each exported package-level
+object, method of an exported type, and exported struct field.
+
+	pkg example.com/p, const MaxSize untyped int = 1024
+	pkg example.com/p, func Open(string) (*File, error)
+	pkg example.com/p, method (*File) Close() error
+	pkg example.com/p, type File struct
+	pkg example.com/p, type File struct, Name string
+
+With -w, apifreeze writes the features to the golden file.  Otherwise it
+compares them with those the golden file records for the packages and
+prints each recorded feature that was removed or changed, preceded by
+"-", and each new feature, preceded by "+".  Removals and changes are
+incompatibilities: they may break clients of the packages.  New
+features are compatible, but the golden file should be regenerated to
+freeze them too.  Parameter names are not part of the API.
+
+The exit status is 0 if there are no incompatibilities, 1 if there
+are, and 2 on error.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, Usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var conf loader.Config
+	if _, err := conf.FromArgs(flag.Args(), false); err != nil {
+		fmt.Fprintf(os.Stderr, "apifreeze: %s\n", err)
+		os.Exit(2)
+	}
+	prog, err := conf.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apifreeze: %s\n", err)
+		os.Exit(2)
+	}
+
+	var current []string
+	paths := make(map[string]bool)
+	for _, info := range prog.InitialPackages() {
+		current = append(current, features(info.Pkg)...)
+		paths[info.Pkg.Path()] = true
+	}
+
+	if *write {
+		var buf bytes.Buffer
+		for _, f := range sortedFeatures(current) {
+			fmt.Fprintln(&buf, f)
+		}
+		if err := ioutil.WriteFile(*apiFile, buf.Bytes(), 0666); err != nil {
+			fmt.Fprintf(os.Stderr, "apifreeze: %s\n", err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(*apiFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apifreeze: %s\n", err)
+		os.Exit(2)
+	}
+	var golden []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" && paths[featurePackage(line)] {
+			golden = append(golden, line)
+		}
+	}
+	if compare(os.Stdout, golden, current) {
+		os.Exit(1)
+	}
+}
@@ -4,6 +4,7 @@
 package main // import "golang.org/x/tools/cmd/eg"
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"go/parser"
@@ -19,6 +20,7 @@ import (
 
 var (
 	beforeeditFlag = flag.String("beforeedit", "", "A command to exec before each file is edited (e.g. chmod, checkout).  Whitespace delimits argument words.  The string '{}' is replaced by the file name.")
+	findFlag       = flag.Bool("find", false, "print the occurrences of the pattern instead of replacing them")
 	helpFlag       = flag.Bool("help", false, "show detailed help message")
 	templateFlag   = flag.String("t", "", "template.go file specifying the refactoring")
 	transitiveFlag = flag.Bool("transitive", false, "apply refactoring to all dependencies too")
@@ -28,10 +30,11 @@ var (
 
 const usage = `eg: an example-based refactoring tool.
 
-Usage: eg -t template.go [-w] [-transitive] <args>...
+Usage: eg -t template.go [-w] [-find] [-transitive] <args>...
 
 -help            show detailed help message
 -t template.go	 specifies the template file (use -help to see explanation)
+-find            print the occurrences of the pattern instead of replacing them.
 -w          	 causes files to be re-written in place.
 -transitive 	 causes all dependencies to be refactored too.
 -v               show verbose matcher diagnostics
@@ -84,7 +87,11 @@ func doMain() error {
 
 	// Analyze the template.
 	template := iprog.Created[0]
-	xform, err := eg.NewTransformer(iprog.Fset, template, *verboseFlag)
+	newTransformer := eg.NewTransformer
+	if *findFlag {
+		newTransformer = eg.NewMatcher
+	}
+	xform, err := newTransformer(iprog.Fset, template, *verboseFlag)
 	if err != nil {
 		return err
 	}
@@ -104,6 +111,14 @@ func doMain() error {
 			continue
 		}
 		for _, file := range pkg.Files {
+			if *findFlag {
+				for _, m := range xform.Find(&pkg.Info, pkg.Pkg, file) {
+					var buf bytes.Buffer
+					printer.Fprint(&buf, iprog.Fset, m.Expr)
+					fmt.Printf("%s: %s\n", iprog.Fset.Position(m.Pos()), buf.String())
+				}
+				continue
+			}
 			n := xform.Transform(&pkg.Info, pkg.Pkg, file)
 			if n == 0 {
 				continue
@@ -0,0 +1,85 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"golang.org/x/tools/go/types"
+)
+
+// diff prints to w the differences between the descriptions of the
+// old and new packages, and reports whether there were any.
+func diff(w io.Writer, old, new *types.Package, sizes types.Sizes) bool {
+	olds := describe(old, sizes)
+	news := describe(new, sizes)
+
+	var keys []string
+	for key := range olds {
+		keys = append(keys, key)
+	}
+	for key := range news {
+		if _, ok := olds[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	differ := false
+	for _, key := range keys {
+		o, inOld := olds[key]
+		n, inNew := news[key]
+		if inOld && inNew && o == n {
+			continue
+		}
+		differ = true
+		if inOld {
+			fmt.Fprintf(w, "- %s\n", o)
+		}
+		if inNew {
+			fmt.Fprintf(w, "+ %s\n", n)
+		}
+	}
+	return differ
+}
+
+// describe returns the descriptions of the package-level objects of
+// pkg, the methods of its named types, and the layouts of its struct
+// types, keyed by name.
+func describe(pkg *types.Package, sizes types.Sizes) map[string]string {
+	descs := make(map[string]string)
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		desc := types.ObjectString(pkg, obj)
+		if c, ok := obj.(*types.Const); ok {
+			desc += " = " + c.Val().String()
+		}
+		descs[name] = desc
+
+		if _, ok := obj.(*types.TypeName); !ok {
+			continue
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		for i, n := 0, named.NumMethods(); i < n; i++ {
+			m := named.Method(i)
+			descs[name+"."+m.Name()] = types.ObjectString(pkg, m)
+		}
+		if s, ok := named.Underlying().(*types.Struct); ok {
+			fields := make([]*types.Var, s.NumFields())
+			for i := range fields {
+				fields[i] = s.Field(i)
+			}
+			descs[name+" layout"] = fmt.Sprintf("layout %s: size %d, align %d, offsets %v",
+				name, sizes.Sizeof(s), sizes.Alignof(s), sizes.Offsetsof(fields))
+		}
+	}
+	return descs
+}
@@ -0,0 +1,75 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/importer"
+	"golang.org/x/tools/go/types"
+)
+
+func TestDiff(t *testing.T) {
+	const oldSrc = `package p
+const C = 1
+type T struct{ a, b int32 }
+func (T) M() {}
+func F(x int)
+var V int
+`
+	const newSrc = `package p
+const C = 2
+type T struct{ a int32; b int64 }
+func (*T) M() {}
+func F(x int)
+func G()
+`
+	// Round-trip the packages through export data.
+	var pkgs [2]*types.Package
+	for i, src := range []string{oldSrc, newSrc} {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, pkgs[i], err = importer.ImportData(make(map[string]*types.Package), importer.ExportData(pkg))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	sizes := &types.StdSizes{WordSize: 8, MaxAlign: 8}
+	if !diff(&buf, pkgs[0], pkgs[1], sizes) {
+		t.Errorf("diff reported no differences")
+	}
+	const want = `- const C untyped int = 1
++ const C untyped int = 2
++ func G()
+- type T struct{a int32; b int32}
++ type T struct{a int32; b int64}
+- layout T: size 8, align 4, offsets [0 4]
++ layout T: size 16, align 8, offsets [0 8]
+- func (T).M()
++ func (*T).M()
+- var V int
+`
+	if got := buf.String(); got != want {
+		t.Errorf("diff printed:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	if diff(&buf, pkgs[0], pkgs[0], sizes) || buf.Len() > 0 {
+		t.Errorf("diff of identical packages printed:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The exportdiff command decodes two files of export data, as written
+// by golang.org/x/tools/go/importer, and prints the differences
+// between the APIs they describe.  See the Usage constant for details.
+package main // import "golang.org/x/tools/cmd/exportdiff"
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/tools/go/importer"
+	"golang.org/x/tools/go/types"
+)
+
+var wordSize = flag.Int("wordsize", 8, "word size in bytes of the target architecture, for struct layouts")
+
+const Usage = `exportdiff: prints the differences between two files of export data.
+
+Usage: exportdiff [-wordsize n] old new
+
+Each file holds the export data of a package, as written by the
+ExportData functions of golang.org/x/tools/go/importer, possibly
+embedded within other data such as an object file.
+
+For each package-level object, method, and struct layout that differs
+between the two, exportdiff prints its old description preceded by
+"-", its new description preceded by "+", or both, in order of name:
+
+	- func F(x int)
+	+ func F(x int, y int)
+	+ const MaxSize untyped int = 1024
+	- layout T: size 16, align 8, offsets [0 8]
+	+ layout T: size 24, align 8, offsets [0 8 16]
+
+Both files must have the same mode; in deep mode, unexported objects
+are compared too.  The exit status is 0 if there are no differences,
+1 if there are, and 2 on error.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, Usage) }
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var pkgs [2]*types.Package
+	for i, filename := range flag.Args() {
+		pkg, err := load(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "exportdiff: %s: %s\n", filename, err)
+			os.Exit(2)
+		}
+		pkgs[i] = pkg
+	}
+
+	sizes := &types.StdSizes{WordSize: int64(*wordSize), MaxAlign: 8}
+	if diff(os.Stdout, pkgs[0], pkgs[1], sizes) {
+		os.Exit(1)
+	}
+}
+
+// exportMagic marks the start of export data.
+const exportMagic = "\n$$ exports $$\n"
+
+// load decodes the export data in the named file.
+func load(filename string) (*types.Package, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.Index(data, []byte(exportMagic))
+	if i < 0 {
+		return nil, fmt.Errorf("no export data")
+	}
+	conf := importer.Config{Mode: importer.Deep}
+	_, pkg, err := conf.ImportData(make(map[string]*types.Package), data[i:])
+	return pkg, err
+}
@@ -19,6 +19,7 @@ import (
 	"go/build"
 	"io"
 	"log"
+	"net"
 	"os"
 	"runtime"
 	"runtime/pprof"
@@ -38,6 +39,12 @@ var formatFlag = flag.String("format", "plain", "Output format.  One of {plain,j
 
 var reflectFlag = flag.Bool("reflect", false, "Analyze reflection soundly (slow).")
 
+var callgraphFlag = flag.String("callgraph", "",
+	"In callhierarchy mode, the algorithm resolving calls of interface methods: none if empty, or cha.")
+
+var socketFlag = flag.String("socket", "",
+	"In serve mode, the Unix domain socket on which to accept connections, or empty to serve stdin.")
+
 const useHelp = "Run 'oracle -help' for more information.\n"
 
 const helpMessage = `Go source code oracle.
@@ -49,20 +56,36 @@ The -format flag controls the output format:
 	json	structured data in JSON syntax.
 	xml	structured data in XML syntax.
 
-The -pos flag is required in all modes.
+The -pos flag is required in all modes except serve.
 
 The mode argument determines the query to perform:
 
 	callees	  	show possible targets of selected function call
 	callers	  	show possible callers of selected function
 	callstack 	show path from callgraph root to selected function
+	callhierarchy	show calls of and by selected function
 	definition	show declaration of selected identifier
 	describe  	describe selected syntax: definition, methods, etc
 	freevars  	show free variables of selection
 	implements	show 'implements' relation for selected type or method
 	peers     	show send/receive corresponding to selected channel op
+	completion	show candidates for completing selected identifier
 	referrers 	show all refs to entity denoted by selected identifier
 	what		show basic information about the selected syntax node
+	serve		load the packages once and answer queries in JSON
+
+In serve mode, the oracle reads a stream of JSON requests such as
+	{"id": 1, "mode": "definition", "pos": "foo.go:#123"}
+from stdin, or from each connection to the -socket, and writes a JSON
+response with the same id and either a "result", in the format of
+-format=json, or an "error", for each.  It answers the callhierarchy,
+completion, definition, describe, freevars, implements, and referrers
+queries.
+Clients report changes to source files with requests such as
+	{"id": 2, "mode": "invalidate", "files": ["foo.go"]}
+The oracle publishes the diagnostics of each file of the packages it
+loads, at the start and after each invalidate request, as responses
+with an id of 0 and the "diagnostics" of the file.
 
 The user manual is available here:  http://golang.org/s/oracle-user-manual
 
@@ -160,6 +183,14 @@ func main() {
 		os.Exit(2)
 	}
 
+	if mode == "serve" {
+		if err := serve(args); err != nil {
+			fmt.Fprintf(os.Stderr, "oracle: %s.\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Ask the oracle.
 	query := oracle.Query{
 		Mode:       mode,
@@ -168,6 +199,7 @@ func main() {
 		Scope:      args,
 		PTALog:     ptalog,
 		Reflection: *reflectFlag,
+		CallGraph:  *callgraphFlag,
 	}
 
 	if err := oracle.Run(&query); err != nil {
@@ -197,3 +229,37 @@ func main() {
 		query.WriteTo(os.Stdout)
 	}
 }
+
+// serve loads the packages specified by args and answers queries about
+// them until stdin is exhausted or, with -socket, indefinitely.
+func serve(args []string) error {
+	conf := loader.Config{Build: &build.Default}
+	if _, err := conf.FromArgs(args, true); err != nil {
+		return err
+	}
+	server, err := oracle.NewServer(&conf)
+	if err != nil {
+		return err
+	}
+	if *socketFlag == "" {
+		return server.Serve(os.Stdin, os.Stdout)
+	}
+
+	l, err := net.Listen("unix", *socketFlag)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := server.Serve(conn, conn); err != nil {
+				log.Printf("serve: %s", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+// This file type-checks the code blocks of a package.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+)
+
+// checkBlock type-checks the code block b of the package info, and
+// returns its errors, at their positions in the file of b.  Blocks of
+// comments that do not parse have no errors.
+func checkBlock(prog *loader.Program, info *loader.PackageInfo, b *block) []error {
+	var errs []error
+	conf := types.Config{
+		Import: func(_ map[string]*types.Package, path string) (*types.Package, error) {
+			if info := prog.Package(path); info != nil {
+				return info.Pkg, nil
+			}
+			return nil, fmt.Errorf("package %q not loaded", path)
+		},
+		StubMissingImports: true,
+		Error: func(err error) {
+			if err := err.(types.Error); !err.Soft {
+				pos := b.position(err.Fset.Position(err.Pos))
+				errs = append(errs, fmt.Errorf("%s: %s", pos, err.Msg))
+			}
+		},
+	}
+
+	fset := token.NewFileSet()
+	const filename = "snippet"
+	var err error
+	if strings.HasPrefix(b.src, "package ") {
+		var f *ast.File
+		f, err = parser.ParseFile(fset, filename, b.src, 0)
+		if err == nil {
+			conf.DisableUnusedImportCheck = true
+			conf.Check("snippet", fset, []*ast.File{f}, nil)
+		}
+	} else {
+		_, err = types.CheckSnippet(&conf, fset, filename, b.src, imports(prog, info), nil)
+	}
+	if list, ok := err.(scanner.ErrorList); ok {
+		if !b.md {
+			return nil // not Go code
+		}
+		for _, e := range list {
+			errs = append(errs, fmt.Errorf("%s: %s", b.position(e.Pos), e.Msg))
+		}
+	}
+	return errs
+}
+
+// imports returns the import paths of the packages a code block of the
+// package info may refer to: the package itself, and the packages it
+// imports, but for those whose names are those of earlier packages.
+func imports(prog *loader.Program, info *loader.PackageInfo) []string {
+	paths := []string{info.Pkg.Path()}
+	names := map[string]bool{info.Pkg.Name(): true}
+	for _, imp := range info.Pkg.Imports() {
+		if !names[imp.Name()] && prog.Package(imp.Path()) != nil {
+			names[imp.Name()] = true
+			paths = append(paths, imp.Path())
+		}
+	}
+	return paths
+}
@@ -0,0 +1,226 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+// This file extracts the code blocks of doc comments and markdown files.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// A block is a code block of a doc comment or markdown file.
+type block struct {
+	src   string           // the code, unindented
+	lines []token.Position // the position in the file of each line of src
+	md    bool             // the block is a fenced markdown block
+}
+
+// position returns the position in the file of the position pos
+// within the block's src.
+func (b *block) position(pos token.Position) token.Position {
+	if pos.Line < 1 || pos.Line > len(b.lines) {
+		return b.lines[0]
+	}
+	p := b.lines[pos.Line-1]
+	p.Offset += pos.Column - 1
+	p.Column += pos.Column - 1
+	return p
+}
+
+// A line is a line of comment text and its position in the file.
+type line struct {
+	text string
+	pos  token.Position
+}
+
+// commentBlocks returns the code blocks of the doc comments of the
+// specified files.
+func commentBlocks(fset *token.FileSet, files []*ast.File) []*block {
+	var blocks []*block
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			var doc *ast.CommentGroup
+			switch n := n.(type) {
+			case *ast.File:
+				doc = n.Doc
+			case *ast.GenDecl:
+				doc = n.Doc
+			case *ast.FuncDecl:
+				doc = n.Doc
+			case *ast.ValueSpec:
+				doc = n.Doc
+			case *ast.TypeSpec:
+				doc = n.Doc
+			case *ast.Field:
+				doc = n.Doc
+			}
+			if doc != nil {
+				blocks = append(blocks, indentedBlocks(commentLines(fset, doc))...)
+			}
+			return true
+		})
+	}
+	return blocks
+}
+
+// commentLines returns the lines of text of the comment group, without
+// comment markers and, as for ast.CommentGroup.Text, the space that
+// follows a "//" marker; for symmetry, the space that follows a "/*"
+// marker is removed too.
+func commentLines(fset *token.FileSet, doc *ast.CommentGroup) []line {
+	var lines []line
+	for _, c := range doc.List {
+		pos := fset.Position(c.Pos())
+		text := c.Text[2:]
+		if c.Text[1] == '/' {
+			pos.Offset += 2
+			pos.Column += 2
+			if strings.HasPrefix(text, " ") {
+				text = text[1:]
+				pos.Offset++
+				pos.Column++
+			}
+			lines = append(lines, line{text, pos})
+			continue
+		}
+		text = strings.TrimSuffix(text, "*/")
+		pos.Offset += 2
+		pos.Column += 2
+		if strings.HasPrefix(text, " ") {
+			text = text[1:]
+			pos.Offset++
+			pos.Column++
+		}
+		for i, l := range strings.Split(text, "\n") {
+			if i > 0 {
+				pos.Line++
+				pos.Column = 1
+			}
+			lines = append(lines, line{l, pos})
+			pos.Offset += len(l) + 1
+		}
+	}
+	return lines
+}
+
+// indentedBlocks returns the code blocks of the lines of a comment:
+// the runs of indented lines, possibly separated by blank lines, that
+// follow a blank line or start the comment.
+func indentedBlocks(lines []line) []*block {
+	var blocks []*block
+	for i := 0; i < len(lines); {
+		if !isIndented(lines[i].text) || i > 0 && !isBlank(lines[i-1].text) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(lines) && (isIndented(lines[j].text) || isBlank(lines[j].text)) {
+			j++
+		}
+		end := j
+		for isBlank(lines[end-1].text) {
+			end--
+		}
+		blocks = append(blocks, unindent(lines[i:end]))
+		i = j
+	}
+	return blocks
+}
+
+// unindent returns the block of the lines, less their common
+// indentation.
+func unindent(lines []line) *block {
+	prefix := lines[0].text[:len(lines[0].text)-len(strings.TrimLeft(lines[0].text, " \t"))]
+	for _, l := range lines[1:] {
+		if isBlank(l.text) {
+			continue
+		}
+		for !strings.HasPrefix(l.text, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	b := new(block)
+	var src []string
+	for _, l := range lines {
+		n := len(prefix)
+		if n > len(l.text) {
+			n = len(l.text)
+		}
+		src = append(src, l.text[n:])
+		l.pos.Offset += n
+		l.pos.Column += n
+		b.lines = append(b.lines, l.pos)
+	}
+	b.src = strings.Join(src, "\n")
+	return b
+}
+
+func isIndented(s string) bool { return s != "" && (s[0] == ' ' || s[0] == '\t') && !isBlank(s) }
+
+func isBlank(s string) bool { return strings.TrimSpace(s) == "" }
+
+// markdownFiles returns the code blocks of the markdown files of the
+// directory dir.
+func markdownFiles(dir string) ([]*block, error) {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	var blocks []*block
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return blocks, err
+		}
+		mdBlocks, err := markdownBlocks(filename, string(data))
+		blocks = append(blocks, mdBlocks...)
+		if err != nil {
+			return blocks, err
+		}
+	}
+	return blocks, nil
+}
+
+// markdownBlocks returns the fenced code blocks of Go code, those
+// opened by a "```go" line, of the markdown text of the file filename.
+// If the last block is not closed, markdownBlocks returns the others
+// and an error.
+func markdownBlocks(filename, text string) ([]*block, error) {
+	var blocks []*block
+	var b *block
+	var src []string
+	var open token.Position // of the fence of b
+	offset := 0
+	for i, l := range strings.Split(text, "\n") {
+		pos := token.Position{Filename: filename, Offset: offset, Line: i + 1, Column: 1}
+		offset += len(l) + 1
+		fence := strings.TrimSpace(l)
+		switch {
+		case b == nil:
+			if fence == "```go" {
+				b = &block{md: true}
+				open = pos
+			}
+		case fence == "```":
+			b.src = strings.Join(src, "\n")
+			if len(b.lines) > 0 {
+				blocks = append(blocks, b)
+			}
+			b, src = nil, nil
+		default:
+			src = append(src, l)
+			b.lines = append(b.lines, pos)
+		}
+	}
+	if b != nil {
+		return blocks, fmt.Errorf("%s: unterminated code block", open)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The snippetcheck command type-checks the code examples in the doc
+// comments and markdown files of packages, and reports those that
+// have gone stale.  See the Usage constant for details.
+package main // import "golang.org/x/tools/cmd/snippetcheck"
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/loader"
+)
+
+var markdown = flag.Bool("md", true, "check the code blocks of the markdown files in each package directory")
+
+const Usage = `snippetcheck: type-checks the code examples of Go packages.
+
+Usage: snippetcheck [-md=false] package...
+
+For each package, snippetcheck extracts the code blocks of its doc
+comments, the indented blocks of the comment text, and of the
+markdown files in its directory, the blocks fenced by a "` + "```go" + `" line,
+and type-checks each as a fragment: a list of declarations, a list
+of statements, an expression, or a complete file.
+
+A fragment is checked as client code, in a file that imports the
+documented package and each package the documented package imports,
+so that it refers to the documented API by qualified identifiers:
+
+	r := strings.NewReplacer("a", "b")
+
+snippetcheck reports each type error, such as a reference to a
+function that has been removed or a call with the wrong number of
+arguments, at its position in the comment or markdown file:
+
+	strings.go:12:7: NewReplacr not declared by package strings
+
+Indented comment blocks that do not parse, such as shell commands or
+program output, are not Go code and are ignored; a fenced markdown
+block that does not parse, or is not terminated, is reported.
+A fragment may refer to other packages only if it declares their
+imports, and imports of packages that are not loaded are not
+reported.  The exit status is 0 if no fragment has errors, 1 if some
+do, and 2 if the packages could not be loaded.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, Usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conf := loader.Config{ParserMode: parser.ParseComments}
+	if _, err := conf.FromArgs(flag.Args(), false); err != nil {
+		fmt.Fprintf(os.Stderr, "snippetcheck: %s\n", err)
+		os.Exit(2)
+	}
+	prog, err := conf.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "snippetcheck: %s\n", err)
+		os.Exit(2)
+	}
+
+	stale := false
+	for _, info := range prog.InitialPackages() {
+		blocks := commentBlocks(prog.Fset, info.Files)
+		if *markdown && len(info.Files) > 0 {
+			dir := filepath.Dir(prog.Fset.File(info.Files[0].Pos()).Name())
+			mdBlocks, err := markdownFiles(dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "snippetcheck: %s\n", err)
+				stale = true
+			}
+			blocks = append(blocks, mdBlocks...)
+		}
+		for _, b := range blocks {
+			for _, err := range checkBlock(prog, info, b) {
+				fmt.Println(err)
+				stale = true
+			}
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
+)
+
+const src = `// Package p is documented.
+//
+// Its examples:
+//
+//	x := p.F(1)
+//	s := p.T{x}.String()
+//
+// and, stale,
+//
+//	p.G()
+//
+// and not Go:
+//
+//	$ go get p
+//
+package p
+
+import "conv"
+
+// T is a type.  It may be used thus:
+//
+//		var t p.T
+//		println(t.String(), conv.Itoa(t.N))
+type T struct{ N int }
+
+func (t T) String() string { return conv.Itoa(t.N) }
+
+/* F returns its argument:
+
+	p.F(1) + p.F("two")
+*/
+func F(x int) int { return x }
+`
+
+const md = "# p\n\nUse it:\n\n```go\nprintln(p.F(1))\n```\n\nor, stale:\n\n```go\n  p.F()\n```\n\n```sh\n$ go get p\n```\n\n```go\nfunc (\n```\n"
+
+func TestCheck(t *testing.T) {
+	conf := loader.Config{
+		ParserMode: parser.ParseComments,
+		Build: buildutil.FakeContext(map[string]map[string]string{
+			"p":    {"p.go": src},
+			"conv": {"conv.go": `package conv; func Itoa(int) string`},
+		}),
+	}
+	conf.Import("p")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := prog.Package("p")
+
+	blocks := commentBlocks(prog.Fset, info.Files)
+	var got []string
+	for _, b := range blocks {
+		got = append(got, fmt.Sprintf("%s %q", b.lines[0], b.src))
+	}
+	want := []string{
+		`/go/src/p/p.go:5:4 "x := p.F(1)\ns := p.T{x}.String()"`,
+		`/go/src/p/p.go:10:4 "p.G()"`,
+		`/go/src/p/p.go:14:4 "$ go get p"`,
+		`/go/src/p/p.go:22:5 "var t p.T\nprintln(t.String(), conv.Itoa(t.N))"`,
+		`/go/src/p/p.go:30:2 "p.F(1) + p.F(\"two\")"`,
+	}
+	if g, w := strings.Join(got, "\n"), strings.Join(want, "\n"); g != w {
+		t.Errorf("got blocks:\n%s\nwant:\n%s", g, w)
+	}
+
+	mdBlocks, err := markdownBlocks("p/README.md", md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks = append(blocks, mdBlocks...)
+	got = nil
+	for _, b := range blocks {
+		for _, err := range checkBlock(prog, info, b) {
+			got = append(got, err.Error())
+		}
+	}
+	want = []string{
+		`/go/src/p/p.go:10:4: G not declared by package p`,
+		`/go/src/p/p.go:30:15: cannot convert "two" (untyped string constant) to int`,
+		`p/README.md:12:7: too few arguments in call to p.F`,
+		`p/README.md:20:8: expected ')', found 'EOF'`,
+	}
+	if g, w := strings.Join(got, "\n"), strings.Join(want, "\n"); g != w {
+		t.Errorf("got errors:\n%s\nwant:\n%s", g, w)
+	}
+
+	// An unterminated block is reported, after the blocks before it.
+	mdBlocks, err = markdownBlocks("p/README.md", "```go\np.F(1)\n```\n\nand\n\n```go\np.F(2)\n")
+	if got, want := fmt.Sprint(err), "p/README.md:7:1: unterminated code block"; got != want {
+		t.Errorf("unterminated block: got error %s, want %s", got, want)
+	}
+	if len(mdBlocks) != 1 {
+		t.Errorf("unterminated block: got %d blocks, want 1", len(mdBlocks))
+	}
+}
@@ -7,6 +7,8 @@ package main
 import (
 	"go/ast"
 	"go/token"
+
+	"golang.org/x/tools/go/types"
 )
 
 func init() {
@@ -33,8 +35,7 @@ func checkAtomicAssignment(f *File, node ast.Node) {
 		if !ok {
 			continue
 		}
-		pkg, ok := sel.X.(*ast.Ident)
-		if !ok || pkg.Name != "atomic" {
+		if !f.isPkgDot(sel, "sync/atomic", "atomic") {
 			continue
 		}
 
@@ -45,6 +46,23 @@ func checkAtomicAssignment(f *File, node ast.Node) {
 	}
 }
 
+// isPkgDot reports whether sel is a qualified identifier denoting a
+// member of the package with the given path.  If type information is
+// missing, it falls back to comparing the package name.
+func (f *File) isPkgDot(sel *ast.SelectorExpr, path, name string) bool {
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	switch obj := f.pkg.uses[id].(type) {
+	case *types.PkgName:
+		return obj.Imported().Path() == path
+	case nil:
+		return id.Name == name
+	}
+	return false // e.g. a local variable named atomic
+}
+
 // checkAtomicAddAssignment walks the atomic.Add* method calls checking for assigning the return value
 // to the same variable being used in the operation
 func (f *File) checkAtomicAddAssignment(left ast.Expr, call *ast.CallExpr) {
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"golang.org/x/tools/cmd/vet/whitelist"
+	"golang.org/x/tools/go/types"
 )
 
 var compositeWhiteList = flag.Bool("compositewhitelist", true, "use composite white list; for testing only")
@@ -27,10 +28,29 @@ func init() {
 // unkeyed fields.
 func checkUnkeyedLiteral(f *File, node ast.Node) {
 	c := node.(*ast.CompositeLit)
+
+	// Check if the CompositeLit contains an unkeyed field.
+	allKeyValue := true
+	for _, e := range c.Elts {
+		if _, ok := e.(*ast.KeyValueExpr); !ok {
+			allKeyValue = false
+			break
+		}
+	}
+	if allKeyValue {
+		return
+	}
+
+	if typ := f.pkg.types[c].Type; typ != nil {
+		f.checkUnkeyedStruct(c, typ)
+		return
+	}
+
+	// No type information is available; use the syntax.
 	typ := c.Type
 	for {
-		if typ1, ok := c.Type.(*ast.ParenExpr); ok {
-			typ = typ1
+		if typ1, ok := typ.(*ast.ParenExpr); ok {
+			typ = typ1.X
 			continue
 		}
 		break
@@ -53,32 +73,8 @@ func checkUnkeyedLiteral(f *File, node ast.Node) {
 	}
 
 	// Otherwise the type is a selector like pkg.Name.
-	// We only care if pkg.Name is a struct, not if it's a map, array, or slice.
-	isStruct, typeString := f.pkg.isStruct(c)
-	if !isStruct {
-		return
-	}
-
-	if typeString == "" { // isStruct doesn't know
-		typeString = f.gofmt(typ)
-	}
-
-	// It's a struct, or we can't tell it's not a struct because we don't have types.
-
-	// Check if the CompositeLit contains an unkeyed field.
-	allKeyValue := true
-	for _, e := range c.Elts {
-		if _, ok := e.(*ast.KeyValueExpr); !ok {
-			allKeyValue = false
-			break
-		}
-	}
-	if allKeyValue {
-		return
-	}
-
-	// Check that the CompositeLit's type has the form pkg.Typ.
-	s, ok := c.Type.(*ast.SelectorExpr)
+	// It may be a struct; we can't tell it's not without types.
+	s, ok := typ.(*ast.SelectorExpr)
 	if !ok {
 		return
 	}
@@ -98,7 +94,37 @@ func checkUnkeyedLiteral(f *File, node ast.Node) {
 		return
 	}
 
-	f.Bad(c.Pos(), typeString+" composite literal uses unkeyed fields")
+	f.Bad(c.Pos(), f.gofmt(typ)+" composite literal uses unkeyed fields")
+}
+
+// checkUnkeyedStruct reports the composite literal c with unkeyed
+// fields if its type typ is a struct type declared in another package,
+// since adding a field to the struct in that package, which is a
+// compatible change, would break the literal.  The package of origin
+// of a struct is the package of its fields, so this also reports
+// literals of local named types whose underlying struct type is
+// imported, and literals whose type is elided or dot-imported.
+func (f *File) checkUnkeyedStruct(c *ast.CompositeLit, typ types.Type) {
+	// An element of a []*T literal may elide &T.
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok || st.NumFields() == 0 {
+		return
+	}
+	origin := st.Field(0).Pkg()
+	if origin == nil || origin == f.pkg.typesPkg {
+		return
+	}
+	if named, ok := typ.(*types.Named); ok && named.Obj().Pkg() == origin {
+		typeName := origin.Path() + "." + named.Obj().Name()
+		if *compositeWhiteList && whitelist.UnkeyedLiteral[typeName] {
+			return
+		}
+	}
+
+	f.Badf(c.Pos(), "%s composite literal uses unkeyed fields", typ)
 }
 
 // pkgPath returns the import path "image/png" for the package name "png".
@@ -67,6 +67,15 @@ Non-standard signatures for methods with familiar names, including:
 	UnmarshalJSON UnreadByte UnreadRune WriteByte
 	WriteTo
 
+Reflection
+
+Flag: -reflect
+
+Misuse of reflection-based APIs: calls to reflect.DeepEqual with func
+values, which are deeply equal only if both are nil, and calls to
+decoding functions such as json.Unmarshal or (*json.Decoder).Decode
+whose destination argument is not a pointer.
+
 Struct tags
 
 Flag: -structtags
@@ -78,7 +87,9 @@ Unkeyed composite literals
 
 Flag: -composites
 
-Composite struct literals that do not use the field-keyed syntax.
+Composite struct literals that do not use the field-keyed syntax, when
+the struct type is declared in another package: adding a field to the
+struct there would break the literal.
 
 Assembly declarations
 
@@ -116,18 +127,44 @@ Flag: -copylocks
 
 Locks that are erroneously passed by value.
 
+Lock balance
+
+Flag: -lockbalance
+
+Calls to the Lock and RLock methods of sync.Mutex and sync.RWMutex that
+are not followed on all paths by the corresponding Unlock or RUnlock
+call, and calls that lock a mutex already locked on some path.
+
 Nil function comparison
 
 Flag: -nilfunc
 
 Comparisons between functions and nil.
 
+Nil interface comparison
+
+Flag: -nilinterface
+
+Comparisons with nil of interface values that may hold a typed nil
+pointer, which makes the comparison misleading.
+
 Range loop variables
 
 Flag: -rangeloops
 
 Incorrect uses of range loop variables in closures.
 
+Tests and examples
+
+Flag: -tests
+
+Malformed function declarations in _test.go files: functions named
+TestXxx or BenchmarkXxx whose signature is not func(*testing.T) or
+func(*testing.B), whose name continues with a lower-case letter, and
+examples that take arguments or return results, or whose output
+comment is not the last comment of the function body and so is
+ignored.
+
 Unreachable code
 
 Flag: -unreachable
@@ -140,6 +177,23 @@ Flag: -shadow=false (experimental; must be set explicitly)
 
 Variables that may have been unintentionally shadowed.
 
+Variables used before assignment
+
+Flag: -uninit=false (experimental; must be set explicitly)
+
+Local variables declared without an initializer that may be read before
+they are assigned on some path through the function, and so yield their
+zero value.
+
+Allocation and copy hotspots
+
+Flag: -hotspot=false (experimental; must be set explicitly)
+
+Implicit allocations and large copies: conversions between string and
+[]byte within loops, and struct or array values larger than
+-hotspotsize bytes that are passed by value, copied by range loops, or
+converted to interfaces.
+
 Misuse of unsafe Pointers
 
 Flag: -unsafeptr
@@ -159,6 +213,15 @@ discarded.  By default, this includes functions like fmt.Errorf and
 fmt.Sprintf and methods like String and Error. The flags -unusedfuncs
 and -unusedstringmethods control the set.
 
+Unreleased resources
+
+Flag: -release
+
+Values that must be released on all paths through a function but may
+not be, such as the cancel function returned by context.WithCancel or
+the body of a response returned by http.Get.  The flag -releasefuncs
+controls the set.
+
 Shifts
 
 Flag: -shift
@@ -173,6 +236,9 @@ These flags configure the behavior of vet:
 		Check everything; disabled if any explicit check is requested.
 	-v
 		Verbose mode
+	-hotspotsize (default 256)
+		The size in bytes above which the hotspot check reports
+		copies of struct and array values.
 	-printfuncs
 		A comma-separated list of print-like functions to supplement
 		the standard list.  Each entry is in the form Name:N where N
@@ -182,6 +248,14 @@ These flags configure the behavior of vet:
 		if you have Warn and Warnf functions that take an
 		io.Writer as their first argument, like Fprintf,
 			-printfuncs=Warn:1,Warnf:1
+	-releasefuncs
+		A comma-separated list of function results that must be
+		released.  Each entry is in the form FUNC:N[.SEL...] where
+		FUNC is a qualified function or method name, N is the index
+		of the result, and the optional selectors name the method
+		that releases it; without selectors the result itself must
+		be called.  For example,
+			-releasefuncs=context.WithCancel:1,net/http.Get:0.Body.Close
 	-shadowstrict
 		Whether to be strict about shadowing; can be noisy.
 	-test
@@ -0,0 +1,275 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+This file contains the check for implicit allocations and large copies
+that are visible in the typed syntax tree:
+
+	- conversions between string and []byte within loops, each of which
+	  allocates and copies, except where the compiler is known to avoid
+	  it: in map index expressions, comparisons, and range operands;
+	- parameters and receivers of struct or array type larger than
+	  -hotspotsize bytes, which are copied at each call;
+	- range statements whose value variable has such a type, which is
+	  copied at each iteration; and
+	- implicit conversions of such values to interface types, in calls,
+	  assignments, and return statements, which copy them to the heap.
+
+Sizes are computed as for a 64-bit platform.  Because these costs are
+often acceptable, the check is experimental and must be enabled
+explicitly.
+*/
+
+package main
+
+import (
+	"flag"
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/types"
+)
+
+var hotspotSize = flag.Int("hotspotsize", 256, "size in bytes above which the hotspot check reports copies")
+
+// hotspotSizes computes sizes as on a 64-bit platform.
+var hotspotSizes = &types.StdSizes{WordSize: 8, MaxAlign: 8}
+
+func init() {
+	register("hotspot",
+		"check for implicit allocations and large copies (experimental; must be set explicitly)",
+		checkHotspot,
+		funcDecl, funcLit)
+	experimental["hotspot"] = true
+}
+
+func checkHotspot(f *File, node ast.Node) {
+	var name string
+	var typ *ast.FuncType
+	var body *ast.BlockStmt
+	var sig *types.Signature
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		name, typ, body = n.Name.Name, n.Type, n.Body
+		if fn, ok := f.pkg.defs[n.Name].(*types.Func); ok {
+			sig = fn.Type().(*types.Signature)
+		}
+		if n.Recv != nil {
+			f.checkLargeParams(name, n.Recv)
+		}
+	case *ast.FuncLit:
+		name, typ, body = "func literal", n.Type, n.Body
+		sig, _ = f.pkg.types[n].Type.(*types.Signature)
+	}
+	f.checkLargeParams(name, typ.Params)
+	if body != nil {
+		f.checkHotspotBody(body, sig)
+	}
+}
+
+// largeSize returns the size of values of type t if it is a struct or
+// array type larger than -hotspotsize bytes, or zero.
+func largeSize(t types.Type) int64 {
+	if t == nil {
+		return 0
+	}
+	switch t.Underlying().(type) {
+	case *types.Struct, *types.Array:
+		if size := hotspotSizes.Sizeof(t); size > int64(*hotspotSize) {
+			return size
+		}
+	}
+	return 0
+}
+
+// checkLargeParams reports the parameters in list that are passed by
+// value and are large.
+func (f *File) checkLargeParams(name string, list *ast.FieldList) {
+	if list == nil {
+		return
+	}
+	for _, field := range list.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			continue
+		}
+		t := f.pkg.types[field.Type].Type
+		if size := largeSize(t); size > 0 {
+			f.Badf(field.Type.Pos(), "%s passes %s by value, copying %d bytes", name, f.gofmt(field.Type), size)
+		}
+	}
+}
+
+// checkHotspotBody checks the statements and expressions of body,
+// which has signature sig, but not those of nested function literals.
+func (f *File) checkHotspotBody(body *ast.BlockStmt, sig *types.Signature) {
+	exempt := make(map[*ast.CallExpr]bool) // conversions that do not allocate
+	exemptConv := func(e ast.Expr) {
+		if call, ok := unparen(e).(*ast.CallExpr); ok {
+			exempt[call] = true
+		}
+	}
+	var visit func(n ast.Node, inLoop bool)
+	visit = func(n ast.Node, inLoop bool) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+
+			case *ast.ForStmt:
+				if n.Init != nil {
+					visit(n.Init, inLoop)
+				}
+				for _, n := range []ast.Node{n.Cond, n.Post, n.Body} {
+					if n != nil {
+						visit(n, true)
+					}
+				}
+				return false
+
+			case *ast.RangeStmt:
+				exemptConv(n.X)
+				if n.Value != nil {
+					if id, ok := n.Value.(*ast.Ident); !ok || id.Name != "_" {
+						if size := largeSize(f.typeOf(n.Value)); size > 0 {
+							f.Badf(n.Value.Pos(), "range value %s copies %d bytes on each iteration", f.gofmt(n.Value), size)
+						}
+					}
+				}
+				visit(n.X, inLoop)
+				visit(n.Body, true)
+				return false
+
+			case *ast.IndexExpr:
+				if t := f.pkg.types[n.X].Type; t != nil {
+					if _, ok := t.Underlying().(*types.Map); ok {
+						exemptConv(n.Index)
+					}
+				}
+
+			case *ast.BinaryExpr:
+				switch n.Op {
+				case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+					exemptConv(n.X)
+					exemptConv(n.Y)
+				}
+
+			case *ast.CallExpr:
+				if inLoop && !exempt[n] {
+					f.checkByteConversion(n)
+				}
+				f.checkBoxedArgs(n)
+
+			case *ast.AssignStmt:
+				if n.Tok == token.ASSIGN && len(n.Lhs) == len(n.Rhs) {
+					for i, rhs := range n.Rhs {
+						f.checkBoxed(rhs, f.pkg.types[n.Lhs[i]].Type, "assigning")
+					}
+				}
+
+			case *ast.ValueSpec:
+				if n.Type != nil && len(n.Names) == len(n.Values) {
+					for _, rhs := range n.Values {
+						f.checkBoxed(rhs, f.pkg.types[n.Type].Type, "assigning")
+					}
+				}
+
+			case *ast.ReturnStmt:
+				if sig != nil && sig.Results().Len() == len(n.Results) {
+					for i, res := range n.Results {
+						f.checkBoxed(res, sig.Results().At(i).Type(), "returning")
+					}
+				}
+			}
+			return true
+		})
+	}
+	visit(body, false)
+}
+
+// typeOf returns the type of e, which may be an identifier defined by
+// a short variable declaration.
+func (f *File) typeOf(e ast.Expr) types.Type {
+	if id, ok := e.(*ast.Ident); ok {
+		if obj := f.pkg.defs[id]; obj != nil {
+			return obj.Type()
+		}
+	}
+	return f.pkg.types[e].Type
+}
+
+// checkByteConversion reports call if it converts between string and
+// []byte.
+func (f *File) checkByteConversion(call *ast.CallExpr) {
+	if !f.pkg.types[call.Fun].IsType() || len(call.Args) != 1 {
+		return
+	}
+	to := f.pkg.types[call.Fun].Type
+	from := f.pkg.types[call.Args[0]].Type
+	if to == nil || from == nil {
+		return
+	}
+	switch {
+	case isByteSlice(to) && isString(from):
+		f.Badf(call.Pos(), "conversion from string to %s in a loop allocates on each iteration", f.gofmt(call.Fun))
+	case isString(to) && isByteSlice(from):
+		f.Badf(call.Pos(), "conversion from %s to string in a loop allocates on each iteration", types.TypeString(f.pkg.typesPkg, from))
+	}
+}
+
+func isByteSlice(t types.Type) bool {
+	if s, ok := t.Underlying().(*types.Slice); ok {
+		b, ok := s.Elem().Underlying().(*types.Basic)
+		return ok && b.Kind() == types.Byte
+	}
+	return false
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+// checkBoxedArgs reports the large arguments of call that are passed
+// to interface parameters.
+func (f *File) checkBoxedArgs(call *ast.CallExpr) {
+	sig, ok := f.pkg.types[call.Fun].Type.(*types.Signature)
+	if !ok || f.pkg.types[call.Fun].IsType() {
+		return
+	}
+	params := sig.Params()
+	for i, arg := range call.Args {
+		var t types.Type
+		switch {
+		case sig.Variadic() && i >= params.Len()-1:
+			if call.Ellipsis.IsValid() {
+				return
+			}
+			s, ok := params.At(params.Len() - 1).Type().(*types.Slice)
+			if !ok {
+				return
+			}
+			t = s.Elem()
+		case i < params.Len():
+			t = params.At(i).Type()
+		default:
+			return
+		}
+		f.checkBoxed(arg, t, "passing")
+	}
+}
+
+// checkBoxed reports the implicit conversion of the large value e to
+// the type t, if it is an interface type.
+func (f *File) checkBoxed(e ast.Expr, t types.Type, doing string) {
+	if t == nil || !isInterface(t) {
+		return
+	}
+	tv := f.pkg.types[e]
+	if tv.Type == nil || tv.Value != nil || isInterface(tv.Type) {
+		return
+	}
+	if size := largeSize(tv.Type); size > 0 {
+		f.Badf(e.Pos(), "%s %s as %s copies %d bytes to the heap", doing, f.gofmt(e), types.TypeString(f.pkg.typesPkg, t), size)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+This file contains the check for unbalanced calls to the Lock and
+Unlock methods of sync.Mutex and sync.RWMutex within a function.
+
+For each call x.Lock() (or x.RLock()) in a function that also calls
+x.Unlock() (or x.RUnlock()), the check builds the control-flow graph
+of the function and reports:
+
+	- return statements reachable from the Lock call along a path that
+	  does not unlock x, either directly or by a deferred call; and
+	- calls that lock x again along a path that does not unlock it,
+	  which deadlock.
+
+Functions that lock x but never unlock it are assumed to acquire the
+lock on behalf of their caller and are not reported.  The receivers of
+the calls are compared syntactically.
+*/
+
+package main
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/cfg"
+	"golang.org/x/tools/go/types"
+)
+
+func init() {
+	register("lockbalance",
+		"check for Lock calls on sync.Mutex and sync.RWMutex not balanced by Unlock on all paths",
+		checkLockBalance,
+		funcDecl, funcLit)
+}
+
+// unlockMethods maps each locking method to its unlocking method.
+var unlockMethods = map[string]string{
+	"(*sync.Mutex).Lock":    "Unlock",
+	"(*sync.RWMutex).Lock":  "Unlock",
+	"(*sync.RWMutex).RLock": "RUnlock",
+}
+
+// A lockCall is a call x.Lock() or similar.
+type lockCall struct {
+	stmt   *ast.ExprStmt
+	recv   string // receiver x, formatted
+	method string // Lock or RLock
+	unlock string // Unlock or RUnlock
+}
+
+func checkLockBalance(f *File, node ast.Node) {
+	var body *ast.BlockStmt
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		body = n.Body
+	case *ast.FuncLit:
+		body = n.Body
+	}
+	if body == nil {
+		return
+	}
+
+	// Find the lock calls and the unlocked receivers of this
+	// function, not counting nested function literals, except
+	// for unlock calls deferred by them.
+	var locks []*lockCall
+	unlocked := make(map[string]bool) // "x.Unlock" for each unlocked receiver x
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ExprStmt:
+			if l := f.lockCall(n); l != nil {
+				locks = append(locks, l)
+			}
+		case *ast.CallExpr:
+			if recv, method := f.syncCall(n); method == "Unlock" || method == "RUnlock" {
+				unlocked[recv+"."+method] = true
+			}
+		case *ast.DeferStmt:
+			f.unlockCalls(n, func(recv, method string) {
+				unlocked[recv+"."+method] = true
+			})
+		}
+		return true
+	})
+	var balanced []*lockCall
+	for _, l := range locks {
+		if unlocked[l.recv+"."+l.unlock] {
+			balanced = append(balanced, l)
+		}
+	}
+	if len(balanced) == 0 {
+		return
+	}
+
+	g := cfg.New(body, nil)
+	for _, l := range balanced {
+		f.checkLockPaths(g, l)
+	}
+}
+
+// syncCall returns the formatted receiver and name of the method of
+// sync.Mutex or sync.RWMutex called by call, if any.
+func (f *File) syncCall(call *ast.CallExpr) (recv, method string) {
+	sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return "", ""
+	}
+	fn, ok := f.pkg.uses[sel.Sel].(*types.Func)
+	if !ok {
+		return "", ""
+	}
+	switch fn.FullName() {
+	case "(*sync.Mutex).Lock", "(*sync.Mutex).Unlock",
+		"(*sync.RWMutex).Lock", "(*sync.RWMutex).Unlock",
+		"(*sync.RWMutex).RLock", "(*sync.RWMutex).RUnlock":
+		return f.gofmt(sel.X), fn.Name()
+	}
+	return "", ""
+}
+
+// lockCall returns the lock call made by statement s, or nil.
+func (f *File) lockCall(s *ast.ExprStmt) *lockCall {
+	call, ok := unparen(s.X).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	fn, ok := f.pkg.uses[sel.Sel].(*types.Func)
+	if !ok {
+		return nil
+	}
+	unlock, ok := unlockMethods[fn.FullName()]
+	if !ok {
+		return nil
+	}
+	return &lockCall{stmt: s, recv: f.gofmt(sel.X), method: fn.Name(), unlock: unlock}
+}
+
+// unlockCalls calls found for each unlock call within n, including
+// those within nested function literals.
+func (f *File) unlockCalls(n ast.Node, found func(recv, method string)) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if recv, method := f.syncCall(call); method == "Unlock" || method == "RUnlock" {
+				found(recv, method)
+			}
+		}
+		return true
+	})
+}
+
+// checkLockPaths reports the paths of g starting at lock call l that
+// reach a return or lock the receiver again without unlocking it.
+func (f *File) checkLockPaths(g *cfg.CFG, l *lockCall) {
+	var defblock *cfg.Block
+	var rest []ast.Node
+outer:
+	for _, b := range g.Blocks {
+		for i, n := range b.Nodes {
+			if n == l.stmt {
+				defblock = b
+				rest = b.Nodes[i+1:]
+				break outer
+			}
+		}
+	}
+	if defblock == nil || !defblock.Live {
+		return
+	}
+
+	unlocks := func(n ast.Node) bool {
+		found := false
+		f.unlockCalls(n, func(recv, method string) {
+			if recv == l.recv && method == l.unlock {
+				found = true
+			}
+		})
+		return found
+	}
+
+	line := f.fset.Position(l.stmt.Pos()).Line
+	reported := false
+	seen := make(map[*cfg.Block]bool)
+	var search func(b *cfg.Block, nodes []ast.Node)
+	search = func(b *cfg.Block, nodes []ast.Node) {
+		for _, n := range nodes {
+			if unlocks(n) {
+				return
+			}
+			if ret, ok := n.(*ast.ReturnStmt); ok {
+				if !reported {
+					reported = true
+					f.Badf(l.stmt.Pos(), "%s.%s() is not followed by %s.%s() on all paths", l.recv, l.method, l.recv, l.unlock)
+				}
+				f.Badf(ret.Pos(), "this return statement may be reached with %s locked (locked on line %d)", l.recv, line)
+				return
+			}
+			if s, ok := n.(*ast.ExprStmt); ok {
+				if l2 := f.lockCall(s); l2 != nil && l2.recv == l.recv && (l2.method == "Lock" || l.method == "Lock") {
+					f.Badf(s.Pos(), "%s.%s() may be called with %s already locked (locked on line %d)", l2.recv, l2.method, l.recv, line)
+					return
+				}
+			}
+		}
+		for _, succ := range b.Succs {
+			if !seen[succ] {
+				seen[succ] = true
+				search(succ, succ.Nodes)
+			}
+		}
+	}
+	search(defblock, rest)
+}
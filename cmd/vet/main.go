@@ -200,6 +200,7 @@ func main() {
 
 	initPrintFlags()
 	initUnusedFlags()
+	initReleaseFlags()
 
 	if flag.NArg() == 0 {
 		Usage()
@@ -280,6 +281,8 @@ type Package struct {
 	spans     map[types.Object]Span
 	files     []*File
 	typesPkg  *types.Package
+
+	typedNils map[*types.Func][]bool // see typedNilResults; computed lazily
 }
 
 // doPackage analyzes the single package constructed from the named files.
@@ -298,7 +301,7 @@ func doPackage(directory string, names []string) bool {
 		checkBuildTag(name, data)
 		var parsedFile *ast.File
 		if strings.HasSuffix(name, ".go") {
-			parsedFile, err = parser.ParseFile(fs, name, data, 0)
+			parsedFile, err = parser.ParseFile(fs, name, data, parser.ParseComments)
 			if err != nil {
 				warnf("%s: %s", name, err)
 				return false
@@ -331,6 +334,8 @@ func doPackage(directory string, names []string) bool {
 	for _, file := range files {
 		file.pkg = pkg
 		file.checkers = chk
+	}
+	for _, file := range files {
 		if file.file != nil {
 			file.walkFile(file.name, file.file)
 		}
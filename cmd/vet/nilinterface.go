@@ -0,0 +1,289 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+This file contains the check for comparisons with nil of interface
+values that may hold a typed nil pointer.
+
+An interface value holding a nil pointer is not itself nil:
+
+	func f() error {
+		var p *MyError
+		if bad() {
+			p = &MyError{}
+		}
+		return p // returns a non-nil error even if p is nil
+	}
+
+	if err := f(); err != nil { ... } // always true
+
+The check uses conservative, flow-insensitive type-flow information.
+A pointer-typed expression may be nil if it is a conversion of nil, as
+in (*T)(nil), or a local variable that is declared without an
+initializer or assigned nil somewhere in its function.  An interface
+result of a function in the package may hold a typed nil if some return
+statement supplies such an expression for it.  A local interface
+variable assigned one of these values, directly or from a call, and
+later compared with nil in the same function is reported, together
+with the assignment that introduced the typed nil.
+*/
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/types"
+)
+
+func init() {
+	register("nilinterface",
+		"check for comparisons with nil of interface values that may hold a typed nil pointer",
+		checkNilInterface,
+		funcDecl, funcLit)
+}
+
+// nilFlow holds the flow-insensitive facts about a single function.
+type nilFlow struct {
+	f     *File
+	ptrs  map[*types.Var]token.Pos // pointer variables that may be nil, and why
+	reach map[*types.Var]token.Pos // interface variables that may hold a typed nil, and where
+}
+
+func checkNilInterface(f *File, node ast.Node) {
+	var body *ast.BlockStmt
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		body = n.Body
+	case *ast.FuncLit:
+		body = n.Body
+	}
+	if body == nil {
+		return
+	}
+	fl := f.nilFlow(body)
+	fl.assignments(body)
+	if len(fl.reach) == 0 {
+		return
+	}
+
+	reported := make(map[token.Pos]bool)
+	inspectFunc(body, func(n ast.Node) {
+		b, ok := n.(*ast.BinaryExpr)
+		if !ok || (b.Op != token.EQL && b.Op != token.NEQ) {
+			return
+		}
+		x := unparen(b.X)
+		if isNil(x) {
+			x = unparen(b.Y)
+		} else if !isNil(b.Y) {
+			return
+		}
+		id, ok := x.(*ast.Ident)
+		if !ok {
+			return
+		}
+		v, ok := f.pkg.uses[id].(*types.Var)
+		if !ok {
+			return
+		}
+		pos, ok := fl.reach[v]
+		if !ok {
+			return
+		}
+		f.Badf(b.Pos(), "comparison of %s with nil is misleading: %s may hold a typed nil pointer assigned on line %d",
+			id.Name, id.Name, f.fset.Position(pos).Line)
+		if !reported[pos] {
+			reported[pos] = true
+			f.Badf(pos, "this assignment may store a typed nil pointer in interface %s", id.Name)
+		}
+	})
+}
+
+// inspectFunc calls fn for each node within body, but not within
+// nested function literals.
+func inspectFunc(body *ast.BlockStmt, fn func(ast.Node)) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		if n != nil {
+			fn(n)
+		}
+		return true
+	})
+}
+
+// nilFlow returns the facts about pointer variables of body.
+func (f *File) nilFlow(body *ast.BlockStmt) *nilFlow {
+	fl := &nilFlow{
+		f:     f,
+		ptrs:  make(map[*types.Var]token.Pos),
+		reach: make(map[*types.Var]token.Pos),
+	}
+	mark := func(id *ast.Ident, pos token.Pos) {
+		if v := f.localVar(id); v != nil && isPointer(v.Type()) {
+			if _, ok := fl.ptrs[v]; !ok {
+				fl.ptrs[v] = pos
+			}
+		}
+	}
+	inspectFunc(body, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if len(n.Values) == 0 {
+				for _, id := range n.Names {
+					mark(id, id.Pos())
+				}
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i, rhs := range n.Rhs {
+					if id, ok := unparen(n.Lhs[i]).(*ast.Ident); ok && isNil(rhs) {
+						mark(id, n.Pos())
+					}
+				}
+			}
+		}
+	})
+	return fl
+}
+
+func isPointer(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Pointer)
+	return ok
+}
+
+func isInterface(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Interface)
+	return ok
+}
+
+// mayBeNilPointer reports whether e is a pointer-typed expression
+// that may be nil.
+func (fl *nilFlow) mayBeNilPointer(e ast.Expr) bool {
+	e = unparen(e)
+	if t := fl.f.pkg.types[e].Type; t == nil || !isPointer(t) {
+		return false
+	}
+	switch e := e.(type) {
+	case *ast.CallExpr:
+		// (*T)(nil)
+		return fl.f.pkg.types[e.Fun].IsType() && len(e.Args) == 1 && isNil(e.Args[0])
+	case *ast.Ident:
+		if v := fl.f.localVar(e); v != nil {
+			_, ok := fl.ptrs[v]
+			return ok
+		}
+	}
+	return false
+}
+
+// assignments records the interface variables of body that are
+// assigned a value that may be a typed nil pointer.
+func (fl *nilFlow) assignments(body *ast.BlockStmt) {
+	f := fl.f
+	record := func(lhs ast.Expr, pos token.Pos) {
+		id, ok := unparen(lhs).(*ast.Ident)
+		if !ok {
+			return
+		}
+		if v := f.localVar(id); v != nil && isInterface(v.Type()) {
+			if _, ok := fl.reach[v]; !ok {
+				fl.reach[v] = pos
+			}
+		}
+	}
+	// fromCall returns the typed-nil results of the call e, if it is one.
+	fromCall := func(e ast.Expr) []bool {
+		if call, ok := unparen(e).(*ast.CallExpr); ok {
+			if fn := f.calledFunc(call); fn != nil {
+				return f.pkg.typedNilResults(fn)
+			}
+		}
+		return nil
+	}
+	// pair handles the assignment of rhs to lhs.
+	pair := func(lhs []ast.Expr, rhs []ast.Expr, pos token.Pos) {
+		if len(lhs) == len(rhs) {
+			for i := range rhs {
+				if fl.mayBeNilPointer(rhs[i]) {
+					record(lhs[i], pos)
+				} else if nilable := fromCall(rhs[i]); len(nilable) == 1 && nilable[0] {
+					record(lhs[i], pos)
+				}
+			}
+		} else if len(rhs) == 1 {
+			for i, nilable := range fromCall(rhs[0]) {
+				if nilable && i < len(lhs) {
+					record(lhs[i], pos)
+				}
+			}
+		}
+	}
+	inspectFunc(body, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok == token.ASSIGN || n.Tok == token.DEFINE {
+				pair(n.Lhs, n.Rhs, n.Pos())
+			}
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(n.Names))
+			for i, id := range n.Names {
+				lhs[i] = id
+			}
+			pair(lhs, n.Values, n.Pos())
+		}
+	})
+}
+
+// typedNilResults reports, for each result of fn, whether fn is a
+// function of this package that may return a typed nil pointer as
+// that result, which is of interface type.
+func (pkg *Package) typedNilResults(fn *types.Func) []bool {
+	if pkg.typedNils == nil {
+		pkg.typedNils = make(map[*types.Func][]bool)
+		for _, file := range pkg.files {
+			if file.file == nil {
+				continue
+			}
+			for _, decl := range file.file.Decls {
+				if decl, ok := decl.(*ast.FuncDecl); ok && decl.Body != nil {
+					file.typedNilReturns(decl)
+				}
+			}
+		}
+	}
+	return pkg.typedNils[fn]
+}
+
+// typedNilReturns records in f.pkg.typedNils the interface results of
+// decl for which some return statement supplies a typed nil pointer.
+func (f *File) typedNilReturns(decl *ast.FuncDecl) {
+	fn, ok := f.pkg.defs[decl.Name].(*types.Func)
+	if !ok {
+		return
+	}
+	results := fn.Type().(*types.Signature).Results()
+	var nilable []bool
+	fl := f.nilFlow(decl.Body)
+	inspectFunc(decl.Body, func(n ast.Node) {
+		ret, ok := n.(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != results.Len() {
+			return
+		}
+		for i, e := range ret.Results {
+			if isInterface(results.At(i).Type()) && fl.mayBeNilPointer(e) {
+				if nilable == nil {
+					nilable = make([]bool, results.Len())
+				}
+				nilable[i] = true
+			}
+		}
+	})
+	if nilable != nil {
+		f.pkg.typedNils[fn] = nilable
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the checks for misuse of reflection-based APIs.
+
+package main
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/types"
+)
+
+func init() {
+	register("reflect",
+		"check for misuse of reflect.DeepEqual and of the arguments of reflection-based decoders",
+		checkReflectCall,
+		callExpr)
+}
+
+// unmarshalFuncs maps the decoding functions whose argument must be a
+// pointer to the index of that argument.
+var unmarshalFuncs = map[string]int{
+	"encoding/asn1.Unmarshal":               1,
+	"encoding/json.Unmarshal":               1,
+	"encoding/xml.Unmarshal":                1,
+	"(*encoding/gob.Decoder).Decode":        0,
+	"(*encoding/json.Decoder).Decode":       0,
+	"(*encoding/xml.Decoder).Decode":        0,
+	"(*encoding/xml.Decoder).DecodeElement": 0,
+}
+
+// checkReflectCall checks calls to functions that inspect their
+// arguments using reflection, which the type checker cannot validate.
+func checkReflectCall(f *File, node ast.Node) {
+	call := node.(*ast.CallExpr)
+	fn := f.calledFunc(call)
+	if fn == nil {
+		return
+	}
+	name := fn.FullName()
+	if name == "reflect.DeepEqual" {
+		f.checkDeepEqual(call)
+		return
+	}
+	if i, ok := unmarshalFuncs[name]; ok && i < len(call.Args) {
+		f.checkUnmarshalArg(call, name, i)
+	}
+}
+
+// checkDeepEqual reports calls to reflect.DeepEqual with an argument
+// of func type: func values are deeply equal only if both are nil.
+func (f *File) checkDeepEqual(call *ast.CallExpr) {
+	for _, arg := range call.Args {
+		t := f.pkg.types[arg].Type
+		if t == nil {
+			continue
+		}
+		if _, ok := t.Underlying().(*types.Signature); ok {
+			f.Badf(arg.Pos(), "reflect.DeepEqual of func value %s is false unless both values are nil", f.gofmt(arg))
+			return
+		}
+	}
+}
+
+// checkUnmarshalArg reports a call to the decoding function name
+// whose i'th argument, which receives the decoded value, is neither a
+// pointer nor an interface that may hold one.
+func (f *File) checkUnmarshalArg(call *ast.CallExpr, name string, i int) {
+	arg := call.Args[i]
+	tv := f.pkg.types[arg]
+	if tv.Type == nil || tv.IsNil() {
+		if tv.IsNil() {
+			f.Badf(arg.Pos(), "call of %s passes nil to receive the decoded value", name)
+		}
+		return
+	}
+	switch tv.Type.Underlying().(type) {
+	case *types.Pointer, *types.Interface:
+		return
+	}
+	f.Badf(arg.Pos(), "call of %s passes non-pointer %s of type %s to receive the decoded value", name, f.gofmt(arg), tv.Type)
+}
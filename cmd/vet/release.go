@@ -0,0 +1,327 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+This file contains the check for values that must be released on all
+paths, such as the cancel function returned by context.WithCancel or
+the body of the response returned by http.Get.
+
+Each entry of the -releasefuncs list has the form FUNC:N[.SEL...],
+where FUNC is the qualified name of a function or method as printed by
+(*types.Func).FullName, N is the index of its result that must be
+released, and the optional selectors name the method that releases it.
+For example,
+
+	context.WithCancel:1
+	net/http.Get:0.Body.Close
+
+state that the second result of context.WithCancel must be called, and
+that the Body.Close method of the first result of http.Get must be
+called.
+
+For each call to a listed function whose result is assigned to a local
+variable, the check builds the control-flow graph of the enclosing
+function and reports return statements reachable from the call along
+a path that does not use the variable.  When the release goes through
+selectors, a use of the variable counts only if it selects the release
+method or is not the operand of a selector; either way, passing,
+storing, or returning the variable counts as a use.  Paths on which an
+error result of the same call is known to be non-nil are not followed.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/cfg"
+	"golang.org/x/tools/go/types"
+)
+
+var releaseFuncsFlag = flag.String("releasefuncs",
+	"context.WithCancel:1,context.WithDeadline:1,context.WithTimeout:1,"+
+		"golang.org/x/net/context.WithCancel:1,golang.org/x/net/context.WithDeadline:1,golang.org/x/net/context.WithTimeout:1,"+
+		"net/http.Get:0.Body.Close,net/http.Head:0.Body.Close,net/http.Post:0.Body.Close,net/http.PostForm:0.Body.Close,"+
+		"(*net/http.Client).Do:0.Body.Close,(*net/http.Client).Get:0.Body.Close,(*net/http.Client).Head:0.Body.Close,"+
+		"(*net/http.Client).Post:0.Body.Close,(*net/http.Client).PostForm:0.Body.Close",
+	"comma-separated list of FUNC:N[.SEL...] entries naming function results that must be released")
+
+func init() {
+	register("release",
+		"check that cancel functions, response bodies, and other values in the -releasefuncs list are released on all paths",
+		checkRelease,
+		funcDecl, funcLit)
+}
+
+// A releaseFunc describes a function result that must be released.
+type releaseFunc struct {
+	result int      // index of the result
+	path   []string // selectors leading to the release method; empty if the result is called
+}
+
+// how describes how the value must be released.
+func (r *releaseFunc) how() string {
+	if len(r.path) == 0 {
+		return "calling it"
+	}
+	return fmt.Sprintf("calling its %s method", strings.Join(r.path, "."))
+}
+
+var releaseFuncs = make(map[string]*releaseFunc)
+
+func initReleaseFlags() {
+	if *releaseFuncsFlag == "" {
+		return
+	}
+	for _, entry := range strings.Split(*releaseFuncsFlag, ",") {
+		i := strings.LastIndex(entry, ":")
+		if i < 0 {
+			errorf("-releasefuncs: missing result index in %q", entry)
+		}
+		spec := strings.Split(entry[i+1:], ".")
+		n, err := strconv.Atoi(spec[0])
+		if err != nil || n < 0 {
+			errorf("-releasefuncs: bad result index in %q", entry)
+		}
+		releaseFuncs[entry[:i]] = &releaseFunc{result: n, path: spec[1:]}
+	}
+}
+
+func checkRelease(f *File, node ast.Node) {
+	if len(releaseFuncs) == 0 {
+		return
+	}
+	var body *ast.BlockStmt
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		body = n.Body
+	case *ast.FuncLit:
+		body = n.Body
+	}
+	if body == nil {
+		return
+	}
+
+	// Find the calls to listed functions in this function,
+	// but not in nested function literals.
+	type site struct {
+		stmt ast.Stmt
+		v    *types.Var   // variable holding the value; nil if discarded
+		err  *types.Var   // variable holding the error result, if any
+		r    *releaseFunc // what to release
+		name string       // name of the called function
+		pos  token.Pos
+	}
+	var sites []site
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			if len(n.Rhs) != 1 {
+				break
+			}
+			call, ok := unparen(n.Rhs[0]).(*ast.CallExpr)
+			if !ok {
+				break
+			}
+			fn := f.calledFunc(call)
+			if fn == nil {
+				break
+			}
+			r := releaseFuncs[fn.FullName()]
+			if r == nil || r.result >= len(n.Lhs) {
+				break
+			}
+			s := site{stmt: n, r: r, name: fn.FullName(), pos: n.Lhs[r.result].Pos()}
+			id, ok := unparen(n.Lhs[r.result]).(*ast.Ident)
+			if !ok {
+				break // stored elsewhere
+			}
+			if id.Name == "_" {
+				f.Badf(id.Pos(), "the result of %s must be released by %s, not discarded", s.name, r.how())
+				break
+			}
+			s.v = f.localVar(id)
+			if s.v == nil {
+				break
+			}
+			if last := n.Lhs[len(n.Lhs)-1]; len(n.Lhs) > r.result+1 {
+				if id, ok := unparen(last).(*ast.Ident); ok {
+					if v := f.localVar(id); v != nil && types.Identical(v.Type().Underlying(), errorType) {
+						s.err = v
+					}
+				}
+			}
+			sites = append(sites, s)
+		}
+		return true
+	})
+	if len(sites) == 0 {
+		return
+	}
+
+	g := cfg.New(body, nil)
+	for _, s := range sites {
+		isUse := func(n ast.Node) bool { return f.usesRelease(n, s.v, s.r) }
+		if ret := f.leakingReturn(g, s.stmt, s.err, isUse); ret != nil {
+			vname := s.v.Name()
+			f.Badf(s.pos, "the result of %s assigned to %s is not released on all paths; release it by %s",
+				s.name, vname, s.r.how())
+			f.Badf(ret.Pos(), "this return statement may be reached without releasing %s (defined on line %d)",
+				vname, f.fset.Position(s.pos).Line)
+		}
+	}
+}
+
+// calledFunc returns the function or method called by call, or nil.
+func (f *File) calledFunc(call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	fn, _ := f.pkg.uses[id].(*types.Func)
+	return fn
+}
+
+// localVar returns the variable defined or used by id if it is local
+// to a function, or nil.
+func (f *File) localVar(id *ast.Ident) *types.Var {
+	obj := f.pkg.defs[id]
+	if obj == nil {
+		obj = f.pkg.uses[id]
+	}
+	v, _ := obj.(*types.Var)
+	if v == nil || v.Pkg() == nil || v.Parent() == v.Pkg().Scope() {
+		return nil
+	}
+	return v
+}
+
+// usesRelease reports whether node n contains a use of v that counts
+// as releasing it according to r.
+func (f *File) usesRelease(n ast.Node, v *types.Var, r *releaseFunc) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if len(r.path) == 0 {
+				break
+			}
+			// Does n select v.path[0]...path[k]?
+			x, sels := ast.Expr(n), []string(nil)
+			for {
+				sel, ok := x.(*ast.SelectorExpr)
+				if !ok {
+					break
+				}
+				sels = append([]string{sel.Sel.Name}, sels...)
+				x = unparen(sel.X)
+			}
+			id, ok := x.(*ast.Ident)
+			if !ok || f.pkg.uses[id] != v {
+				break
+			}
+			found = len(sels) >= len(r.path) && strings.Join(sels[:len(r.path)], ".") == strings.Join(r.path, ".")
+			// Don't visit the prefixes of non-releasing selections.
+			return false
+		case *ast.Ident:
+			if f.pkg.uses[n] == v {
+				found = true
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// leakingReturn returns a return statement of g that is reachable from
+// stmt without passing through a node for which isUse reports true, or
+// nil if there is none.  Edges leaving a block whose final node tests
+// err against nil are not followed on the side where err is non-nil.
+func (f *File) leakingReturn(g *cfg.CFG, stmt ast.Stmt, err *types.Var, isUse func(ast.Node) bool) *ast.ReturnStmt {
+	// Find the block and position of stmt.
+	var defblock *cfg.Block
+	var rest []ast.Node
+outer:
+	for _, b := range g.Blocks {
+		for i, n := range b.Nodes {
+			if n == stmt {
+				defblock = b
+				rest = b.Nodes[i+1:]
+				break outer
+			}
+		}
+	}
+	if defblock == nil || !defblock.Live {
+		return nil
+	}
+
+	seen := make(map[*cfg.Block]bool)
+	var search func(b *cfg.Block, nodes []ast.Node) *ast.ReturnStmt
+	search = func(b *cfg.Block, nodes []ast.Node) *ast.ReturnStmt {
+		for _, n := range nodes {
+			if isUse(n) {
+				return nil
+			}
+		}
+		if ret := b.Return(); ret != nil {
+			return ret
+		}
+		succs := b.Succs
+		if len(succs) == 2 && len(b.Nodes) > 0 {
+			if e, ok := b.Nodes[len(b.Nodes)-1].(ast.Expr); ok {
+				switch f.errTest(e, err) {
+				case token.NEQ: // if err != nil: then-branch has err != nil
+					succs = succs[1:]
+				case token.EQL: // if err == nil: else-branch has err != nil
+					succs = succs[:1]
+				}
+			}
+		}
+		for _, succ := range succs {
+			if !seen[succ] {
+				seen[succ] = true
+				if ret := search(succ, succ.Nodes); ret != nil {
+					return ret
+				}
+			}
+		}
+		return nil
+	}
+	return search(defblock, rest)
+}
+
+// errTest returns token.NEQ or token.EQL if e compares err with nil
+// using that operator, or token.ILLEGAL otherwise.
+func (f *File) errTest(e ast.Expr, err *types.Var) token.Token {
+	if err == nil {
+		return token.ILLEGAL
+	}
+	b, ok := unparen(e).(*ast.BinaryExpr)
+	if !ok || (b.Op != token.NEQ && b.Op != token.EQL) {
+		return token.ILLEGAL
+	}
+	x, y := unparen(b.X), unparen(b.Y)
+	if isNil(x) {
+		x, y = y, x
+	}
+	if id, ok := x.(*ast.Ident); ok && f.pkg.uses[id] == err && isNil(y) {
+		return b.Op
+	}
+	return token.ILLEGAL
+}
@@ -41,3 +41,14 @@ func AtomicTests() {
 
 	x = atomic.AddUint64() // Used to make vet crash; now silently ignored.
 }
+
+type T struct{}
+
+func (T) AddUint64(addr *uint64, delta uint64) uint64 { return 0 }
+
+func NonAtomicTests() {
+	x := uint64(1)
+	atomic := T{}
+	x = atomic.AddUint64(&x, 1) // ok: not the sync/atomic package
+	_ = x
+}
@@ -57,6 +57,36 @@ var BadStructLiteralUsedInTests = flag.Flag{ // ERROR "unkeyed fields"
 	"DefValue",
 }
 
+// Elided types are checked too.
+var BadElidedLiterals = []flag.Flag{
+	{ // ERROR "unkeyed fields"
+		"Name",
+		"Usage",
+		nil, // Value
+		"DefValue",
+	},
+}
+
+var BadElidedPointerLiterals = []*flag.Flag{
+	{ // ERROR "unkeyed fields"
+		"Name",
+		"Usage",
+		nil, // Value
+		"DefValue",
+	},
+}
+
+// A local type whose underlying struct is declared in another package
+// breaks just the same when that package adds a field.
+type LocalFlag flag.Flag
+
+var BadLocalStructLiteral = LocalFlag{ // ERROR "unkeyed fields"
+	"Name",
+	"Usage",
+	nil, // Value
+	"DefValue",
+}
+
 // Used to test the check for slices and arrays: If that test is disabled and
 // vet is run with --compositewhitelist=false, this line triggers an error.
 // Clumsy but sufficient.
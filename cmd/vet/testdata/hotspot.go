@@ -0,0 +1,75 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the hotspot checker.
+
+package testdata
+
+type Big struct {
+	buf [512]byte
+}
+
+type Small struct {
+	x, y int
+}
+
+func (b Big) Value() int { // ERROR "Value passes Big by value, copying 512 bytes"
+	return len(b.buf)
+}
+
+func (b *Big) Ptr() int {
+	return len(b.buf)
+}
+
+func TakeBig(n int, b Big) {} // ERROR "TakeBig passes Big by value, copying 512 bytes"
+
+func TakeSmall(s Small) {}
+
+func TakeArray(a [1024]int) {} // ERROR "TakeArray passes \[1024\]int by value, copying 8192 bytes"
+
+func Conversions(words []string, data [][]byte, m map[string]int) int {
+	n := 0
+	b := []byte(words[0])
+	for _, w := range words {
+		b = append(b, []byte(w)...) // ERROR "conversion from string to \[\]byte in a loop allocates on each iteration"
+	}
+	for i := 0; i < len(data); i++ {
+		s := string(data[i]) // ERROR "conversion from \[\]byte to string in a loop allocates on each iteration"
+		n += len(s)
+		n += m[string(data[i])]
+		if string(data[i]) == "x" {
+			n++
+		}
+	}
+	for range []byte(words[0]) {
+		n++
+	}
+	return n
+}
+
+func Ranges(bigs []Big, smalls []Small) int {
+	n := 0
+	for _, b := range bigs { // ERROR "range value b copies 512 bytes on each iteration"
+		n += len(b.buf)
+	}
+	for i := range bigs {
+		n += len(bigs[i].buf)
+	}
+	for _, s := range smalls {
+		n += s.x
+	}
+	return n
+}
+
+func sinkInterface(x interface{}) {}
+
+func Boxing(b Big, p *Big, s Small) interface{} { // ERROR "Boxing passes Big by value, copying 512 bytes"
+	sinkInterface(b) // ERROR "passing b as interface{} copies 512 bytes to the heap"
+	sinkInterface(p)
+	sinkInterface(s)
+	var x interface{}
+	x = b // ERROR "assigning b as interface{} copies 512 bytes to the heap"
+	_ = x
+	return b // ERROR "returning b as interface{} copies 512 bytes to the heap"
+}
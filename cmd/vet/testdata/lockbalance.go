@@ -0,0 +1,60 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the lockbalance checker.
+
+package testdata
+
+import "sync"
+
+type LockedMap struct {
+	mu sync.RWMutex
+	m  map[string]int
+}
+
+func (m *LockedMap) Get(k string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.m[k]
+}
+
+func (m *LockedMap) Set(k string, v int) bool {
+	m.mu.Lock() // ERROR "m.mu.Lock\(\) is not followed by m.mu.Unlock\(\) on all paths"
+	if _, ok := m.m[k]; ok {
+		return false // ERROR "this return statement may be reached with m.mu locked"
+	}
+	m.m[k] = v
+	m.mu.Unlock()
+	return true
+}
+
+func (m *LockedMap) Delete(k string) {
+	m.mu.Lock()
+	if _, ok := m.m[k]; !ok {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.m, k)
+	m.mu.Unlock()
+}
+
+func (m *LockedMap) lock() {
+	m.mu.Lock() // ok: acquired for the caller
+}
+
+func DoubleLock(mu *sync.Mutex, ok bool) {
+	mu.Lock()
+	if ok {
+		mu.Lock() // ERROR "mu.Lock\(\) may be called with mu already locked"
+	}
+	mu.Unlock()
+}
+
+func DeferredLiteral(mu *sync.Mutex) int {
+	mu.Lock()
+	defer func() {
+		mu.Unlock()
+	}()
+	return 1
+}
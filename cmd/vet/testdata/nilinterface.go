@@ -0,0 +1,51 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the nilinterface checker.
+
+package testdata
+
+type MyError struct{}
+
+func (*MyError) Error() string { return "" }
+
+func bad() bool { return false }
+
+func MayReturnTypedNil() error {
+	var p *MyError
+	if bad() {
+		p = &MyError{}
+	}
+	return p
+}
+
+func ReturnsUntypedNil() error {
+	if bad() {
+		return &MyError{}
+	}
+	return nil
+}
+
+func NilInterfaceTests() {
+	err := MayReturnTypedNil() // ERROR "this assignment may store a typed nil pointer in interface err"
+	if err != nil {            // ERROR "comparison of err with nil is misleading"
+		println()
+	}
+
+	err2 := ReturnsUntypedNil()
+	if err2 != nil {
+		println()
+	}
+
+	var e error = (*MyError)(nil) // ERROR "this assignment may store a typed nil pointer in interface e"
+	if e == nil {                 // ERROR "comparison of e with nil is misleading"
+		println()
+	}
+
+	var q *MyError = &MyError{}
+	var e2 error = q
+	if e2 == nil {
+		println()
+	}
+}
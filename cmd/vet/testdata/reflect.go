@@ -0,0 +1,28 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the reflect checker.
+
+package testdata
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+func ReflectTests(data []byte, f, g func(), x, y []int) {
+	_ = reflect.DeepEqual(x, y)
+	_ = reflect.DeepEqual(f, g) // ERROR "reflect.DeepEqual of func value f is false unless both values are nil"
+
+	var v struct{ X int }
+	_ = json.Unmarshal(data, &v)
+	_ = json.Unmarshal(data, v)   // ERROR "call of encoding/json.Unmarshal passes non-pointer v"
+	_ = json.Unmarshal(data, nil) // ERROR "call of encoding/json.Unmarshal passes nil"
+
+	var i interface{} = &v
+	_ = json.Unmarshal(data, i)
+
+	var d *json.Decoder
+	_ = d.Decode(x) // ERROR "call of \(\*encoding/json.Decoder\).Decode passes non-pointer x"
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the release checker.
+
+package testdata
+
+import (
+	"io/ioutil"
+	"net/http"
+)
+
+func ReleaseOK(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func ReleaseMissing(url string) (int, error) {
+	resp, err := http.Get(url) // ERROR "the result of net/http.Get assigned to resp is not released on all paths"
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != 200 {
+		return resp.StatusCode, nil // ERROR "this return statement may be reached without releasing resp"
+	}
+	resp.Body.Close()
+	return 200, nil
+}
+
+func ReleaseDiscarded(url string) {
+	_, err := http.Get(url) // ERROR "the result of net/http.Get must be released by calling its Body.Close method, not discarded"
+	_ = err
+}
+
+func ReleaseReturned(url string) (*http.Response, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
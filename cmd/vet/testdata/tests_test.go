@@ -0,0 +1,39 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the tests checker.
+
+package testdata
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGood(t *testing.T)            {}
+func TestMain(m *testing.M)            {}
+func BenchmarkGood(b *testing.B)       {}
+func Testingood(t *testing.T)          {}             // ERROR "Testingood has malformed name: first letter after 'Test' must not be lowercase"
+func TestBadArg(b *testing.B)          {}             // ERROR "TestBadArg has wrong signature: want func TestBadArg\(\*testing.T\)"
+func TestBadResult(t *testing.T) error { return nil } // ERROR "TestBadResult has wrong signature"
+func BenchmarkBad(t *testing.T)        {}             // ERROR "BenchmarkBad has wrong signature: want func BenchmarkBad\(\*testing.B\)"
+func Benchmarkbad(b *testing.B)        {}             // ERROR "Benchmarkbad has malformed name"
+func ExampleBadArg(t *testing.T)       {}             // ERROR "ExampleBadArg should be niladic"
+func ExamplesAreNotExamples(x int)     {}
+
+type testsReceiver struct{}
+
+func (testsReceiver) TestMethodsAreIgnored(x int) {}
+
+func ExampleGood() {
+	fmt.Println("hello")
+	// Output: hello
+}
+
+func ExampleMisplacedOutput() {
+	fmt.Println("hello")
+	// Output: hello // ERROR "output comment block must be the last comment block of ExampleMisplacedOutput"
+
+	// A trailing comment hides the output.
+}
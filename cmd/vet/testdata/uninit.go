@@ -0,0 +1,107 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the uninit checker.
+
+package testdata
+
+func newError() error { return nil }
+
+func UninitOnSomePath(ok bool) error {
+	var err error
+	if ok {
+		err = newError()
+	}
+	return err // ERROR "err may be used before it is assigned"
+}
+
+func UninitOnNoPath(ok bool) error {
+	var err error
+	if ok {
+		err = newError()
+	} else {
+		err = nil
+	}
+	return err
+}
+
+func UninitLoop(xs []int) int {
+	var last int
+	for _, x := range xs {
+		last = x
+	}
+	return last // ERROR "last may be used before it is assigned"
+}
+
+func UninitSwitch(x int) string {
+	var s string
+	switch x {
+	case 0:
+		s = "zero"
+	case 1:
+		return "one"
+	default:
+		s = "many"
+	}
+	return s
+}
+
+func UninitAccumulate(xs []int) ([]int, int) {
+	var ys []int
+	var sum int
+	for _, x := range xs {
+		ys = append(ys, x)
+		sum += x
+	}
+	var n int
+	n++
+	return ys, sum + n
+}
+
+func UninitIgnored() int {
+	var p *int
+	var x int
+	p = &x
+	var f func()
+	var y int
+	f = func() { y = 1 }
+	f()
+	return *p + y
+}
+
+func UninitPanic(ok bool) int {
+	var x int
+	if ok {
+		x = 1
+	} else {
+		panic("not ok")
+	}
+	return x
+}
+
+func UninitLabeled(xs []int) int {
+	var x int
+L:
+	for {
+		for _, x = range xs {
+			if x > 0 {
+				break L
+			}
+		}
+		x = 0
+		break
+	}
+	return x
+}
+
+func UninitNilCheck(ok bool) error {
+	var err error
+	if ok {
+		err = newError()
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
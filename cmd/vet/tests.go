@@ -0,0 +1,133 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the check for the signatures of test, benchmark,
+// and example functions and for the output comments of examples.
+
+package main
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/types"
+)
+
+func init() {
+	register("tests",
+		"check for malformed test, benchmark, and example functions in _test.go files",
+		checkTestFunctions,
+		funcDecl)
+}
+
+// outputRx matches an example output comment, as recognized by go/doc.
+var outputRx = regexp.MustCompile(`(?i)^[[:space:]]*output:`)
+
+// checkTestFunctions checks a top-level function declared in a
+// _test.go file whose name marks it as a test, benchmark, or example.
+func checkTestFunctions(f *File, node ast.Node) {
+	fn := node.(*ast.FuncDecl)
+	if fn.Recv != nil || !strings.HasSuffix(f.name, "_test.go") {
+		return
+	}
+	name := fn.Name.Name
+	switch {
+	case strings.HasPrefix(name, "Example"):
+		if !isTestSuffix(name[len("Example"):], true) {
+			return // e.g. ExamplesAreGood is not an example
+		}
+		if len(fn.Type.Params.List) > 0 || fn.Type.Results != nil && len(fn.Type.Results.List) > 0 {
+			f.Badf(fn.Pos(), "%s should be niladic", name)
+		}
+		f.checkExampleOutput(fn)
+	case strings.HasPrefix(name, "Test"):
+		f.checkTestSignature(fn, "Test", "T")
+	case strings.HasPrefix(name, "Benchmark"):
+		f.checkTestSignature(fn, "Benchmark", "B")
+	}
+}
+
+// isTestSuffix reports whether suffix, the remainder of a function name
+// after its Test, Benchmark, or Example prefix, is valid: it must be
+// empty or not start with a lower-case letter.  Example suffixes may
+// also start with an underscore.
+func isTestSuffix(suffix string, example bool) bool {
+	if suffix == "" || example && suffix[0] == '_' {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(suffix)
+	return !unicode.IsLower(r)
+}
+
+// checkTestSignature reports a test or benchmark function fn whose
+// name has the specified prefix but whose signature is not
+// func(*testing.<typ>).
+func (f *File) checkTestSignature(fn *ast.FuncDecl, prefix, typ string) {
+	name := fn.Name.Name
+	if !isTestSuffix(name[len(prefix):], false) {
+		f.Badf(fn.Pos(), "%s has malformed name: first letter after '%s' must not be lowercase", name, prefix)
+		return
+	}
+	if name == "TestMain" && f.hasTestingParam(fn, "M") {
+		return // func TestMain(*testing.M) is the test driver
+	}
+	if !f.hasTestingParam(fn, typ) {
+		f.Badf(fn.Pos(), "%s has wrong signature: want func %s(*testing.%s)", name, name, typ)
+	}
+}
+
+// hasTestingParam reports whether fn has no results and a single
+// parameter of type *testing.<typ>.
+func (f *File) hasTestingParam(fn *ast.FuncDecl, typ string) bool {
+	if obj, ok := f.pkg.defs[fn.Name].(*types.Func); ok {
+		sig := obj.Type().(*types.Signature)
+		if sig.Results().Len() != 0 || sig.Params().Len() != 1 {
+			return false
+		}
+		param := sig.Params().At(0).Type()
+		if ptr, ok := param.(*types.Pointer); ok {
+			param = ptr.Elem()
+			if named, ok := param.(*types.Named); ok {
+				obj := named.Obj()
+				return obj.Pkg() != nil && obj.Pkg().Path() == "testing" && obj.Name() == typ
+			}
+		}
+		if param != types.Typ[types.Invalid] {
+			return false
+		}
+		// The testing package is unavailable; use the syntax.
+	}
+
+	if fn.Type.Results != nil && len(fn.Type.Results.List) > 0 {
+		return false
+	}
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) > 1 {
+		return false
+	}
+	return f.gofmt(params[0].Type) == "*testing."+typ
+}
+
+// checkExampleOutput reports an output comment of example fn that
+// go/doc would not recognize because it is not the last comment of
+// the function body.
+func (f *File) checkExampleOutput(fn *ast.FuncDecl) {
+	if fn.Body == nil {
+		return
+	}
+	var groups []*ast.CommentGroup
+	for _, cg := range f.file.Comments {
+		if fn.Body.Pos() < cg.Pos() && cg.End() < fn.Body.End() {
+			groups = append(groups, cg)
+		}
+	}
+	for i, cg := range groups {
+		if outputRx.MatchString(cg.Text()) && i < len(groups)-1 {
+			f.Badf(cg.Pos(), "output comment block must be the last comment block of %s", fn.Name.Name)
+		}
+	}
+}
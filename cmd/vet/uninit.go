@@ -0,0 +1,359 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+This file contains the code to check for local variables that may be
+read before they are assigned.
+
+A variable declared without an initializer, as in "var x T", holds the
+zero value of its type until it is first assigned.  Reading it before
+any assignment is well-defined, but when the variable is assigned on
+some paths and not on others it is often a mistake:
+
+	func f(ok bool) error {
+		var err error
+		if ok {
+			err = g()
+		}
+		return err // nil if !ok; intended?
+	}
+
+The check builds the control-flow graph of each function and reports
+reads of such a variable that are reachable from its declaration along
+a path that does not assign it.  Because it is often intentional to
+rely on the zero value, the check is experimental and must be enabled
+explicitly.
+
+To reduce noise, the check ignores variables of struct and array type,
+variables whose address is taken or that are used as the operand of a
+selector, index, or slice expression, and variables referenced by a
+nested function literal.  It also ignores variables that are compared
+with nil or updated by accumulation, as in n++, sum += x, or
+s = append(s, x), since these evidently rely on the zero value.
+*/
+
+package main
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/cfg"
+	"golang.org/x/tools/go/types"
+)
+
+func init() {
+	register("uninit",
+		"check for local variables that may be read before being assigned (experimental; must be set explicitly)",
+		checkUninit,
+		funcDecl, funcLit)
+	experimental["uninit"] = true
+}
+
+// uninitState holds the state of the uninit check for a single function.
+type uninitState struct {
+	f      *File
+	vars   []*types.Var             // candidate variables
+	index  map[*types.Var]int       // index of each candidate in vars
+	decls  map[*ast.ValueSpec][]int // candidates declared by each spec
+	writes map[*ast.Ident]bool      // identifiers that are assigned, not read
+	seen   map[*ast.Ident]bool      // reads already reported
+}
+
+func checkUninit(f *File, node ast.Node) {
+	var body *ast.BlockStmt
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		body = n.Body
+	case *ast.FuncLit:
+		body = n.Body
+	}
+	if body == nil {
+		return
+	}
+
+	u := &uninitState{
+		f:      f,
+		index:  make(map[*types.Var]int),
+		decls:  make(map[*ast.ValueSpec][]int),
+		writes: make(map[*ast.Ident]bool),
+		seen:   make(map[*ast.Ident]bool),
+	}
+	u.findCandidates(body)
+	if len(u.vars) == 0 {
+		return
+	}
+	ignored := u.findIgnored(body)
+	u.findWrites(body, ignored)
+	u.retain(body, ignored)
+	if len(u.vars) == 0 {
+		return
+	}
+
+	g := cfg.New(body, nil)
+	u.solve(g)
+}
+
+// findCandidates records the variables declared without an initializer
+// in body, excluding those declared in nested function literals and
+// those of struct or array type.
+func (u *uninitState) findCandidates(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ValueSpec:
+			if len(n.Values) > 0 {
+				break
+			}
+			for _, id := range n.Names {
+				v, ok := u.f.pkg.defs[id].(*types.Var)
+				if !ok || id.Name == "_" {
+					continue
+				}
+				switch v.Type().Underlying().(type) {
+				case *types.Struct, *types.Array:
+					continue
+				}
+				u.index[v] = len(u.vars)
+				u.vars = append(u.vars, v)
+			}
+		}
+		return true
+	})
+}
+
+// findIgnored returns the candidates whose accesses are beyond the
+// analysis: those whose address is taken, that are the operand of a
+// selector, index, or slice expression, or that are referenced by a
+// nested function literal.  Candidates compared with nil evidently
+// rely on their zero value and are ignored too.
+func (u *uninitState) findIgnored(body *ast.BlockStmt) map[*types.Var]bool {
+	ignored := make(map[*types.Var]bool)
+	var visit func(n ast.Node, nested bool)
+	visit = func(n ast.Node, nested bool) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			var id *ast.Ident
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				visit(n.Body, true)
+				return false
+			case *ast.Ident:
+				if nested {
+					id = n
+				}
+			case *ast.UnaryExpr:
+				if n.Op == token.AND {
+					id, _ = unparen(n.X).(*ast.Ident)
+				}
+			case *ast.SelectorExpr:
+				id, _ = unparen(n.X).(*ast.Ident)
+			case *ast.IndexExpr:
+				id, _ = unparen(n.X).(*ast.Ident)
+			case *ast.SliceExpr:
+				id, _ = unparen(n.X).(*ast.Ident)
+			case *ast.BinaryExpr:
+				// x == nil and x != nil test for the zero value.
+				if n.Op == token.EQL || n.Op == token.NEQ {
+					if isNil(n.Y) {
+						id, _ = unparen(n.X).(*ast.Ident)
+					} else if isNil(n.X) {
+						id, _ = unparen(n.Y).(*ast.Ident)
+					}
+				}
+			}
+			if id != nil {
+				if v := u.candidate(id); v != nil {
+					ignored[v] = true
+				}
+			}
+			return true
+		})
+	}
+	visit(body, false)
+	return ignored
+}
+
+// findWrites records the identifiers in body that denote an assignment
+// to a candidate variable.  Candidates that are updated by accumulation
+// (x++, x += y, x = f(x)) evidently rely on their zero value, and are
+// added to ignored.
+func (u *uninitState) findWrites(body *ast.BlockStmt, ignored map[*types.Var]bool) {
+	write := func(e ast.Expr) *types.Var {
+		if id, ok := unparen(e).(*ast.Ident); ok {
+			if v := u.candidate(id); v != nil {
+				u.writes[id] = true
+				return v
+			}
+		}
+		return nil
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			assigned := make(map[*types.Var]bool)
+			for _, lhs := range n.Lhs {
+				if v := write(lhs); v != nil {
+					assigned[v] = true
+					if n.Tok != token.ASSIGN && n.Tok != token.DEFINE {
+						ignored[v] = true // x op= y
+					}
+				}
+			}
+			for _, rhs := range n.Rhs {
+				ast.Inspect(rhs, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok {
+						if v := u.candidate(id); assigned[v] {
+							ignored[v] = true // x = f(x)
+						}
+					}
+					return true
+				})
+			}
+		case *ast.IncDecStmt:
+			if v := write(n.X); v != nil {
+				ignored[v] = true
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				if n.Key != nil {
+					write(n.Key)
+				}
+				if n.Value != nil {
+					write(n.Value)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// retain discards the ignored candidates and records the declarations
+// of the remaining ones.
+func (u *uninitState) retain(body *ast.BlockStmt, ignored map[*types.Var]bool) {
+	vars := u.vars
+	u.vars = nil
+	u.index = make(map[*types.Var]int)
+	for _, v := range vars {
+		if !ignored[v] {
+			u.index[v] = len(u.vars)
+			u.vars = append(u.vars, v)
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ValueSpec:
+			for _, id := range n.Names {
+				if v, ok := u.f.pkg.defs[id].(*types.Var); ok {
+					if i, ok := u.index[v]; ok {
+						u.decls[n] = append(u.decls[n], i)
+					}
+				}
+			}
+		}
+		return true
+	})
+}
+
+// isNil reports whether e is the identifier nil.
+func isNil(e ast.Expr) bool {
+	id, ok := unparen(e).(*ast.Ident)
+	return ok && id.Name == "nil"
+}
+
+// candidate returns the candidate variable used by id, or nil.
+func (u *uninitState) candidate(id *ast.Ident) *types.Var {
+	if v, ok := u.f.pkg.uses[id].(*types.Var); ok {
+		if _, ok := u.index[v]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// solve computes, for the entry of each block of g, the set of
+// candidate variables that may be unassigned, and reports the reads
+// that may observe an unassigned variable.
+func (u *uninitState) solve(g *cfg.CFG) {
+	in := make([][]bool, len(g.Blocks))
+	in[0] = make([]bool, len(u.vars))
+	queue := []*cfg.Block{g.Blocks[0]}
+	queued := make([]bool, len(g.Blocks))
+	queued[0] = true
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		queued[b.Index] = false
+
+		out := u.transfer(b, in[b.Index], false)
+		for _, succ := range b.Succs {
+			changed := false
+			if in[succ.Index] == nil {
+				in[succ.Index] = make([]bool, len(u.vars))
+				changed = true
+			}
+			for i, unassigned := range out {
+				if unassigned && !in[succ.Index][i] {
+					in[succ.Index][i] = true
+					changed = true
+				}
+			}
+			if changed && !queued[succ.Index] {
+				queue = append(queue, succ)
+				queued[succ.Index] = true
+			}
+		}
+	}
+
+	// Report reads in the fixed point.
+	for _, b := range g.Blocks {
+		if in[b.Index] != nil {
+			u.transfer(b, in[b.Index], true)
+		}
+	}
+}
+
+// transfer returns the set of possibly unassigned variables at the
+// end of block b given the set at its entry.  If report is set, it
+// reports the reads of possibly unassigned variables.
+func (u *uninitState) transfer(b *cfg.Block, in []bool, report bool) []bool {
+	state := append([]bool(nil), in...)
+	for _, n := range b.Nodes {
+		if spec, ok := n.(*ast.ValueSpec); ok {
+			for _, i := range u.decls[spec] {
+				state[i] = true
+			}
+		}
+		// Operands are evaluated before the assignment takes effect.
+		var assigned []int
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.Ident:
+				v := u.candidate(n)
+				if v == nil {
+					break
+				}
+				i := u.index[v]
+				if u.writes[n] {
+					assigned = append(assigned, i)
+				} else if report && state[i] && !u.seen[n] {
+					u.seen[n] = true
+					u.f.Badf(n.Pos(), "%s may be used before it is assigned", n.Name)
+				}
+			}
+			return true
+		})
+		for _, i := range assigned {
+			state[i] = false
+		}
+	}
+	return state
+}
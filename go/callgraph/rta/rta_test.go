@@ -93,6 +93,63 @@ func TestRTA(t *testing.T) {
 	}
 }
 
+// TestMonomorphicCalls checks callgraph.MonomorphicCalls on the
+// call graph computed by RTA.
+func TestMonomorphicCalls(t *testing.T) {
+	const src = `package main
+
+type I interface{ f() }
+
+type J interface{ g() }
+
+type A int
+
+func (A) f() {}
+
+type B int
+
+func (B) f() {}
+func (B) g() {}
+
+type C int
+
+func (C) g() {} // C is never converted to an interface
+
+func main() {
+	var i I = A(0)
+	if len("") == 0 {
+		i = B(1)
+	}
+	i.f() // A.f and B.f
+
+	var j J = B(2)
+	j.g() // only B.g
+}
+`
+	var conf loader.Config
+	f, err := conf.ParseFile("main.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := ssautil.CreateProgram(iprog, 0)
+	mainPkg := prog.Package(iprog.Created[0].Pkg)
+	prog.BuildAll()
+
+	res := rta.Analyze([]*ssa.Function{mainPkg.Func("main")}, true)
+	var got []string
+	for site, callee := range callgraph.MonomorphicCalls(res.CallGraph) {
+		got = append(got, fmt.Sprintf("%d: %s", prog.Fset.Position(site.Pos()).Line, callee.RelString(mainPkg.Object)))
+	}
+	if got, want := strings.Join(got, "; "), "28: (B).g"; got != want {
+		t.Errorf("MonomorphicCalls: got %s, want %s", got, want)
+	}
+}
+
 func printResult(res *rta.Result, from *types.Package) string {
 	var buf bytes.Buffer
 
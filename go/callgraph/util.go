@@ -179,3 +179,43 @@ func removeInEdge(edge *Edge) {
 	}
 	panic("edge not found: " + edge.String())
 }
+
+// MonomorphicCalls returns a new map from each interface method call
+// ("invoke" mode) in graph g that has exactly one callee to that
+// callee.  Such a call may be a candidate for devirtualization: it
+// could be replaced by a type assertion and a static call, or the
+// interface might be replaced by the concrete type.
+//
+// Synthetic wrappers are identified with the methods they wrap, so
+// that a call whose callees are (T).f and its wrapper (*T).f has the
+// single callee (T).f.
+//
+// The result is only as precise, and as sound, as the analysis that
+// constructed g.  It is meaningful for a call graph of a complete
+// program computed by RTA or pointer analysis, but not for one of a
+// library, to which other programs may add implementations.
+//
+func MonomorphicCalls(g *Graph) map[ssa.CallInstruction]*ssa.Function {
+	callees := make(map[ssa.CallInstruction]*ssa.Function)
+	poly := make(map[ssa.CallInstruction]bool)
+	for _, n := range g.Nodes {
+		for _, e := range n.Out {
+			if e.Site == nil || !e.Site.Common().IsInvoke() || poly[e.Site] {
+				continue
+			}
+			callee := e.Callee.Func
+			if w := callee.Wrapper(); w != nil && w.Kind == ssa.MethodWrapper {
+				if decl := callee.Prog.FuncValue(w.Method); decl != nil {
+					callee = decl
+				}
+			}
+			if fn, ok := callees[e.Site]; !ok {
+				callees[e.Site] = callee
+			} else if fn != callee {
+				delete(callees, e.Site)
+				poly[e.Site] = true
+			}
+		}
+	}
+	return callees
+}
@@ -0,0 +1,209 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+const src = `package main
+
+import "log"
+
+func f1() {
+	live()
+	return
+	dead()
+}
+
+func f2() {
+	for {
+		live()
+	}
+	dead()
+}
+
+func f3() {
+	for {
+		live()
+		break
+		dead()
+	}
+	live()
+}
+
+func f4() {
+L:
+	for {
+		for {
+			break L
+		}
+		dead()
+	}
+	live()
+}
+
+func f5() {
+	goto L
+	dead()
+L:
+	live()
+}
+
+func f6() {
+	panic(1)
+	dead()
+}
+
+func f7() {
+	log.Fatal("oops")
+	dead()
+}
+
+func f8(x int) {
+	switch x {
+	case 1:
+		live()
+		fallthrough
+	case 2:
+		live()
+		return
+		dead()
+	default:
+		break
+	}
+	live()
+}
+
+func f9(ch chan int) {
+L:
+	select {
+	case <-ch:
+		for {
+			live()
+			break L
+		}
+		dead()
+	}
+	live()
+}
+
+func f10(x interface{}) {
+	switch x.(type) {
+	case int:
+		return
+		dead()
+	}
+	live()
+}
+
+func f11() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			live()
+		}
+	}()
+	live()
+	panic(1)
+	dead()
+}
+`
+
+func TestDeadCode(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "dummy.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range f.Decls {
+		if decl, ok := decl.(*ast.FuncDecl); ok {
+			g := New(decl.Body, mayReturn)
+
+			// Print statements in unreachable blocks
+			// (in order determined by builder).
+			var buf bytes.Buffer
+			for _, b := range g.Blocks {
+				if !b.Live {
+					for _, n := range b.Nodes {
+						fmt.Fprintf(&buf, "\t%s\n", formatNode(fset, n))
+					}
+				}
+			}
+
+			// Check that the result contains "dead" at least once but not "live".
+			if !bytes.Contains(buf.Bytes(), []byte("dead")) ||
+				bytes.Contains(buf.Bytes(), []byte("live")) {
+				t.Errorf("unexpected dead statements in function %s:\n%s",
+					decl.Name.Name,
+					&buf)
+				t.Logf("control flow graph:\n%s", g.Format(fset))
+			}
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "dummy.go", `package p
+
+func panics() {
+	defer func() {}()
+	if cond {
+		panic(1)
+	}
+}
+
+func recovers() {
+	defer func() { recover() }()
+	if cond {
+		panic(1)
+	}
+}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{false, true} {
+		g := New(f.Decls[i].(*ast.FuncDecl).Body, nil)
+		if got := g.Recover != nil; got != want {
+			t.Errorf("%s: Recover != nil is %t, want %t", f.Decls[i].(*ast.FuncDecl).Name.Name, got, want)
+			continue
+		}
+		var panics []*Block
+		for _, b := range g.Blocks {
+			if b.Panic {
+				panics = append(panics, b)
+			}
+		}
+		if len(panics) != 1 {
+			t.Errorf("got %d panicking blocks, want 1", len(panics))
+			continue
+		}
+		b := panics[0]
+		if g.Recover == nil {
+			if len(b.Succs) != 0 {
+				t.Errorf("panicking block has successors %v, want none", b.Succs)
+			}
+		} else if len(b.Succs) != 1 || b.Succs[0] != g.Recover || !g.Recover.Live {
+			t.Errorf("panicking block has successors %v, want live %s", b.Succs, g.Recover)
+		}
+	}
+}
+
+// A trivial mayReturn predicate that looks only at syntax, not types.
+func mayReturn(call *ast.CallExpr) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name != "panic"
+	case *ast.SelectorExpr:
+		return fun.Sel.Name != "Fatal"
+	}
+	return true
+}
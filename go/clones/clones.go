@@ -0,0 +1,283 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package clones detects duplicated code: pairs of functions whose
+// bodies are the same, or nearly so, up to the names of their local
+// variables and the spelling of their constants.
+//
+// Each function body is normalized to a sequence of tokens that
+// describe its syntax tree in preorder, with positions, comments, and
+// parentheses erased.  The local variables of the function, its
+// parameters and results included, are renamed in order of first
+// occurrence; references to other objects, such as package-level
+// functions and fields, are kept, qualified by package, as are
+// operators and the names of selected fields and methods.  Each
+// constant expression, such as 1<<10 or a reference to a named
+// constant, is folded into its value, as computed by the type checker.
+//
+// The similarity of two bodies is the Jaccard index of the sets of
+// their shingles, the runs of a few consecutive tokens: 1 if their
+// normalized forms are the same, less if they share fewer
+// subsequences.
+package clones // import "golang.org/x/tools/go/clones"
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+)
+
+// shingleSize is the number of consecutive tokens in a shingle.
+const shingleSize = 5
+
+// A Clone is a pair of functions with similar bodies.
+type Clone struct {
+	A, B       *types.Func // in order of position
+	Similarity float64     // in (0, 1]; 1 for bodies with the same normal form
+}
+
+func (c *Clone) String() string {
+	return fmt.Sprintf("%s ~ %s (%.2f)", c.A.FullName(), c.B.FullName(), c.Similarity)
+}
+
+// A Config specifies which pairs of functions are clones.
+type Config struct {
+	// Threshold is the minimum similarity of clones; 0 means 0.8.
+	Threshold float64
+
+	// MinTokens is the minimum number of tokens of the normal form of
+	// each body of a clone, which excludes trivial functions; 0 means
+	// 50.
+	MinTokens int
+}
+
+// Find returns the clones among the functions and methods declared in
+// the initial packages of prog, in order of decreasing similarity and
+// then of position.
+func (conf *Config) Find(prog *loader.Program) []*Clone {
+	threshold := conf.Threshold
+	if threshold == 0 {
+		threshold = 0.8
+	}
+	minTokens := conf.MinTokens
+	if minTokens == 0 {
+		minTokens = 50
+	}
+
+	// Normalize each body, and index the bodies by shingle.
+	type body struct {
+		fn       *types.Func
+		shingles map[string]bool
+	}
+	var bodies []*body
+	index := make(map[string][]int) // indices of the bodies with each shingle
+	for _, info := range prog.InitialPackages() {
+		for _, f := range info.Files {
+			for _, decl := range f.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				fn, ok := info.Defs[fd.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				tokens := Normalize(&info.Info, fd)
+				if len(tokens) < minTokens {
+					continue
+				}
+				b := &body{fn, shingles(tokens)}
+				for s := range b.shingles {
+					index[s] = append(index[s], len(bodies))
+				}
+				bodies = append(bodies, b)
+			}
+		}
+	}
+
+	// Count the shingles each pair of bodies shares.
+	shared := make(map[[2]int]int)
+	for _, is := range index {
+		for x := 0; x < len(is); x++ {
+			for y := x + 1; y < len(is); y++ {
+				shared[[2]int{is[x], is[y]}]++
+			}
+		}
+	}
+
+	var clones []*Clone
+	for pair, n := range shared {
+		a, b := bodies[pair[0]], bodies[pair[1]]
+		sim := float64(n) / float64(len(a.shingles)+len(b.shingles)-n)
+		if sim < threshold {
+			continue
+		}
+		if b.fn.Pos() < a.fn.Pos() {
+			a, b = b, a
+		}
+		clones = append(clones, &Clone{A: a.fn, B: b.fn, Similarity: sim})
+	}
+	sort.Sort(bySimilarity(clones))
+	return clones
+}
+
+type bySimilarity []*Clone
+
+func (b bySimilarity) Len() int { return len(b) }
+func (b bySimilarity) Less(i, j int) bool {
+	if b[i].Similarity != b[j].Similarity {
+		return b[i].Similarity > b[j].Similarity
+	}
+	if b[i].A.Pos() != b[j].A.Pos() {
+		return b[i].A.Pos() < b[j].A.Pos()
+	}
+	return b[i].B.Pos() < b[j].B.Pos()
+}
+func (b bySimilarity) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+
+// shingles returns the set of shingles of the tokens.
+func shingles(tokens []string) map[string]bool {
+	set := make(map[string]bool)
+	for i := 0; i+shingleSize <= len(tokens); i++ {
+		s := ""
+		for _, tok := range tokens[i : i+shingleSize] {
+			s += tok + "\x00"
+		}
+		set[s] = true
+	}
+	return set
+}
+
+// Normalize returns the normal form of the body of the function
+// declaration fd, as described in the package documentation, whose
+// type information is supplied in info.  The Types, Defs, and Uses maps
+// of info must be populated.
+func Normalize(info *types.Info, fd *ast.FuncDecl) []string {
+	n := &normalizer{info: info, fd: fd, locals: make(map[types.Object]int)}
+	// The parameters and results are locals of the body.
+	ast.Inspect(fd.Type, func(node ast.Node) bool {
+		if id, ok := node.(*ast.Ident); ok {
+			if obj := info.Defs[id]; obj != nil {
+				n.local(obj)
+			}
+		}
+		return true
+	})
+	if fd.Recv != nil {
+		for _, field := range fd.Recv.List {
+			for _, id := range field.Names {
+				if obj := info.Defs[id]; obj != nil {
+					n.local(obj)
+				}
+			}
+		}
+	}
+	ast.Inspect(fd.Body, n.visit)
+	return n.tokens
+}
+
+type normalizer struct {
+	info   *types.Info
+	fd     *ast.FuncDecl
+	locals map[types.Object]int // number of each local, in order of first occurrence
+	tokens []string
+}
+
+func (n *normalizer) emit(tok string) { n.tokens = append(n.tokens, tok) }
+
+// local returns the normalized name of the local object.
+func (n *normalizer) local(obj types.Object) string {
+	i, ok := n.locals[obj]
+	if !ok {
+		i = len(n.locals)
+		n.locals[obj] = i
+	}
+	return fmt.Sprintf("$%d", i)
+}
+
+func (n *normalizer) isLocal(obj types.Object) bool {
+	return obj.Pos() >= n.fd.Pos() && obj.Pos() < n.fd.End() && obj.Parent() != nil && obj.Parent() != obj.Pkg().Scope()
+}
+
+func (n *normalizer) visit(node ast.Node) bool {
+	if node == nil {
+		n.emit(")")
+		return false
+	}
+	if e, ok := node.(ast.Expr); ok {
+		if tv, ok := n.info.Types[e]; ok && tv.Value != nil {
+			n.emit("const " + tv.Value.String())
+			n.emit(")")
+			return false
+		}
+	}
+	switch node := node.(type) {
+	case *ast.ParenExpr:
+		ast.Inspect(node.X, n.visit)
+		return false
+	case *ast.Ident:
+		n.emit(n.ident(node))
+	case *ast.SelectorExpr:
+		if obj := n.info.Uses[node.Sel]; obj != nil && obj.Pkg() != nil {
+			if _, ok := n.info.Uses[identOf(node.X)].(*types.PkgName); ok {
+				n.emit(obj.Pkg().Path() + "." + obj.Name()) // qualified identifier
+				n.emit(")")
+				return false
+			}
+		}
+		n.emit("select ." + node.Sel.Name)
+		ast.Inspect(node.X, n.visit)
+		n.emit(")")
+		return false
+	case *ast.BinaryExpr:
+		n.emit("binary " + node.Op.String())
+	case *ast.UnaryExpr:
+		n.emit("unary " + node.Op.String())
+	case *ast.AssignStmt:
+		n.emit("assign " + node.Tok.String())
+	case *ast.IncDecStmt:
+		n.emit("incdec " + node.Tok.String())
+	case *ast.BranchStmt:
+		n.emit("branch " + node.Tok.String())
+		return false // ignore the label
+	case *ast.LabeledStmt:
+		n.emit("labeled")
+		ast.Inspect(node.Stmt, n.visit)
+		n.emit(")")
+		return false
+	case *ast.BasicLit:
+		n.emit("lit " + node.Kind.String() + " " + node.Value)
+	default:
+		n.emit(fmt.Sprintf("%T", node))
+	}
+	return true
+}
+
+// ident returns the normalized form of the identifier.
+func (n *normalizer) ident(id *ast.Ident) string {
+	obj := n.info.Defs[id]
+	if obj == nil {
+		obj = n.info.Uses[id]
+	}
+	switch {
+	case obj == nil:
+		return "_"
+	case obj.Pkg() == nil:
+		return obj.Name() // universe
+	case n.isLocal(obj):
+		return n.local(obj)
+	case obj.Parent() == obj.Pkg().Scope():
+		return obj.Pkg().Path() + "." + obj.Name()
+	}
+	return "." + obj.Name() // a field or method, as in a composite literal key
+}
+
+// identOf returns the identifier e, or nil.
+func identOf(e ast.Expr) *ast.Ident {
+	id, _ := e.(*ast.Ident)
+	return id
+}
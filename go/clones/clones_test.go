@@ -0,0 +1,107 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clones_test
+
+import (
+	"go/ast"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/clones"
+	"golang.org/x/tools/go/loader"
+)
+
+const input = `package p
+
+const kilo = 1 << 10
+
+type T struct{ n int }
+
+func sum(xs []int) int {
+	total := 0
+	for i, x := range xs {
+		if x > kilo {
+			total += x * i
+		}
+	}
+	return total
+}
+
+func add(values []int) int {
+	s := 0
+	for j, v := range values {
+		if v > 1024 {
+			s += (v * j)
+		}
+	}
+	return s
+}
+
+func (t *T) count(ys []int) int {
+	c := 0
+	for _, y := range ys {
+		if y > 1024 {
+			c++
+		}
+		t.n++
+	}
+	return c
+}
+`
+
+func load(t *testing.T) *loader.Program {
+	var conf loader.Config
+	f, err := conf.ParseFile("p.go", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("p", f)
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prog
+}
+
+func TestFind(t *testing.T) {
+	prog := load(t)
+	conf := clones.Config{MinTokens: 10}
+	var got []string
+	for _, c := range conf.Find(prog) {
+		got = append(got, c.String())
+	}
+	want := []string{"p.sum ~ p.add (1.00)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find: got %q, want %q", got, want)
+	}
+
+	conf.Threshold = 0.01
+	if n := len(conf.Find(prog)); n != 3 {
+		t.Errorf("Find with low threshold: got %d clones, want 3", n)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	prog := load(t)
+	info := prog.Created[0]
+	forms := make(map[string][]string)
+	for _, decl := range info.Files[0].Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			forms[fd.Name.Name] = clones.Normalize(&info.Info, fd)
+		}
+	}
+	if !reflect.DeepEqual(forms["sum"], forms["add"]) {
+		t.Errorf("sum and add: different normal forms:\n%q\n%q", forms["sum"], forms["add"])
+	}
+	if reflect.DeepEqual(forms["sum"], forms["count"]) {
+		t.Errorf("sum and count: same normal form %q", forms["sum"])
+	}
+	for _, tok := range forms["sum"] {
+		if tok == "const 1024" {
+			return
+		}
+	}
+	t.Errorf("sum: constant kilo not folded: %q", forms["sum"])
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"go/ast"
+	"go/token"
 	"strings"
 
 	"golang.org/x/tools/go/exact"
@@ -30,12 +31,39 @@ func format() byte {
 	return 'p'
 }
 
+// A Mode determines how much detail export data records.
+type Mode int
+
+const (
+	// Shallow export data records only what is needed to import
+	// the package for type checking: its exported objects and the
+	// types they depend on.  It records no positions.
+	Shallow Mode = iota
+
+	// Deep export data records in addition the unexported
+	// package-level objects, and the file and line of each
+	// package-level object and method, for tools that need a
+	// complete view of the package.
+	Deep
+)
+
 // ExportData serializes the interface (exported package objects)
 // of package pkg and returns the corresponding data. The export
 // format is described elsewhere (TODO).
+//
+// ExportData is equivalent to ExportDataMode(nil, pkg, Shallow).
 func ExportData(pkg *types.Package) []byte {
+	return ExportDataMode(nil, pkg, Shallow)
+}
+
+// ExportDataMode serializes package pkg in the specified mode and
+// returns the corresponding data.  Positions in Deep export data are
+// interpreted using fset; if fset is nil, none are recorded.
+func ExportDataMode(fset *token.FileSet, pkg *types.Package, mode Mode) []byte {
 	p := exporter{
 		data:     append([]byte(magic), format()),
+		fset:     fset,
+		mode:     mode,
 		pkgIndex: make(map[*types.Package]int),
 		typIndex: make(map[types.Type]int),
 	}
@@ -50,30 +78,52 @@ func ExportData(pkg *types.Package) []byte {
 		defer p.tracef("\n")
 	}
 
-	p.string(version)
+	p.int(version)
+	p.int(compatVersion)
+	p.int(int(mode))
 
-	p.pkg(pkg)
+	p.section(objectsSection, func() {
+		p.pkg(pkg)
 
-	// collect exported objects from package scope
-	var list []types.Object
-	scope := pkg.Scope()
-	for _, name := range scope.Names() {
-		if exported(name) {
-			list = append(list, scope.Lookup(name))
+		// collect exported (or, in deep mode, all) objects from package scope
+		var list []types.Object
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			if exported(name) || mode == Deep {
+				list = append(list, scope.Lookup(name))
+			}
 		}
-	}
 
-	// write objects
-	p.int(len(list))
-	for _, obj := range list {
-		p.obj(obj)
-	}
+		// write objects
+		p.int(len(list))
+		for _, obj := range list {
+			p.obj(obj)
+		}
+	})
+	p.int(endSection)
 
 	return p.data
 }
 
+// section writes a section of the specified kind whose contents are
+// written by f.
+func (p *exporter) section(kind int, f func()) {
+	if trace {
+		p.tracef("section %d {\n", kind)
+		defer p.tracef("}\n")
+	}
+
+	p.int(kind)
+	n := len(p.data)
+	p.data = append(p.data, 0, 0, 0, 0) // length, set below
+	f()
+	binary.LittleEndian.PutUint32(p.data[n:], uint32(len(p.data)-n-4))
+}
+
 type exporter struct {
 	data     []byte
+	fset     *token.FileSet // for positions; may be nil
+	mode     Mode
 	pkgIndex map[*types.Package]int
 	typIndex map[types.Type]int
 
@@ -113,6 +163,7 @@ func (p *exporter) obj(obj types.Object) {
 	switch obj := obj.(type) {
 	case *types.Const:
 		p.int(constTag)
+		p.pos(obj)
 		p.string(obj.Name())
 		p.typ(obj.Type())
 		p.value(obj.Val())
@@ -122,10 +173,12 @@ func (p *exporter) obj(obj types.Object) {
 		p.typ(obj.Type().(*types.Named))
 	case *types.Var:
 		p.int(varTag)
+		p.pos(obj)
 		p.string(obj.Name())
 		p.typ(obj.Type())
 	case *types.Func:
 		p.int(funcTag)
+		p.pos(obj)
 		p.string(obj.Name())
 		p.typ(obj.Type())
 	default:
@@ -133,6 +186,21 @@ func (p *exporter) obj(obj types.Object) {
 	}
 }
 
+// pos writes the file and line of obj, in deep mode only.
+func (p *exporter) pos(obj types.Object) {
+	if p.mode != Deep {
+		return
+	}
+	var file string
+	var line int
+	if p.fset != nil && obj.Pos().IsValid() {
+		posn := p.fset.Position(obj.Pos())
+		file, line = posn.Filename, posn.Line
+	}
+	p.string(file)
+	p.int(line)
+}
+
 func (p *exporter) value(x exact.Value) {
 	if trace {
 		p.tracef("value { ")
@@ -285,6 +353,7 @@ func (p *exporter) typ(typ types.Type) {
 		obj := t.Obj()
 		p.string(obj.Name())
 		p.pkg(obj.Pkg())
+		p.pos(obj)
 
 		// write underlying type
 		p.typ(t.Underlying())
@@ -295,6 +364,7 @@ func (p *exporter) typ(typ types.Type) {
 		for i := 0; i < n; i++ {
 			m := t.Method(i)
 			p.string(m.Name())
+			p.pos(m)
 			p.typ(m.Type())
 		}
 
@@ -24,6 +24,39 @@ import (
 // general it is not recommended to call ImportData on untrusted
 // data.
 func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Package, error) {
+	return new(Config).ImportData(imports, data)
+}
+
+// ImportDataInterned is like ImportData, but if table is non-nil,
+// the names and paths read from data are interned in it.
+func ImportDataInterned(imports map[string]*types.Package, data []byte, table *types.StringTable) (int, *types.Package, error) {
+	conf := Config{Strings: table}
+	return conf.ImportData(imports, data)
+}
+
+// A Config specifies how export data is imported.
+// The zero value imports as ImportData does.
+type Config struct {
+	// Mode is the most detailed mode of export data the client
+	// wants.  Whatever the mode in which the data was written,
+	// only its Shallow information is imported if Mode is Shallow,
+	// and the Deep information it has, if any, if Mode is Deep.
+	Mode Mode
+
+	// If Fset is non-nil and Mode is Deep, the positions recorded
+	// in deep export data are added to Fset, as lines of files
+	// with the recorded names.  Otherwise imported objects have
+	// no positions.
+	Fset *token.FileSet
+
+	// If Strings is non-nil, the names and paths read from the
+	// data are interned in it.
+	Strings *types.StringTable
+}
+
+// ImportData is like the package-level function of the same name,
+// but imports according to the configuration.
+func (conf *Config) ImportData(imports map[string]*types.Package, data []byte) (int, *types.Package, error) {
 	datalen := len(data)
 
 	// check magic string
@@ -47,9 +80,11 @@ func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Pac
 	}
 
 	p := importer{
+		conf:    conf,
 		data:    data,
 		datalen: datalen,
 		imports: imports,
+		strings: conf.Strings,
 	}
 
 	// populate typList with predeclared types
@@ -57,19 +92,58 @@ func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Pac
 		p.typList = append(p.typList, t)
 	}
 
-	if v := p.string(); v != version {
-		return 0, nil, fmt.Errorf("unknown version: got %s; want %s", v, version)
+	v, compat := p.int(), p.int()
+	if compat > version {
+		return 0, nil, fmt.Errorf("export data version %d requires importer version %d; have %d", v, compat, version)
 	}
-
-	pkg := p.pkg()
-	if debug && p.pkgList[0] != pkg {
-		panic("imported packaged not found in pkgList[0]")
+	switch mode := Mode(p.int()); mode {
+	case Shallow:
+	case Deep:
+		p.deep = true
+	default:
+		return 0, nil, fmt.Errorf("unknown export data mode %d", mode)
 	}
 
-	// read objects
-	n := p.int()
-	for i := 0; i < n; i++ {
-		p.obj(pkg)
+	// read sections, skipping those of unknown kinds
+	var pkg *types.Package
+	for {
+		kind := p.int()
+		if kind == endSection {
+			break
+		}
+		if len(p.data) < 4 {
+			return 0, nil, fmt.Errorf("truncated section %d", kind)
+		}
+		size := int(binary.LittleEndian.Uint32(p.data))
+		p.data = p.data[4:]
+		if size > len(p.data) {
+			return 0, nil, fmt.Errorf("truncated section %d", kind)
+		}
+		end := p.consumed() + size
+
+		switch kind {
+		case objectsSection:
+			pkg = p.pkg()
+			if debug && p.pkgList[0] != pkg {
+				panic("imported packaged not found in pkgList[0]")
+			}
+
+			// read objects
+			n := p.int()
+			for i := 0; i < n; i++ {
+				p.obj(pkg)
+			}
+
+		default:
+			p.data = p.data[size:]
+		}
+
+		if p.consumed() != end {
+			return 0, nil, fmt.Errorf("section %d: read %d bytes; want %d", kind, p.consumed()-(end-size), size)
+		}
+	}
+	if pkg == nil {
+		return 0, nil, fmt.Errorf("no objects section")
 	}
 
 	// complete interfaces
@@ -79,6 +153,15 @@ func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Pac
 		}
 	}
 
+	// establish the lines of the files of the imported positions
+	for _, f := range p.files {
+		lines := make([]int, f.lines)
+		for i := range lines {
+			lines[i] = i
+		}
+		f.file.SetLines(lines)
+	}
+
 	// package was imported completely and without errors
 	pkg.MarkComplete()
 
@@ -86,11 +169,15 @@ func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Pac
 }
 
 type importer struct {
+	conf    *Config
 	data    []byte
+	deep    bool // data is in deep mode
+	files   map[string]*posFile
 	datalen int
 	imports map[string]*types.Package
 	pkgList []*types.Package
 	typList []types.Type
+	strings *types.StringTable // if non-nil, interns strings
 }
 
 func (p *importer) pkg() *types.Package {
@@ -124,24 +211,64 @@ func (p *importer) obj(pkg *types.Package) {
 	var obj types.Object
 	switch tag := p.int(); tag {
 	case constTag:
-		obj = types.NewConst(token.NoPos, pkg, p.string(), p.typ(), p.value())
+		obj = types.NewConst(p.pos(), pkg, p.string(), p.typ(), p.value())
 	case typeTag:
 		// type object is added to scope via respective named type
 		_ = p.typ().(*types.Named)
 		return
 	case varTag:
-		obj = types.NewVar(token.NoPos, pkg, p.string(), p.typ())
+		obj = types.NewVar(p.pos(), pkg, p.string(), p.typ())
 	case funcTag:
-		obj = types.NewFunc(token.NoPos, pkg, p.string(), p.typ().(*types.Signature))
+		obj = types.NewFunc(p.pos(), pkg, p.string(), p.typ().(*types.Signature))
 	default:
 		panic(fmt.Sprintf("unexpected object tag %d", tag))
 	}
 
+	if !exported(obj.Name()) && p.conf.Mode != Deep {
+		return // recorded by deep export data only
+	}
+
 	if alt := pkg.Scope().Insert(obj); alt != nil {
 		panic(fmt.Sprintf("%s already declared", alt.Name()))
 	}
 }
 
+// A posFile is a file of imported positions, whose offsets are
+// line numbers.
+type posFile struct {
+	file  *token.File
+	lines int // number of lines needed
+}
+
+// maxLines is the most lines a file of imported positions may have.
+const maxLines = 1 << 20
+
+// pos reads the file and line of an object, if the data is in deep
+// mode, and returns the corresponding position, if the client wants
+// positions.
+func (p *importer) pos() token.Pos {
+	if !p.deep {
+		return token.NoPos
+	}
+	file := p.string()
+	line := p.int()
+	if p.conf.Mode != Deep || p.conf.Fset == nil || file == "" || line <= 0 || line > maxLines {
+		return token.NoPos
+	}
+	f := p.files[file]
+	if f == nil {
+		if p.files == nil {
+			p.files = make(map[string]*posFile)
+		}
+		f = &posFile{file: p.conf.Fset.AddFile(file, -1, maxLines)}
+		p.files[file] = f
+	}
+	if line > f.lines {
+		f.lines = line
+	}
+	return f.file.Pos(line - 1)
+}
+
 func (p *importer) value() exact.Value {
 	switch kind := exact.Kind(p.int()); kind {
 	case falseTag:
@@ -303,12 +430,13 @@ func (p *importer) typ() types.Type {
 		// read type object
 		name := p.string()
 		pkg := p.pkg()
+		pos := p.pos()
 		scope := pkg.Scope()
 		obj := scope.Lookup(name)
 
 		// if the object doesn't exist yet, create and insert it
 		if obj == nil {
-			obj = types.NewTypeName(token.NoPos, pkg, name, nil)
+			obj = types.NewTypeName(pos, pkg, name, nil)
 			scope.Insert(obj)
 		}
 
@@ -324,7 +452,9 @@ func (p *importer) typ() types.Type {
 
 		// read associated methods
 		for i, n := 0, p.int(); i < n; i++ {
-			t0.AddMethod(types.NewFunc(token.NoPos, pkg, p.string(), p.typ().(*types.Signature)))
+			name := p.string()
+			pos := p.pos()
+			t0.AddMethod(types.NewFunc(pos, pkg, name, p.typ().(*types.Signature)))
 		}
 
 		return t
@@ -401,7 +531,11 @@ func (p *importer) tuple() *types.Tuple {
 // decoders
 
 func (p *importer) string() string {
-	return string(p.bytes())
+	s := string(p.bytes())
+	if p.strings != nil {
+		s = p.strings.Intern(s)
+	}
+	return s
 }
 
 func (p *importer) int() int {
@@ -98,6 +98,125 @@ func TestImportSrc(t *testing.T) {
 	}
 }
 
+func TestImportDeep(t *testing.T) {
+	const src = `package p
+
+type T struct{ x int }
+
+func (T) M() {}
+
+func F() T { return T{} }
+
+func f() {}
+
+var v = 1
+`
+	f, err := parser.ParseFile(fset, "deep.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg0, err := typecheck("deep", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		export, imp Mode
+		hasPos      bool
+		wantNames   string
+	}{
+		{Shallow, Shallow, false, "F T"},
+		{Shallow, Deep, false, "F T"},
+		{Deep, Shallow, false, "F T"},
+		{Deep, Deep, true, "F T f v"},
+	} {
+		data := ExportDataMode(fset, pkg0, test.export)
+		fset1 := token.NewFileSet()
+		conf := Config{Mode: test.imp, Fset: fset1}
+		n, pkg1, err := conf.ImportData(make(map[string]*types.Package), data)
+		if err != nil {
+			t.Errorf("export %d, import %d: %s", test.export, test.imp, err)
+			continue
+		}
+		if n != len(data) {
+			t.Errorf("export %d, import %d: not all input data consumed", test.export, test.imp)
+		}
+		if got := fmt.Sprint(pkg1.Scope().Names()); got != "["+test.wantNames+"]" {
+			t.Errorf("export %d, import %d: got names %s, want [%s]", test.export, test.imp, got, test.wantNames)
+		}
+
+		// Check the positions of T, its method M, and F.
+		T := pkg1.Scope().Lookup("T")
+		for _, pos := range []struct {
+			obj  types.Object
+			line int
+		}{
+			{T, 3},
+			{T.Type().(*types.Named).Method(0), 5},
+			{pkg1.Scope().Lookup("F"), 7},
+		} {
+			obj := pos.obj
+			var got string
+			if obj.Pos().IsValid() {
+				posn := fset1.Position(obj.Pos())
+				got = fmt.Sprintf("%s:%d", posn.Filename, posn.Line)
+			}
+			var want string
+			if test.hasPos {
+				want = fmt.Sprintf("deep.go:%d", pos.line)
+			}
+			if got != want {
+				t.Errorf("export %d, import %d: position of %s = %q, want %q", test.export, test.imp, obj.Name(), got, want)
+			}
+		}
+	}
+}
+
+func TestImportVersions(t *testing.T) {
+	pkg0, err := pkgForSource(`package p; type T struct{ X int }; func F(T) int`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := ExportData(pkg0)
+
+	// header returns the export data header for the specified versions.
+	header := func(version, compat int) []byte {
+		p := exporter{data: append([]byte(magic), format())}
+		p.int(version)
+		p.int(compat)
+		p.int(int(Shallow))
+		return p.data
+	}
+	hdr := header(version, compatVersion)
+	if !bytes.HasPrefix(data, hdr) {
+		t.Fatalf("export data does not start with the expected header")
+	}
+
+	// Data from a newer exporter, with a section of an unknown
+	// kind before and after the objects, can still be read.
+	p := exporter{data: header(version+1, compatVersion)}
+	p.section(99, func() { p.string("future") })
+	p.data = append(p.data, data[len(hdr):len(data)-1]...) // objects section
+	p.section(100, func() { p.int(42) })
+	p.int(endSection)
+	n, pkg1, err := ImportData(make(map[string]*types.Package), p.data)
+	if err != nil {
+		t.Fatalf("import of newer data failed: %s", err)
+	}
+	if n != len(p.data) {
+		t.Errorf("import of newer data consumed %d bytes, want %d", n, len(p.data))
+	}
+	if got, want := pkgString(pkg1), pkgString(pkg0); got != want {
+		t.Errorf("import of newer data: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Data that requires a newer importer cannot be read.
+	newer := append(header(version+1, version+1), data[len(hdr):]...)
+	if _, _, err := ImportData(make(map[string]*types.Package), newer); err == nil {
+		t.Errorf("import of incompatible data succeeded unexpectedly")
+	}
+}
+
 func TestImportStdLib(t *testing.T) {
 	start := time.Now()
 
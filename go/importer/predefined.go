@@ -6,9 +6,25 @@ package importer
 
 import "golang.org/x/tools/go/types"
 
+const magic = "\n$$ exports $$\n"
+
+// Versions.  Export data records the version of the exporter that
+// wrote it, and the oldest version of the importer that can read it;
+// an importer reads all data that does not require a newer importer.
+// Additions to the format that older importers may ignore, such as
+// new kinds of sections, need not change compatVersion.
+const (
+	version       = 2 // version of this exporter and importer
+	compatVersion = 2 // oldest importer version that can read this exporter's data
+)
+
+// Sections.  After the header, export data is a sequence of
+// sections, each a (positive) kind, a 4-byte little-endian length,
+// and that many bytes of contents, followed by endSection.  An
+// importer skips the sections of kinds it does not know.
 const (
-	magic   = "\n$$ exports $$\n"
-	version = "v0"
+	endSection     = 0
+	objectsSection = 1 // the package and its objects; required
 )
 
 // Tags. Must be < 0.
@@ -0,0 +1,276 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package index exports the symbols of a program—their definitions,
+// references, hover text, and implementation relations—as an index
+// for code-intelligence backends, in a language-neutral format.
+//
+// An index is a sequence of JSON objects, one per line, each a Record
+// whose kind determines its fields:
+//
+//	{"kind":"package","path":"example.com/p","name":"p"}
+//	{"kind":"symbol","id":1,"name":"T","objKind":"type","moniker":"go:example.com/p.T","hover":"type T struct{}"}
+//	{"kind":"occurrence","symbol":1,"role":"def","file":"/src/p/p.go","range":[3,6,3,7]}
+//	{"kind":"implements","symbol":1,"target":2}
+//
+// A package record precedes the records for the files of the package.
+// A symbol record precedes all the records that refer to the symbol by
+// its id, which is unique within the index.  The moniker of a symbol
+// that may be referenced from other programs, a package or a member of
+// one, or a field or method of a package-level named type, identifies
+// it across indexes, as "go:" followed by its import path and
+// qualified name, such as "go:net/http.Client.Do"; local symbols have
+// none.  Ranges are [startLine, startColumn, endLine, endColumn],
+// 1-based, with columns in bytes and the end exclusive.  A symbol
+// implements a target if it is a named type that, or whose pointer,
+// implements the named interface target.
+package index // import "golang.org/x/tools/go/index"
+
+import (
+	"encoding/json"
+	"go/ast"
+	"io"
+	"sort"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+)
+
+// A Record is a line of an index.
+type Record struct {
+	Kind string `json:"kind"` // "package", "symbol", "occurrence", or "implements"
+
+	// package
+	Path string `json:"path,omitempty"`
+	Name string `json:"name,omitempty"` // also for symbol
+
+	// symbol
+	ID      int    `json:"id,omitempty"`
+	ObjKind string `json:"objKind,omitempty"` // as by types.ObjectKind.String, such as "func" or "field"
+	Moniker string `json:"moniker,omitempty"`
+	Hover   string `json:"hover,omitempty"`
+
+	// occurrence, implements
+	Symbol int    `json:"symbol,omitempty"`
+	Role   string `json:"role,omitempty"` // "def" or "ref"
+	File   string `json:"file,omitempty"`
+	Range  []int  `json:"range,omitempty"`
+	Target int    `json:"target,omitempty"`
+}
+
+// Write writes the index of the initial packages of prog to w, as
+// described in the package documentation.  The index includes the
+// symbols of other packages referenced by the initial ones.
+func Write(w io.Writer, prog *loader.Program) error {
+	enc := json.NewEncoder(w)
+	var err error
+	ix := newIndexer(prog, func(r *Record) {
+		if err == nil {
+			err = enc.Encode(r)
+		}
+	})
+	ix.index()
+	return err
+}
+
+// Records returns the records of the index of the initial packages of
+// prog, in the order in which Write writes them.
+func Records(prog *loader.Program) []*Record {
+	var records []*Record
+	newIndexer(prog, func(r *Record) { records = append(records, r) }).index()
+	return records
+}
+
+type indexer struct {
+	prog    *loader.Program
+	emit    func(*Record)
+	ids     map[interface{}]int     // the id of each symbol (an Object, or a *types.Package)
+	members map[*types.Var]string   // qualified names of the fields of package-level named types
+	indexed map[*types.Package]bool // packages whose members have been found
+}
+
+func newIndexer(prog *loader.Program, emit func(*Record)) *indexer {
+	return &indexer{
+		prog:    prog,
+		emit:    emit,
+		ids:     make(map[interface{}]int),
+		members: make(map[*types.Var]string),
+		indexed: make(map[*types.Package]bool),
+	}
+}
+
+func (ix *indexer) index() {
+	infos := ix.prog.InitialPackages()
+	sort.Sort(byPath(infos))
+	var named []*types.TypeName // package-level named types of the initial packages
+	for _, info := range infos {
+		ix.emit(&Record{Kind: "package", Path: info.Pkg.Path(), Name: info.Pkg.Name()})
+		for _, f := range info.Files {
+			ix.file(info, f)
+		}
+		scope := info.Pkg.Scope()
+		for _, name := range scope.Names() {
+			if tname, ok := scope.Lookup(name).(*types.TypeName); ok {
+				named = append(named, tname)
+			}
+		}
+	}
+	ix.implements(named)
+}
+
+type byPath []*loader.PackageInfo
+
+func (b byPath) Len() int           { return len(b) }
+func (b byPath) Less(i, j int) bool { return b[i].Pkg.Path() < b[j].Pkg.Path() }
+func (b byPath) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// file emits the occurrences of the identifiers of f.
+func (ix *indexer) file(info *loader.PackageInfo, f *ast.File) {
+	var ids []*ast.Ident
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			ids = append(ids, id)
+		}
+		return true
+	})
+	for _, id := range ids {
+		role := "def"
+		obj := info.Defs[id]
+		if obj == nil {
+			role = "ref"
+			if obj = info.Uses[id]; obj == nil {
+				continue
+			}
+		}
+		if obj.Pkg() == nil || obj.Name() == "_" {
+			continue // predeclared or blank
+		}
+		start := ix.prog.Fset.Position(id.Pos())
+		end := ix.prog.Fset.Position(id.End())
+		ix.emit(&Record{
+			Kind:   "occurrence",
+			Symbol: ix.symbol(info.Pkg, obj),
+			Role:   role,
+			File:   start.Filename,
+			Range:  []int{start.Line, start.Column, end.Line, end.Column},
+		})
+	}
+}
+
+// symbol returns the id of the symbol obj, emitting its record on
+// first use; an imported package name stands for the package.
+func (ix *indexer) symbol(from *types.Package, obj types.Object) int {
+	var key interface{} = obj
+	if pkgName, ok := obj.(*types.PkgName); ok {
+		key = pkgName.Imported()
+	}
+	if id, ok := ix.ids[key]; ok {
+		return id
+	}
+	id := len(ix.ids) + 1
+	ix.ids[key] = id
+	r := &Record{Kind: "symbol", ID: id, Name: obj.Name(), ObjKind: obj.Kind().String()}
+	if pkg, ok := key.(*types.Package); ok {
+		r.Name = pkg.Name()
+		r.Moniker = "go:" + pkg.Path()
+		r.Hover = "package " + pkg.Name() + ` "` + pkg.Path() + `"`
+	} else {
+		r.Moniker = ix.moniker(obj)
+		r.Hover = types.ObjectString(from, obj)
+	}
+	ix.emit(r)
+	return id
+}
+
+// moniker returns the moniker of obj, or "" if it is local.
+func (ix *indexer) moniker(obj types.Object) string {
+	pkg := obj.Pkg()
+	prefix := "go:" + pkg.Path() + "."
+	switch obj := obj.(type) {
+	case *types.Func:
+		if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+			T := recv.Type()
+			if ptr, ok := T.(*types.Pointer); ok {
+				T = ptr.Elem()
+			}
+			if named, ok := T.(*types.Named); ok && ix.isPackageLevel(named.Obj()) {
+				return prefix + named.Obj().Name() + "." + obj.Name()
+			}
+			return ""
+		}
+	case *types.Var:
+		if obj.IsField() {
+			if name, ok := ix.fields(pkg)[obj]; ok {
+				return prefix + name
+			}
+			return ""
+		}
+	}
+	if ix.isPackageLevel(obj) {
+		return prefix + obj.Name()
+	}
+	return ""
+}
+
+func (ix *indexer) isPackageLevel(obj types.Object) bool {
+	return obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope()
+}
+
+// fields returns the qualified names, T.f, of the fields of the
+// package-level named struct types of pkg.
+func (ix *indexer) fields(pkg *types.Package) map[*types.Var]string {
+	if !ix.indexed[pkg] {
+		ix.indexed[pkg] = true
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			if tname, ok := scope.Lookup(name).(*types.TypeName); ok {
+				if st, ok := tname.Type().Underlying().(*types.Struct); ok {
+					for i := 0; i < st.NumFields(); i++ {
+						ix.members[st.Field(i)] = name + "." + st.Field(i).Name()
+					}
+				}
+			}
+		}
+	}
+	return ix.members
+}
+
+// implements emits the implementation relations between the named
+// types and the named interfaces of the program.
+func (ix *indexer) implements(named []*types.TypeName) {
+	var ifaces []*types.TypeName
+	for _, info := range ix.prog.AllPackages {
+		scope := info.Pkg.Scope()
+		for _, name := range scope.Names() {
+			if tname, ok := scope.Lookup(name).(*types.TypeName); ok && types.IsInterface(tname.Type()) {
+				if tname.Type().Underlying().(*types.Interface).NumMethods() > 0 {
+					ifaces = append(ifaces, tname)
+				}
+			}
+		}
+	}
+	sort.Sort(byQualifiedName(ifaces))
+	for _, T := range named {
+		if types.IsInterface(T.Type()) {
+			continue
+		}
+		for _, I := range ifaces {
+			iface := I.Type().Underlying().(*types.Interface)
+			if types.Implements(T.Type(), iface) || types.Implements(types.NewPointer(T.Type()), iface) {
+				ix.emit(&Record{Kind: "implements", Symbol: ix.symbol(T.Pkg(), T), Target: ix.symbol(T.Pkg(), I)})
+			}
+		}
+	}
+}
+
+type byQualifiedName []*types.TypeName
+
+func (b byQualifiedName) Len() int { return len(b) }
+func (b byQualifiedName) Less(i, j int) bool {
+	if b[i].Pkg().Path() != b[j].Pkg().Path() {
+		return b[i].Pkg().Path() < b[j].Pkg().Path()
+	}
+	return b[i].Name() < b[j].Name()
+}
+func (b byQualifiedName) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,91 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package index_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/index"
+	"golang.org/x/tools/go/loader"
+)
+
+func TestWrite(t *testing.T) {
+	conf := loader.Config{Build: buildutil.FakeContext(map[string]map[string]string{
+		"a": {"a.go": `package a
+
+type Shape interface{ Area() float64 }
+`},
+		"b": {"b.go": `package b
+
+import "a"
+
+type Square struct{ Side float64 }
+
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+var _ a.Shape = new(Square)
+`},
+	})}
+	conf.Import("b")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := index.Write(&buf, prog); err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[int]string) // symbol names by id
+	var got []string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var r index.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		switch r.Kind {
+		case "package":
+			got = append(got, "package "+r.Path)
+		case "symbol":
+			names[r.ID] = r.Name
+			got = append(got, fmt.Sprintf("symbol %s %s %q %q", r.Name, r.ObjKind, r.Moniker, r.Hover))
+		case "occurrence":
+			got = append(got, fmt.Sprintf("%s %s %d:%d-%d:%d", r.Role, names[r.Symbol], r.Range[0], r.Range[1], r.Range[2], r.Range[3]))
+		case "implements":
+			got = append(got, fmt.Sprintf("implements %s %s", names[r.Symbol], names[r.Target]))
+		}
+	}
+
+	want := []string{
+		"package b",
+		`symbol Square type "go:b.Square" "type Square struct{Side float64}"`,
+		"def Square 5:6-5:12",
+		`symbol Side field "go:b.Square.Side" "field Side float64"`,
+		"def Side 5:21-5:25",
+		`symbol s param "" "var s *Square"`,
+		"def s 7:7-7:8",
+		"ref Square 7:10-7:16",
+		`symbol Area method "go:b.Square.Area" "func (*Square).Area() float64"`,
+		"def Area 7:18-7:22",
+		"ref s 7:42-7:43",
+		"ref Side 7:44-7:48",
+		"ref s 7:51-7:52",
+		"ref Side 7:53-7:57",
+		`symbol a package "go:a" "package a \"a\""`,
+		"ref a 9:7-9:8",
+		`symbol Shape type "go:a.Shape" "type a.Shape interface{Area() float64}"`,
+		"ref Shape 9:9-9:14",
+		"ref Square 9:21-9:27",
+		"implements Square Shape",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got index:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package index
+
+// This file defines Lenses, annotations of the declarations of a file
+// for display above them ("code lenses").
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// A Lens is an annotation of a declaration.
+type Lens struct {
+	Pos   token.Pos // the position of the declared name
+	Kind  string    // "references", "implementations", or "test"
+	Title string    // the text to display, such as "3 references" or "run test"
+	Count int       // the number of references or implementations
+	Test  string    // the name of the test function to run, for "test"
+}
+
+// A LensProvider returns the lenses of the declarations of file f of
+// a package of the program of c.  ReferenceLenses,
+// ImplementationLenses, and TestLenses are LensProviders.
+type LensProvider func(c *LensContext, info *loader.PackageInfo, f *ast.File) []*Lens
+
+// A LensContext holds the facts about a program, computed when first
+// needed, from which providers compute lenses, so that they compute
+// them only once for all the files of the program.
+//
+// A LensContext is safe for concurrent use.
+//
+type LensContext struct {
+	Prog *loader.Program
+
+	refsOnce  sync.Once
+	refs      map[types.Object]int // number of references to each object
+	hierOnce  sync.Once
+	hierarchy *typeutil.TypeHierarchy
+}
+
+// NewLensContext returns a context for computing the lenses of the
+// files of prog.
+func NewLensContext(prog *loader.Program) *LensContext {
+	return &LensContext{Prog: prog}
+}
+
+// Lenses returns the lenses of file f of the package info, as computed
+// by providers, in order of position.
+func (c *LensContext) Lenses(info *loader.PackageInfo, f *ast.File, providers ...LensProvider) []*Lens {
+	var lenses []*Lens
+	for _, provider := range providers {
+		lenses = append(lenses, provider(c, info, f)...)
+	}
+	sort.Stable(byLensPos(lenses))
+	return lenses
+}
+
+// References returns the number of references to obj within the
+// packages of the program.
+func (c *LensContext) References(obj types.Object) int {
+	c.refsOnce.Do(func() {
+		c.refs = make(map[types.Object]int)
+		for _, info := range c.Prog.AllPackages {
+			for _, obj := range info.Uses {
+				c.refs[obj]++
+			}
+		}
+	})
+	return c.refs[obj]
+}
+
+// Hierarchy returns the type hierarchy of the packages of the program.
+func (c *LensContext) Hierarchy() *typeutil.TypeHierarchy {
+	c.hierOnce.Do(func() {
+		var pkgs []*types.Package
+		for _, info := range c.Prog.AllPackages {
+			pkgs = append(pkgs, info.Pkg)
+		}
+		sort.Sort(byPkgPath(pkgs))
+		c.hierarchy = typeutil.NewTypeHierarchy(pkgs...)
+	})
+	return c.hierarchy
+}
+
+// ReferenceLenses annotates each package-level declaration, and each
+// method, with the number of references to it in the program.
+func ReferenceLenses(c *LensContext, info *loader.PackageInfo, f *ast.File) []*Lens {
+	var lenses []*Lens
+	add := func(id *ast.Ident) {
+		if obj := info.Defs[id]; obj != nil && id.Name != "_" {
+			n := c.References(obj)
+			lenses = append(lenses, &Lens{Pos: id.Pos(), Kind: "references", Title: plural(n, "reference"), Count: n})
+		}
+	}
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil || decl.Name.Name != "init" {
+				add(decl.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name)
+				case *ast.ValueSpec:
+					for _, id := range spec.Names {
+						add(id)
+					}
+				}
+			}
+		}
+	}
+	return lenses
+}
+
+// ImplementationLenses annotates each declaration of a named interface
+// with the number of named types in the program that implement it.
+func ImplementationLenses(c *LensContext, info *loader.PackageInfo, f *ast.File) []*Lens {
+	var lenses []*Lens
+	for _, decl := range f.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.TYPE {
+			for _, spec := range decl.Specs {
+				id := spec.(*ast.TypeSpec).Name
+				tname, ok := info.Defs[id].(*types.TypeName)
+				if !ok || !types.IsInterface(tname.Type()) {
+					continue
+				}
+				n := len(c.Hierarchy().Subtypes(tname.Type().(*types.Named)))
+				lenses = append(lenses, &Lens{Pos: id.Pos(), Kind: "implementations", Title: plural(n, "implementation"), Count: n})
+			}
+		}
+	}
+	return lenses
+}
+
+// TestLenses annotates each test function, of the form
+// "func TestXxx(*testing.T)" in a _test.go file, with a lens to run it.
+func TestLenses(c *LensContext, info *loader.PackageInfo, f *ast.File) []*Lens {
+	if !strings.HasSuffix(c.Prog.Fset.File(f.Pos()).Name(), "_test.go") {
+		return nil
+	}
+	var lenses []*Lens
+	for _, decl := range f.Decls {
+		if decl, ok := decl.(*ast.FuncDecl); ok && decl.Recv == nil && isTest(info, decl.Name) {
+			lenses = append(lenses, &Lens{Pos: decl.Name.Pos(), Kind: "test", Title: "run test", Test: decl.Name.Name})
+		}
+	}
+	return lenses
+}
+
+// isTest reports whether id declares a function of the form
+// TestXxx(*testing.T), as by "go test".
+func isTest(info *loader.PackageInfo, id *ast.Ident) bool {
+	name := id.Name
+	if !strings.HasPrefix(name, "Test") {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(name[len("Test"):]); unicode.IsLower(r) {
+		return false
+	}
+	fn, ok := info.Defs[id].(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+		return false
+	}
+	ptr, ok := sig.Params().At(0).Type().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "testing" && named.Obj().Name() == "T"
+}
+
+// plural returns n and noun, in the plural unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+type byLensPos []*Lens
+
+func (b byLensPos) Len() int           { return len(b) }
+func (b byLensPos) Less(i, j int) bool { return b[i].Pos < b[j].Pos }
+func (b byLensPos) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type byPkgPath []*types.Package
+
+func (b byPkgPath) Len() int           { return len(b) }
+func (b byPkgPath) Less(i, j int) bool { return b[i].Path() < b[j].Path() }
+func (b byPkgPath) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
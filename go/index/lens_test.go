@@ -0,0 +1,74 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package index_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/index"
+	"golang.org/x/tools/go/loader"
+)
+
+func TestLenses(t *testing.T) {
+	conf := loader.Config{Build: buildutil.FakeContext(map[string]map[string]string{
+		"testing": {"testing.go": `package testing
+
+type T struct{}
+`},
+		"a": {
+			"a.go": `package a
+
+type Shape interface{ Area() int }
+
+type Square int
+
+func (s Square) Area() int { return int(s * s) }
+
+var _ Shape = Square(1)
+`,
+			"a_test.go": `package a
+
+import "testing"
+
+func TestArea(t *testing.T) { Square(2).Area() }
+
+func Testing(t *testing.T) {}
+
+func TestHelper(n int) {}
+`,
+		},
+	})}
+	conf.ImportWithTests("a")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := index.NewLensContext(prog)
+	info := prog.Imported["a"]
+	var got []string
+	for _, f := range info.Files {
+		for _, lens := range c.Lenses(info, f, index.ReferenceLenses, index.ImplementationLenses, index.TestLenses) {
+			posn := prog.Fset.Position(lens.Pos)
+			got = append(got, fmt.Sprintf("%s:%d: %s: %s %s", posn.Filename[strings.LastIndex(posn.Filename, "/")+1:], posn.Line, lens.Kind, lens.Title, lens.Test))
+		}
+	}
+	want := []string{
+		"a.go:3: references: 1 reference ",
+		"a.go:3: implementations: 1 implementation ",
+		"a.go:5: references: 3 references ",
+		"a.go:7: references: 1 reference ",
+		"a_test.go:5: references: 0 references ",
+		"a_test.go:5: test: run test TestArea",
+		"a_test.go:7: references: 0 references ",
+		"a_test.go:9: references: 0 references ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got lenses:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
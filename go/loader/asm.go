@@ -0,0 +1,84 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file associates external functions with the assembly files
+// that implement them.
+
+import (
+	"bufio"
+	"go/build"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/types"
+)
+
+// textRE matches the TEXT directive of an assembly file that defines
+// a function of the package, capturing its name, such as "Sqrt" for
+// "TEXT ·Sqrt(SB),NOSPLIT,$0", or "(*T).M" for a method.
+var textRE = regexp.MustCompile(`^\s*TEXT\s+[^\s·]*·([^\s(]*(?:\(\*?[^\s)]*\)[^\s(]*)?)\(SB\)`)
+
+// asmSymbols returns the function symbols defined by the TEXT
+// directives of the assembly files of the package bp, mapped to the
+// display names of the files that define them.  Methods are named
+// "T.M", whatever their receivers.  Files that cannot be read are
+// ignored.
+func (conf *Config) asmSymbols(bp *build.Package) map[string][]string {
+	ctxt := conf.build()
+	syms := make(map[string][]string)
+	for _, name := range bp.SFiles {
+		filename := buildutil.JoinPath(ctxt, bp.Dir, name)
+		rd, err := buildutil.OpenFile(ctxt, filename)
+		if err != nil {
+			continue
+		}
+		if conf.DisplayPath != nil {
+			filename = conf.DisplayPath(filename)
+		}
+		sc := bufio.NewScanner(rd)
+		for sc.Scan() {
+			m := textRE.FindStringSubmatch(sc.Text())
+			if m == nil {
+				continue
+			}
+			sym := strings.NewReplacer("(*", "", "(", "", ")", "").Replace(m[1])
+			if files := syms[sym]; len(files) == 0 || files[len(files)-1] != filename {
+				syms[sym] = append(files, filename)
+			}
+		}
+		rd.Close()
+	}
+	return syms
+}
+
+// AsmFiles returns the names of the assembly files of the package that
+// likely implement the function or method fn, an external function
+// (see types.Func.External) of the package: those whose TEXT
+// directives define its symbol.  Typically such a function has one
+// implementation per architecture, each in a file selected by build
+// constraints, such as "sqrt_amd64.s"; only the files of the build
+// configuration of the program are considered.
+//
+// AsmFiles returns nil if no file defines fn, which then may be
+// implemented by the runtime or linked by a directive, or if the
+// package was not loaded from a directory.
+//
+func (info *PackageInfo) AsmFiles(fn *types.Func) []string {
+	name := fn.Name()
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		T := recv.Type()
+		if ptr, ok := T.(*types.Pointer); ok {
+			T = ptr.Elem()
+		}
+		named, ok := T.(*types.Named)
+		if !ok {
+			return nil
+		}
+		name = named.Obj().Name() + "." + name
+	}
+	return info.asm[name]
+}
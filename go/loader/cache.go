@@ -0,0 +1,304 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file defines Cache, a bounded cache of type-checked packages
+// that may be shared by successive calls to Load.
+
+import (
+	"container/list"
+	"errors"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A Cache holds the packages loaded from source by previous calls to
+// Load, so that later calls may reuse them instead of loading them
+// again.  It holds at most a fixed number of packages, evicting the
+// least recently used ones first, except those that are pinned.
+//
+// A package is cached only together with its dependencies: evicting a
+// package also evicts the cached packages that import it, directly or
+// indirectly, and a package is never evicted while a package that
+// depends on it is pinned.
+//
+// The cache does not detect changes to source files; clients must
+// call Remove for each package whose files have changed, or Invalidate
+// for the changed files.  All Configs that share a Cache must have the
+// same Fset (or nil), and should have the same build context and
+// type-checker options, since the cached results are reused regardless
+// of them.  Packages augmented by their in-package tests (see
+// Config.ImportWithTests), and those that depend on them, are not
+// cached.
+//
+// A Cache may be used by concurrent calls to Load.
+//
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	fset     *token.FileSet
+	entries  map[string]*cacheEntry // by import path
+	lru      list.List              // of *cacheEntry, most recently used first
+	pinned   map[string]bool
+	stats    CacheStats
+}
+
+type cacheEntry struct {
+	info *PackageInfo
+	elem *list.Element
+}
+
+// CacheStats holds statistics about the use of a Cache.
+type CacheStats struct {
+	Hits      int // loads of a package satisfied by the cache
+	Misses    int // loads of a package not in the cache
+	Evictions int // packages removed to respect the capacity
+	Len       int // number of packages in the cache
+	Pinned    int // number of pinned import paths
+}
+
+// NewCache returns a new, empty Cache that holds at most capacity
+// packages, not counting those that must be retained because they
+// are pinned or are dependencies of pinned packages.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		entries:  make(map[string]*cacheEntry),
+		pinned:   make(map[string]bool),
+	}
+}
+
+// Pin prevents the package with the specified import path, and its
+// dependencies, from being evicted, whether or not it is already
+// cached.
+func (c *Cache) Pin(path string) {
+	c.mu.Lock()
+	c.pinned[path] = true
+	c.mu.Unlock()
+}
+
+// Unpin undoes the effect of Pin.  The package may then be evicted.
+func (c *Cache) Unpin(path string) {
+	c.mu.Lock()
+	delete(c.pinned, path)
+	c.trim()
+	c.mu.Unlock()
+}
+
+// Remove removes the package with the specified import path, and all
+// cached packages that depend on it, from the cache, whether or not
+// they are pinned.  Clients should call it when the package's files
+// change.
+func (c *Cache) Remove(path string) {
+	c.mu.Lock()
+	if c.entries[path] != nil {
+		for _, e := range c.dependents(path) {
+			c.evict(e)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// Invalidate removes from the cache the packages whose directories
+// contain any of the specified files, which have been changed, added,
+// or deleted, together with the cached packages that depend on them,
+// as by Remove.  It returns the import paths of the removed packages,
+// in sorted order.
+func (c *Cache) Invalidate(files ...string) []string {
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		dirs[filepath.Dir(file)] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var changed []string
+	for path, e := range c.entries {
+		for _, f := range e.info.Files {
+			if dirs[filepath.Dir(c.fset.File(f.Pos()).Name())] {
+				changed = append(changed, path)
+				break
+			}
+		}
+	}
+	var removed []string
+	for _, path := range changed {
+		if c.entries[path] != nil { // (may have been removed as a dependent)
+			for _, e := range c.dependents(path) {
+				removed = append(removed, e.info.Pkg.Path())
+				c.evict(e)
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// Stats returns statistics about the use of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Len = len(c.entries)
+	stats.Pinned = len(c.pinned)
+	return stats
+}
+
+// setFset establishes the file set of the cache, which must be that
+// of each Config that uses it.
+func (c *Cache) setFset(conf *Config) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fset == nil {
+		c.fset = conf.fset()
+	} else if conf.Fset == nil {
+		conf.Fset = c.fset
+	} else if conf.Fset != c.fset {
+		return errors.New("loader: Config.Fset is not that of its Cache")
+	}
+	return nil
+}
+
+// get returns the cached package with the specified import path,
+// followed by the cached packages on which it depends, or nil if it is
+// not cached or depends on a package for which exclude returns true.
+func (c *Cache) get(path string, exclude func(path string) bool) []*PackageInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var infos []*PackageInfo
+	seen := make(map[string]bool)
+	var visit func(path string) bool
+	visit = func(path string) bool {
+		if seen[path] {
+			return true
+		}
+		seen[path] = true
+		e := c.entries[path]
+		if e == nil || exclude(path) {
+			return false
+		}
+		infos = append(infos, e.info)
+		for _, dep := range e.info.Pkg.Imports() {
+			if dep != types.Unsafe && !visit(dep.Path()) {
+				return false
+			}
+		}
+		return true
+	}
+	if !visit(path) {
+		c.stats.Misses++
+		return nil
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(c.entries[path].elem)
+	return infos
+}
+
+// add adds to the cache each importable package of prog, loaded from
+// source, whose dependencies are all in the cache or added too,
+// except those for which exclude returns true.  It then evicts
+// packages as needed to respect the capacity.
+func (c *Cache) add(prog *Program, exclude func(path string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	infos := make(map[string]*PackageInfo)
+	for _, info := range prog.AllPackages {
+		if info.Importable && info.Files != nil && !exclude(info.Pkg.Path()) {
+			infos[info.Pkg.Path()] = info
+		}
+	}
+	ok := make(map[string]bool) // memoizes cacheable; false while visiting (import cycle)
+	var cacheable func(path string) bool
+	cacheable = func(path string) bool {
+		if result, found := ok[path]; found {
+			return result
+		}
+		info := infos[path]
+		if info == nil {
+			return false
+		}
+		if e := c.entries[path]; e != nil {
+			ok[path] = e.info == info
+			return ok[path]
+		}
+		ok[path] = false
+		for _, dep := range info.Pkg.Imports() {
+			if dep != types.Unsafe && !cacheable(dep.Path()) {
+				return false
+			}
+		}
+		ok[path] = true
+		e := &cacheEntry{info: info}
+		e.elem = c.lru.PushFront(e)
+		c.entries[path] = e
+		return true
+	}
+	for path := range infos {
+		cacheable(path)
+	}
+	c.trim()
+}
+
+// trim evicts the least recently used packages, together with their
+// dependents, until the cache respects its capacity, or no package
+// may be evicted.
+func (c *Cache) trim() {
+	for victim := c.lru.Back(); victim != nil && len(c.entries) > c.capacity; {
+		path := victim.Value.(*cacheEntry).info.Pkg.Path()
+		deps := c.dependents(path)
+		evictable := true
+		for _, e := range deps {
+			if c.pinned[e.info.Pkg.Path()] {
+				evictable = false
+				break
+			}
+		}
+		if !evictable {
+			victim = victim.Prev()
+			continue
+		}
+		for _, e := range deps {
+			c.evict(e)
+			c.stats.Evictions++
+		}
+		victim = c.lru.Back() // start over: victim's neighbors may have been evicted
+	}
+}
+
+// dependents returns the entry for the specified import path and
+// those of the packages that depend on it.
+func (c *Cache) dependents(path string) []*cacheEntry {
+	// Compute the reverse import graph of the cache.
+	importers := make(map[string][]*cacheEntry)
+	for _, e := range c.entries {
+		for _, dep := range e.info.Pkg.Imports() {
+			importers[dep.Path()] = append(importers[dep.Path()], e)
+		}
+	}
+	var result []*cacheEntry
+	seen := make(map[string]bool)
+	var visit func(e *cacheEntry)
+	visit = func(e *cacheEntry) {
+		path := e.info.Pkg.Path()
+		if !seen[path] {
+			seen[path] = true
+			result = append(result, e)
+			for _, imp := range importers[path] {
+				visit(imp)
+			}
+		}
+	}
+	visit(c.entries[path])
+	return result
+}
+
+func (c *Cache) evict(e *cacheEntry) {
+	c.lru.Remove(e.elem)
+	delete(c.entries, e.info.Pkg.Path())
+}
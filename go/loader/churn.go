@@ -0,0 +1,191 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file compares the functions and types of two versions of a
+// program, for release notes and review triage.
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A ChangeKind classifies a SymbolChange.
+type ChangeKind int
+
+const (
+	SymbolAdded ChangeKind = iota
+	SymbolRemoved
+	SymbolChanged
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case SymbolAdded:
+		return "added"
+	case SymbolRemoved:
+		return "removed"
+	case SymbolChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// A SymbolChange is a function, method, or type added, removed, or
+// changed between two versions of a package.
+type SymbolChange struct {
+	Kind     ChangeKind
+	Path     string // import path of the package
+	Name     string // the name of the function or type, or, for a method, "T.M"
+	Old, New string // the declarations in each version, as by types.ObjectString, or "" if absent
+
+	// BodyChanged reports, for a function or method whose
+	// declaration is unchanged, that its body changed.
+	BodyChanged bool
+}
+
+// A Churn reports the changes between two versions of a program.
+type Churn struct {
+	Changes []*SymbolChange // in order of package path and name
+}
+
+// ChurnBetween compares the functions, methods, and types of the
+// initial packages of the old and new versions of a program, matched
+// by import path, such as those loaded from two worktrees of a
+// repository.  A function or method has changed if its signature or
+// the text of its body, without comments and regardless of layout,
+// differs; a type, if its underlying type does.
+func ChurnBetween(oldProg, newProg *Program) *Churn {
+	olds := churnSymbols(oldProg)
+	news := churnSymbols(newProg)
+	c := &Churn{}
+	for key, o := range olds {
+		n, ok := news[key]
+		switch {
+		case !ok:
+			c.Changes = append(c.Changes, &SymbolChange{Kind: SymbolRemoved, Path: key[0], Name: key[1], Old: o.desc})
+		case o.desc != n.desc:
+			c.Changes = append(c.Changes, &SymbolChange{Kind: SymbolChanged, Path: key[0], Name: key[1], Old: o.desc, New: n.desc})
+		case o.body != n.body:
+			c.Changes = append(c.Changes, &SymbolChange{Kind: SymbolChanged, Path: key[0], Name: key[1], Old: o.desc, New: n.desc, BodyChanged: true})
+		}
+	}
+	for key, n := range news {
+		if _, ok := olds[key]; !ok {
+			c.Changes = append(c.Changes, &SymbolChange{Kind: SymbolAdded, Path: key[0], Name: key[1], New: n.desc})
+		}
+	}
+	sort.Sort(byChange(c.Changes))
+	return c
+}
+
+type byChange []*SymbolChange
+
+func (b byChange) Len() int { return len(b) }
+func (b byChange) Less(i, j int) bool {
+	if b[i].Path != b[j].Path {
+		return b[i].Path < b[j].Path
+	}
+	return b[i].Name < b[j].Name
+}
+func (b byChange) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+
+// Stats returns the numbers of added, removed, and changed symbols.
+func (c *Churn) Stats() (added, removed, changed int) {
+	for _, ch := range c.Changes {
+		switch ch.Kind {
+		case SymbolAdded:
+			added++
+		case SymbolRemoved:
+			removed++
+		case SymbolChanged:
+			changed++
+		}
+	}
+	return
+}
+
+// WriteTo writes a report of the churn to w: a line for each change,
+// giving its kind, the package path and name of its symbol, and its
+// declarations, then a line of statistics:
+//
+//	added	example.com/p.G	func G()
+//	changed	example.com/p.F	func F(x int) -> func F(x int, y int)
+//	changed	example.com/p.T.M	func (T).M() (body)
+//	removed	example.com/p.V	type V int
+//	1 added, 1 removed, 2 changed
+func (c *Churn) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	printf := func(format string, args ...interface{}) {
+		m, _ := fmt.Fprintf(bw, format, args...)
+		n += int64(m)
+	}
+	for _, ch := range c.Changes {
+		printf("%s\t%s.%s\t", ch.Kind, ch.Path, ch.Name)
+		switch {
+		case ch.Kind == SymbolAdded:
+			printf("%s\n", ch.New)
+		case ch.Kind == SymbolRemoved:
+			printf("%s\n", ch.Old)
+		case ch.BodyChanged:
+			printf("%s (body)\n", ch.New)
+		default:
+			printf("%s -> %s\n", ch.Old, ch.New)
+		}
+	}
+	added, removed, changed := c.Stats()
+	printf("%d added, %d removed, %d changed\n", added, removed, changed)
+	return n, bw.Flush()
+}
+
+// A churnSymbol is the declaration of a function, method, or type, and
+// for a function or method, the text of its body.
+type churnSymbol struct {
+	desc, body string
+}
+
+// churnSymbols returns the functions, methods, and types of the
+// initial packages of prog, keyed by package path and name.
+func churnSymbols(prog *Program) map[[2]string]churnSymbol {
+	syms := make(map[[2]string]churnSymbol)
+	for _, info := range prog.InitialPackages() {
+		path := info.Pkg.Path()
+		body := func(obj types.Object) string {
+			decl, _ := info.Decl(obj)
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				return ""
+			}
+			var buf bytes.Buffer
+			printer.Fprint(&buf, prog.Fset, fd.Body)
+			return strings.Join(strings.Fields(buf.String()), " ") // ignore layout
+		}
+		scope := info.Pkg.Scope()
+		for _, name := range scope.Names() {
+			switch obj := scope.Lookup(name).(type) {
+			case *types.Func:
+				syms[[2]string{path, name}] = churnSymbol{types.ObjectString(info.Pkg, obj), body(obj)}
+			case *types.TypeName:
+				syms[[2]string{path, name}] = churnSymbol{desc: types.ObjectString(info.Pkg, obj)}
+				if named, ok := obj.Type().(*types.Named); ok {
+					for i := 0; i < named.NumMethods(); i++ {
+						m := named.Method(i)
+						syms[[2]string{path, name + "." + m.Name()}] = churnSymbol{types.ObjectString(info.Pkg, m), body(m)}
+					}
+				}
+			}
+		}
+	}
+	return syms
+}
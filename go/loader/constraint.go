@@ -0,0 +1,264 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file maps the package-level symbols of a package to the build
+// configurations under which they are declared.
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A Constraint is a condition on build configurations, in disjunctive
+// normal form: a configuration satisfies it if it satisfies all the
+// terms of one of its clauses.  A term, such as "linux" or "!cgo", is
+// a possibly negated operating system, architecture, or build tag.
+// The Constraint with a single empty clause always holds, and that
+// with no clauses never does.
+type Constraint [][]string
+
+// always is the Constraint that always holds.
+var always = Constraint{{}}
+
+// Always reports whether the constraint always holds: whether it has
+// a clause without terms.
+func (c Constraint) Always() bool {
+	for _, clause := range c {
+		if len(clause) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the constraint in the syntax of a +build line, such
+// as "linux,amd64 windows", or "" if it always holds.
+func (c Constraint) String() string {
+	if c.Always() {
+		return ""
+	}
+	var clauses []string
+	for _, clause := range c {
+		clauses = append(clauses, strings.Join(clause, ","))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// Match reports whether the build configuration satisfies the
+// constraint.  A term holds if it names the configuration's GOOS,
+// GOARCH, or one of its tags, or if it is a release tag such as
+// "go1.4"; tags such as "cgo" and "gccgo" must be specified.
+func (c Constraint) Match(bc BuildConfig) bool {
+	for _, clause := range c {
+		ok := true
+		for _, term := range clause {
+			if !matchTerm(term, bc) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchTerm(term string, bc BuildConfig) bool {
+	if strings.HasPrefix(term, "!") {
+		return !matchTerm(term[1:], bc)
+	}
+	if term == bc.GOOS || term == bc.GOARCH || strings.HasPrefix(term, "go1.") {
+		return true
+	}
+	for _, tag := range bc.Tags {
+		if term == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// and returns the conjunction of the constraints.
+func (c Constraint) and(d Constraint) Constraint {
+	var result Constraint
+	for _, x := range c {
+		for _, y := range d {
+			clause := append(append([]string(nil), x...), y...)
+			result = append(result, clause)
+		}
+	}
+	return result
+}
+
+// or returns the disjunction of the constraints, which is unconditional
+// if either is.
+func (c Constraint) or(d Constraint) Constraint {
+	if c.Always() || d.Always() {
+		return always
+	}
+	return append(append(Constraint(nil), c...), d...)
+}
+
+// A ConditionalSymbol is a package-level symbol, or a method, of a
+// package, and the build constraint under which it is declared.
+type ConditionalSymbol struct {
+	Name       string     // the symbol's name, or, for a method, "T.M"
+	Files      []string   // the files that declare it
+	Constraint Constraint // the disjunction of the constraints of Files
+}
+
+// ConditionalSymbols returns the package-level symbols and methods of
+// the package with the specified import path, in order of name, and
+// the build constraints under which they are declared, derived from
+// the +build lines and names of the files that declare them, such as
+// "p_windows.go".  Documentation and API tools may use it to annotate
+// platform-specific declarations.
+//
+// All the Go files of the package are considered, whatever the build
+// context's configuration; in-package test files are considered too,
+// if tests is set.  Files that cannot be parsed are skipped, and their
+// errors returned.
+//
+func (conf *Config) ConditionalSymbols(path string, tests bool) ([]*ConditionalSymbol, []error) {
+	ctxt := conf.build()
+	bp, err := ctxt.Import(path, conf.Cwd, 0)
+	if _, ok := err.(*build.NoGoError); err != nil && !ok {
+		return nil, []error{err}
+	}
+	filenames := append(append(bp.GoFiles, bp.CgoFiles...), bp.IgnoredGoFiles...)
+	if tests {
+		filenames = append(filenames, bp.TestGoFiles...)
+	}
+	fset := token.NewFileSet()
+	files, errs, _ := parseFiles(fset, ctxt, conf.DisplayPath, bp.Dir, filenames, parser.ParseComments, nil)
+
+	syms := make(map[string]*ConditionalSymbol)
+	for _, f := range files {
+		if f.Name.Name != bp.Name && bp.Name != "" {
+			continue // e.g. a "package main" file ignored by a +build ignore line
+		}
+		filename := fset.File(f.Pos()).Name()
+		c := fileConstraint(f, filename)
+		def := func(name string) {
+			sym := syms[name]
+			if sym == nil {
+				sym = &ConditionalSymbol{Name: name}
+				syms[name] = sym
+			}
+			sym.Files = append(sym.Files, filename)
+			sym.Constraint = sym.Constraint.or(c)
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				name := decl.Name.Name
+				if decl.Recv != nil && len(decl.Recv.List) == 1 {
+					if id := embeddedIdent(decl.Recv.List[0].Type); id != nil {
+						name = id.Name + "." + name
+					}
+				}
+				if name != "init" && name != "_" {
+					def(name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							if id.Name != "_" {
+								def(id.Name)
+							}
+						}
+					case *ast.TypeSpec:
+						def(spec.Name.Name)
+					}
+				}
+			}
+		}
+	}
+
+	var result []*ConditionalSymbol
+	for _, sym := range syms {
+		result = append(result, sym)
+	}
+	sort.Sort(byName(result))
+	return result, errs
+}
+
+type byName []*ConditionalSymbol
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// fileConstraint returns the build constraint of the file f, named
+// filename: the conjunction of that of its +build lines and of its
+// name.
+func fileConstraint(f *ast.File, filename string) Constraint {
+	c := nameConstraint(filename)
+	for _, cg := range f.Comments {
+		if cg.End() >= f.Package {
+			break
+		}
+		if cg == f.Doc {
+			continue // constraints must be followed by a blank line
+		}
+		for _, comment := range cg.List {
+			line := strings.TrimPrefix(comment.Text, "//")
+			fields := strings.Fields(line)
+			if len(fields) == 0 || fields[0] != "+build" {
+				continue
+			}
+			var d Constraint
+			for _, clause := range fields[1:] {
+				d = append(d, strings.Split(clause, ","))
+			}
+			c = c.and(d)
+		}
+	}
+	return c
+}
+
+// nameConstraint returns the build constraint implied by the name of
+// a file, as described at go/build: *_GOOS, *_GOARCH, or
+// *_GOOS_GOARCH, optionally followed by _test.
+func nameConstraint(filename string) Constraint {
+	name := strings.TrimSuffix(filepath.Base(filename), ".go")
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return always
+	}
+	l := strings.Split(name[i:], "_")
+	if n := len(l); n > 0 && l[n-1] == "test" {
+		l = l[:n-1]
+	}
+	n := len(l)
+	if n >= 2 && knownOS[l[n-2]] && knownArch[l[n-1]] {
+		return Constraint{{l[n-2], l[n-1]}}
+	}
+	if n >= 1 && (knownOS[l[n-1]] || knownArch[l[n-1]]) {
+		return Constraint{{l[n-1]}}
+	}
+	return always
+}
+
+var knownOS = make(map[string]bool)
+var knownArch = make(map[string]bool)
+
+func init() {
+	for _, v := range strings.Fields("android darwin dragonfly freebsd linux nacl netbsd openbsd plan9 solaris windows") {
+		knownOS[v] = true
+	}
+	for _, v := range strings.Fields("386 amd64 amd64p32 arm armbe arm64 arm64be ppc64 ppc64le mips mipsle mips64 mips64le ppc s390 s390x sparc sparc64") {
+		knownArch[v] = true
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file defines the association of declared objects with the
+// syntax that declares them.
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A declInfo records the syntax that declares an object.
+type declInfo struct {
+	decl ast.Decl          // innermost enclosing declaration
+	node ast.Node          // declaring FuncDecl, ValueSpec, TypeSpec, ImportSpec, or Field
+	doc  *ast.CommentGroup // doc comment, or nil
+}
+
+// Decl returns the syntax that declares obj, if it was declared in the
+// files of this package by a declaration: node is the *ast.FuncDecl,
+// *ast.ValueSpec, *ast.TypeSpec, *ast.ImportSpec, or *ast.Field that
+// declares it, and decl is the innermost declaration enclosing node,
+// which is node itself for a FuncDecl.  For instance, for a struct
+// field, node is the Field and decl is the GenDecl of the struct type,
+// and for a parameter, node is the Field and decl is the FuncDecl.
+// Decl returns nils for objects declared otherwise, such as by a
+// short variable declaration, and for objects of other packages.
+//
+// Refactoring tools may use Decl to find the syntax to edit for an
+// object without scanning the files by position.
+//
+func (info *PackageInfo) Decl(obj types.Object) (decl ast.Decl, node ast.Node) {
+	d := info.declInfo(obj)
+	return d.decl, d.node
+}
+
+// Doc returns the doc comment of the declaration of obj, a package
+// member, method, struct field, interface method, or local object
+// defined by a declaration in the files of this package, or nil if it
+// has none or was not declared in them.  Comments are available only
+// if the files were parsed with parser.ParseComments.
+//
+// The doc comment of a const, var, or type specification is that of
+// the specification, or, if it has none and is the only specification
+// of an unparenthesized declaration, that of the declaration.  For
+// instance, the doc comment of T is "// T is a type." in both of these
+// declarations:
+//
+//	// T is a type.
+//	type T int
+//
+//	type (
+//		// T is a type.
+//		T int
+//	)
+//
+func (info *PackageInfo) Doc(obj types.Object) *ast.CommentGroup {
+	return info.declInfo(obj).doc
+}
+
+// declInfo returns the declaration information for obj, computing
+// that of all objects on first use.
+func (info *PackageInfo) declInfo(obj types.Object) declInfo {
+	info.declsOnce.Do(info.indexDecls)
+	return info.decls[obj]
+}
+
+// indexDecls computes the declaration information of each object
+// declared in the package's files.
+func (info *PackageInfo) indexDecls() {
+	info.decls = make(map[types.Object]declInfo)
+	def := func(id *ast.Ident, d declInfo) {
+		if obj := info.Defs[id]; obj != nil {
+			info.decls[obj] = d
+		}
+	}
+	var decls []ast.Decl // stack of enclosing declarations
+	var stack []ast.Node // stack of enclosing nodes
+	for _, f := range info.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if n == nil {
+				if _, ok := stack[len(stack)-1].(ast.Decl); ok {
+					decls = decls[:len(decls)-1]
+				}
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			stack = append(stack, n)
+			if decl, ok := n.(ast.Decl); ok {
+				decls = append(decls, decl)
+			}
+
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				def(n.Name, declInfo{decl: n, node: n, doc: n.Doc})
+
+			case *ast.GenDecl:
+				for _, spec := range n.Specs {
+					var doc *ast.CommentGroup
+					var names []*ast.Ident
+					var implicit types.Object // package name of an unrenamed import
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						doc, names = spec.Doc, spec.Names
+					case *ast.TypeSpec:
+						doc, names = spec.Doc, []*ast.Ident{spec.Name}
+					case *ast.ImportSpec:
+						doc = spec.Doc
+						if spec.Name != nil {
+							names = []*ast.Ident{spec.Name}
+						} else {
+							implicit = info.Implicits[spec]
+						}
+					}
+					if doc == nil && !n.Lparen.IsValid() {
+						doc = n.Doc
+					}
+					if implicit != nil {
+						info.decls[implicit] = declInfo{decl: n, node: spec, doc: doc}
+					}
+					for _, id := range names {
+						def(id, declInfo{decl: n, node: spec, doc: doc})
+					}
+				}
+
+			case *ast.Field:
+				var decl ast.Decl
+				if len(decls) > 0 {
+					decl = decls[len(decls)-1]
+				}
+				d := declInfo{decl: decl, node: n, doc: n.Doc}
+				for _, id := range n.Names {
+					def(id, d)
+				}
+				if n.Names == nil {
+					// embedded field or interface
+					if id := embeddedIdent(n.Type); id != nil {
+						def(id, d)
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// embeddedIdent returns the identifier that names the type of an
+// embedded field, which the type checker records as its definition,
+// or nil.
+func embeddedIdent(typ ast.Expr) *ast.Ident {
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		return typ
+	case *ast.SelectorExpr:
+		return typ.Sel
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file defines heuristics for grouping constants into enums.
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/exact"
+	"golang.org/x/tools/go/types"
+)
+
+// An Enum is a group of the package-level constants of a named type
+// that are declared by the same const declaration, as enumerations
+// conventionally are:
+//
+//	type Color int
+//
+//	const (
+//		Red Color = iota
+//		Green
+//		Blue
+//	)
+//
+type Enum struct {
+	Type   *types.Named
+	Decl   *ast.GenDecl
+	Consts []*types.Const // the non-blank constants, in declaration order
+
+	// Iota reports whether some value of the declaration is an
+	// explicit expression using iota.
+	Iota bool
+
+	// Bitmask reports whether the enum has at least three non-zero
+	// values, and they are distinct powers of two, as for flags.
+	Bitmask bool
+
+	// Gaps holds the values missing from the sequence of the enum's
+	// integer values, in increasing order: for a bitmask, the powers
+	// of two between the least and the greatest, and otherwise the
+	// integers between them.  It is nil if a value is not an integer
+	// representable as an int64, or if there are more gaps than
+	// values, since the constants are then not a sequence.
+	Gaps []int64
+
+	// Duplicates holds the groups of constants that have the same
+	// value, in declaration order.
+	Duplicates [][]*types.Const
+}
+
+// Enums returns the enums of the package, in source order.  Analyzers
+// may use them to check switches and conversions, and generators to
+// derive String methods and the like.
+func (info *PackageInfo) Enums() []*Enum {
+	iota := types.Universe.Lookup("iota")
+	var enums []*Enum
+	for _, f := range info.Files {
+		for _, decl := range f.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.CONST {
+				continue
+			}
+			byType := make(map[*types.Named]*Enum)
+			var usesIota bool
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				if spec.Values != nil {
+					usesIota = false
+					for _, v := range spec.Values {
+						ast.Inspect(v, func(n ast.Node) bool {
+							if id, ok := n.(*ast.Ident); ok && info.Uses[id] == iota {
+								usesIota = true
+							}
+							return true
+						})
+					}
+				}
+				for _, id := range spec.Names {
+					obj, ok := info.Defs[id].(*types.Const)
+					if !ok || obj.Name() == "_" {
+						continue
+					}
+					named, ok := obj.Type().(*types.Named)
+					if !ok {
+						continue
+					}
+					e := byType[named]
+					if e == nil {
+						e = &Enum{Type: named, Decl: decl}
+						byType[named] = e
+						enums = append(enums, e)
+					}
+					e.Consts = append(e.Consts, obj)
+					if usesIota {
+						e.Iota = true
+					}
+				}
+			}
+		}
+	}
+	for _, e := range enums {
+		e.analyze()
+	}
+	return enums
+}
+
+// analyze computes the Bitmask, Gaps, and Duplicates of e.
+func (e *Enum) analyze() {
+	// Group the constants by value.
+	groups := make(map[string][]*types.Const)
+	var keys []string // distinct values, in declaration order
+	for _, c := range e.Consts {
+		k := c.Val().String()
+		if groups[k] == nil {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], c)
+	}
+	for _, k := range keys {
+		if g := groups[k]; len(g) > 1 {
+			e.Duplicates = append(e.Duplicates, g)
+		}
+	}
+
+	// Find the distinct integer values.
+	var vals int64s
+	for _, k := range keys {
+		v, ok := exact.Int64Val(groups[k][0].Val())
+		if !ok {
+			return // not an integer, or too large
+		}
+		vals = append(vals, v)
+	}
+	sort.Sort(vals)
+
+	var nonzero int
+	e.Bitmask = true
+	for _, v := range vals {
+		if v != 0 {
+			nonzero++
+			if v < 0 || v&(v-1) != 0 {
+				e.Bitmask = false
+			}
+		}
+	}
+	if nonzero < 3 {
+		e.Bitmask = false
+	}
+
+	var gaps []int64
+	for i := 1; i < len(vals); i++ {
+		lo, hi := vals[i-1], vals[i]
+		if e.Bitmask {
+			if lo == 0 {
+				continue
+			}
+			for v := lo << 1; v < hi; v <<= 1 {
+				gaps = append(gaps, v)
+			}
+			continue
+		}
+		if uint64(hi-lo) > uint64(len(vals)) {
+			return // too sparse
+		}
+		for v := lo + 1; v < hi; v++ {
+			gaps = append(gaps, v)
+		}
+	}
+	if len(gaps) > len(vals) {
+		return
+	}
+	e.Gaps = gaps
+}
+
+type int64s []int64
+
+func (s int64s) Len() int           { return len(s) }
+func (s int64s) Less(i, j int) bool { return s[i] < s[j] }
+func (s int64s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,345 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file defines queries over the import graph of a program.
+
+import "sort"
+
+// imports returns the packages of the program directly imported by
+// info, in the order of Pkg.Imports.  Imports of packages the program
+// does not contain, such as "unsafe", are omitted.
+func (prog *Program) imports(info *PackageInfo) []*PackageInfo {
+	var infos []*PackageInfo
+	for _, imp := range info.Pkg.Imports() {
+		if info := prog.AllPackages[imp]; info != nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// packages returns the packages of the program with the specified
+// import paths, omitting those it does not contain.
+func (prog *Program) packages(paths []string) []*PackageInfo {
+	var infos []*PackageInfo
+	for _, path := range paths {
+		if info := prog.Package(path); info != nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// Deps returns the packages transitively imported by the packages with
+// the specified import paths, in order of import path.  The packages
+// themselves are included only if one imports another, directly or
+// not.  Paths of packages the program does not contain are ignored.
+func (prog *Program) Deps(paths ...string) []*PackageInfo {
+	seen := make(map[*PackageInfo]bool)
+	var visit func(info *PackageInfo)
+	visit = func(info *PackageInfo) {
+		for _, imp := range prog.imports(info) {
+			if !seen[imp] {
+				seen[imp] = true
+				visit(imp)
+			}
+		}
+	}
+	for _, info := range prog.packages(paths) {
+		visit(info)
+	}
+	return sortedInfos(seen)
+}
+
+// Dependents returns the packages of the program that transitively
+// import any of the packages with the specified import paths, in
+// order of import path.  As for Deps, the packages themselves are
+// included only if one imports another.
+func (prog *Program) Dependents(paths ...string) []*PackageInfo {
+	importers := make(map[*PackageInfo][]*PackageInfo)
+	for _, info := range prog.AllPackages {
+		for _, imp := range prog.imports(info) {
+			importers[imp] = append(importers[imp], info)
+		}
+	}
+	seen := make(map[*PackageInfo]bool)
+	var visit func(info *PackageInfo)
+	visit = func(info *PackageInfo) {
+		for _, importer := range importers[info] {
+			if !seen[importer] {
+				seen[importer] = true
+				visit(importer)
+			}
+		}
+	}
+	for _, info := range prog.packages(paths) {
+		visit(info)
+	}
+	return sortedInfos(seen)
+}
+
+// GroupCycles returns the cycles of the import graph among groups of
+// packages, where group maps the import path of each package to the
+// name of its group, such as its directory or the repository that
+// contains it.  Though packages may not import each other cyclically,
+// groups of them may, which defeats layering.  Each cycle is a
+// strongly connected component of the graph of groups: a sorted list
+// of two or more group names, each of which imports the others,
+// directly or not.  The cycles are in order of their first names.
+// Packages whose group is "" are ignored.
+func (prog *Program) GroupCycles(group func(path string) string) [][]string {
+	edges := make(map[string]map[string]bool)
+	for _, info := range prog.AllPackages {
+		from := group(info.Pkg.Path())
+		if from == "" {
+			continue
+		}
+		if edges[from] == nil {
+			edges[from] = make(map[string]bool)
+		}
+		for _, imp := range prog.imports(info) {
+			if to := group(imp.Pkg.Path()); to != "" && to != from {
+				edges[from][to] = true
+			}
+		}
+	}
+	var names []string
+	for name := range edges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Tarjan's strongly connected components algorithm.
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+	var strongconnect func(v string)
+	strongconnect = func(v string) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for w := range edges[v] {
+			if _, ok := index[w]; !ok {
+				if edges[w] == nil {
+					continue // a group without packages of its own; can't be on a cycle
+				}
+				strongconnect(w)
+				if lowlink[w] < lowlink[v] {
+					lowlink[v] = lowlink[w]
+				}
+			} else if onStack[w] && index[w] < lowlink[v] {
+				lowlink[v] = index[w]
+			}
+		}
+		if lowlink[v] == index[v] {
+			var scc []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				scc = append(scc, w)
+				if w == v {
+					break
+				}
+			}
+			if len(scc) > 1 {
+				sort.Strings(scc)
+				cycles = append(cycles, scc)
+			}
+		}
+	}
+	for _, name := range names {
+		if _, ok := index[name]; !ok {
+			strongconnect(name)
+		}
+	}
+	sort.Sort(byFirst(cycles))
+	return cycles
+}
+
+type byFirst [][]string
+
+func (b byFirst) Len() int           { return len(b) }
+func (b byFirst) Less(i, j int) bool { return b[i][0] < b[j][0] }
+func (b byFirst) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// Dominators returns the packages through which package root
+// transitively imports the package path: those on every import path
+// from root to it.  They are ordered from root, which comes first, to
+// the immediate dominator of path; removing the import of the last of
+// them, or of any other, would drop path from the dependencies of
+// root.  Dominators returns nil if root does not import path, directly
+// or not.
+func (prog *Program) Dominators(root, path string) []*PackageInfo {
+	from, to := prog.Package(root), prog.Package(path)
+	if from == nil || to == nil || from == to {
+		return nil
+	}
+
+	// Number the packages reachable from root in reverse postorder.
+	var order []*PackageInfo // postorder
+	num := make(map[*PackageInfo]int)
+	var visit func(info *PackageInfo)
+	visit = func(info *PackageInfo) {
+		num[info] = -1
+		for _, imp := range prog.imports(info) {
+			if _, ok := num[imp]; !ok {
+				visit(imp)
+			}
+		}
+		order = append(order, info)
+	}
+	visit(from)
+	if _, ok := num[to]; !ok {
+		return nil
+	}
+	n := len(order)
+	for i, info := range order {
+		num[info] = n - 1 - i
+	}
+	preds := make([][]int, n)
+	for _, info := range order {
+		for _, imp := range prog.imports(info) {
+			preds[num[imp]] = append(preds[num[imp]], num[info])
+		}
+	}
+
+	// Compute the immediate dominators, using the iterative
+	// algorithm of Cooper, Harvey, and Kennedy.
+	idom := make([]int, n)
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[0] = 0
+	intersect := func(a, b int) int {
+		for a != b {
+			for a > b {
+				a = idom[a]
+			}
+			for b > a {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for v := 1; v < n; v++ {
+			d := -1
+			for _, p := range preds[v] {
+				if idom[p] < 0 {
+					continue // not yet processed
+				}
+				if d < 0 {
+					d = p
+				} else {
+					d = intersect(d, p)
+				}
+			}
+			if d != idom[v] {
+				idom[v] = d
+				changed = true
+			}
+		}
+	}
+
+	var doms []*PackageInfo
+	for v := idom[num[to]]; ; v = idom[v] {
+		doms = append(doms, order[n-1-v])
+		if v == 0 {
+			break
+		}
+	}
+	for i, j := 0, len(doms)-1; i < j; i, j = i+1, j-1 {
+		doms[i], doms[j] = doms[j], doms[i]
+	}
+	return doms
+}
+
+// PulledInBy returns the packages directly imported by package root
+// that transitively import the package path, or are it, in order of
+// import path: the imports of root that pull in path.
+func (prog *Program) PulledInBy(root, path string) []*PackageInfo {
+	from, to := prog.Package(root), prog.Package(path)
+	if from == nil || to == nil {
+		return nil
+	}
+	reaches := make(map[*PackageInfo]bool) // memo of whether a package reaches to
+	var visit func(info *PackageInfo) bool
+	visit = func(info *PackageInfo) bool {
+		if r, ok := reaches[info]; ok {
+			return r
+		}
+		reaches[info] = false // break cycles
+		r := info == to
+		for _, imp := range prog.imports(info) {
+			if visit(imp) {
+				r = true
+			}
+		}
+		reaches[info] = r
+		return r
+	}
+	seen := make(map[*PackageInfo]bool)
+	for _, imp := range prog.imports(from) {
+		if visit(imp) {
+			seen[imp] = true
+		}
+	}
+	return sortedInfos(seen)
+}
+
+// A Weight measures the size of a set of packages.
+type Weight struct {
+	Packages int // number of packages
+	Files    int // number of files
+	Lines    int // number of lines of the files
+	Symbols  int // number of package-level objects
+}
+
+// Weight returns the weight of the packages with the specified import
+// paths and of their dependencies, which is that of the code a program
+// that imports them must compile.  The weight of an import is the
+// difference between the weight of a package with it and without it;
+// see also Dominators.
+func (prog *Program) Weight(paths ...string) Weight {
+	infos := make(map[*PackageInfo]bool)
+	for _, info := range prog.packages(paths) {
+		infos[info] = true
+	}
+	for _, info := range prog.Deps(paths...) {
+		infos[info] = true
+	}
+	var w Weight
+	for info := range infos {
+		w.Packages++
+		w.Files += len(info.Files)
+		for _, f := range info.Files {
+			w.Lines += prog.Fset.File(f.Pos()).LineCount()
+		}
+		w.Symbols += len(info.Pkg.Scope().Names())
+	}
+	return w
+}
+
+// sortedInfos returns the elements of the set, in order of import path.
+func sortedInfos(set map[*PackageInfo]bool) []*PackageInfo {
+	infos := make([]*PackageInfo, 0, len(set))
+	for info := range set {
+		infos = append(infos, info)
+	}
+	sort.Sort(byPath(infos))
+	return infos
+}
+
+type byPath []*PackageInfo
+
+func (b byPath) Len() int           { return len(b) }
+func (b byPath) Less(i, j int) bool { return b[i].Pkg.Path() < b[j].Pkg.Path() }
+func (b byPath) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
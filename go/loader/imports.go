@@ -0,0 +1,119 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file computes the use of each file's imports.
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/types"
+)
+
+// FileImports describes the use of the imports of a file.
+type FileImports struct {
+	File    *ast.File
+	Imports []*ImportUse // in the order of the file's import specs
+}
+
+// An ImportUse describes the use, within its file, of an import.
+type ImportUse struct {
+	Spec    *ast.ImportSpec
+	PkgName *types.PkgName // the package name declared by Spec
+
+	// Uses holds the identifiers of the file, in order, that refer
+	// to the import: the package names x of qualified identifiers
+	// x.f, or, for a dot import, the identifiers that denote members
+	// of the imported package.
+	Uses []*ast.Ident
+
+	// UsedElsewhere reports whether, though the file does not use
+	// the import, another file of the package uses the same package.
+	UsedElsewhere bool
+}
+
+// Unused reports whether the import is unused, and so may be removed:
+// it has no use, and it is not a blank import, imported for its side
+// effects.
+func (u *ImportUse) Unused() bool {
+	return len(u.Uses) == 0 && u.PkgName.Name() != "_"
+}
+
+// ImportUses returns, for each file of the package, the use of each
+// of its imports.  Import pruning and grouping tools may use it to
+// find the imports they can remove or must keep.  Imports that could
+// not be loaded are omitted.
+func (info *PackageInfo) ImportUses() []*FileImports {
+	var result []*FileImports
+	used := make(map[*types.Package]bool) // packages used by some file
+	for _, f := range info.Files {
+		fi := &FileImports{File: f}
+		byName := make(map[*types.PkgName]*ImportUse)
+		dot := make(map[*types.Package]*ImportUse)
+		for _, spec := range f.Imports {
+			var obj types.Object
+			if spec.Name != nil {
+				obj = info.Defs[spec.Name]
+			} else {
+				obj = info.Implicits[spec]
+			}
+			pkgname, ok := obj.(*types.PkgName)
+			if !ok {
+				continue // import failed
+			}
+			u := &ImportUse{Spec: spec, PkgName: pkgname}
+			fi.Imports = append(fi.Imports, u)
+			if pkgname.Name() == "." {
+				dot[pkgname.Imported()] = u
+			} else {
+				byName[pkgname] = u
+			}
+		}
+
+		// Find the uses of the imports, in order.  The Sel of a
+		// qualified identifier is not a use of a dot import.
+		qualified := make(map[*ast.Ident]bool)
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if x, ok := n.X.(*ast.Ident); ok {
+					if _, ok := info.Uses[x].(*types.PkgName); ok {
+						qualified[n.Sel] = true
+					}
+				}
+
+			case *ast.Ident:
+				obj := info.Uses[n]
+				if obj == nil {
+					break
+				}
+				if pkgname, ok := obj.(*types.PkgName); ok {
+					if u := byName[pkgname]; u != nil {
+						u.Uses = append(u.Uses, n)
+					}
+				} else if pkg := obj.Pkg(); pkg != nil && !qualified[n] && obj.Parent() == pkg.Scope() {
+					if u := dot[pkg]; u != nil {
+						u.Uses = append(u.Uses, n)
+					}
+				}
+			}
+			return true
+		})
+
+		for _, u := range fi.Imports {
+			if len(u.Uses) > 0 {
+				used[u.PkgName.Imported()] = true
+			}
+		}
+		result = append(result, fi)
+	}
+
+	for _, fi := range result {
+		for _, u := range fi.Imports {
+			u.UsedElsewhere = len(u.Uses) == 0 && used[u.PkgName.Imported()]
+		}
+	}
+	return result
+}
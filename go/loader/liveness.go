@@ -0,0 +1,254 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file computes which exported symbols of the dependencies of a
+// set of root packages those packages reference, for link-time
+// pruning ("tree shaking") tools.
+//
+// The analysis is syntactic and conservative.  It indexes, for each
+// package-level object and method, the objects referenced by its
+// declaration (the referrers index, inverted), and computes the
+// objects reachable from the roots: all package-level objects and
+// methods of the root packages, and, for each of their dependencies,
+// its init functions and initialized package-level variables, whose
+// initialization the linker cannot remove.  All the methods of a live
+// type are live, since any of them may be called through an interface.
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"io"
+	"sort"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A Liveness reports which of the exported package-level objects and
+// methods of the dependencies of some root packages are live: referenced
+// from the roots, directly or not.
+type Liveness struct {
+	// Live and Dead hold the live and dead symbols, in order of
+	// package path and name, the methods of each type following it.
+	Live, Dead []types.Object
+}
+
+// Liveness computes the liveness of the exported symbols of the
+// dependencies of the packages with the specified import paths.
+func (prog *Program) Liveness(roots ...string) *Liveness {
+	refs := make(map[types.Object][]types.Object) // objects referenced by each declaration
+	for _, info := range prog.AllPackages {
+		info.indexRefs(refs)
+	}
+
+	live := make(map[types.Object]bool)
+	var mark func(obj types.Object)
+	mark = func(obj types.Object) {
+		if live[obj] {
+			return
+		}
+		live[obj] = true
+		for _, ref := range refs[obj] {
+			mark(ref)
+		}
+		if tname, ok := obj.(*types.TypeName); ok {
+			if named, ok := tname.Type().(*types.Named); ok {
+				for i := 0; i < named.NumMethods(); i++ {
+					mark(named.Method(i))
+				}
+			}
+		}
+	}
+	for _, info := range prog.packages(roots) {
+		for obj := range refs {
+			if obj.Pkg() == info.Pkg {
+				mark(obj)
+			}
+		}
+	}
+	deps := prog.Deps(roots...)
+	for _, info := range deps {
+		for _, obj := range info.initRoots() {
+			mark(obj)
+		}
+	}
+
+	isRoot := make(map[*PackageInfo]bool)
+	for _, info := range prog.packages(roots) {
+		isRoot[info] = true
+	}
+	l := new(Liveness)
+	for _, info := range deps {
+		if isRoot[info] {
+			continue
+		}
+		for _, obj := range exportedSymbols(info.Pkg) {
+			if live[obj] {
+				l.Live = append(l.Live, obj)
+			} else {
+				l.Dead = append(l.Dead, obj)
+			}
+		}
+	}
+	return l
+}
+
+// indexRefs records in refs the objects referenced by the declaration
+// of each package-level object and method of the package.
+func (info *PackageInfo) indexRefs(refs map[types.Object][]types.Object) {
+	for _, f := range info.Files {
+		for _, decl := range f.Decls {
+			var objs []types.Object // the objects declared by decl
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				objs = append(objs, info.Defs[decl.Name])
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							objs = append(objs, info.Defs[id])
+						}
+					case *ast.TypeSpec:
+						objs = append(objs, info.Defs[spec.Name])
+					}
+				}
+			}
+			var used []types.Object
+			ast.Inspect(decl, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					if obj := info.Uses[id]; obj != nil && isSymbol(obj) {
+						used = append(used, obj)
+					}
+				}
+				return true
+			})
+			for _, obj := range objs {
+				if obj != nil {
+					refs[obj] = append(refs[obj], used...)
+				}
+			}
+		}
+	}
+}
+
+// initRoots returns the objects of the package that are live whenever
+// it is linked: its init functions, and its package-level variables
+// with initializers.
+func (info *PackageInfo) initRoots() []types.Object {
+	var roots []types.Object
+	for _, f := range info.Files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name == "init" {
+					if obj := info.Defs[decl.Name]; obj != nil {
+						roots = append(roots, obj)
+					}
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*ast.ValueSpec); ok && spec.Values != nil {
+						for _, id := range spec.Names {
+							if obj, ok := info.Defs[id].(*types.Var); ok {
+								roots = append(roots, obj)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return roots
+}
+
+// isSymbol reports whether obj is a package-level object or a method.
+func isSymbol(obj types.Object) bool {
+	if obj.Pkg() == nil {
+		return false // universe
+	}
+	if fn, ok := obj.(*types.Func); ok && fn.Type().(*types.Signature).Recv() != nil {
+		return true
+	}
+	return obj.Parent() == obj.Pkg().Scope()
+}
+
+// exportedSymbols returns the exported package-level objects of pkg,
+// and the exported methods of its named types, each type's following
+// it, in order of name.
+func exportedSymbols(pkg *types.Package) []types.Object {
+	var objs []types.Object
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if obj.Exported() {
+			objs = append(objs, obj)
+		}
+		if tname, ok := obj.(*types.TypeName); ok {
+			if named, ok := tname.Type().(*types.Named); ok {
+				var methods []types.Object
+				for i := 0; i < named.NumMethods(); i++ {
+					if m := named.Method(i); m.Exported() {
+						methods = append(methods, m)
+					}
+				}
+				sort.Sort(byObjName(methods))
+				objs = append(objs, methods...)
+			}
+		}
+	}
+	return objs
+}
+
+type byObjName []types.Object
+
+func (b byObjName) Len() int           { return len(b) }
+func (b byObjName) Less(i, j int) bool { return b[i].Name() < b[j].Name() }
+func (b byObjName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// SymbolName returns the name of the package-level object or method
+// obj in the form used by the linker, such as "path/to/pkg.F",
+// "path/to/pkg.T.M", or "path/to/pkg.(*T).M".
+func SymbolName(obj types.Object) string {
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			T := recv.Type()
+			ptr := false
+			if p, ok := T.(*types.Pointer); ok {
+				T, ptr = p.Elem(), true
+			}
+			if named, ok := T.(*types.Named); ok {
+				if ptr {
+					return fmt.Sprintf("%s.(*%s).%s", obj.Pkg().Path(), named.Obj().Name(), obj.Name())
+				}
+				return fmt.Sprintf("%s.%s.%s", obj.Pkg().Path(), named.Obj().Name(), obj.Name())
+			}
+		}
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// WriteTo writes a report of the liveness to w, one symbol per line,
+// in the form "live" or "dead", a tab, and the symbol's linker name
+// (see SymbolName), with the live symbols first:
+//
+//	live	fmt.Println
+//	dead	fmt.(*pp).Flag
+//
+func (l *Liveness) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	for _, s := range []struct {
+		label string
+		objs  []types.Object
+	}{{"live", l.Live}, {"dead", l.Dead}} {
+		for _, obj := range s.objs {
+			m, _ := fmt.Fprintf(bw, "%s\t%s\n", s.label, SymbolName(obj))
+			n += int64(m)
+		}
+	}
+	return n, bw.Flush()
+}
@@ -7,6 +7,7 @@ package loader
 // See doc.go for package documentation and implementation notes.
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -53,6 +54,21 @@ type Config struct {
 	// checked.
 	TypeCheckFuncBodies func(string) bool
 
+	// If ConfigureTypeChecker is non-nil, it is called before
+	// each list of files of a package is type-checked, allowing
+	// the TypeChecker options to vary by package and, for
+	// packages augmented by in-package tests, between the
+	// package's ordinary files and its test files.
+	//
+	// path is the package's import path.  conf is the package's
+	// private copy of TypeChecker, with IgnoreFuncBodies set
+	// according to TypeCheckFuncBodies; when test is true, conf
+	// holds the options used for the ordinary files.  Changes to
+	// the Import and Error fields are ignored.
+	//
+	// It must be safe to call concurrently from multiple goroutines.
+	ConfigureTypeChecker func(path string, test bool, conf *types.Config)
+
 	// If Build is non-nil, it is used to locate source packages.
 	// Otherwise &build.Default is used.
 	//
@@ -62,6 +78,24 @@ type Config struct {
 	// to startup, or by setting Build.CgoEnabled=false.
 	Build *build.Context
 
+	// If FakeCgo is set, Go files that import "C" are not
+	// preprocessed by cgo, but parsed and type-checked as they are,
+	// as if with TypeChecker.FakeImportC: references to members of
+	// package "C" are not errors, but have no precise types, and
+	// may not be used where one is needed.  This approximation
+	// requires no C toolchain, is faster, and preserves the offsets
+	// of the files' syntax.  It has no effect if Build.CgoEnabled is
+	// false, as such files are then excluded from their packages.
+	FakeCgo bool
+
+	// Preprocessors transform the source files of packages before
+	// they are parsed: each file is processed by the first
+	// preprocessor whose pattern matches its name, if any.  The SWIG
+	// files of a package (see build.Package.SwigFiles), which are
+	// otherwise ignored, are added to its files if a preprocessor
+	// matches them, so that it may generate their Go bindings.
+	Preprocessors []Preprocessor
+
 	// The current directory, used for resolving relative package
 	// references such as "./go/loader".  If empty, os.Getwd will be
 	// used instead.
@@ -103,6 +137,12 @@ type Config struct {
 	//
 	// It must be safe to call concurrently from multiple goroutines.
 	FindPackage func(ctxt *build.Context, importPath string) (*build.Package, error)
+
+	// If Cache is non-nil, packages loaded from source are
+	// retrieved from it if present, and added to it afterwards,
+	// so that they may be reused by later calls to Load.
+	// See Cache for the conditions on its use.
+	Cache *Cache
 }
 
 // A PkgSpec specifies a non-importable package to be created by Load.
@@ -134,12 +174,44 @@ type Program struct {
 	// dependencies, including incomplete ones.
 	AllPackages map[*types.Package]*PackageInfo
 
+	// ImportCycles contains each import cycle detected among the
+	// packages loaded from source, in unspecified order.
+	ImportCycles []*ImportCycle
+
 	// importMap is the canonical mapping of import paths to
 	// packages.  It contains all Imported initial packages, but not
 	// Created ones, and all imported dependencies.
 	importMap map[string]*types.Package
 }
 
+// An ImportCycle describes a cycle in the import graph: for each
+// i < len(Pos), package Path[i] imports Path[i+1], by the import
+// declaration at Pos[i].  The first and last elements of Path are
+// the same.  An ImportCycle is also the error reported for the import
+// that completes the cycle.
+type ImportCycle struct {
+	Fset *token.FileSet // file set for interpretation of Pos
+	Path []string       // import paths along the cycle
+	Pos  []token.Pos    // positions of the import declarations
+}
+
+// Error returns a description of the cycle of the form
+//
+//	import cycle: p -> q -> p (p.go:3:8: p imports q; q.go:4:8: q imports p)
+//
+func (c *ImportCycle) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "import cycle: %s (", strings.Join(c.Path, " -> "))
+	for i, pos := range c.Pos {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		fmt.Fprintf(&buf, "%s: %s imports %s", c.Fset.Position(pos), c.Path[i], c.Path[i+1])
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
 // PackageInfo holds the ASTs and facts derived by the type-checker
 // for a single package.
 //
@@ -153,12 +225,40 @@ type PackageInfo struct {
 	Errors                []error     // non-nil if the package had errors
 	types.Info                        // type-checker deductions.
 
-	checker   *types.Checker // transient type-checker state
+	generated map[*ast.File]bool        // files marked as generated code
+	declsOnce sync.Once                 // guards the computation of decls
+	decls     map[types.Object]declInfo // declaration information, computed by indexDecls
+	asm       map[string][]string       // assembly files defining each TEXT symbol (see AsmFiles)
+	checker   *types.Checker            // transient type-checker state
+	tc        *types.Config             // transient type-checker configuration
 	errorFunc func(error)
 }
 
 func (info *PackageInfo) String() string { return info.Pkg.Path() }
 
+// IsGenerated reports whether file f of this package was generated by
+// a tool, as indicated by a comment line of the form
+//
+//	// Code generated by <tool>; DO NOT EDIT.
+//
+// Files loaded from source are recognized regardless of ParserMode;
+// already-parsed files supplied via Config.CreatePkgs are recognized
+// only if they were parsed with parser.ParseComments.
+//
+// Analysis tools may use IsGenerated to avoid reporting problems in,
+// and refactoring tools to avoid editing, generated files.
+func (info *PackageInfo) IsGenerated(f *ast.File) bool { return info.generated[f] }
+
+// markGenerated records the files in gen as generated code.
+func (info *PackageInfo) markGenerated(gen map[*ast.File]bool) {
+	for f := range gen {
+		if info.generated == nil {
+			info.generated = make(map[*ast.File]bool)
+		}
+		info.generated[f] = true
+	}
+}
+
 func (info *PackageInfo) appendError(err error) {
 	if info.errorFunc != nil {
 		info.errorFunc(err)
@@ -368,8 +468,9 @@ type importer struct {
 	// Since non-importable packages cannot be cyclic, we ignore
 	// their imports, thus we only need the subgraph over importable
 	// packages.  Nodes are identified by their import paths.
-	graphMu sync.Mutex
-	graph   map[string]map[string]bool
+	graphMu   sync.Mutex
+	graph     map[string]map[string]bool
+	importPos map[string]map[string]token.Pos // importPos[x][y] is the position of x's import of y, if known
 }
 
 // importInfo tracks the success or failure of a single import.
@@ -448,6 +549,12 @@ func (conf *Config) Load() (*Program, error) {
 		}
 	}
 
+	if conf.Cache != nil {
+		if err := conf.Cache.setFset(conf); err != nil {
+			return nil, err
+		}
+	}
+
 	prog := &Program{
 		Fset:        conf.fset(),
 		Imported:    make(map[string]*PackageInfo),
@@ -456,11 +563,12 @@ func (conf *Config) Load() (*Program, error) {
 	}
 
 	imp := importer{
-		conf:     conf,
-		prog:     prog,
-		imported: make(map[string]*importInfo),
-		start:    time.Now(),
-		graph:    make(map[string]map[string]bool),
+		conf:      conf,
+		prog:      prog,
+		imported:  make(map[string]*importInfo),
+		start:     time.Now(),
+		graph:     make(map[string]map[string]bool),
+		importPos: make(map[string]map[string]token.Pos),
 	}
 
 	// -- loading proper (concurrent phase) --------------------------------
@@ -503,10 +611,13 @@ func (conf *Config) Load() (*Program, error) {
 		imp.importedMu.Unlock()
 
 		// Parse the in-package test files.
-		files, errs := imp.conf.parsePackageFiles(bp, 't')
+		files, errs, gen := imp.conf.parsePackageFiles(bp, 't')
 		for _, err := range errs {
 			info.appendError(err)
 		}
+		info.markGenerated(gen)
+
+		imp.configure(info, path, true)
 
 		// The test files augmenting package P cannot be imported,
 		// but may import packages that import P,
@@ -514,11 +625,12 @@ func (conf *Config) Load() (*Program, error) {
 		imp.addFiles(info, files, false)
 	}
 
-	createPkg := func(path string, files []*ast.File, errs []error) {
+	createPkg := func(path string, files []*ast.File, errs []error, gen map[*ast.File]bool) {
 		info := imp.newPackageInfo(path)
 		for _, err := range errs {
 			info.appendError(err)
 		}
+		info.markGenerated(gen)
 
 		// Ad hoc packages are non-importable,
 		// so no cycle check is needed.
@@ -529,8 +641,13 @@ func (conf *Config) Load() (*Program, error) {
 
 	// Create packages specified by conf.CreatePkgs.
 	for _, cp := range conf.CreatePkgs {
-		files, errs := parseFiles(conf.fset(), conf.build(), nil, ".", cp.Filenames, conf.ParserMode)
+		files, errs, gen := parseFiles(conf.fset(), conf.build(), nil, ".", cp.Filenames, conf.ParserMode, conf.preprocess)
 		files = append(files, cp.Files...)
+		for _, f := range cp.Files {
+			if isGeneratedFile(f) {
+				gen[f] = true
+			}
+		}
 
 		path := cp.Path
 		if path == "" {
@@ -540,14 +657,14 @@ func (conf *Config) Load() (*Program, error) {
 				path = "(unnamed)"
 			}
 		}
-		createPkg(path, files, errs)
+		createPkg(path, files, errs, gen)
 	}
 
 	// Create external test packages.
 	sort.Sort(byImportPath(xtestPkgs))
 	for _, bp := range xtestPkgs {
-		files, errs := imp.conf.parsePackageFiles(bp, 'x')
-		createPkg(bp.ImportPath+"_test", files, errs)
+		files, errs, gen := imp.conf.parsePackageFiles(bp, 'x')
+		createPkg(bp.ImportPath+"_test", files, errs, gen)
 	}
 
 	// -- finishing up (sequential) ----------------------------------------
@@ -565,6 +682,7 @@ func (conf *Config) Load() (*Program, error) {
 		} else {
 			// finished
 			info.checker = nil
+			info.tc = nil
 			info.errorFunc = nil
 		}
 	}
@@ -589,6 +707,10 @@ func (conf *Config) Load() (*Program, error) {
 
 	markErrorFreePackages(prog.AllPackages)
 
+	if conf.Cache != nil {
+		conf.Cache.add(prog, imp.augmented)
+	}
+
 	return prog, nil
 }
 
@@ -649,14 +771,14 @@ func (conf *Config) build() *build.Context {
 
 // parsePackageFiles enumerates the files belonging to package path,
 // then loads, parses and returns them, plus a list of I/O or parse
-// errors that were encountered.
+// errors that were encountered and the set of generated files.
 //
 // 'which' indicates which files to include:
 //    'g': include non-test *.go source files (GoFiles + processed CgoFiles)
 //    't': include in-package *_test.go source files (TestGoFiles)
 //    'x': include external *_test.go source files. (XTestGoFiles)
 //
-func (conf *Config) parsePackageFiles(bp *build.Package, which rune) ([]*ast.File, []error) {
+func (conf *Config) parsePackageFiles(bp *build.Package, which rune) ([]*ast.File, []error, map[*ast.File]bool) {
 	var filenames []string
 	switch which {
 	case 'g':
@@ -669,19 +791,33 @@ func (conf *Config) parsePackageFiles(bp *build.Package, which rune) ([]*ast.Fil
 		panic(which)
 	}
 
-	files, errs := parseFiles(conf.fset(), conf.build(), conf.DisplayPath, bp.Dir, filenames, conf.ParserMode)
+	if which == 'g' {
+		swig := append(append([]string(nil), bp.SwigFiles...), bp.SwigCXXFiles...)
+		filenames = append(append([]string(nil), filenames...), conf.preprocessed(swig)...)
+	}
+	files, errs, gen := parseFiles(conf.fset(), conf.build(), conf.DisplayPath, bp.Dir, filenames, conf.ParserMode, conf.preprocess)
 
-	// Preprocess CgoFiles and parse the outputs (sequentially).
+	// Preprocess CgoFiles and parse the outputs (sequentially),
+	// or, faking cgo, parse CgoFiles as they are.
 	if which == 'g' && bp.CgoFiles != nil {
-		cgofiles, err := processCgoFiles(bp, conf.fset(), conf.DisplayPath, conf.ParserMode)
-		if err != nil {
-			errs = append(errs, err)
-		} else {
+		if conf.FakeCgo {
+			cgofiles, cgoerrs, cgogen := parseFiles(conf.fset(), conf.build(), conf.DisplayPath, bp.Dir, bp.CgoFiles, conf.ParserMode, conf.preprocess)
 			files = append(files, cgofiles...)
+			errs = append(errs, cgoerrs...)
+			for f := range cgogen {
+				gen[f] = true
+			}
+		} else {
+			cgofiles, err := processCgoFiles(bp, conf.fset(), conf.DisplayPath, conf.ParserMode)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				files = append(files, cgofiles...)
+			}
 		}
 	}
 
-	return files, errs
+	return files, errs, gen
 }
 
 // doImport imports the package denoted by path.
@@ -720,7 +856,16 @@ func (imp *importer) doImport(from *PackageInfo, to string) (*types.Package, err
 	fromPath := from.Pkg.Path()
 	if cycle := imp.findPath(to, fromPath); cycle != nil {
 		cycle = append([]string{fromPath}, cycle...)
-		return nil, fmt.Errorf("import cycle: %s", strings.Join(cycle, " -> "))
+		err := &ImportCycle{Fset: imp.conf.fset(), Path: cycle}
+		imp.graphMu.Lock()
+		for i := 0; i+1 < len(cycle); i++ {
+			err.Pos = append(err.Pos, imp.importPos[cycle[i]][cycle[i+1]])
+		}
+		imp.graphMu.Unlock()
+		imp.progMu.Lock()
+		imp.prog.ImportCycles = append(imp.prog.ImportCycles, err)
+		imp.progMu.Unlock()
+		return nil, err
 	}
 
 	panic("internal error: import of incomplete (yet acyclic) package: " + fromPath)
@@ -835,16 +980,26 @@ func (imp *importer) startLoad(path string) *importInfo {
 // located by go/build.
 //
 func (imp *importer) load(path string) (*PackageInfo, error) {
+	if info := imp.cached(path); info != nil {
+		return info, nil
+	}
 	bp, err := imp.conf.FindPackage(imp.conf.build(), path)
 	if err != nil {
 		return nil, err // package not found
 	}
 	info := imp.newPackageInfo(bp.ImportPath)
 	info.Importable = true
-	files, errs := imp.conf.parsePackageFiles(bp, 'g')
+	if imp.conf.FakeCgo && bp.CgoFiles != nil {
+		info.tc.FakeImportC = true
+	}
+	files, errs, gen := imp.conf.parsePackageFiles(bp, 'g')
 	for _, err := range errs {
 		info.appendError(err)
 	}
+	info.markGenerated(gen)
+	if bp.SFiles != nil {
+		info.asm = imp.conf.asmSymbols(bp)
+	}
 
 	imp.addFiles(info, files, true)
 
@@ -855,6 +1010,43 @@ func (imp *importer) load(path string) (*PackageInfo, error) {
 	return info, nil
 }
 
+// cached returns the package with the specified import path from the
+// cache, or nil if it is not there.  The cached packages on which it
+// depends are added to the program too, as already completed imports.
+func (imp *importer) cached(path string) *PackageInfo {
+	if imp.conf.Cache == nil || imp.augmented(path) {
+		return nil
+	}
+	infos := imp.conf.Cache.get(path, imp.augmented)
+	if infos == nil {
+		return nil
+	}
+	imp.importedMu.Lock()
+	for _, info := range infos[1:] {
+		dep := info.Pkg.Path()
+		if _, ok := imp.imported[dep]; !ok {
+			ii := &importInfo{path: dep, info: info}
+			ii.complete.L = &ii.mu
+			imp.imported[dep] = ii
+		}
+	}
+	imp.importedMu.Unlock()
+
+	imp.progMu.Lock()
+	for _, info := range infos {
+		imp.prog.importMap[info.Pkg.Path()] = info.Pkg
+		imp.prog.AllPackages[info.Pkg] = info
+	}
+	imp.progMu.Unlock()
+	return infos[0]
+}
+
+// augmented reports whether the package with the specified import
+// path is to be augmented by its in-package tests.
+func (imp *importer) augmented(path string) bool {
+	return imp.conf.ImportPkgs[path]
+}
+
 // addFiles adds and type-checks the specified files to info, loading
 // their dependencies if needed.  The order of files determines the
 // package initialization order.  It may be called multiple times on the
@@ -867,11 +1059,15 @@ func (imp *importer) addFiles(info *PackageInfo, files []*ast.File, cycleCheck b
 	info.Files = append(info.Files, files...)
 
 	// Ensure the dependencies are loaded, in parallel.
+	imports, positions := scanImports(files)
 	var fromPath string
 	if cycleCheck {
 		fromPath = info.Pkg.Path()
+		imp.graphMu.Lock()
+		imp.importPos[fromPath] = positions
+		imp.graphMu.Unlock()
 	}
-	imp.loadAll(fromPath, scanImports(files))
+	imp.loadAll(fromPath, imports)
 
 	if trace {
 		fmt.Fprintf(os.Stderr, "%s: start %q (%d)\n",
@@ -909,10 +1105,8 @@ func (imp *importer) newPackageInfo(path string) *PackageInfo {
 	if f := imp.conf.TypeCheckFuncBodies; f != nil {
 		tc.IgnoreFuncBodies = !f(path)
 	}
-	tc.Import = func(_ map[string]*types.Package, to string) (*types.Package, error) {
-		return imp.doImport(info, to)
-	}
-	tc.Error = info.appendError // appendError wraps the user's Error function
+	info.tc = &tc
+	imp.configure(info, path, false)
 
 	info.checker = types.NewChecker(&tc, imp.conf.fset(), pkg, &info.Info)
 	imp.progMu.Lock()
@@ -920,3 +1114,17 @@ func (imp *importer) newPackageInfo(path string) *PackageInfo {
 	imp.progMu.Unlock()
 	return info
 }
+
+// configure applies the client's ConfigureTypeChecker hook, if any, to
+// the type-checker configuration of info, and (re)establishes the
+// loader's own Import and Error functions.
+func (imp *importer) configure(info *PackageInfo, path string, test bool) {
+	tc := info.tc
+	if f := imp.conf.ConfigureTypeChecker; f != nil {
+		f(path, test, tc)
+	}
+	tc.Import = func(_ map[string]*types.Package, to string) (*types.Package, error) {
+		return imp.doImport(info, to)
+	}
+	tc.Error = info.appendError // appendError wraps the user's Error function
+}
@@ -5,8 +5,12 @@
 package loader_test
 
 import (
+	"bytes"
 	"fmt"
+	"go/ast"
 	"go/build"
+	"go/parser"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -15,6 +19,7 @@ import (
 
 	"golang.org/x/tools/go/buildutil"
 	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
 )
 
 // TestFromArgs checks that conf.FromArgs populates conf correctly.
@@ -517,6 +522,352 @@ func TestErrorReporting(t *testing.T) {
 	}
 }
 
+func TestConfigureTypeChecker(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"main": {
+			"main.go":      `package main; import "a"`,
+			"main_test.go": `package main; import "b"`,
+		},
+		"a": {"a.go": `package a; func f() { var _ int = "a" }`},
+		"b": {"b.go": `package b; func f() { var _ int = "b" }`},
+	})
+	conf := loader.Config{
+		AllowErrors: true,
+		Build:       ctxt,
+		ConfigureTypeChecker: func(path string, test bool, tc *types.Config) {
+			switch path {
+			case "main":
+				// relaxed unused import check for test files only
+				tc.DisableUnusedImportCheck = test
+			case "a":
+				tc.IgnoreFuncBodies = true
+			}
+		},
+	}
+	var mu sync.Mutex
+	var allErrors []error
+	conf.TypeChecker.Error = func(err error) {
+		mu.Lock()
+		allErrors = append(allErrors, err)
+		mu.Unlock()
+	}
+	conf.ImportWithTests("main")
+	if _, err := conf.Load(); err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+
+	for _, test := range []struct {
+		substr string
+		want   bool
+	}{
+		{`"a" imported but not used`, true},
+		{`"b" imported but not used`, false},
+		{`cannot convert "a"`, false},
+		{`cannot convert "b"`, true},
+	} {
+		if got := hasError(allErrors, test.substr); got != test.want {
+			t.Errorf("error %q reported: got %t, want %t (errors: %q)", test.substr, got, test.want, allErrors)
+		}
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"main": {
+			"a.go": "package main",
+			"b.go": "// Code generated by stringer; DO NOT EDIT.\n\npackage main",
+			"c.go": "// Code generated by hand, but feel free to edit.\npackage main",
+		},
+	})
+	conf := loader.Config{Build: ctxt} // no parser.ParseComments
+	conf.Import("main")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	info := prog.Imported["main"]
+	var generated []string
+	for _, f := range info.Files {
+		if info.IsGenerated(f) {
+			generated = append(generated, prog.Fset.File(f.Pos()).Name())
+		}
+	}
+	if got, want := fmt.Sprint(generated), "[/go/src/main/b.go]"; got != want {
+		t.Errorf("generated files = %s, want %s", got, want)
+	}
+}
+
+func TestDoc(t *testing.T) {
+	const src = `package p
+
+// C is a constant.
+const C = 1
+
+const (
+	// D is another constant.
+	D = 2
+	E = 3 // E has only a line comment.
+)
+
+// T is a type.
+type T struct {
+	// F is a field.
+	F int
+	// S is embedded.
+	*S
+}
+
+// S is another type.
+type S struct{}
+
+// M is a method.
+func (T) M() {
+	// x is local.
+	var x int
+	_ = x
+}
+
+// I is an interface.
+type I interface {
+	// N is an interface method.
+	N()
+}
+`
+	conf := loader.Config{ParserMode: parser.ParseComments}
+	f, err := conf.ParseFile("p.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("p", f)
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	info := prog.Created[0]
+
+	// Inspect the objects by name.
+	doc := make(map[string]string)
+	for id, obj := range info.Defs {
+		if v, ok := obj.(*types.Var); obj == nil || ok && v.Anonymous() {
+			continue
+		}
+		doc[id.Name] = strings.TrimSpace(info.Doc(obj).Text())
+	}
+	for name, want := range map[string]string{
+		"C": "C is a constant.",
+		"D": "D is another constant.",
+		"E": "",
+		"T": "T is a type.",
+		"F": "F is a field.",
+		"S": "S is another type.",
+		"M": "M is a method.",
+		"x": "x is local.",
+		"I": "I is an interface.",
+		"N": "N is an interface method.",
+	} {
+		if got := doc[name]; got != want {
+			t.Errorf("Doc(%s) = %q, want %q", name, got, want)
+		}
+	}
+
+	// The embedded field S has its own doc comment.
+	field := info.Pkg.Scope().Lookup("T").Type().Underlying().(*types.Struct).Field(1)
+	if got, want := strings.TrimSpace(info.Doc(field).Text()), "S is embedded."; got != want {
+		t.Errorf("Doc(embedded field S) = %q, want %q", got, want)
+	}
+}
+
+func TestDecl(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+type T struct{ F, G int }
+
+func (t T) M(x int) (y int) {
+	var v, w = 1, unsafe.Sizeof(t)
+	z := v + int(w)
+	return z
+}
+
+const (
+	A = iota
+	B
+)
+`
+	var conf loader.Config
+	f, err := conf.ParseFile("p.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("p", f)
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	info := prog.Created[0]
+
+	objs := make(map[string]types.Object)
+	for id, obj := range info.Defs {
+		if obj != nil {
+			objs[id.Name] = obj
+		}
+	}
+	for _, obj := range info.Implicits {
+		objs[obj.Name()] = obj
+	}
+	str := func(n ast.Node) string {
+		if n == nil {
+			return "nil"
+		}
+		return fmt.Sprintf("%T@%d", n, prog.Fset.Position(n.Pos()).Line)
+	}
+	for _, test := range []struct {
+		name, decl, node string
+	}{
+		{"unsafe", "*ast.GenDecl@3", "*ast.ImportSpec@3"},
+		{"T", "*ast.GenDecl@5", "*ast.TypeSpec@5"},
+		{"G", "*ast.GenDecl@5", "*ast.Field@5"},
+		{"M", "*ast.FuncDecl@7", "*ast.FuncDecl@7"},
+		{"t", "*ast.FuncDecl@7", "*ast.Field@7"},
+		{"y", "*ast.FuncDecl@7", "*ast.Field@7"},
+		{"w", "*ast.GenDecl@8", "*ast.ValueSpec@8"},
+		{"z", "nil", "nil"}, // short variable declaration
+		{"B", "*ast.GenDecl@13", "*ast.ValueSpec@15"},
+	} {
+		obj := objs[test.name]
+		if obj == nil {
+			t.Errorf("no object %s", test.name)
+			continue
+		}
+		decl, node := info.Decl(obj)
+		if got := str(decl); got != test.decl {
+			t.Errorf("Decl(%s): decl = %s, want %s", test.name, got, test.decl)
+		}
+		if got := str(node); got != test.node {
+			t.Errorf("Decl(%s): node = %s, want %s", test.name, got, test.node)
+		}
+	}
+}
+
+func TestImportUses(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"a": {"a.go": `package a; type T int; func F() {}`},
+		"b": {"b.go": `package b; var V int`},
+		"c": {"c.go": `package c; var W int`},
+		"p": {
+			"p1.go": `package p; import ("a"; "b"; _ "c"); var x a.T = a.T(b.V)`,
+			"p2.go": `package p; import (. "a"; aa "a"; "c"); func f() { F(); var _ T }`,
+		},
+	})
+	conf := loader.Config{
+		Build:       ctxt,
+		AllowErrors: true,
+	}
+	conf.TypeChecker.Error = func(err error) {} // ignore "aa" and "c" not used
+	conf.Import("p")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+
+	var got []string
+	for _, fi := range prog.Imported["p"].ImportUses() {
+		for _, u := range fi.Imports {
+			var uses []string
+			for _, id := range u.Uses {
+				posn := prog.Fset.Position(id.Pos())
+				uses = append(uses, fmt.Sprintf("%s@%d", id.Name, posn.Column))
+			}
+			got = append(got, fmt.Sprintf("%s %s %s uses=%v unused=%t elsewhere=%t",
+				filepath.Base(prog.Fset.Position(u.Spec.Pos()).Filename),
+				u.PkgName.Name(), u.Spec.Path.Value, uses, u.Unused(), u.UsedElsewhere))
+		}
+	}
+	want := []string{
+		`p1.go a "a" uses=[a@44 a@50] unused=false elsewhere=false`,
+		`p1.go b "b" uses=[b@54] unused=false elsewhere=false`,
+		`p1.go _ "c" uses=[] unused=false elsewhere=false`,
+		`p2.go . "a" uses=[F@52 T@63] unused=false elsewhere=false`,
+		`p2.go aa "a" uses=[] unused=true elsewhere=true`,
+		`p2.go c "c" uses=[] unused=true elsewhere=false`,
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("ImportUses:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctxt := fakeContext(map[string]string{
+		"a": `package a; type T int`,
+		"b": `package b; import "a"; var X a.T`,
+		"c": `package c; import "b"; var Y = b.X`,
+		"d": `package d`,
+	})
+	cache := loader.NewCache(3)
+	load := func(path string) *loader.Program {
+		conf := loader.Config{Build: ctxt, Cache: cache}
+		conf.Import(path)
+		prog, err := conf.Load()
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %s", path, err)
+		}
+		return prog
+	}
+	checkStats := func(want loader.CacheStats) {
+		if got := cache.Stats(); got != want {
+			t.Errorf("Stats() = %+v, want %+v", got, want)
+		}
+	}
+
+	prog1 := load("c")
+	checkStats(loader.CacheStats{Misses: 3, Len: 3})
+
+	// A second load of c reuses it and its dependencies.
+	prog2 := load("c")
+	checkStats(loader.CacheStats{Hits: 1, Misses: 3, Len: 3})
+	for _, path := range []string{"a", "b", "c"} {
+		if prog1.Package(path).Pkg != prog2.Package(path).Pkg {
+			t.Errorf("second load of %s did not reuse cached package", path)
+		}
+	}
+	if got, want := strings.Join(all(prog2), " "), "a b c"; got != want {
+		t.Errorf("second load: all packages = %s, want %s", got, want)
+	}
+
+	// Pinning c retains c, b, and a, so d is evicted at once.
+	cache.Pin("c")
+	load("d")
+	checkStats(loader.CacheStats{Hits: 1, Misses: 4, Evictions: 1, Len: 3, Pinned: 1})
+
+	// Once c is unpinned, it may be evicted in favor of d.
+	cache.Unpin("c")
+	load("d")
+	if got := cache.Stats(); got.Evictions < 3 || got.Len > 3 {
+		t.Errorf("after Unpin: Stats() = %+v, want at least 3 evictions and at most 3 packages", got)
+	}
+	if load("c").Package("c").Pkg == prog1.Package("c").Pkg {
+		t.Errorf("load of c after eviction reused cached package")
+	}
+
+	// Remove evicts a package and its dependents, even if pinned.
+	cache = loader.NewCache(10)
+	cache.Pin("c")
+	load("c")
+	cache.Remove("b")
+	checkStats(loader.CacheStats{Misses: 3, Len: 1, Pinned: 1})
+
+	// Invalidate evicts the packages in the directories of the
+	// files, and their dependents.
+	cache = loader.NewCache(10)
+	load("c")
+	load("d")
+	if got, want := strings.Join(cache.Invalidate("/go/src/a/new.go"), " "), "a b c"; got != want {
+		t.Errorf("Invalidate removed %s, want %s", got, want)
+	}
+	checkStats(loader.CacheStats{Misses: 4, Len: 1})
+}
+
 func TestCycles(t *testing.T) {
 	for _, test := range []struct {
 		descr   string
@@ -604,6 +955,24 @@ func TestCycles(t *testing.T) {
 			t.Errorf("%s: Load() errors = %q, want %q",
 				test.descr, allErrors, test.wantErr)
 		}
+
+		// Check the cycle recorded in the Program.
+		if len(prog.ImportCycles) != 1 {
+			t.Errorf("%s: got %d ImportCycles, want 1", test.descr, len(prog.ImportCycles))
+			continue
+		}
+		cycle := prog.ImportCycles[0]
+		if got, want := strings.Join(cycle.Path, " -> "), strings.TrimPrefix(test.wantErr, "import cycle: "); got != want {
+			t.Errorf("%s: ImportCycles[0].Path = %s, want %s", test.descr, got, want)
+		}
+		if len(cycle.Pos) != len(cycle.Path)-1 {
+			t.Errorf("%s: ImportCycles[0] has %d positions for %d edges", test.descr, len(cycle.Pos), len(cycle.Path)-1)
+		}
+		for i, pos := range cycle.Pos {
+			if !pos.IsValid() {
+				t.Errorf("%s: no position for import of %s by %s", test.descr, cycle.Path[i+1], cycle.Path[i])
+			}
+		}
 	}
 
 	// TODO(adonovan):
@@ -667,3 +1036,565 @@ func created(prog *loader.Program) string {
 	}
 	return strings.Join(pkgs, " ")
 }
+
+func TestEnums(t *testing.T) {
+	const src = `package p
+
+type Color int
+
+const (
+	_ Color = iota
+	Red
+	Green
+	Blue = Green
+	Black Color = 5
+)
+
+type Flag uint
+
+const (
+	A Flag = 1 << iota
+	B
+	_
+	D
+	N = 7 // untyped
+)
+
+type Sparse int
+
+const (
+	X Sparse = 1
+	Y Sparse = 100
+)
+
+const One = 1
+`
+	var conf loader.Config
+	f, err := conf.ParseFile("p.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("p", f)
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+
+	var got []string
+	for _, e := range prog.Created[0].Enums() {
+		var consts []string
+		for _, c := range e.Consts {
+			consts = append(consts, c.Name())
+		}
+		var dups [][]string
+		for _, g := range e.Duplicates {
+			var names []string
+			for _, c := range g {
+				names = append(names, c.Name())
+			}
+			dups = append(dups, names)
+		}
+		got = append(got, fmt.Sprintf("%s %v iota=%t bitmask=%t gaps=%v dups=%v",
+			e.Type.Obj().Name(), consts, e.Iota, e.Bitmask, e.Gaps, dups))
+	}
+	want := []string{
+		"Color [Red Green Blue Black] iota=true bitmask=false gaps=[3 4] dups=[[Green Blue]]",
+		"Flag [A B D] iota=true bitmask=true gaps=[4] dups=[]",
+		"Sparse [X Y] iota=false bitmask=false gaps=[] dups=[]",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGraph(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"main": {"main.go": `package main; import (_ "x/a"; _ "x/b")`},
+		"x/a":  {"a.go": `package a; import (_ "y/c"; _ "z/e")`},
+		"x/b":  {"b.go": `package b; import _ "y/c"`},
+		"x/u":  {"u.go": `package u; const U = 0`},
+		"y/c":  {"c.go": "package c\nimport (_ \"z/d\"; _ \"x/u\")\nvar C, D int\n"},
+		"z/d":  {"d.go": `package d; func D()`},
+		"z/e":  {"e.go": `package e`},
+	})
+	conf := loader.Config{Build: ctxt}
+	conf.Import("main")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+
+	paths := func(infos []*loader.PackageInfo) string {
+		var paths []string
+		for _, info := range infos {
+			paths = append(paths, info.Pkg.Path())
+		}
+		return strings.Join(paths, " ")
+	}
+	for _, test := range []struct {
+		query string
+		got   []*loader.PackageInfo
+		want  string
+	}{
+		{"Deps(x/a)", prog.Deps("x/a"), "x/u y/c z/d z/e"},
+		{"Deps(x/a, y/c)", prog.Deps("x/a", "y/c"), "x/u y/c z/d z/e"},
+		{"Deps(z/d)", prog.Deps("z/d"), ""},
+		{"Dependents(z/d)", prog.Dependents("z/d"), "main x/a x/b y/c"},
+		{"Dependents(x/a)", prog.Dependents("x/a"), "main"},
+		{"Dominators(main, z/d)", prog.Dominators("main", "z/d"), "main y/c"},
+		{"Dominators(main, z/e)", prog.Dominators("main", "z/e"), "main x/a"},
+		{"Dominators(x/b, z/e)", prog.Dominators("x/b", "z/e"), ""},
+		{"PulledInBy(main, z/d)", prog.PulledInBy("main", "z/d"), "x/a x/b"},
+		{"PulledInBy(main, z/e)", prog.PulledInBy("main", "z/e"), "x/a"},
+		{"PulledInBy(main, x/b)", prog.PulledInBy("main", "x/b"), "x/b"},
+	} {
+		if got := paths(test.got); got != test.want {
+			t.Errorf("%s = [%s], want [%s]", test.query, got, test.want)
+		}
+	}
+
+	cycles := prog.GroupCycles(func(path string) string {
+		if i := strings.Index(path, "/"); i >= 0 {
+			return path[:i]
+		}
+		return path
+	})
+	if got, want := fmt.Sprint(cycles), "[[x y]]"; got != want {
+		t.Errorf("GroupCycles = %s, want %s", got, want)
+	}
+
+	if got, want := prog.Weight("y/c"), (loader.Weight{Packages: 3, Files: 3, Lines: 5, Symbols: 4}); got != want {
+		t.Errorf("Weight(y/c) = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadMatrix(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"p": {
+			"p.go":         `package p; var x = sys()`,
+			"p_linux.go":   `package p; type Handle int; func sys() Handle { return 0 }`,
+			"p_windows.go": `package p; type Handle uintptr; func sys() Handle { return "" }`,
+			"p_purego.go":  "// +build purego\n\npackage p\n\nfunc Pure() {}\n",
+		},
+	})
+	conf := loader.Config{Build: ctxt}
+	conf.Import("p")
+	var configs []loader.BuildConfig
+	for _, s := range []string{"linux/amd64", "windows/386", "linux/arm,purego"} {
+		c, err := loader.ParseBuildConfig(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.String() != s {
+			t.Errorf("ParseBuildConfig(%q).String() = %q", s, c)
+		}
+		configs = append(configs, c)
+	}
+	m := conf.LoadMatrix(configs)
+	for i, err := range m.LoadErrors {
+		if err != nil {
+			t.Errorf("%s: %s", configs[i], err)
+		}
+	}
+
+	var got []string
+	for _, err := range m.PartialErrors() {
+		got = append(got, fmt.Sprintf("%s %v", err.Msg, err.Configs))
+	}
+	want := []string{
+		`/go/src/p/p_windows.go:1:60: cannot convert "" (untyped string constant) to uintptr [1]`,
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("PartialErrors:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = nil
+	for _, sym := range m.Symbols {
+		got = append(got, fmt.Sprintf("%s.%s partial=%t varies=%t", sym.Path, sym.Name, sym.Partial(), sym.Varies()))
+	}
+	want = []string{
+		"p.Handle partial=false varies=true",
+		"p.Pure partial=true varies=false",
+		"p.sys partial=false varies=false",
+		"p.x partial=false varies=false",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("Symbols:\n%s\nwant:\n%s", got, want)
+	}
+	if _, err := loader.ParseBuildConfig("linux"); err == nil {
+		t.Errorf("ParseBuildConfig(\"linux\") succeeded")
+	}
+}
+
+func TestConditionalSymbols(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"p": {
+			"p.go":             `package p; func F() {}; type T int`,
+			"p_linux.go":       `package p; func Sys() {}; func (T) M() {}`,
+			"p_windows_386.go": `package p; func Sys() {}; const C = 1`,
+			"p_arm64.go":       `package p; func (*T) M() {}`,
+			"p_purego.go":      "// +build purego !cgo\n// +build !windows\n\npackage p\n\nvar V, _ int\n",
+			"gen.go":           "// +build ignore\n\npackage main\n\nfunc main() {}\n",
+			"p_test.go":        `package p; func helper() {}`,
+		},
+	})
+	conf := loader.Config{Build: ctxt}
+	syms, errs := conf.ConditionalSymbols("p", true)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	var got []string
+	for _, sym := range syms {
+		got = append(got, fmt.Sprintf("%s %q", sym.Name, sym.Constraint))
+	}
+	want := []string{
+		`C "windows,386"`,
+		`F ""`,
+		`Sys "linux windows,386"`,
+		`T ""`,
+		`T.M "linux arm64"`,
+		`V "purego,!windows !cgo,!windows"`,
+		`helper ""`,
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("ConditionalSymbols:\n%s\nwant:\n%s", got, want)
+	}
+
+	c := syms[2].Constraint // Sys
+	for _, test := range []struct {
+		config string
+		want   bool
+	}{
+		{"linux/amd64", true},
+		{"windows/386", true},
+		{"windows/amd64", false},
+		{"darwin/amd64,linux", true}, // a tag
+	} {
+		bc, _ := loader.ParseBuildConfig(test.config)
+		if got := c.Match(bc); got != test.want {
+			t.Errorf("%s.Match(%s) = %t, want %t", c, test.config, got, test.want)
+		}
+	}
+}
+
+func TestAsmFiles(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"p": {
+			"p.go":         `package p; type T int; func Sqrt(x float64) float64; func (*T) M(); func (T) N(); func f() {}`,
+			"sqrt_amd64.s": "#include \"textflag.h\"\n\n// func Sqrt(x float64) float64\nTEXT ·Sqrt(SB),NOSPLIT,$0\n\tRET\n\nTEXT ·(*T).M(SB),NOSPLIT,$0\n\tRET\n",
+			"sqrt_arm.s":   "TEXT ·Sqrt(SB),NOSPLIT,$0\n\tRET\n",
+		},
+	})
+	ctxt.GOOS, ctxt.GOARCH = "linux", "amd64"
+	conf := loader.Config{Build: ctxt}
+	conf.Import("p")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	info := prog.Imported["p"]
+	scope := info.Pkg.Scope()
+	T := scope.Lookup("T").Type().(*types.Named)
+	for _, test := range []struct {
+		fn       *types.Func
+		external bool
+		files    string
+	}{
+		{scope.Lookup("Sqrt").(*types.Func), true, "[/go/src/p/sqrt_amd64.s]"},
+		{T.Method(0), true, "[/go/src/p/sqrt_amd64.s]"}, // M
+		{T.Method(1), true, "[]"},                       // N
+		{scope.Lookup("f").(*types.Func), false, "[]"},
+	} {
+		if got := test.fn.External(); got != test.external {
+			t.Errorf("%s.External() = %t, want %t", test.fn.Name(), got, test.external)
+		}
+		if got := fmt.Sprint(info.AsmFiles(test.fn)); got != test.files {
+			t.Errorf("AsmFiles(%s) = %s, want %s", test.fn.Name(), got, test.files)
+		}
+	}
+}
+
+func TestFakeCgo(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"p": {
+			"p.go": `package p; func F() { c() }`,
+			"c.go": "package p\n\n// int twice(int x) { return 2*x; }\nimport \"C\"\n\nfunc c() C.int { return C.twice(21) }\n",
+		},
+	})
+	ctxt.CgoEnabled = true
+	conf := loader.Config{Build: ctxt, FakeCgo: true}
+	conf.Import("p")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	info := prog.Imported["p"]
+	if len(info.Files) != 2 {
+		t.Errorf("got %d files, want 2", len(info.Files))
+	}
+	if obj := info.Pkg.Scope().Lookup("c"); obj == nil {
+		t.Errorf("c not declared")
+	} else if pos := prog.Fset.Position(obj.Pos()); pos.String() != "/go/src/p/c.go:6:6" {
+		t.Errorf("c declared at %s, want /go/src/p/c.go:6:6", pos)
+	}
+}
+
+func TestPreprocessors(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"p": {
+			"p.go":      `package p; var x = T(gen())`,
+			"t_tmpl.go": `package p; type T ELEM`,
+			"drop.go":   `package p; this is not Go`,
+			"gen.swig":  `gen 42`,
+		},
+	})
+	conf := loader.Config{
+		Build: ctxt,
+		Preprocessors: []loader.Preprocessor{
+			{Pattern: "*_tmpl.go", Process: func(filename string, src []byte) ([]byte, error) {
+				return []byte(strings.Replace(string(src), "ELEM", "int", -1)), nil
+			}},
+			{Pattern: "drop.go", Process: func(string, []byte) ([]byte, error) { return nil, nil }},
+			{Pattern: "*.swig", Process: func(filename string, src []byte) ([]byte, error) {
+				var name string
+				var value int
+				if _, err := fmt.Sscanf(string(src), "%s %d", &name, &value); err != nil {
+					return nil, err
+				}
+				return []byte(fmt.Sprintf("package p\nfunc %s() int { return %d }\n", name, value)), nil
+			}},
+		},
+	}
+	conf.Import("p")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	var got []string
+	for _, f := range prog.Imported["p"].Files {
+		got = append(got, filepath.Base(prog.Fset.Position(f.Pos()).Filename))
+	}
+	sort.Strings(got)
+	if got, want := fmt.Sprint(got), "[gen.swig p.go t_tmpl.go]"; got != want {
+		t.Errorf("got files %s, want %s", got, want)
+	}
+	if obj := prog.Imported["p"].Pkg.Scope().Lookup("x"); obj == nil || obj.Type().String() != "p.T" {
+		t.Errorf("x = %v, want var of type p.T", obj)
+	}
+
+	// Preprocessing errors are reported.
+	conf = loader.Config{
+		Build:       ctxt,
+		AllowErrors: true,
+		Preprocessors: []loader.Preprocessor{
+			{Pattern: "*.swig", Process: func(string, []byte) ([]byte, error) { return nil, fmt.Errorf("swig failed") }},
+		},
+	}
+	conf.TypeChecker.Error = func(error) {}
+	conf.Import("p")
+	prog, err = conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	found := false
+	for _, err := range prog.Imported["p"].Errors {
+		if err.Error() == "preprocessing /go/src/p/gen.swig: swig failed" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("got errors %v, want preprocessing error", prog.Imported["p"].Errors)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"main": {"main.go": `package main; import "a"; func main() { a.F(); var _ a.T }`},
+		"a": {"a.go": `package a
+import "b"
+func F() { h() }
+func G() { b.B() }
+func H() {}
+func h() { var _ I = T{} }
+type I interface{ M() }
+type T struct{}
+func (T) M() {}
+func (*T) N() {}
+type U int
+func (U) M() {}
+var V = newV()
+func newV() int { return b.C }
+`},
+		"b": {"b.go": `package b; func B() {}; const C = 1; var D int`},
+	})
+	conf := loader.Config{Build: ctxt}
+	conf.Import("main")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	var buf bytes.Buffer
+	if _, err := prog.Liveness("main").WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `live	a.F
+live	a.I
+live	a.T
+live	a.T.M
+live	a.(*T).N
+live	a.V
+live	b.C
+dead	a.G
+dead	a.H
+dead	a.U
+dead	a.U.M
+dead	b.B
+dead	b.D
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Liveness:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestChurnBetween(t *testing.T) {
+	load := func(src string) *loader.Program {
+		ctxt := buildutil.FakeContext(map[string]map[string]string{"p": {"p.go": src}})
+		conf := loader.Config{Build: ctxt}
+		conf.Import("p")
+		prog, err := conf.Load()
+		if err != nil {
+			t.Fatalf("Load failed: %s", err)
+		}
+		return prog
+	}
+	oldProg := load(`package p
+func F(x int) int { return x }
+func G() {}
+func H() {
+	// A comment.
+	println()
+}
+type T struct{ a int }
+func (T) M() {}
+type U int
+`)
+	newProg := load(`package p
+func F(x, y int) int { return x }
+func G() { println() }
+func H() { println() }
+type T struct{ a int }
+func (T) M() {}
+func (*T) N() {}
+type V int
+`)
+	var buf bytes.Buffer
+	if _, err := loader.ChurnBetween(oldProg, newProg).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `changed	p.F	func F(x int) int -> func F(x int, y int) int
+changed	p.G	func G() (body)
+added	p.T.N	func (*T).N()
+removed	p.U	type U int
+added	p.V	type V int
+2 added, 1 removed, 2 changed
+`
+	if got := buf.String(); got != want {
+		t.Errorf("ChurnBetween:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLookupSymbol(t *testing.T) {
+	ctxt := fakeContext(map[string]string{
+		"example.com/yaml.v2": `package yaml
+type Node struct{ Kind int; inner }
+type inner struct{ line int }
+func (n *Node) Decode(v interface{}) error { return nil }
+func (n Node) String() string { return "" }
+const Version = 2`,
+		"example.com/yaml": `package yaml; var Old int`,
+	})
+	conf := loader.Config{Build: ctxt}
+	conf.Import("example.com/yaml.v2")
+	conf.Import("example.com/yaml")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	for _, test := range []struct {
+		ref, want string
+	}{
+		{"example.com/yaml.v2.Version", "const example.com/yaml.v2.Version untyped int"},
+		{"example.com/yaml.Old", "var example.com/yaml.Old int"},
+		{"example.com/yaml.v2.Node.Kind", "field Kind int"},
+		{"example.com/yaml.v2.Node.line", "field line int"},
+		{"example.com/yaml.v2.Node.Decode", "func (*example.com/yaml.v2.Node).Decode(v interface{}) error"},
+		{"(example.com/yaml.v2.Node).String", "func (example.com/yaml.v2.Node).String() string"},
+		{"(*example.com/yaml.v2.Node).Decode", "func (*example.com/yaml.v2.Node).Decode(v interface{}) error"},
+		{`(*"example.com/yaml.v2".Node).String`, "func (example.com/yaml.v2.Node).String() string"},
+		{`"example.com/yaml".Old`, "var example.com/yaml.Old int"},
+		{"(example.com/yaml.v2.Node).Decode", "error: type example.com/yaml.v2.Node has no field or method Decode"},
+		{"(example.com/yaml.v2.Node).Kind", "error: example.com/yaml.v2.Node.Kind is not a method"},
+		{"example.com/yaml.v2.Missing", "error: package example.com/yaml.v2 has no member Missing"},
+		{"example.com/yaml.v2.Version.X", "error: example.com/yaml.v2.Version is not a type"},
+		{"example.com/json.Decoder", `error: invalid symbol reference "example.com/json.Decoder": no loaded package`},
+		{"(example.com/yaml.v2.Node", `error: invalid symbol reference "(example.com/yaml.v2.Node": want (T).M or (*T).M`},
+	} {
+		var got string
+		if obj, err := prog.LookupSymbol(test.ref); err != nil {
+			got = "error: " + err.Error()
+		} else {
+			got = obj.String()
+		}
+		if got != test.want {
+			t.Errorf("LookupSymbol(%s) = %s, want %s", test.ref, got, test.want)
+		}
+	}
+}
+
+func TestMemo(t *testing.T) {
+	ctxt := fakeContext(map[string]string{
+		"a": `package a; type T int`,
+		"b": `package b; import "a"; var X a.T`,
+		"c": `package c; var Y int`,
+	})
+	cache := loader.NewCache(10)
+	load := func() *loader.Program {
+		conf := loader.Config{Build: ctxt, Cache: cache}
+		conf.Import("b")
+		conf.Import("c")
+		prog, err := conf.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prog
+	}
+	memo := loader.NewMemo()
+	var computed []string
+	query := func(prog *loader.Program, path string) {
+		memo.Get("members", []*loader.PackageInfo{prog.Package(path)}, func() interface{} {
+			computed = append(computed, path)
+			return prog.Package(path).Pkg.Scope().Names()
+		})
+	}
+
+	prog := load()
+	query(prog, "b")
+	query(prog, "c")
+	query(prog, "b")
+	if got := memo.Stats(); got != (loader.MemoStats{Hits: 1, Misses: 2, Len: 2}) {
+		t.Errorf("Stats() = %+v", got)
+	}
+
+	// A change to package a invalidates the result for b, which depends on it.
+	cache.Invalidate("/go/src/a/x.go")
+	prog = load()
+	memo.Prune(prog)
+	if got := memo.Stats(); got.Len != 1 {
+		t.Errorf("after Prune: Stats() = %+v, want 1 result", got)
+	}
+	query(prog, "b")
+	query(prog, "c")
+	if got, want := strings.Join(computed, " "), "b c b"; got != want {
+		t.Errorf("computed results for %s, want %s", got, want)
+	}
+}
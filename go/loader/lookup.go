@@ -0,0 +1,121 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file resolves textual references to package-level objects and
+// their fields and methods, for command-line tools and configuration
+// files.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/types"
+)
+
+// LookupSymbol returns the object denoted by the symbol reference ref
+// in one of the packages of prog.  A reference has one of the forms:
+//
+//	net/http.Client                 package member (const, func, var, type)
+//	net/http.Client.Do              field or method of a package-level named type
+//	(net/http.Client).Do            method of a named type, but not of the pointer to it
+//	(*bytes.Buffer).WriteString     method of the pointer to a named type
+//	"gopkg.in/yaml.v2".Node         package member, with a quoted import path
+//
+// The import path of an unquoted reference is the longest prefix,
+// ending before a dot after its last slash, that is the path of a
+// package of prog, so that paths such as gopkg.in/yaml.v2 need not be
+// quoted.  Fields and methods include those promoted from embedded
+// fields; unexported ones are those of the package of the type.
+//
+func (prog *Program) LookupSymbol(ref string) (types.Object, error) {
+	s := strings.TrimSpace(ref)
+	recv := "" // "T" or "*T" if the reference has a parenthesized receiver
+	var member string
+	if strings.HasPrefix(s, "(") {
+		i := strings.Index(s, ").")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid symbol reference %q: want (T).M or (*T).M", ref)
+		}
+		s, member = s[1:i], s[i+2:]
+		recv = "T"
+		if strings.HasPrefix(s, "*") {
+			s, recv = s[1:], "*T"
+		}
+		if member == "" || strings.Contains(member, ".") {
+			return nil, fmt.Errorf("invalid symbol reference %q: want (T).M or (*T).M", ref)
+		}
+	}
+
+	info, rest, err := prog.symbolPackage(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid symbol reference %q: %v", ref, err)
+	}
+	names := strings.Split(rest, ".")
+	if len(names) > 2 || recv != "" && len(names) != 1 || names[0] == "" {
+		return nil, fmt.Errorf("invalid symbol reference %q", ref)
+	}
+	if len(names) == 2 {
+		member = names[1]
+	}
+
+	pkg := info.Pkg
+	obj := pkg.Scope().Lookup(names[0])
+	if obj == nil {
+		return nil, fmt.Errorf("package %s has no member %s", pkg.Path(), names[0])
+	}
+	if member == "" {
+		return obj, nil
+	}
+	tname, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s.%s is not a type", pkg.Path(), obj.Name())
+	}
+	T := tname.Type()
+	if recv == "*T" {
+		T = types.NewPointer(T)
+	}
+	// Only the (T).M form excludes the methods of *T.
+	sel, _, _ := types.LookupFieldOrMethod(T, recv == "", pkg, member)
+	if sel == nil {
+		return nil, fmt.Errorf("type %s has no field or method %s", types.TypeString(nil, T), member)
+	}
+	if _, ok := sel.(*types.Func); !ok && recv != "" {
+		return nil, fmt.Errorf("%s.%s is not a method", types.TypeString(nil, T), member)
+	}
+	return sel, nil
+}
+
+// symbolPackage splits the symbol reference s into the package of prog
+// it refers to and the rest of the reference, after the dot.
+func (prog *Program) symbolPackage(s string) (*PackageInfo, string, error) {
+	if strings.HasPrefix(s, `"`) {
+		i := strings.Index(s[1:], `"`) + 1
+		if i == 0 || !strings.HasPrefix(s[i+1:], ".") {
+			return nil, "", fmt.Errorf(`want "path".Name`)
+		}
+		path, err := strconv.Unquote(s[:i+1])
+		if err != nil {
+			return nil, "", err
+		}
+		info := prog.Package(path)
+		if info == nil {
+			return nil, "", fmt.Errorf("package %q is not loaded", path)
+		}
+		return info, s[i+2:], nil
+	}
+
+	// Find the longest prefix that is the path of a package.
+	slash := strings.LastIndex(s, "/")
+	for i := len(s) - 1; i > slash; i-- {
+		if s[i] == '.' {
+			if info := prog.Package(s[:i]); info != nil {
+				return info, s[i+1:], nil
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("no loaded package")
+}
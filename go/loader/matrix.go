@@ -0,0 +1,217 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file defines LoadMatrix, which loads a program under several
+// build configurations.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A BuildConfig is a build configuration: a target operating system
+// and architecture, and a set of build tags.
+type BuildConfig struct {
+	GOOS, GOARCH string
+	Tags         []string
+}
+
+// String returns the configuration in the form accepted by
+// ParseBuildConfig, such as "linux/amd64" or "linux/arm,purego".
+func (c BuildConfig) String() string {
+	return strings.Join(append([]string{c.GOOS + "/" + c.GOARCH}, c.Tags...), ",")
+}
+
+// ParseBuildConfig parses a build configuration of the form
+// "GOOS/GOARCH", followed by a comma-separated list of build tags,
+// if any: "linux/amd64", "windows/386,purego,appengine".
+func ParseBuildConfig(s string) (BuildConfig, error) {
+	fields := strings.Split(s, ",")
+	i := strings.Index(fields[0], "/")
+	if i <= 0 || i == len(fields[0])-1 {
+		return BuildConfig{}, fmt.Errorf("invalid build configuration %q: want GOOS/GOARCH[,tag...]", s)
+	}
+	c := BuildConfig{GOOS: fields[0][:i], GOARCH: fields[0][i+1:]}
+	for _, tag := range fields[1:] {
+		if tag != "" {
+			c.Tags = append(c.Tags, tag)
+		}
+	}
+	return c, nil
+}
+
+// wordSize returns the size in bytes of a word of the architecture.
+func wordSize(goarch string) int64 {
+	switch goarch {
+	case "386", "arm", "armbe", "amd64p32", "mips", "mipsle", "ppc", "s390", "sparc":
+		return 4
+	}
+	return 8
+}
+
+// A Matrix is the result of loading a program under several build
+// configurations.  It unifies the errors and package-level symbols of
+// the initial packages of the program under each configuration, so
+// that those peculiar to some of them may be reported.
+type Matrix struct {
+	Configs  []BuildConfig
+	Programs []*Program // Programs[i] is the program under Configs[i], or nil if it failed to load
+
+	// LoadErrors[i] is the error, if any, that prevented loading
+	// the program under Configs[i].
+	LoadErrors []error
+
+	Errors  []*MatrixError  // errors of the initial packages, in order of message
+	Symbols []*MatrixSymbol // package-level symbols of the initial packages, in order of package and name
+}
+
+// A MatrixError is an error reported under some configurations of a
+// Matrix, by position and message.
+type MatrixError struct {
+	Msg     string
+	Configs []int // indices in Matrix.Configs under which Msg is reported
+}
+
+// A MatrixSymbol is a package-level object of an initial package,
+// declared under some configurations of a Matrix.
+type MatrixSymbol struct {
+	Path, Name string
+	Objects    []types.Object // Objects[i] is the object under Matrix.Configs[i], or nil
+}
+
+// Partial reports whether the symbol is not declared under all
+// configurations.
+func (sym *MatrixSymbol) Partial() bool {
+	for _, obj := range sym.Objects {
+		if obj == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Varies reports whether the types of the symbol's objects, or for a
+// type, its underlying types, differ among the configurations under
+// which it is declared, as may those of system structures.
+func (sym *MatrixSymbol) Varies() bool {
+	s := ""
+	for _, obj := range sym.Objects {
+		if obj == nil {
+			continue
+		}
+		T := obj.Type()
+		if _, ok := obj.(*types.TypeName); ok {
+			T = T.Underlying()
+		}
+		t := types.TypeString(obj.Pkg(), T)
+		if s == "" {
+			s = t
+		} else if t != s {
+			return true
+		}
+	}
+	return false
+}
+
+// PartialErrors returns the errors of the matrix not reported under
+// all of its configurations.
+func (m *Matrix) PartialErrors() []*MatrixError {
+	var errs []*MatrixError
+	for _, err := range m.Errors {
+		if len(err.Configs) < len(m.Configs) {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// LoadMatrix loads the program specified by conf under each of the
+// specified build configurations in turn, as if by conf.Load with the
+// build context's GOOS, GOARCH, and BuildTags set from the
+// configuration, and unifies the results.  Cross-platform libraries
+// may use it to check all their supported platforms in one go.
+//
+// Errors do not prevent loading, as if conf.AllowErrors were set, and
+// are not reported to conf.TypeChecker.Error.  Unless conf.TypeChecker
+// specifies Sizes, the word size and maximum alignment are those of
+// each configuration's architecture.  LoadMatrix does not use
+// conf.Cache, and it does not modify conf.
+//
+func (conf *Config) LoadMatrix(configs []BuildConfig) *Matrix {
+	m := &Matrix{Configs: configs}
+	errs := make(map[string]*MatrixError)
+	syms := make(map[[2]string]*MatrixSymbol)
+	for i, bc := range configs {
+		ctxt := *conf.build()
+		ctxt.GOOS = bc.GOOS
+		ctxt.GOARCH = bc.GOARCH
+		ctxt.BuildTags = bc.Tags
+
+		c := *conf
+		c.Build = &ctxt
+		c.Cache = nil
+		c.AllowErrors = true
+		c.TypeChecker.Error = func(error) {} // recorded in PackageInfo.Errors
+		if c.TypeChecker.Sizes == nil {
+			ws := wordSize(bc.GOARCH)
+			c.TypeChecker.Sizes = &types.StdSizes{WordSize: ws, MaxAlign: ws}
+		}
+		prog, err := c.Load()
+		m.Programs = append(m.Programs, prog)
+		m.LoadErrors = append(m.LoadErrors, err)
+		if prog == nil {
+			continue
+		}
+
+		for _, info := range prog.InitialPackages() {
+			for _, err := range info.Errors {
+				e := errs[err.Error()]
+				if e == nil {
+					e = &MatrixError{Msg: err.Error()}
+					errs[e.Msg] = e
+					m.Errors = append(m.Errors, e)
+				}
+				if n := len(e.Configs); n == 0 || e.Configs[n-1] != i {
+					e.Configs = append(e.Configs, i)
+				}
+			}
+			scope := info.Pkg.Scope()
+			for _, name := range scope.Names() {
+				key := [2]string{info.Pkg.Path(), name}
+				sym := syms[key]
+				if sym == nil {
+					sym = &MatrixSymbol{Path: key[0], Name: name, Objects: make([]types.Object, len(configs))}
+					syms[key] = sym
+					m.Symbols = append(m.Symbols, sym)
+				}
+				sym.Objects[i] = scope.Lookup(name)
+			}
+		}
+	}
+	sort.Sort(byMsg(m.Errors))
+	sort.Sort(bySymbol(m.Symbols))
+	return m
+}
+
+type byMsg []*MatrixError
+
+func (b byMsg) Len() int           { return len(b) }
+func (b byMsg) Less(i, j int) bool { return b[i].Msg < b[j].Msg }
+func (b byMsg) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type bySymbol []*MatrixSymbol
+
+func (b bySymbol) Len() int { return len(b) }
+func (b bySymbol) Less(i, j int) bool {
+	if b[i].Path != b[j].Path {
+		return b[i].Path < b[j].Path
+	}
+	return b[i].Name < b[j].Name
+}
+func (b bySymbol) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,151 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file defines Memo, a memoization layer for results derived from
+// the packages of long-lived programs.
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A Memo memoizes the results of derived queries—functions of a set of
+// packages, such as method sets, an index of implementations, or a call
+// graph—across successive loads of a program, so that each result is
+// recomputed only when the packages from which it is derived, or the
+// packages on which they depend, change.
+//
+// A result is keyed by the name of its query and its inputs: the
+// packages of the query and their dependencies, which are the same
+// only if they are the same *types.Package, as when a Cache supplies
+// them to successive loads.  The result of a query must thus depend
+// only on its inputs.
+//
+// A Memo may be used concurrently; concurrent requests for the same
+// result wait for a single computation.
+//
+type Memo struct {
+	mu      sync.Mutex
+	ids     map[*types.Package]int // a number for each input package
+	nextID  int
+	entries map[string]*memoEntry // by key
+	stats   MemoStats
+}
+
+type memoEntry struct {
+	inputs []*types.Package
+	ready  chan struct{} // closed when value is computed
+	value  interface{}
+}
+
+// MemoStats holds statistics about the use of a Memo.
+type MemoStats struct {
+	Hits   int // requests for a memoized result
+	Misses int // requests that computed a result
+	Len    int // number of memoized results
+}
+
+// NewMemo returns a new, empty Memo.
+func NewMemo() *Memo {
+	return &Memo{
+		ids:     make(map[*types.Package]int),
+		entries: make(map[string]*memoEntry),
+	}
+}
+
+// Get returns the result of the query named query about the packages
+// pkgs, calling compute to compute it unless it is memoized.
+func (m *Memo) Get(query string, pkgs []*PackageInfo, compute func() interface{}) interface{} {
+	var inputs []*types.Package
+	seen := make(map[*types.Package]bool)
+	var visit func(pkg *types.Package)
+	visit = func(pkg *types.Package) {
+		if !seen[pkg] && pkg != types.Unsafe {
+			seen[pkg] = true
+			inputs = append(inputs, pkg)
+			for _, dep := range pkg.Imports() {
+				visit(dep)
+			}
+		}
+	}
+	for _, info := range pkgs {
+		visit(info.Pkg)
+	}
+
+	m.mu.Lock()
+	key := m.key(query, inputs)
+	e := m.entries[key]
+	if e != nil {
+		m.stats.Hits++
+		m.mu.Unlock()
+		<-e.ready
+		return e.value
+	}
+	e = &memoEntry{inputs: inputs, ready: make(chan struct{})}
+	m.entries[key] = e
+	m.stats.Misses++
+	m.mu.Unlock()
+
+	e.value = compute()
+	close(e.ready)
+	return e.value
+}
+
+// key returns the key of the result of query about inputs.
+// Precondition: m.mu is held.
+func (m *Memo) key(query string, inputs []*types.Package) string {
+	ids := make([]int, len(inputs))
+	for i, pkg := range inputs {
+		id, ok := m.ids[pkg]
+		if !ok {
+			m.nextID++
+			id = m.nextID
+			m.ids[pkg] = id
+		}
+		ids[i] = id
+	}
+	sort.Ints(ids)
+	var buf bytes.Buffer
+	buf.WriteString(query)
+	for _, id := range ids {
+		fmt.Fprintf(&buf, " %d", id)
+	}
+	return buf.String()
+}
+
+// Prune discards the results derived from packages that are not among
+// those of prog, which may thus no longer be requested, so that they
+// may be garbage-collected.
+func (m *Memo) Prune(prog *Program) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, e := range m.entries {
+		for _, pkg := range e.inputs {
+			if prog.AllPackages[pkg] == nil {
+				delete(m.entries, key)
+				break
+			}
+		}
+	}
+	for pkg := range m.ids {
+		if prog.AllPackages[pkg] == nil {
+			delete(m.ids, pkg)
+		}
+	}
+}
+
+// Stats returns statistics about the use of the memo.
+func (m *Memo) Stats() MemoStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Len = len(m.entries)
+	return stats
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file defines the preprocessing of source files before parsing.
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// A Preprocessor transforms or replaces the source files of packages
+// whose names match a pattern, before they are parsed, so that
+// packages whose code is generated or transformed during the build
+// may be analyzed as they are compiled.
+type Preprocessor struct {
+	// Pattern is a filepath.Match pattern, such as "*.swig" or
+	// "*_tmpl.go", that selects the files to preprocess by their
+	// base names.
+	Pattern string
+
+	// Process returns the Go source to parse in place of the
+	// source src of the file filename, or nil if the file is to be
+	// omitted from its package.  The resulting syntax has the
+	// positions of the returned source, under the file's name;
+	// Process may use //line comments to relate them to others.
+	//
+	// It must be safe to call concurrently from multiple goroutines.
+	Process func(filename string, src []byte) ([]byte, error)
+}
+
+// preprocessor returns the first preprocessor of conf whose pattern
+// matches the base name of filename, or nil.
+func (conf *Config) preprocessor(filename string) *Preprocessor {
+	base := filepath.Base(filename)
+	for i := range conf.Preprocessors {
+		p := &conf.Preprocessors[i]
+		if ok, _ := filepath.Match(p.Pattern, base); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// preprocess applies the preprocessor, if any, for the file filename
+// to its source src.
+func (conf *Config) preprocess(filename string, src []byte) ([]byte, error) {
+	p := conf.preprocessor(filename)
+	if p == nil {
+		return src, nil
+	}
+	out, err := p.Process(filename, src)
+	if err != nil {
+		return nil, fmt.Errorf("preprocessing %s: %s", filename, err)
+	}
+	return out, nil
+}
+
+// preprocessed returns the names of the files for which conf has a
+// preprocessor.
+func (conf *Config) preprocessed(filenames []string) []string {
+	var result []string
+	for _, filename := range filenames {
+		if conf.preprocessor(filename) != nil {
+			result = append(result, filename)
+		}
+	}
+	return result
+}
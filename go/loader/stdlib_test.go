@@ -39,6 +39,7 @@ func TestStdlib(t *testing.T) {
 	ctxt := build.Default // copy
 	ctxt.GOPATH = ""      // disable GOPATH
 	conf := loader.Config{Build: &ctxt}
+	conf.TypeChecker.Strings = types.NewStringTable()
 	for _, path := range buildutil.AllPackages(conf.Build) {
 		conf.ImportWithTests(path)
 	}
@@ -110,6 +111,9 @@ func TestStdlib(t *testing.T) {
 	t.Log("#Source lines:        ", lineCount)
 	t.Log("Load/parse/typecheck: ", t1.Sub(t0))
 	t.Log("#MB:                  ", int64(memstats.Alloc-alloc)/1000000)
+	stats := conf.TypeChecker.Strings.Stats()
+	t.Log("#Interned strings:    ", stats.Strings)
+	t.Log("#MB saved by interning:", float64(stats.SavedBytes)/1000000)
 }
 
 func TestCgoOption(t *testing.T) {
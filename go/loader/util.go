@@ -5,13 +5,16 @@
 package loader
 
 import (
+	"bytes"
 	"go/ast"
 	"go/build"
 	"go/parser"
 	"go/token"
 	"io"
+	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 
 	"golang.org/x/tools/go/buildutil"
@@ -23,12 +26,15 @@ var sema = make(chan bool, 10)
 
 // parseFiles parses the Go source files within directory dir and
 // returns the ASTs of the ones that could be at least partially parsed,
-// along with a list of I/O and parse errors encountered.
+// along with a list of I/O and parse errors encountered, and the set
+// of ASTs whose source carries a "generated code" marker.
 //
 // I/O is done via ctxt, which may specify a virtual file system.
 // displayPath is used to transform the filenames attached to the ASTs.
+// If process is non-nil, it transforms the source of each file before
+// parsing; a file whose transformed source is nil is skipped.
 //
-func parseFiles(fset *token.FileSet, ctxt *build.Context, displayPath func(string) string, dir string, files []string, mode parser.Mode) ([]*ast.File, []error) {
+func parseFiles(fset *token.FileSet, ctxt *build.Context, displayPath func(string) string, dir string, files []string, mode parser.Mode, process func(string, []byte) ([]byte, error)) ([]*ast.File, []error, map[*ast.File]bool) {
 	if displayPath == nil {
 		displayPath = func(path string) string { return path }
 	}
@@ -36,6 +42,7 @@ func parseFiles(fset *token.FileSet, ctxt *build.Context, displayPath func(strin
 	n := len(files)
 	parsed := make([]*ast.File, n)
 	errors := make([]error, n)
+	generated := make([]bool, n)
 	for i, file := range files {
 		if !buildutil.IsAbsPath(ctxt, file) {
 			file = buildutil.JoinPath(ctxt, dir, file)
@@ -59,17 +66,38 @@ func parseFiles(fset *token.FileSet, ctxt *build.Context, displayPath func(strin
 				return
 			}
 
-			// ParseFile may return both an AST and an error.
-			parsed[i], errors[i] = parser.ParseFile(fset, displayPath(file), rd, mode)
+			src, err := ioutil.ReadAll(rd)
 			rd.Close()
+			if err != nil {
+				errors[i] = err // read failed
+				return
+			}
+			if process != nil {
+				src, err = process(file, src)
+				if err != nil {
+					errors[i] = err // preprocessing failed
+					return
+				}
+				if src == nil {
+					return // file dropped
+				}
+			}
+			generated[i] = isGeneratedSource(src)
+
+			// ParseFile may return both an AST and an error.
+			parsed[i], errors[i] = parser.ParseFile(fset, displayPath(file), src, mode)
 		}(i, file)
 	}
 	wg.Wait()
 
 	// Eliminate nils, preserving order.
 	var o int
-	for _, f := range parsed {
+	gen := make(map[*ast.File]bool)
+	for i, f := range parsed {
 		if f != nil {
+			if generated[i] {
+				gen[f] = true
+			}
 			parsed[o] = f
 			o++
 		}
@@ -85,13 +113,62 @@ func parseFiles(fset *token.FileSet, ctxt *build.Context, displayPath func(strin
 	}
 	errors = errors[:o]
 
-	return parsed, errors
+	return parsed, errors, gen
+}
+
+// generatedPrefix and generatedSuffix delimit the comment line that
+// marks a file as generated by a tool, per the convention
+//
+//	// Code generated by <tool>; DO NOT EDIT.
+//
+const (
+	generatedPrefix = "// Code generated "
+	generatedSuffix = " DO NOT EDIT."
+)
+
+// isGeneratedSource reports whether the Go source src contains a line
+// marking it as generated code.
+func isGeneratedSource(src []byte) bool {
+	for len(src) > 0 {
+		line := src
+		if i := bytes.IndexByte(src, '\n'); i >= 0 {
+			line, src = src[:i], src[i+1:]
+		} else {
+			src = nil
+		}
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if isGeneratedComment(string(line)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneratedFile reports whether the comments of f, which must have
+// been parsed with parser.ParseComments, mark it as generated code.
+func isGeneratedFile(f *ast.File) bool {
+	for _, g := range f.Comments {
+		for _, c := range g.List {
+			if isGeneratedComment(c.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isGeneratedComment(line string) bool {
+	return strings.HasPrefix(line, generatedPrefix) &&
+		strings.HasSuffix(line, generatedSuffix) &&
+		len(line) >= len(generatedPrefix)+len(generatedSuffix)
 }
 
 // scanImports returns the set of all package import paths from all
-// import specs in the specified files.
-func scanImports(files []*ast.File) map[string]bool {
+// import specs in the specified files, and for each path the position
+// of the first import spec that mentions it.
+func scanImports(files []*ast.File) (map[string]bool, map[string]token.Pos) {
 	imports := make(map[string]bool)
+	positions := make(map[string]token.Pos)
 	for _, f := range files {
 		for _, decl := range f.Decls {
 			if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.IMPORT {
@@ -106,12 +183,15 @@ func scanImports(files []*ast.File) map[string]bool {
 					if path == "C" || path == "unsafe" {
 						continue // skip pseudo packages
 					}
-					imports[path] = true
+					if !imports[path] {
+						imports[path] = true
+						positions[path] = spec.Pos()
+					}
 				}
 			}
 		}
 	}
-	return imports
+	return imports, positions
 }
 
 // ---------- Internal helpers ----------
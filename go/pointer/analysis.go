@@ -205,6 +205,8 @@ func (a *analysis) computeTrackBits() {
 	}
 }
 
+var errNoMains = fmt.Errorf("no main/test packages to analyze (check $GOROOT/$GOPATH)")
+
 // Analyze runs the pointer analysis with the scope and options
 // specified by config, and returns the (synthetic) root of the callgraph.
 //
@@ -213,7 +215,7 @@ func (a *analysis) computeTrackBits() {
 //
 func Analyze(config *Config) (result *Result, err error) {
 	if config.Mains == nil {
-		return nil, fmt.Errorf("no main/test packages to analyze (check $GOROOT/$GOPATH)")
+		return nil, errNoMains
 	}
 	defer func() {
 		if p := recover(); p != nil {
@@ -0,0 +1,238 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pointer
+
+// This file defines GoroutineLeaks, a heuristic check for goroutines
+// that block forever on channel operations.
+//
+// A goroutine launched by a go statement runs the callees of the
+// statement and the functions they call, transitively.  If it sends on
+// an unbuffered channel that no reachable code outside those functions
+// receives from, or receives from a channel that no such code sends
+// on or closes, the operation can never complete, and the goroutine
+// leaks.  The channels of the operations are identified by their
+// allocation sites (make(chan T) instructions), per the points-to sets
+// of the pointer analysis.
+//
+// The check is neither sound nor complete: it does not consider
+// whether the peer operations execute, nor that a goroutine may be
+// launched several times and communicate with its own instances, nor
+// channels operated on through reflection.
+
+import (
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"golang.org/x/tools/go/types"
+)
+
+// A Leak is a channel operation of a goroutine that may block
+// forever.
+type Leak struct {
+	Go   *ssa.Go       // the go statement that launches the goroutine
+	Op   token.Pos     // the position of the channel operation
+	Dir  types.ChanDir // SendOnly or RecvOnly
+	Chan *ssa.MakeChan // the allocation site of the channel
+}
+
+// GoroutineLeaks runs the pointer analysis specified by config, after
+// adding queries for the channels of all the channel operations of the
+// program, and returns the result and the leaks it implies, in order
+// of the position of their go statement and operation.  It sets
+// config.BuildCallGraph.
+//
+func GoroutineLeaks(config *Config) (*Result, []*Leak, error) {
+	if config.Mains == nil {
+		return nil, nil, errNoMains
+	}
+	var ops []chanOp
+	for fn := range ssautil.AllFunctions(config.prog()) {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				for _, op := range chanOps(instr) {
+					op.fn = fn
+					ops = append(ops, op)
+					config.AddQuery(op.ch)
+				}
+				if g, ok := instr.(*ssa.Go); ok {
+					for _, arg := range g.Call.Args {
+						if _, ok := arg.Type().Underlying().(*types.Chan); ok {
+							config.AddQuery(arg)
+						}
+					}
+				}
+			}
+		}
+	}
+	config.BuildCallGraph = true
+	result, err := Analyze(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	cg := result.CallGraph
+
+	// Index the operations by channel allocation site.
+	byChan := make(map[*ssa.MakeChan][]chanOp)
+	byFunc := make(map[*ssa.Function][]chanOp)
+	for _, op := range ops {
+		if cg.Nodes[op.fn] == nil {
+			continue // unreachable
+		}
+		byFunc[op.fn] = append(byFunc[op.fn], op)
+		for _, l := range result.Queries[op.ch].PointsTo().Labels() {
+			if mc, ok := l.Value().(*ssa.MakeChan); ok {
+				byChan[mc] = append(byChan[mc], op)
+			}
+		}
+	}
+
+	var leaks []*Leak
+	for fn, node := range cg.Nodes {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				g, ok := instr.(*ssa.Go)
+				if !ok {
+					continue
+				}
+				run := goroutineFuncs(node, g)
+				for f := range run {
+					for _, op := range byFunc[f] {
+						if !op.blocking || op.dir == types.SendRecv {
+							continue // close, or a select with a default case
+						}
+						ch := op.ch
+						if arg := goArg(g, ch); arg != nil {
+							ch = arg // more precise
+						}
+						for _, l := range result.Queries[ch].PointsTo().Labels() {
+							mc, ok := l.Value().(*ssa.MakeChan)
+							if !ok || op.dir == types.SendOnly && isBuffered(mc) {
+								continue
+							}
+							if !hasPeer(byChan[mc], op.dir, run) {
+								leaks = append(leaks, &Leak{Go: g, Op: op.pos, Dir: op.dir, Chan: mc})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.Sort(byLeakPos(leaks))
+	return result, leaks, nil
+}
+
+type byLeakPos []*Leak
+
+func (b byLeakPos) Len() int { return len(b) }
+func (b byLeakPos) Less(i, j int) bool {
+	if b[i].Go.Pos() != b[j].Go.Pos() {
+		return b[i].Go.Pos() < b[j].Go.Pos()
+	}
+	return b[i].Op < b[j].Op
+}
+func (b byLeakPos) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+
+// A chanOp is a channel operation.
+type chanOp struct {
+	ch       ssa.Value
+	dir      types.ChanDir // SendOnly or RecvOnly, or SendRecv for close
+	pos      token.Pos
+	blocking bool
+	fn       *ssa.Function
+}
+
+// chanOps returns the channel operations of instr.
+func chanOps(instr ssa.Instruction) []chanOp {
+	var ops []chanOp
+	switch instr := instr.(type) {
+	case *ssa.UnOp:
+		if instr.Op == token.ARROW {
+			ops = append(ops, chanOp{ch: instr.X, dir: types.RecvOnly, pos: instr.Pos(), blocking: true})
+		}
+	case *ssa.Send:
+		ops = append(ops, chanOp{ch: instr.Chan, dir: types.SendOnly, pos: instr.Pos(), blocking: true})
+	case *ssa.Select:
+		for _, st := range instr.States {
+			ops = append(ops, chanOp{ch: st.Chan, dir: st.Dir, pos: st.Pos, blocking: instr.Blocking})
+		}
+	case ssa.CallInstruction:
+		cc := instr.Common()
+		if b, ok := cc.Value.(*ssa.Builtin); ok && b.Name() == "close" {
+			ops = append(ops, chanOp{ch: cc.Args[0], dir: types.SendRecv, pos: cc.Pos()})
+		}
+	}
+	return ops
+}
+
+// goroutineFuncs returns the functions run by the goroutine launched
+// by the go statement g of the function of node: the callees of g,
+// and the functions they call, transitively, but not those run by the
+// goroutines they launch.
+func goroutineFuncs(node *callgraph.Node, g *ssa.Go) map[*ssa.Function]bool {
+	run := make(map[*ssa.Function]bool)
+	var visit func(n *callgraph.Node)
+	visit = func(n *callgraph.Node) {
+		if run[n.Func] {
+			return
+		}
+		run[n.Func] = true
+		for _, e := range n.Out {
+			if _, ok := e.Site.(*ssa.Go); !ok {
+				visit(e.Callee)
+			}
+		}
+	}
+	for _, e := range node.Out {
+		if e.Site == ssa.CallInstruction(g) {
+			visit(e.Callee)
+		}
+	}
+	return run
+}
+
+// goArg returns the argument of the go statement g that is the
+// channel ch, if ch is a parameter of the static callee of g, or nil.
+func goArg(g *ssa.Go, ch ssa.Value) ssa.Value {
+	p, ok := ch.(*ssa.Parameter)
+	if !ok {
+		return nil
+	}
+	if p.Parent() != g.Call.StaticCallee() {
+		return nil
+	}
+	for i, param := range p.Parent().Params {
+		if param == p {
+			return g.Call.Args[i]
+		}
+	}
+	return nil
+}
+
+// hasPeer reports whether one of the operations ops, on a channel,
+// outside the functions run may complete an operation of direction
+// dir on it: a receive for a send, or a send or close for a receive.
+func hasPeer(ops []chanOp, dir types.ChanDir, run map[*ssa.Function]bool) bool {
+	for _, op := range ops {
+		if run[op.fn] {
+			continue
+		}
+		if dir == types.SendOnly && op.dir == types.RecvOnly ||
+			dir == types.RecvOnly && op.dir != types.RecvOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// isBuffered reports whether the channel allocated by mc may be
+// buffered.
+func isBuffered(mc *ssa.MakeChan) bool {
+	c, ok := mc.Size.(*ssa.Const)
+	return !ok || c.Int64() != 0
+}
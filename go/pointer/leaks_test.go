@@ -0,0 +1,99 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pointer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/pointer"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"golang.org/x/tools/go/types"
+)
+
+func TestGoroutineLeaks(t *testing.T) {
+	const src = `package main
+
+func main() {
+	done := make(chan int)
+	go func() { done <- 1 }() // no leak: received below
+	<-done
+
+	lost := make(chan int)
+	go func() { lost <- 1 }() // leak: send on lost
+
+	never := make(chan int)
+	go wait(never) // leak: receive from never
+
+	closed := make(chan int)
+	go wait(closed) // no leak: closed below
+	close(closed)
+
+	buffered := make(chan int, 1)
+	go func() { buffered <- 1 }() // no leak: buffered
+
+	poll := make(chan int)
+	go func() { // no leak: non-blocking
+		select {
+		case <-poll:
+		default:
+		}
+	}()
+
+	ch := make(chan int)
+	out := make(chan int)
+	go relay(ch, out) // leak: send on ch
+}
+
+func wait(ch chan int) { <-ch }
+
+func relay(in, out chan int) {
+	go func() { <-out }() // leak: receive from out
+	in <- 1
+}
+`
+	var conf loader.Config
+	f, err := conf.ParseFile("leaks.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := ssautil.CreateProgram(iprog, 0)
+	mainPkg := prog.Package(iprog.Created[0].Pkg)
+	prog.BuildAll()
+
+	_, leaks, err := pointer.GoroutineLeaks(&pointer.Config{Mains: []*ssa.Package{mainPkg}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each channel is named by the variable declared on the line of
+	// its allocation.
+	lines := strings.Split(src, "\n")
+	var got []string
+	for _, l := range leaks {
+		dir := "receive from"
+		if l.Dir == types.SendOnly {
+			dir = "send on"
+		}
+		name := strings.Fields(lines[prog.Fset.Position(l.Chan.Pos()).Line-1])[0]
+		got = append(got, fmt.Sprintf("%d: %s %s", prog.Fset.Position(l.Go.Pos()).Line, dir, name))
+	}
+	var want []string
+	for i, line := range lines {
+		if j := strings.Index(line, "// leak: "); j >= 0 {
+			want = append(want, fmt.Sprintf("%d: %s", i+1, line[j+len("// leak: "):]))
+		}
+	}
+	if g, w := strings.Join(got, "\n"), strings.Join(want, "\n"); g != w {
+		t.Errorf("got leaks:\n%s\nwant:\n%s", g, w)
+	}
+}
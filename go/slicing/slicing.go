@@ -0,0 +1,662 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slicing computes program slices within a single function.
+//
+// Given a variable and a position at which it is mentioned, the
+// backward slice is the set of statements of the function that may
+// affect the value of the variable at that position, and the forward
+// slice is the set of statements whose execution or values may be
+// affected by it.  Slices are intended as a building block for
+// debugging and code-review tools.
+//
+// Slices are computed from the control-flow graph of the function
+// body (see golang.org/x/tools/go/cfg) and the type checker's record
+// of definitions, uses, and writes, so the Defs, Uses, Implicits,
+// Selections, and Writes maps of the types.Info must be populated.
+// The elements of a slice are the nodes of the CFG: statements,
+// expressions such as the conditions of if and for statements, and
+// the ValueSpecs of var declarations.  Each is reported by its
+// position.
+//
+// A statement depends on the statements that assign the variables it
+// reads (data dependence), and on the conditions that determine
+// whether it executes (control dependence).  The analysis is
+// conservative in some respects and not in others:
+//
+//   - A write to an element, field, or pointee of a variable, as in
+//     a[i] = x, is a partial write of the variable: it may affect
+//     later reads of the variable but does not hide earlier writes.
+//
+//   - A variable assigned by a function literal within the body is
+//     partially written by the statement containing the literal.
+//
+//   - Aliasing is not modelled: writes through pointers to other
+//     variables and the effects of called functions are not tracked.
+package slicing // import "golang.org/x/tools/go/slicing"
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/cfg"
+	"golang.org/x/tools/go/types"
+)
+
+// Backward returns the positions, in order, of the statements of the
+// function body that may affect the value of v at pos, which must be
+// the position of an identifier denoting v in body.
+//
+// If the identifier is written, as in the left operand of an
+// assignment, the slice contains the assignment and the statements
+// that may affect the assigned value; otherwise it contains the
+// statements that may have assigned the value read there.
+// Backward returns nil if pos does not denote v.
+func Backward(info *types.Info, body *ast.BlockStmt, v *types.Var, pos token.Pos) []token.Pos {
+	s := newSlicer(info, body)
+	crit, write := s.criterion(v, pos)
+	if crit == nil {
+		return nil
+	}
+	if write {
+		s.add(crit)
+	} else {
+		for _, def := range s.reachingDefs(crit, v) {
+			s.add(def)
+		}
+	}
+	for len(s.queue) > 0 {
+		n := s.queue[len(s.queue)-1]
+		s.queue = s.queue[:len(s.queue)-1]
+		for u := range n.uses {
+			for _, def := range s.reachingDefs(n, u) {
+				s.add(def)
+			}
+		}
+		for _, c := range s.controls[n.block.Index] {
+			if cond := s.cond(c); cond != nil {
+				s.add(cond)
+			}
+		}
+	}
+	return s.positions()
+}
+
+// Forward returns the positions, in order, of the statements of the
+// function body that may be affected by the value of v at pos, which
+// must be the position of an identifier denoting v in body.
+//
+// If the identifier is written, the slice contains the assignment and
+// the statements that read the assigned value, directly or
+// indirectly; otherwise it contains the statement that reads v and the
+// statements affected by it.
+// Forward returns nil if pos does not denote v.
+func Forward(info *types.Info, body *ast.BlockStmt, v *types.Var, pos token.Pos) []token.Pos {
+	s := newSlicer(info, body)
+	crit, write := s.criterion(v, pos)
+	if crit == nil {
+		return nil
+	}
+	if write {
+		// Only the value of v flows from the criterion;
+		// the statement's other effects are independent of it.
+		s.inSlice[crit] = true
+		for _, use := range s.reachedUses(crit, v) {
+			s.add(use)
+		}
+	} else {
+		s.add(crit)
+	}
+	for len(s.queue) > 0 {
+		n := s.queue[len(s.queue)-1]
+		s.queue = s.queue[:len(s.queue)-1]
+		for d := range n.defs {
+			for _, use := range s.reachedUses(n, d) {
+				s.add(use)
+			}
+		}
+		if b := s.decided(n); b != nil {
+			for _, dep := range s.dependents[b.Index] {
+				for _, m := range s.blocks[dep.Index] {
+					s.add(m)
+				}
+			}
+		}
+	}
+	return s.positions()
+}
+
+// A node is a node of the CFG together with its effects.
+type node struct {
+	n     ast.Node
+	block *cfg.Block
+	index int                 // index of n within block.Nodes
+	defs  map[*types.Var]bool // variables written by n; true if overwritten entirely
+	uses  map[*types.Var]bool // variables read by n
+}
+
+// A slicer holds the state of a single slice computation.
+type slicer struct {
+	info       *types.Info
+	g          *cfg.CFG
+	blocks     [][]*node                        // nodes of each block, by block index
+	preds      [][]*cfg.Block                   // predecessors of each block, by block index
+	controls   [][]*cfg.Block                   // blocks on which each block is control dependent
+	dependents [][]*cfg.Block                   // blocks control dependent on each block
+	implicits  map[*ast.AssignStmt][]*types.Var // variables declared by type switches
+	ranges     map[ast.Expr]*ast.RangeStmt      // range statement of each key, value, and operand
+
+	inSlice map[*node]bool
+	queue   []*node
+}
+
+func newSlicer(info *types.Info, body *ast.BlockStmt) *slicer {
+	s := &slicer{
+		info:      info,
+		g:         cfg.New(body, nil),
+		implicits: make(map[*ast.AssignStmt][]*types.Var),
+		ranges:    make(map[ast.Expr]*ast.RangeStmt),
+		inSlice:   make(map[*node]bool),
+	}
+
+	// The variables declared by "switch x := y.(type)" are the
+	// implicit objects of its clauses.
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.TypeSwitchStmt:
+			if assign, ok := n.Assign.(*ast.AssignStmt); ok {
+				for _, clause := range n.Body.List {
+					if v, ok := info.Implicits[clause].(*types.Var); ok {
+						s.implicits[assign] = append(s.implicits[assign], v)
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			for _, e := range []ast.Expr{n.Key, n.Value, n.X} {
+				if e != nil {
+					s.ranges[e] = n
+				}
+			}
+		}
+		return true
+	})
+
+	s.blocks = make([][]*node, len(s.g.Blocks))
+	s.preds = make([][]*cfg.Block, len(s.g.Blocks))
+	for _, b := range s.g.Blocks {
+		if !b.Live {
+			continue
+		}
+		for i, n := range b.Nodes {
+			m := &node{
+				n:     n,
+				block: b,
+				index: i,
+				defs:  make(map[*types.Var]bool),
+				uses:  make(map[*types.Var]bool),
+			}
+			s.effects(m)
+			s.blocks[b.Index] = append(s.blocks[b.Index], m)
+		}
+		for _, succ := range b.Succs {
+			s.preds[succ.Index] = append(s.preds[succ.Index], b)
+		}
+	}
+	s.controlDeps()
+	return s
+}
+
+// add adds n to the slice if it is not already present.
+func (s *slicer) add(n *node) {
+	if !s.inSlice[n] {
+		s.inSlice[n] = true
+		s.queue = append(s.queue, n)
+	}
+}
+
+// positions returns the positions of the nodes of the slice, in order.
+func (s *slicer) positions() []token.Pos {
+	var posns []token.Pos
+	for n := range s.inSlice {
+		posns = append(posns, n.n.Pos())
+	}
+	sort.Sort(byPos(posns))
+	return posns
+}
+
+type byPos []token.Pos
+
+func (p byPos) Len() int           { return len(p) }
+func (p byPos) Less(i, j int) bool { return p[i] < p[j] }
+func (p byPos) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// criterion returns the node containing the identifier denoting v at
+// pos, and whether the identifier is written there.
+func (s *slicer) criterion(v *types.Var, pos token.Pos) (*node, bool) {
+	for _, nodes := range s.blocks {
+		for _, n := range nodes {
+			if !(n.n.Pos() <= pos && pos < n.n.End()) {
+				continue
+			}
+			var id *ast.Ident
+			ast.Inspect(n.n, func(n ast.Node) bool {
+				if x, ok := n.(*ast.Ident); ok && x.Pos() == pos {
+					id = x
+				}
+				return id == nil
+			})
+			if id == nil {
+				continue
+			}
+			if s.info.Defs[id] == v {
+				return n, true
+			}
+			if s.info.Uses[id] == v {
+				_, write := s.info.Writes[id]
+				return n, write
+			}
+		}
+	}
+	return nil, false
+}
+
+// reachingDefs returns the nodes that write v and whose write may be
+// observed by n.
+func (s *slicer) reachingDefs(n *node, v *types.Var) []*node {
+	var defs []*node
+	seen := make(map[*cfg.Block]bool)
+	var search func(nodes []*node, b *cfg.Block)
+	search = func(nodes []*node, b *cfg.Block) {
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if kill, ok := nodes[i].defs[v]; ok {
+				defs = append(defs, nodes[i])
+				if kill {
+					return
+				}
+			}
+		}
+		for _, pred := range s.preds[b.Index] {
+			if !seen[pred] {
+				seen[pred] = true
+				search(s.blocks[pred.Index], pred)
+			}
+		}
+	}
+	search(s.blocks[n.block.Index][:n.index], n.block)
+	return defs
+}
+
+// reachedUses returns the nodes that read v and that may observe the
+// write of v by n.
+func (s *slicer) reachedUses(n *node, v *types.Var) []*node {
+	var uses []*node
+	seen := make(map[*cfg.Block]bool)
+	var search func(nodes []*node, b *cfg.Block)
+	search = func(nodes []*node, b *cfg.Block) {
+		for _, m := range nodes {
+			// Operands are evaluated before the assignment takes effect.
+			if m.uses[v] {
+				uses = append(uses, m)
+			}
+			if m.defs[v] {
+				return
+			}
+		}
+		for _, succ := range b.Succs {
+			if !seen[succ] {
+				seen[succ] = true
+				search(s.blocks[succ.Index], succ)
+			}
+		}
+	}
+	search(s.blocks[n.block.Index][n.index+1:], n.block)
+	return uses
+}
+
+// cond returns the node that decides the branch taken at the end of
+// block b, or nil if there is none.  The loop block of a range
+// statement has no nodes; its operand decides instead.
+func (s *slicer) cond(b *cfg.Block) *node {
+	if nodes := s.blocks[b.Index]; len(nodes) > 0 {
+		return nodes[len(nodes)-1]
+	}
+	for _, pred := range s.preds[b.Index] {
+		nodes := s.blocks[pred.Index]
+		if len(nodes) == 0 {
+			continue
+		}
+		last := nodes[len(nodes)-1]
+		if e, ok := last.n.(ast.Expr); ok {
+			if rng, ok := s.ranges[e]; ok && e == rng.X {
+				return last
+			}
+		}
+	}
+	return nil
+}
+
+// decided returns the block whose branch is decided by n, or nil; it
+// is the inverse of cond.
+func (s *slicer) decided(n *node) *cfg.Block {
+	b := n.block
+	if n.index != len(b.Nodes)-1 {
+		return nil
+	}
+	if len(b.Succs) == 2 {
+		return b
+	}
+	if e, ok := n.n.(ast.Expr); ok && len(b.Succs) == 1 {
+		if rng, ok := s.ranges[e]; ok && e == rng.X {
+			return b.Succs[0]
+		}
+	}
+	return nil
+}
+
+// controlDeps computes the control dependences among the live blocks
+// of the CFG from their post-dominators: block y is control dependent
+// on block x if x has two successors, y post-dominates one of them,
+// and y does not strictly post-dominate x.
+//
+// Blocks that have no successors or from which no such block is
+// reachable, as in an infinite loop, are treated as exits.  Within
+// such a loop, a block is therefore control dependent only on the
+// conditions of its immediate predecessors.
+func (s *slicer) controlDeps() {
+	nblocks := len(s.g.Blocks)
+
+	// Find the blocks that reach an exit.
+	reachesExit := make([]bool, nblocks)
+	var mark func(b *cfg.Block)
+	mark = func(b *cfg.Block) {
+		reachesExit[b.Index] = true
+		for _, pred := range s.preds[b.Index] {
+			if !reachesExit[pred.Index] {
+				mark(pred)
+			}
+		}
+	}
+	for _, b := range s.g.Blocks {
+		if b.Live && len(b.Succs) == 0 {
+			mark(b)
+		}
+	}
+	isExit := func(b *cfg.Block) bool {
+		return len(b.Succs) == 0 || !reachesExit[b.Index]
+	}
+
+	// Compute the post-dominator sets iteratively.
+	pdom := make([][]bool, nblocks)
+	for _, b := range s.g.Blocks {
+		if !b.Live {
+			continue
+		}
+		pdom[b.Index] = make([]bool, nblocks)
+		if isExit(b) {
+			pdom[b.Index][b.Index] = true
+		} else {
+			for i := range pdom[b.Index] {
+				pdom[b.Index][i] = true
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range s.g.Blocks {
+			if !b.Live || isExit(b) {
+				continue
+			}
+			set := pdom[b.Index]
+			for i := range set {
+				if !set[i] || i == int(b.Index) {
+					continue
+				}
+				for _, succ := range b.Succs {
+					if !pdom[succ.Index][i] {
+						set[i] = false
+						changed = true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	s.controls = make([][]*cfg.Block, nblocks)
+	s.dependents = make([][]*cfg.Block, nblocks)
+	for _, x := range s.g.Blocks {
+		if !x.Live || len(x.Succs) != 2 {
+			continue
+		}
+		for _, y := range s.g.Blocks {
+			if !y.Live || (y != x && pdom[x.Index][y.Index]) {
+				continue
+			}
+			for _, succ := range x.Succs {
+				if pdom[succ.Index][y.Index] {
+					s.controls[y.Index] = append(s.controls[y.Index], x)
+					s.dependents[x.Index] = append(s.dependents[x.Index], y)
+					break
+				}
+			}
+		}
+	}
+}
+
+// effects records the variables written and read by n.
+func (s *slicer) effects(n *node) {
+	switch x := n.n.(type) {
+	case *ast.ValueSpec:
+		for _, id := range x.Names {
+			if v, ok := s.info.Defs[id].(*types.Var); ok {
+				n.defs[v] = true
+			}
+		}
+		for _, e := range x.Values {
+			s.reads(n, e)
+		}
+
+	case *ast.AssignStmt:
+		if vars, ok := s.implicits[x]; ok {
+			for _, v := range vars {
+				n.defs[v] = true
+			}
+			s.reads(n, x.Rhs[0])
+			return
+		}
+		for _, lhs := range x.Lhs {
+			s.write(n, lhs, x.Tok == token.ASSIGN || x.Tok == token.DEFINE)
+		}
+		for _, rhs := range x.Rhs {
+			s.reads(n, rhs)
+		}
+
+	case *ast.IncDecStmt:
+		s.write(n, x.X, false)
+
+	case ast.Expr:
+		if rng, ok := s.ranges[x]; ok && x == rng.X {
+			s.reads(n, x)
+			return
+		}
+		// The key and value of a range statement and the
+		// left operand of a receive in a select statement
+		// appear as separate nodes.  The key and value
+		// are derived from the range operand.
+		if _, ok := s.info.Writes[x]; ok {
+			s.write(n, x, true)
+		} else {
+			s.reads(n, x)
+		}
+		if rng, ok := s.ranges[x]; ok {
+			s.reads(n, rng.X)
+		}
+
+	default:
+		s.reads(n, x)
+	}
+}
+
+// write records the write to the left operand lhs by n.  If assign is
+// false, the write is an update such as x += y that reads the operand
+// too.
+func (s *slicer) write(n *node, lhs ast.Expr, assign bool) {
+	kind := s.info.Writes[lhs]
+	if kind == types.BlankWrite || kind == 0 {
+		return
+	}
+	v := s.variable(s.root(lhs))
+	switch kind {
+	case types.DefineWrite, types.RedefineWrite, types.VarWrite:
+		if v != nil {
+			if assign {
+				n.defs[v] = true
+			} else {
+				n.partial(v)
+				n.uses[v] = true
+			}
+		}
+	default:
+		// Partial write of a field, element, or pointee:
+		// the rest of lhs is read.
+		if v != nil {
+			n.partial(v)
+		}
+		s.reads(n, lhs)
+	}
+}
+
+// partial records a partial write of v by n.
+func (n *node) partial(v *types.Var) {
+	if _, ok := n.defs[v]; !ok {
+		n.defs[v] = false
+	}
+}
+
+// reads records the variables read by the subexpressions of x.
+// Variables of the enclosing function written within function literals
+// are recorded as partially written by n.
+func (s *slicer) reads(n *node, x ast.Node) {
+	ast.Inspect(x, func(x ast.Node) bool {
+		switch x := x.(type) {
+		case *ast.Ident:
+			if v := s.variable(x); v != nil && s.info.Uses[x] == v {
+				n.uses[v] = true
+			}
+		case *ast.SelectorExpr:
+			// Field and method names are not variables,
+			// but qualified identifiers may be.
+			if s.info.Selections[x] == nil {
+				if v := s.variable(x.Sel); v != nil {
+					n.uses[v] = true
+				}
+				return false
+			}
+			s.reads(n, x.X)
+			return false
+		case *ast.FuncLit:
+			s.captures(n, x)
+			return false
+		}
+		return true
+	})
+}
+
+// captures records the variables of the enclosing function that are
+// read or written by the function literal lit within n.  Variables
+// assigned by lit are partially written by n.
+func (s *slicer) captures(n *node, lit *ast.FuncLit) {
+	outer := func(v *types.Var) bool {
+		return v != nil && !(lit.Pos() <= v.Pos() && v.Pos() < lit.End())
+	}
+	var visit func(x ast.Node) bool
+	visit = func(x ast.Node) bool {
+		switch x := x.(type) {
+		case *ast.Ident:
+			if v := s.variable(x); outer(v) && s.info.Uses[x] == v {
+				n.uses[v] = true
+			}
+		case *ast.AssignStmt:
+			if x.Tok != token.ASSIGN && x.Tok != token.DEFINE {
+				break
+			}
+			for _, lhs := range x.Lhs {
+				if id, ok := unparen(lhs).(*ast.Ident); ok {
+					if v := s.variable(id); outer(v) {
+						n.partial(v)
+					}
+				} else {
+					ast.Inspect(lhs, visit)
+				}
+			}
+			for _, rhs := range x.Rhs {
+				ast.Inspect(rhs, visit)
+			}
+			return false
+		}
+		if e, ok := x.(ast.Expr); ok {
+			if kind, ok := s.info.Writes[e]; ok && kind != types.BlankWrite {
+				if v := s.variable(s.root(e)); outer(v) {
+					n.partial(v)
+				}
+			}
+		}
+		return true
+	}
+	ast.Inspect(lit.Body, visit)
+}
+
+// variable returns the variable, other than a struct field, defined or
+// used by id, or nil.
+func (s *slicer) variable(id *ast.Ident) *types.Var {
+	if id == nil {
+		return nil
+	}
+	obj := s.info.Defs[id]
+	if obj == nil {
+		obj = s.info.Uses[id]
+	}
+	if v, ok := obj.(*types.Var); ok && !v.IsField() {
+		return v
+	}
+	return nil
+}
+
+// root returns the identifier at the root of the selector, index,
+// slice, and indirection expressions of e, or nil if there is none.
+// For a qualified identifier p.x, it returns x.
+func (s *slicer) root(e ast.Expr) *ast.Ident {
+	for {
+		switch x := e.(type) {
+		case *ast.Ident:
+			return x
+		case *ast.ParenExpr:
+			e = x.X
+		case *ast.SelectorExpr:
+			if s.info.Selections[x] == nil {
+				return x.Sel
+			}
+			e = x.X
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.SliceExpr:
+			e = x.X
+		case *ast.StarExpr:
+			e = x.X
+		default:
+			return nil
+		}
+	}
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
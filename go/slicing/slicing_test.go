@@ -0,0 +1,148 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slicing_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/slicing"
+	"golang.org/x/tools/go/types"
+)
+
+const src = `package p
+
+func f1(a, b int, c bool) int {
+	x := a
+	y := b
+	if c {
+		x = y + 1
+	}
+	z := y * 2
+	_ = z
+	return x /*@x*/
+}
+
+func f2(n int) int {
+	sum := 0
+	for i := 0; i < n; i++ {
+		sum += i
+	}
+	return sum /*@sum*/
+}
+
+func f3(s []int) int {
+	var m int
+	for _, e := range s {
+		if e > m {
+			m = e
+		}
+	}
+	println(len(s))
+	return m /*@m*/
+}
+
+func f4(a []int) int {
+	a[0] = 1
+	t := a[1]
+	a[2] = t
+	f := func() { t = 0 }
+	f()
+	return a /*@a*/ [0]
+}
+`
+
+func TestSlices(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Writes:     make(map[ast.Expr]types.WriteKind),
+	}
+	if _, err := new(types.Config).Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	// text returns the source of the outermost node starting at pos.
+	text := func(pos token.Pos) string {
+		var node ast.Node
+		ast.Inspect(f, func(n ast.Node) bool {
+			if node == nil && n != nil && n.Pos() == pos {
+				node = n
+			}
+			return node == nil
+		})
+		if node == nil {
+			return "?"
+		}
+		return src[fset.Position(node.Pos()).Offset:fset.Position(node.End()).Offset]
+	}
+
+	for _, test := range []struct {
+		fn, ref, def string // function, criterion reads ref, criterion writes def
+		slice        func(*types.Info, *ast.BlockStmt, *types.Var, token.Pos) []token.Pos
+		want         string
+	}{
+		{"f1", "x", "", slicing.Backward, "x := a; y := b; c; x = y + 1"},
+		{"f1", "", "y", slicing.Forward, "y := b; x = y + 1; z := y * 2; _ = z; return x"},
+		{"f1", "", "z", slicing.Backward, "y := b; z := y * 2"},
+		{"f2", "sum", "", slicing.Backward, "sum := 0; i := 0; i < n; i++; sum += i"},
+		{"f2", "", "sum", slicing.Forward, "sum := 0; sum += i; return sum"},
+		{"f3", "m", "", slicing.Backward, "m int; e; s; e > m; m = e"},
+		{"f3", "", "m", slicing.Forward, "m int; e > m; m = e; return m"},
+		{"f4", "a", "", slicing.Backward, "a[0] = 1; t := a[1]; a[2] = t"},
+		{"f4", "", "t", slicing.Forward, "t := a[1]; a[2] = t; return a /*@a*/ [0]"},
+	} {
+		var decl *ast.FuncDecl
+		for _, d := range f.Decls {
+			if d, ok := d.(*ast.FuncDecl); ok && d.Name.Name == test.fn {
+				decl = d
+			}
+		}
+
+		// Find the criterion: the identifier preceding the
+		// comment /*@ref*/, or the first definition of def.
+		var id *ast.Ident
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			x, ok := n.(*ast.Ident)
+			if !ok || id != nil {
+				return id == nil
+			}
+			if test.def != "" && x.Name == test.def && info.Defs[x] != nil {
+				id = x
+			}
+			if test.ref != "" && x.Name == test.ref &&
+				strings.HasPrefix(src[fset.Position(x.End()).Offset:], " /*@"+test.ref+"*/") {
+				id = x
+			}
+			return true
+		})
+		if id == nil {
+			t.Errorf("%s: criterion not found", test.fn)
+			continue
+		}
+		obj := info.Defs[id]
+		if obj == nil {
+			obj = info.Uses[id]
+		}
+
+		var got []string
+		for _, pos := range test.slice(info, decl.Body, obj.(*types.Var), id.Pos()) {
+			got = append(got, text(pos))
+		}
+		if s := strings.Join(got, "; "); s != test.want {
+			t.Errorf("slice of %s.%s%s = %q, want %q", test.fn, test.ref, test.def, s, test.want)
+		}
+	}
+}
@@ -559,6 +559,7 @@ func (b *builder) expr0(fn *Function, e ast.Expr, tv types.TypeAndValue) Value {
 		}
 		v := &MakeClosure{Fn: fn2}
 		v.setType(tv.Type)
+		v.setPos(e.Type.Func)
 		for _, fv := range fn2.FreeVars {
 			v.Bindings = append(v.Bindings, fv.outer)
 			fv.outer = nil
@@ -6,6 +6,7 @@ package ssa_test
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -417,3 +418,61 @@ var (
 		t.Errorf("want func: %q: %q", fn, descr)
 	}
 }
+
+func TestWrappers(t *testing.T) {
+	const input = `package P
+type T int
+func (T) f() int
+func (*T) g() int
+type I interface{ h() }
+var (
+	a = T.f                     // thunk
+	b = (struct{*T}).g          // thunk
+	c = new(T).g                // bound
+	d = I.h                     // thunk
+	e interface{} = struct{T}{} // wrappers
+)
+`
+	var conf loader.Config
+	f, err := conf.ParseFile("<input>", input)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	conf.CreateFromFiles(f.Name.Name, f)
+	lprog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	prog := ssautil.CreateProgram(lprog, 0)
+	prog.BuildAll()
+
+	var got []string
+	for _, w := range prog.Wrappers() {
+		if w.Func.Wrapper() == nil || w.Method != w.Func.Object() {
+			t.Errorf("%s: inconsistent Wrapper: %+v", w.Func, w)
+		}
+		var sel string
+		if w.Selection != nil {
+			sel = fmt.Sprintf(" %s %v", w.Selection.Recv(), w.Selection.Index())
+		}
+		got = append(got, fmt.Sprintf("%s: %s %s%s", w.Func, w.Kind, w.Method.FullName(), sel))
+	}
+	want := []string{
+		"(*P.T).f: wrapper (P.T).f *P.T [0]",
+		"(*P.T).g$bound: bound (*P.T).g",
+		"(P.I).h$thunk: thunk (P.I).h P.I [0]",
+		"(P.T).f$thunk: thunk (P.T).f P.T [0]",
+		"(struct{*P.T}).g$thunk: thunk (*P.T).g struct{*P.T} [0 1]",
+		"(struct{P.T}).f: wrapper (P.T).f struct{P.T} [0 0]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrappers:\n got %s\nwant %s", strings.Join(got, "\n     "), strings.Join(want, "\n     "))
+	}
+
+	// Source functions are not wrappers.
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Synthetic == "" && fn.Wrapper() != nil {
+			t.Errorf("%s: source function has Wrapper %+v", fn, fn.Wrapper())
+		}
+	}
+}
@@ -109,6 +109,13 @@
 // either accurate or unambiguous.  The public API exposes a number of
 // name-based maps for client convenience.
 //
+// Construction is deterministic: for the same input, the builder
+// creates the same blocks, instructions, and anonymous functions, in
+// the same order and with the same names, whether or not packages are
+// built in parallel.  So the printed form of each function is stable,
+// and ssautil.Dump provides a canonical dump of a package, free of
+// source positions, suitable for golden-file tests.
+//
 // The ssa/ssautil package provides various utilities that depend only
 // on the public API of this package.
 //
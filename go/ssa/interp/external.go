@@ -8,6 +8,7 @@ package interp
 // external or because they use "unsafe" or "reflect" operations.
 
 import (
+	"fmt"
 	"math"
 	"os"
 	"runtime"
@@ -28,6 +29,79 @@ type externalFn func(fr *frame, args []value) value
 // Key strings are from Function.String().
 var externals map[string]externalFn
 
+// An External is a Go implementation of a function that the
+// interpreter cannot interpret, such as one written in assembly, one
+// that makes system calls, or one that relies on unsafe operations.
+//
+// An External receives the arguments of the call and returns its
+// results.  Values of boolean, numeric, and string types are
+// represented by the Go values of the corresponding basic types,
+// e.g. int64 for a parameter of type time.Duration.  Other values use
+// the interpreter's internal representation: an External may pass
+// them on or return them, but should not otherwise depend on it.
+//
+// A function with several results returns them as a []interface{}.
+// A result of type error may be returned as a Go error, which the
+// interpreter converts to an error of the target program with the
+// same message; a nil result of another type denotes the zero value.
+type External func(args []interface{}) interface{}
+
+// RegisterExternal registers fn as the implementation of the function
+// with the specified name, as reported by ssa.Function.String, such as
+// "math.Sqrt" or "(*sync.Pool).Get".  It replaces the interpreter's own
+// implementation, if any.  The interpreter calls fn in place of the
+// function's SSA body, if it has one.
+//
+// RegisterExternal must not be called concurrently with Interpret.
+//
+func RegisterExternal(name string, fn External) {
+	externals[name] = func(fr *frame, args []value) value {
+		in := make([]interface{}, len(args))
+		for i, arg := range args {
+			in[i] = arg
+		}
+		return fromExternal(fr.fn.Signature.Results(), fn(in))
+	}
+}
+
+// fromExternal converts the results returned by an External to the
+// interpreter's representation for a function with the specified
+// results.
+func fromExternal(results *types.Tuple, v interface{}) value {
+	switch results.Len() {
+	case 0:
+		return nil
+	case 1:
+		return fromExternalResult(results.At(0).Type(), v)
+	}
+	vs, ok := v.([]interface{})
+	if !ok || len(vs) != results.Len() {
+		panic(fmt.Sprintf("external function returned %v, want %d results", v, results.Len()))
+	}
+	t := make(tuple, len(vs))
+	for i, v := range vs {
+		t[i] = fromExternalResult(results.At(i).Type(), v)
+	}
+	return t
+}
+
+func fromExternalResult(t types.Type, v interface{}) value {
+	if types.Identical(t, universeError) {
+		switch v := v.(type) {
+		case nil:
+			return iface{}
+		case error:
+			return wrapError(v)
+		}
+	}
+	if v == nil {
+		return zero(t)
+	}
+	return v
+}
+
+var universeError = types.Universe.Lookup("error").Type()
+
 func init() {
 	// That little dot ۰ is an Arabic zero numeral (U+06F0), categories [Nd].
 	externals = map[string]externalFn{
@@ -76,8 +150,14 @@ func init() {
 		"math.Float64bits":                 ext۰math۰Float64bits,
 		"math.Float64frombits":             ext۰math۰Float64frombits,
 		"math.Ldexp":                       ext۰math۰Ldexp,
+		"math.Ceil":                        ext۰math۰Ceil,
+		"math.Floor":                       ext۰math۰Floor,
+		"math.Hypot":                       ext۰math۰Hypot,
 		"math.Log":                         ext۰math۰Log,
+		"math.Max":                         ext۰math۰Max,
 		"math.Min":                         ext۰math۰Min,
+		"math.Sqrt":                        ext۰math۰Sqrt,
+		"math.Trunc":                       ext۰math۰Trunc,
 		"os.runtime_args":                  ext۰os۰runtime_args,
 		"os.runtime_beforeExit":            ext۰os۰runtime_beforeExit,
 		"reflect.New":                      ext۰reflect۰New,
@@ -96,6 +176,7 @@ func init() {
 		"runtime.Gosched":                  ext۰runtime۰Gosched,
 		"runtime.init":                     ext۰runtime۰init,
 		"runtime.NumCPU":                   ext۰runtime۰NumCPU,
+		"runtime.NumGoroutine":             ext۰runtime۰NumGoroutine,
 		"runtime.ReadMemStats":             ext۰runtime۰ReadMemStats,
 		"runtime.SetFinalizer":             ext۰runtime۰SetFinalizer,
 		"(*runtime.Func).Entry":            ext۰runtime۰Func۰Entry,
@@ -119,6 +200,7 @@ func init() {
 		"syscall.Close":                    ext۰syscall۰Close,
 		"syscall.Exit":                     ext۰syscall۰Exit,
 		"syscall.Fstat":                    ext۰syscall۰Fstat,
+		"syscall.Getpagesize":              ext۰syscall۰Getpagesize,
 		"syscall.Getpid":                   ext۰syscall۰Getpid,
 		"syscall.Getwd":                    ext۰syscall۰Getwd,
 		"syscall.Kill":                     ext۰syscall۰Kill,
@@ -225,6 +307,30 @@ func ext۰math۰Log(fr *frame, args []value) value {
 	return math.Log(args[0].(float64))
 }
 
+func ext۰math۰Max(fr *frame, args []value) value {
+	return math.Max(args[0].(float64), args[1].(float64))
+}
+
+func ext۰math۰Sqrt(fr *frame, args []value) value {
+	return math.Sqrt(args[0].(float64))
+}
+
+func ext۰math۰Floor(fr *frame, args []value) value {
+	return math.Floor(args[0].(float64))
+}
+
+func ext۰math۰Ceil(fr *frame, args []value) value {
+	return math.Ceil(args[0].(float64))
+}
+
+func ext۰math۰Trunc(fr *frame, args []value) value {
+	return math.Trunc(args[0].(float64))
+}
+
+func ext۰math۰Hypot(fr *frame, args []value) value {
+	return math.Hypot(args[0].(float64), args[1].(float64))
+}
+
 func ext۰os۰runtime_args(fr *frame, args []value) value {
 	return fr.i.osArgs
 }
@@ -362,6 +468,12 @@ func ext۰runtime۰NumCPU(fr *frame, args []value) value {
 	return runtime.NumCPU()
 }
 
+func ext۰runtime۰NumGoroutine(fr *frame, args []value) value {
+	// Interpreted goroutines are goroutines of the interpreter,
+	// so this is an approximation.
+	return runtime.NumGoroutine()
+}
+
 func ext۰runtime۰ReadMemStats(fr *frame, args []value) value {
 	// TODO(adonovan): populate args[0].(Struct)
 	return nil
@@ -480,6 +592,10 @@ func ext۰syscall۰Getpid(fr *frame, args []value) value {
 	return syscall.Getpid()
 }
 
+func ext۰syscall۰Getpagesize(fr *frame, args []value) value {
+	return syscall.Getpagesize()
+}
+
 func valueToBytes(v value) []byte {
 	in := v.([]value)
 	b := make([]byte, len(in))
@@ -8,6 +8,14 @@ package interp
 
 import "syscall"
 
+func init() {
+	externals["syscall.Getegid"] = ext۰syscall۰Getegid
+	externals["syscall.Geteuid"] = ext۰syscall۰Geteuid
+	externals["syscall.Getgid"] = ext۰syscall۰Getgid
+	externals["syscall.Getppid"] = ext۰syscall۰Getppid
+	externals["syscall.Getuid"] = ext۰syscall۰Getuid
+}
+
 func fillStat(st *syscall.Stat_t, stat structure) {
 	stat[0] = st.Dev
 	stat[1] = st.Ino
@@ -130,3 +138,23 @@ func ext۰syscall۰RawSyscall(fr *frame, args []value) value {
 func syswrite(fd int, b []byte) (int, error) {
 	return syscall.Write(fd, b)
 }
+
+func ext۰syscall۰Getegid(fr *frame, args []value) value {
+	return syscall.Getegid()
+}
+
+func ext۰syscall۰Geteuid(fr *frame, args []value) value {
+	return syscall.Geteuid()
+}
+
+func ext۰syscall۰Getgid(fr *frame, args []value) value {
+	return syscall.Getgid()
+}
+
+func ext۰syscall۰Getppid(fr *frame, args []value) value {
+	return syscall.Getppid()
+}
+
+func ext۰syscall۰Getuid(fr *frame, args []value) value {
+	return syscall.Getuid()
+}
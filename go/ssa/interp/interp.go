@@ -16,7 +16,10 @@
 //
 // * Unsafe operations, including all uses of unsafe.Pointer, are
 // impossible to support given the "boxed" value representation we
-// have chosen.
+// have chosen.  Functions that use them, and those written in
+// assembly, may instead be given Go implementations using
+// RegisterExternal; the interpreter provides its own for many
+// functions of the standard library.
 //
 // * The reflect package is only partially implemented.
 //
@@ -8,8 +8,10 @@ package interp_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"go/build"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -299,6 +301,23 @@ func TestTestdataFiles(t *testing.T) {
 	printFailures(failures)
 }
 
+// TestExternals runs the interpreter on a program whose functions
+// without bodies are implemented by RegisterExternal.
+func TestExternals(t *testing.T) {
+	interp.RegisterExternal("main.hypot", func(args []interface{}) interface{} {
+		x, y := args[0].(float64), args[1].(float64)
+		return math.Sqrt(x*x + y*y)
+	})
+	interp.RegisterExternal("main.divmod", func(args []interface{}) interface{} {
+		x, y := args[0].(int), args[1].(int)
+		if y == 0 {
+			return []interface{}{nil, nil, errors.New("division by zero")}
+		}
+		return []interface{}{x / y, x % y, nil}
+	})
+	run(t, "testdata"+slash, "externals.go", success)
+}
+
 // TestGorootTest runs the interpreter on $GOROOT/test/*.go.
 func TestGorootTest(t *testing.T) {
 	if testing.Short() {
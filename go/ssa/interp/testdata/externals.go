@@ -0,0 +1,20 @@
+package main
+
+// Tests of functions implemented by interp.RegisterExternal.
+// See TestExternals.
+
+func hypot(x, y float64) float64
+
+func divmod(x, y int) (q, r int, err error)
+
+func main() {
+	if h := hypot(3, 4); h != 5 {
+		panic(h)
+	}
+	if q, r, err := divmod(7, 2); q != 3 || r != 1 || err != nil {
+		panic("divmod(7, 2)")
+	}
+	if q, r, err := divmod(1, 0); q != 0 || r != 0 || err == nil || err.Error() != "division by zero" {
+		panic("divmod(1, 0)")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"go/ast"
 	"go/token"
 
+	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/types"
 )
 
@@ -171,6 +172,173 @@ func (f *Function) ValueForExpr(e ast.Expr) (value Value, isAddr bool) {
 	return
 }
 
+// FunctionAt returns the innermost function of pkg that contains the
+// position pos within file, or nil if there is none.  It is a
+// convenience wrapper around EnclosingFunction, and has the same
+// preconditions.
+//
+func FunctionAt(pkg *Package, file *ast.File, pos token.Pos) *Function {
+	path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+	if path == nil {
+		return nil
+	}
+	return EnclosingFunction(pkg, path)
+}
+
+// InstructionsForNode returns, in order, the instructions of f that
+// arose from the operation of syntax node n itself, as opposed to those
+// of its subexpressions: those whose Pos() is the token designated
+// for n (see Value.Pos).  The instructions of anonymous functions
+// within f are not included; a function literal within f gives rise
+// to the MakeClosure instruction, if any, that creates its closure.
+//
+// The Sel identifier of a selector expression has the same position as
+// the selector; instructions at that position, such as FieldAddr,
+// are attributed to both.
+//
+// Positions are recorded even when f was not built in debug mode,
+// but some instructions, such as implicit loads and stores, have no
+// position or an unspecified one, and are attributed to no node or to
+// an unexpected one.
+//
+func (f *Function) InstructionsForNode(n ast.Node) []Instruction {
+	var instrs []Instruction
+	posns := designatedPositions(n)
+	if posns == nil {
+		return nil
+	}
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if _, ok := instr.(*DebugRef); ok {
+				continue
+			}
+			if pos := instr.Pos(); pos.IsValid() {
+				for _, p := range posns {
+					if pos == p {
+						instrs = append(instrs, instr)
+						break
+					}
+				}
+			}
+		}
+	}
+	return instrs
+}
+
+// ValuesForNode returns the values computed by f for syntax node n: the
+// values defined by InstructionsForNode(n) and, if f was built in debug
+// mode, the value that ValueForExpr reports for n, if any.
+//
+func (f *Function) ValuesForNode(n ast.Node) []Value {
+	var values []Value
+	for _, instr := range f.InstructionsForNode(n) {
+		if v, ok := instr.(Value); ok {
+			values = append(values, v)
+		}
+	}
+	if e, ok := n.(ast.Expr); ok {
+		if v, _ := f.ValueForExpr(e); v != nil {
+			for _, w := range values {
+				if w == v {
+					return values
+				}
+			}
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// PathEnclosingInstruction returns the path from the syntax node of
+// file from which instruction instr arose, as determined by its
+// position, to the root of file, in the manner of
+// astutil.PathEnclosingInterval.  It is the inverse of
+// InstructionsForNode; for a DebugRef, the first element of the path
+// is the referenced expression.
+//
+// PathEnclosingInstruction returns nil if instr has no position or if
+// no node of file is designated by its position.
+//
+func PathEnclosingInstruction(file *ast.File, instr Instruction) []ast.Node {
+	if ref, ok := instr.(*DebugRef); ok {
+		path, _ := astutil.PathEnclosingInterval(file, ref.Expr.Pos(), ref.Expr.End())
+		for i, n := range path {
+			if n == ref.Expr {
+				return path[i:]
+			}
+		}
+		return nil
+	}
+	pos := instr.Pos()
+	if !pos.IsValid() {
+		return nil
+	}
+	path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+	for i, n := range path {
+		if sel, ok := n.(*ast.Ident); ok && i+1 < len(path) {
+			if parent, ok := path[i+1].(*ast.SelectorExpr); ok && parent.Sel == sel {
+				continue // attribute to the selector
+			}
+		}
+		for _, p := range designatedPositions(n) {
+			if p == pos {
+				return path[i:]
+			}
+		}
+	}
+	return nil
+}
+
+// designatedPositions returns the positions of the tokens designated
+// for the operations of syntax node n, as documented for the Pos
+// methods of the Values and Instructions that may arise from them.
+//
+func designatedPositions(n ast.Node) []token.Pos {
+	switch n := n.(type) {
+	case *ast.Ident:
+		return []token.Pos{n.NamePos}
+	case *ast.CallExpr:
+		return []token.Pos{n.Lparen, n.Rparen}
+	case *ast.BinaryExpr:
+		return []token.Pos{n.OpPos}
+	case *ast.UnaryExpr:
+		return []token.Pos{n.OpPos}
+	case *ast.StarExpr:
+		return []token.Pos{n.Star}
+	case *ast.CompositeLit:
+		return []token.Pos{n.Lbrace}
+	case *ast.KeyValueExpr:
+		return []token.Pos{n.Colon}
+	case *ast.IndexExpr:
+		return []token.Pos{n.Lbrack}
+	case *ast.SliceExpr:
+		return []token.Pos{n.Lbrack}
+	case *ast.SelectorExpr:
+		return []token.Pos{n.Sel.Pos()}
+	case *ast.TypeAssertExpr:
+		return []token.Pos{n.Lparen}
+	case *ast.FuncLit:
+		return []token.Pos{n.Type.Func}
+	case *ast.AssignStmt:
+		return []token.Pos{n.TokPos}
+	case *ast.IncDecStmt:
+		return []token.Pos{n.TokPos}
+	case *ast.SendStmt:
+		return []token.Pos{n.Arrow}
+	case *ast.ReturnStmt:
+		return []token.Pos{n.Return}
+	case *ast.GoStmt:
+		return []token.Pos{n.Go}
+	case *ast.DeferStmt:
+		return []token.Pos{n.Defer}
+	case *ast.SelectStmt:
+		return []token.Pos{n.Select}
+	case *ast.RangeStmt:
+		return []token.Pos{n.For}
+	}
+	return nil
+}
+
 // --- Lookup functions for source-level named entities (types.Objects) ---
 
 // Package returns the SSA Package corresponding to the specified
@@ -382,3 +382,100 @@ func TestEnclosingFunction(t *testing.T) {
 		}
 	}
 }
+
+func TestSourceMapping(t *testing.T) {
+	const input = `package main
+
+func f(m map[string]int, p *struct{ x int }) int {
+	s := []int{1, 2}
+	n := len(s) + m["k"]
+	p.x = n
+	g := func() int { return n }
+	return g() * 2
+}
+`
+	conf := loader.Config{Fset: token.NewFileSet()}
+	f, err := conf.ParseFile("input.go", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := ssautil.CreateProgram(iprog, 0)
+	pkg := prog.Package(iprog.Created[0].Pkg)
+	pkg.Build()
+
+	// FunctionAt finds the innermost function.
+	for _, test := range []struct{ substr, fn string }{
+		{"len(s)", "main.f"},
+		{"return n", "main.f$1"},
+		{"package", "(none)"},
+	} {
+		pos := f.Pos() + token.Pos(strings.Index(input, test.substr))
+		name := "(none)"
+		if fn := ssa.FunctionAt(pkg, f, pos); fn != nil {
+			name = fn.String()
+		}
+		if name != test.fn {
+			t.Errorf("FunctionAt(%q) = %s, want %s", test.substr, name, test.fn)
+		}
+	}
+
+	// Each instruction with a position maps to a node that maps
+	// back to the instruction.
+	fn := pkg.Func("f")
+	nodes := make(map[string]bool) // "kind: node" for each instruction
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			path := ssa.PathEnclosingInstruction(f, instr)
+			if path == nil {
+				continue
+			}
+			found := false
+			for _, x := range fn.InstructionsForNode(path[0]) {
+				if x == instr {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("InstructionsForNode(PathEnclosingInstruction(%s)) does not contain it", instr)
+			}
+			kind := strings.TrimPrefix(fmt.Sprintf("%T", instr), "*ssa.")
+			start := conf.Fset.Position(path[0].Pos()).Offset
+			end := conf.Fset.Position(path[0].End()).Offset
+			nodes[kind+": "+input[start:end]] = true
+		}
+	}
+	for _, want := range []string{
+		"Alloc: []int{1, 2}",
+		"Alloc: n",
+		"Call: g()",
+		`Lookup: m["k"]`,
+		"MakeClosure: func() int { return n }",
+		"FieldAddr: p.x",
+		"Return: return g() * 2",
+	} {
+		if !nodes[want] {
+			t.Errorf("no instruction maps to node: %s", want)
+		}
+	}
+
+	// ValuesForNode finds the values of an expression.
+	var mul *ast.BinaryExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if b, ok := n.(*ast.BinaryExpr); ok && b.Op == token.MUL {
+			mul = b
+		}
+		return true
+	})
+	values := fn.ValuesForNode(mul)
+	if len(values) != 1 {
+		t.Fatalf("ValuesForNode(g() * 2) = %v, want a single value", values)
+	}
+	if b, ok := values[0].(*ssa.BinOp); !ok || b.Op != token.MUL {
+		t.Errorf("ValuesForNode(g() * 2) = %s, want multiplication", values[0])
+	}
+}
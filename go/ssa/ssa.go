@@ -301,6 +301,7 @@ type Function struct {
 	name      string
 	object    types.Object     // a declared *types.Func or one of its wrappers
 	method    *types.Selection // info about provenance of synthetic methods
+	wrapper   WrapperKind      // kind of synthetic wrapper, if any
 	Signature *types.Signature
 	pos       token.Pos
 
@@ -0,0 +1,230 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil
+
+// This file implements a check of the propagation of contexts, the
+// values that carry deadlines, cancelation, and request-scoped data
+// across API boundaries.
+//
+// A function that receives a context as its first parameter should
+// pass it, or a context derived from it, to the functions it calls
+// that take one, so that they are canceled with it.  The check
+// reports calls that instead pass a new context, as returned by
+// Background or TODO, or nil, or any other context not derived from
+// the function's own.  A context is derived from another if it is the
+// result of a call with it as argument, such as WithCancel(ctx).
+// Calls of the functions of the context packages themselves are not
+// checked, as they are the means of derivation.
+//
+// Contexts are recognized by type: a context is a value of a named
+// type Context declared in one of a set of packages, so that contexts
+// of packages that predate the standard one, or wrap it, are
+// recognized too.
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+// DefaultContextPackages are the import paths of the context packages
+// recognized by ContextDrops by default.
+var DefaultContextPackages = []string{"context", "golang.org/x/net/context"}
+
+// A ContextDrop is a call that fails to propagate the context of the
+// function that makes it.
+type ContextDrop struct {
+	Func   *ssa.Function // the function whose context is dropped
+	Call   ssa.CallInstruction
+	Reason string // e.g. "passes context.Background()", "passes nil"
+}
+
+// Pos returns the position of the call.
+func (d *ContextDrop) Pos() token.Pos { return d.Call.Pos() }
+
+func (d *ContextDrop) String() string {
+	return fmt.Sprintf("%s: call %s", d.Func, d.Reason)
+}
+
+// ContextDrops returns the calls made by the specified functions that
+// pass to a function taking a context as its first parameter a
+// context not derived from that received by the caller as its own
+// first parameter, in order of position.
+//
+// A context is a value of a named type Context declared in a package
+// whose import path is among pkgs, or, if pkgs is nil, among
+// DefaultContextPackages.  A path ending in "/..." matches any path
+// with the preceding prefix, and the prefix itself.
+//
+// Precondition: the functions are built.
+//
+func ContextDrops(fns map[*ssa.Function]bool, pkgs []string) []*ContextDrop {
+	if pkgs == nil {
+		pkgs = DefaultContextPackages
+	}
+	c := contextChecker{pkgs}
+	var drops []*ContextDrop
+	for fn := range fns {
+		ctx := c.contextParam(fn)
+		if ctx == nil || c.isContextPackage(fn.Pkg) {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				if callee := call.Common().StaticCallee(); callee != nil && c.isContextPackage(callee.Pkg) {
+					continue // a derivation, such as WithCancel(ctx)
+				}
+				arg := c.contextArg(call.Common())
+				if arg == nil {
+					continue
+				}
+				if origin := c.origin(arg, ctx, make(map[ssa.Value]bool)); origin != "" {
+					drops = append(drops, &ContextDrop{Func: fn, Call: call, Reason: "passes " + origin})
+				}
+			}
+		}
+	}
+	sort.Sort(byDropPos(drops))
+	return drops
+}
+
+type byDropPos []*ContextDrop
+
+func (b byDropPos) Len() int           { return len(b) }
+func (b byDropPos) Less(i, j int) bool { return b[i].Pos() < b[j].Pos() }
+func (b byDropPos) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type contextChecker struct {
+	pkgs []string // patterns of import paths of context packages
+}
+
+// matchesPath reports whether the import path matches one of the
+// patterns of c.pkgs.
+func (c contextChecker) matchesPath(path string) bool {
+	for _, pattern := range c.pkgs {
+		if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (c contextChecker) isContextPackage(pkg *ssa.Package) bool {
+	return pkg != nil && c.matchesPath(pkg.Object.Path())
+}
+
+// isContext reports whether T is a context type.
+func (c contextChecker) isContext(T types.Type) bool {
+	named, ok := T.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == "Context" && obj.Pkg() != nil && c.matchesPath(obj.Pkg().Path())
+}
+
+// contextParam returns the parameter of fn holding the context it
+// receives as its first parameter, or nil if it receives none.
+func (c contextChecker) contextParam(fn *ssa.Function) *ssa.Parameter {
+	params := fn.Signature.Params()
+	if params.Len() == 0 || !c.isContext(params.At(0).Type()) {
+		return nil
+	}
+	if fn.Signature.Recv() != nil {
+		return fn.Params[1]
+	}
+	return fn.Params[0]
+}
+
+// contextArg returns the argument of the call passed as the context
+// first parameter of the callee, or nil if it takes none.
+func (c contextChecker) contextArg(call *ssa.CallCommon) ssa.Value {
+	sig := call.Signature()
+	if sig.Params().Len() == 0 || !c.isContext(sig.Params().At(0).Type()) {
+		return nil
+	}
+	if !call.IsInvoke() && sig.Recv() != nil {
+		return call.Args[1] // Args[0] is the receiver
+	}
+	return call.Args[0]
+}
+
+// origin returns a description of the origin of the context v if it
+// is not derived from ctx, or "" if it is.  Contexts that may come
+// from either are not derived.
+func (c contextChecker) origin(v ssa.Value, ctx *ssa.Parameter, seen map[ssa.Value]bool) string {
+	if seen[v] {
+		return ""
+	}
+	seen[v] = true
+	switch v := v.(type) {
+	case *ssa.Parameter:
+		if v == ctx || c.isContext(v.Type()) {
+			return ""
+		}
+	case *ssa.Const:
+		if v.IsNil() {
+			return "nil"
+		}
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			if origin := c.origin(edge, ctx, seen); origin != "" {
+				return origin
+			}
+		}
+		return ""
+	case *ssa.ChangeType:
+		return c.origin(v.X, ctx, seen)
+	case *ssa.ChangeInterface:
+		return c.origin(v.X, ctx, seen)
+	case *ssa.MakeInterface:
+		return c.origin(v.X, ctx, seen)
+	case *ssa.TypeAssert:
+		return c.origin(v.X, ctx, seen)
+	case *ssa.Extract:
+		if call, ok := v.Tuple.(*ssa.Call); ok {
+			return c.callOrigin(call, ctx, seen)
+		}
+	case *ssa.Call:
+		return c.callOrigin(v, ctx, seen)
+	}
+	return "a context not derived from " + ctx.Name()
+}
+
+// callOrigin returns the origin of the context returned by call.
+func (c contextChecker) callOrigin(call *ssa.Call, ctx *ssa.Parameter, seen map[ssa.Value]bool) string {
+	common := call.Common()
+	if callee := common.StaticCallee(); callee != nil && c.isContextPackage(callee.Pkg) {
+		switch callee.Name() {
+		case "Background", "TODO":
+			return fmt.Sprintf("%s.%s()", callee.Pkg.Object.Name(), callee.Name())
+		}
+	}
+	derived := false
+	for _, arg := range common.Args {
+		if c.isContext(arg.Type()) {
+			if origin := c.origin(arg, ctx, seen); origin != "" {
+				return origin
+			}
+			derived = true
+		}
+	}
+	if !derived {
+		return "a context not derived from " + ctx.Name()
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil_test
+
+import (
+	"go/parser"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+const oldContext = `package context
+
+type Context interface {
+	Done() <-chan struct{}
+}
+
+type emptyCtx int
+
+func (emptyCtx) Done() <-chan struct{} { return nil }
+
+func Background() Context { return emptyCtx(0) }
+func TODO() Context       { return emptyCtx(1) }
+
+type cancelCtx struct{ Context }
+
+func WithCancel(parent Context) (Context, func()) {
+	return &cancelCtx{parent}, func() {}
+}
+
+type valueCtx struct {
+	Context
+	key, val interface{}
+}
+
+func WithValue(parent Context, key, val interface{}) Context {
+	return &valueCtx{parent, key, val}
+}
+`
+
+func TestContextDrops(t *testing.T) {
+	src, err := ioutil.ReadFile("testdata/context.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := loader.Config{
+		Build:      buildutil.FakeContext(map[string]map[string]string{"old/context": {"context.go": oldContext}}),
+		ParserMode: parser.ParseComments,
+	}
+	f, err := conf.ParseFile("testdata/context.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prog := ssautil.CreateProgram(iprog, 0)
+	prog.BuildAll()
+
+	want := make(map[int]string)
+	for _, c := range f.Comments {
+		text := strings.TrimSpace(c.Text())
+		if strings.HasPrefix(text, "drop: ") {
+			want[prog.Fset.Position(c.Pos()).Line] = strings.TrimPrefix(text, "drop: ")
+		}
+	}
+
+	fns := make(map[*ssa.Function]bool)
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg != nil && fn.Pkg.Object.Path() == "main" {
+			fns[fn] = true
+		}
+	}
+	got := make(map[int]string)
+	for _, d := range ssautil.ContextDrops(fns, []string{"old/..."}) {
+		got[prog.Fset.Position(d.Pos()).Line] = d.Reason
+	}
+	for line, reason := range want {
+		if got[line] != reason {
+			t.Errorf("line %d: got drop %q, want %q", line, got[line], reason)
+		}
+	}
+	for line, reason := range got {
+		if want[line] == "" {
+			t.Errorf("line %d: unexpected drop %q", line, reason)
+		}
+	}
+
+	// The default context packages do not include old/context.
+	if drops := ssautil.ContextDrops(fns, nil); drops != nil {
+		t.Errorf("got %d drops with the default context packages, want none", len(drops))
+	}
+}
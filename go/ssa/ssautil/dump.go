@@ -0,0 +1,264 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil
+
+// This file defines a canonical textual dump of the SSA form of a
+// package, and a parser for it, for use in golden-file tests.
+//
+// Unlike ssa.WriteFunction, whose output is intended for human
+// readers, the dump contains no source positions or alignment
+// padding, and it lists the functions of a package in a fixed order:
+// so long as the input is the same, so is the dump, regardless of
+// file names, builder mode, or the order in which packages are built.
+//
+// The dump of each function has this form:
+//
+//	func NAME(PARAMS) RESULTS
+//	free NAME TYPE                  (for each free variable)
+//	INDEX:<tab>COMMENT<tab><- PREDS<tab>-> SUCCS  (for each block)
+//	<tab>NAME = INSTRUCTION<tab>TYPE  (for an instruction that is a value)
+//	<tab>INSTRUCTION                  (for other instructions)
+//
+// where PREDS and SUCCS are space-separated block indices, and it is
+// terminated by a blank line.  Types are written relative to the
+// function's package.  External functions have no blocks.
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+// Dump writes to w the canonical dump of each function of pkg: its
+// package-level functions including init, the methods it declares,
+// and, after each of these, its anonymous functions, recursively.
+// The functions are ordered by name.  Synthetic wrappers, which
+// belong to no package, are not included.
+//
+// Precondition: pkg is built.
+//
+func Dump(w io.Writer, pkg *ssa.Package) error {
+	var funcs []*ssa.Function
+	for _, mem := range pkg.Members {
+		switch mem := mem.(type) {
+		case *ssa.Function:
+			funcs = append(funcs, mem)
+		case *ssa.Type:
+			for _, T := range []types.Type{mem.Type(), types.NewPointer(mem.Type())} {
+				mset := pkg.Prog.MethodSets.MethodSet(T)
+				for i, n := 0, mset.Len(); i < n; i++ {
+					if fn := pkg.Prog.Method(mset.At(i)); fn != nil && fn.Pkg == pkg {
+						funcs = append(funcs, fn)
+					}
+				}
+			}
+		}
+	}
+	sort.Sort(byName(funcs))
+
+	var buf bytes.Buffer
+	var dump func(fn *ssa.Function)
+	dump = func(fn *ssa.Function) {
+		DumpFunction(&buf, fn)
+		for _, anon := range fn.AnonFuncs {
+			dump(anon)
+		}
+	}
+	for i, fn := range funcs {
+		if i > 0 && funcs[i-1] == fn {
+			continue // a method of both T and *T
+		}
+		dump(fn)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type byName []*ssa.Function
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Less(i, j int) bool { return s[i].String() < s[j].String() }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// DumpFunction writes to buf the canonical dump of fn.
+func DumpFunction(buf *bytes.Buffer, fn *ssa.Function) {
+	var from *types.Package
+	if fn.Pkg != nil {
+		from = fn.Pkg.Object
+	}
+	fmt.Fprintf(buf, "func %s", fn.RelString(from))
+	types.WriteSignature(buf, from, fn.Signature)
+	buf.WriteByte('\n')
+	for _, fv := range fn.FreeVars {
+		fmt.Fprintf(buf, "free %s %s\n", fv.Name(), types.TypeString(from, fv.Type()))
+	}
+	for _, b := range fn.Blocks {
+		fmt.Fprintf(buf, "%d:\t%s\t<-%s\t->%s\n", b.Index, b.Comment, indices(b.Preds), indices(b.Succs))
+		for _, instr := range b.Instrs {
+			if v, ok := instr.(ssa.Value); ok && v.Type() != nil {
+				fmt.Fprintf(buf, "\t%s = %s\t%s\n", v.Name(), instr, types.TypeString(from, v.Type()))
+			} else {
+				fmt.Fprintf(buf, "\t%s\n", instr)
+			}
+		}
+	}
+	buf.WriteByte('\n')
+}
+
+func indices(blocks []*ssa.BasicBlock) string {
+	var buf bytes.Buffer
+	for _, b := range blocks {
+		fmt.Fprintf(&buf, " %d", b.Index)
+	}
+	return buf.String()
+}
+
+// A DumpedFunction is a function read from a canonical dump.
+type DumpedFunction struct {
+	Name      string // e.g. "(*T).f"
+	Signature string // e.g. "(x int) bool"
+	FreeVars  []DumpedVar
+	Blocks    []*DumpedBlock
+}
+
+// A DumpedVar is a free variable of a DumpedFunction.
+type DumpedVar struct {
+	Name, Type string
+}
+
+// A DumpedBlock is a basic block of a DumpedFunction.
+type DumpedBlock struct {
+	Index        int
+	Comment      string
+	Preds, Succs []int // block indices
+	Instrs       []DumpedInstr
+}
+
+// A DumpedInstr is an instruction of a DumpedBlock.  Name and Type
+// are empty unless the instruction is a value.
+type DumpedInstr struct {
+	Name, Text, Type string
+}
+
+// ParseDump parses a canonical dump, as written by Dump or
+// DumpFunction, and returns its functions in order.
+func ParseDump(r io.Reader) ([]*DumpedFunction, error) {
+	var funcs []*DumpedFunction
+	var fn *DumpedFunction // current function, or nil
+	var b *DumpedBlock     // current block, or nil
+	in := bufio.NewScanner(r)
+	for line := 1; in.Scan(); line++ {
+		text := in.Text()
+		errorf := func(format string, args ...interface{}) error {
+			return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case text == "":
+			fn, b = nil, nil
+
+		case fn == nil:
+			if !strings.HasPrefix(text, "func ") {
+				return nil, errorf("want func, got %q", text)
+			}
+			text = text[len("func "):]
+			i := signatureStart(text)
+			if i < 0 {
+				return nil, errorf("no signature in %q", text)
+			}
+			fn = &DumpedFunction{Name: text[:i], Signature: text[i:]}
+			funcs = append(funcs, fn)
+
+		case strings.HasPrefix(text, "free "):
+			if fn.Blocks != nil {
+				return nil, errorf("free variable after blocks")
+			}
+			fields := strings.SplitN(text[len("free "):], " ", 2)
+			if len(fields) != 2 {
+				return nil, errorf("malformed free variable %q", text)
+			}
+			fn.FreeVars = append(fn.FreeVars, DumpedVar{fields[0], fields[1]})
+
+		case strings.HasPrefix(text, "\t"):
+			if b == nil {
+				return nil, errorf("instruction outside block")
+			}
+			var instr DumpedInstr
+			text = text[1:]
+			if i := strings.LastIndex(text, "\t"); i >= 0 {
+				j := strings.Index(text, " = ")
+				if j < 0 || j > i {
+					return nil, errorf("malformed value %q", text)
+				}
+				instr = DumpedInstr{Name: text[:j], Text: text[j+len(" = ") : i], Type: text[i+1:]}
+			} else {
+				instr.Text = text
+			}
+			b.Instrs = append(b.Instrs, instr)
+
+		default:
+			fields := strings.Split(text, "\t")
+			if len(fields) != 4 ||
+				!strings.HasSuffix(fields[0], ":") ||
+				!strings.HasPrefix(fields[2], "<-") ||
+				!strings.HasPrefix(fields[3], "->") {
+				return nil, errorf("malformed block header %q", text)
+			}
+			index, err := strconv.Atoi(strings.TrimSuffix(fields[0], ":"))
+			if err != nil || index != len(fn.Blocks) {
+				return nil, errorf("bad block index %q", fields[0])
+			}
+			b = &DumpedBlock{Index: index, Comment: fields[1]}
+			if b.Preds, err = parseIndices(fields[2][len("<-"):]); err != nil {
+				return nil, errorf("bad predecessors: %s", err)
+			}
+			if b.Succs, err = parseIndices(fields[3][len("->"):]); err != nil {
+				return nil, errorf("bad successors: %s", err)
+			}
+			fn.Blocks = append(fn.Blocks, b)
+		}
+	}
+	if err := in.Err(); err != nil {
+		return nil, err
+	}
+	return funcs, nil
+}
+
+// signatureStart returns the index of the parenthesis at which the
+// signature begins in s, the remainder of a func line, or -1.  A
+// method name such as "(*T).f" itself contains parentheses.
+func signatureStart(s string) int {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			if depth == 0 && i > 0 {
+				return i
+			}
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	return -1
+}
+
+func parseIndices(s string) ([]int, error) {
+	var indices []int
+	for _, f := range strings.Fields(s) {
+		i, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, err
+		}
+		indices = append(indices, i)
+	}
+	return indices, nil
+}
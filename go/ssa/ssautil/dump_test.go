@@ -0,0 +1,141 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil_test
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+const dumpSrc = `package p
+
+type T struct{ n int }
+
+func (t *T) inc() { t.n++ }
+
+func (t T) get() int { return t.n }
+
+func sum(xs []int) int {
+	s := 0
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+func counter() func() int {
+	var n int
+	return func() int {
+		n++
+		return n
+	}
+}
+`
+
+// dump builds dumpSrc, in a file of the given name, and returns the
+// canonical dump of its package.
+func dump(t *testing.T, filename string, mode ssa.BuilderMode) []byte {
+	var conf loader.Config
+	f, err := conf.ParseFile(filename, dumpSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("p", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := ssautil.CreateProgram(iprog, mode)
+	prog.BuildAll()
+
+	var buf bytes.Buffer
+	if err := ssautil.Dump(&buf, prog.Package(iprog.Created[0].Pkg)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDump(t *testing.T) {
+	d1 := dump(t, "a.go", ssa.BuildSerially)
+	for i := 0; i < 3; i++ {
+		if d2 := dump(t, "b.go", 0); !bytes.Equal(d1, d2) {
+			t.Fatalf("dumps differ:\n%s\nvs:\n%s", d1, d2)
+		}
+	}
+
+	funcs, err := ssautil.ParseDump(bytes.NewReader(d1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fn := range funcs {
+		names = append(names, fn.Name+fn.Signature)
+	}
+	const want = "(*T).inc(); (T).get() int; counter() func() int; counter$1() int; init(); sum(xs []int) int"
+	if got := strings.Join(names, "; "); got != want {
+		t.Errorf("dumped functions: got %q, want %q", got, want)
+	}
+
+	sum := funcs[len(funcs)-1]
+	if got := sum.Blocks[0]; got.Comment != "entry" || got.Preds != nil || !reflect.DeepEqual(got.Succs, []int{1}) {
+		t.Errorf("sum: got entry block %+v", got)
+	}
+	if got, want := funcs[3].FreeVars, []ssautil.DumpedVar{{"n", "*int"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("counter$1: got free variables %v, want %v", got, want)
+	}
+	var found bool
+	for _, b := range sum.Blocks {
+		for _, instr := range b.Instrs {
+			if strings.HasPrefix(instr.Text, "phi ") && instr.Type == "int" && instr.Name != "" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Errorf("sum: no φ-node of type int in %+v", sum.Blocks)
+	}
+
+	// Dump and ParseDump are inverses.
+	var buf bytes.Buffer
+	for _, fn := range funcs {
+		buf.WriteString("func " + fn.Name + fn.Signature + "\n")
+		for _, fv := range fn.FreeVars {
+			buf.WriteString("free " + fv.Name + " " + fv.Type + "\n")
+		}
+		for _, b := range fn.Blocks {
+			buf.WriteString(strings.Join([]string{strconv.Itoa(b.Index) + ":", b.Comment, "<-" + itoas(b.Preds), "->" + itoas(b.Succs)}, "\t") + "\n")
+			for _, instr := range b.Instrs {
+				if instr.Name != "" {
+					buf.WriteString("\t" + instr.Name + " = " + instr.Text + "\t" + instr.Type + "\n")
+				} else {
+					buf.WriteString("\t" + instr.Text + "\n")
+				}
+			}
+		}
+		buf.WriteString("\n")
+	}
+	if !bytes.Equal(buf.Bytes(), d1) {
+		t.Errorf("reconstructed dump differs:\n%s\nvs:\n%s", buf.Bytes(), d1)
+	}
+
+	if _, err := ssautil.ParseDump(strings.NewReader("func f()\n0:\tentry\n")); err == nil {
+		t.Errorf("ParseDump accepted a malformed block header")
+	}
+}
+
+func itoas(indices []int) string {
+	var buf bytes.Buffer
+	for _, i := range indices {
+		buf.WriteString(" " + strconv.Itoa(i))
+	}
+	return buf.String()
+}
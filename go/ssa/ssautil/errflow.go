@@ -0,0 +1,370 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil
+
+// This file implements an analysis of the flow of error values
+// between functions.
+//
+// The analysis traces, within each function, the error results of its
+// return statements back to their origins: through φ-nodes, to the
+// result of a call, which the function propagates; to a conversion to
+// the error interface, or a load of a (sentinel) variable, which it
+// originates; or to a parameter, which it merely passes through.  A
+// call or conversion whose error-typed operands, or, for the
+// conversion of a pointer to a composite literal, whose error-typed
+// fields, are themselves the results of calls wraps those errors: a
+// call of fmt.Errorf("...: %v", err) or the construction of a
+// &PathError{Err: err} does.  The error results of calls are swallowed
+// if they are not used, or only compared with nil.
+//
+// Errors are recognized by type: only values of the predeclared
+// interface type error, not those of concrete types that implement
+// it, are traced.
+
+import (
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// An ErrorGraph describes the flow of error values among a set of
+// functions.
+type ErrorGraph struct {
+	Funcs     map[*ssa.Function]*ErrorFunc
+	Swallowed []*Swallow // in order of position
+}
+
+// An ErrorFunc describes the errors a function returns.
+type ErrorFunc struct {
+	Func       *ssa.Function
+	Originates []ssa.Value  // values that originate the errors it returns, such as conversions to error
+	Edges      []*ErrorEdge // calls whose errors it returns, in the order of its instructions
+}
+
+// An ErrorEdge is a call whose error result a function returns,
+// unchanged or wrapped.
+type ErrorEdge struct {
+	Call    ssa.CallInstruction
+	Callee  *ssa.Function // the static callee, or nil for a dynamic call
+	Wrapped bool          // the error is wrapped, not returned as is
+}
+
+// A Swallow is a call whose error result is dropped.
+type Swallow struct {
+	Call   ssa.CallInstruction
+	Reason string // "ignored", "checked but dropped", "deferred", or "discarded by go"
+}
+
+// Pos returns the position of the call.
+func (s *Swallow) Pos() token.Pos { return s.Call.Pos() }
+
+// ErrorFlow returns the graph of the flow of error values among the
+// specified functions.  Each function that returns an error has an
+// ErrorFunc in the graph.
+//
+// Precondition: the functions are built.
+//
+func ErrorFlow(fns map[*ssa.Function]bool) *ErrorGraph {
+	g := &ErrorGraph{Funcs: make(map[*ssa.Function]*ErrorFunc)}
+	for fn := range fns {
+		if returnsError(fn.Signature) {
+			g.Funcs[fn] = errorFunc(fn)
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if call, ok := instr.(ssa.CallInstruction); ok {
+					if reason := swallowed(call); reason != "" {
+						g.Swallowed = append(g.Swallowed, &Swallow{Call: call, Reason: reason})
+					}
+				}
+			}
+		}
+	}
+	sort.Sort(byPos(g.Swallowed))
+	return g
+}
+
+type byPos []*Swallow
+
+func (b byPos) Len() int           { return len(b) }
+func (b byPos) Less(i, j int) bool { return b[i].Pos() < b[j].Pos() }
+func (b byPos) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// Origins returns the functions of the graph that originate errors
+// that fn may return, directly or by propagating those of its
+// callees, in order of name.  Dynamic calls are not followed.
+func (g *ErrorGraph) Origins(fn *ssa.Function) []*ssa.Function {
+	seen := make(map[*ssa.Function]bool)
+	var origins []*ssa.Function
+	var visit func(fn *ssa.Function)
+	visit = func(fn *ssa.Function) {
+		if seen[fn] {
+			return
+		}
+		seen[fn] = true
+		ef := g.Funcs[fn]
+		if ef == nil {
+			return
+		}
+		if ef.Originates != nil {
+			origins = append(origins, fn)
+		}
+		for _, e := range ef.Edges {
+			if e.Callee != nil {
+				visit(e.Callee)
+			}
+		}
+	}
+	visit(fn)
+	sort.Sort(byFuncName(origins))
+	return origins
+}
+
+type byFuncName []*ssa.Function
+
+func (b byFuncName) Len() int           { return len(b) }
+func (b byFuncName) Less(i, j int) bool { return b[i].String() < b[j].String() }
+func (b byFuncName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// returnsError reports whether a function of signature sig returns
+// an error.
+func returnsError(sig *types.Signature) bool {
+	res := sig.Results()
+	for i := 0; i < res.Len(); i++ {
+		if types.Identical(res.At(i).Type(), errorType) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorFunc traces the errors returned by fn.
+func errorFunc(fn *ssa.Function) *ErrorFunc {
+	ef := &ErrorFunc{Func: fn}
+	seen := make(map[ssa.Value]bool)
+	for _, b := range fn.Blocks {
+		if ret, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok {
+			for _, v := range ret.Results {
+				if types.Identical(v.Type(), errorType) {
+					ef.trace(v, seen)
+				}
+			}
+		}
+	}
+	return ef
+}
+
+// trace records the origin of the error value v.
+func (ef *ErrorFunc) trace(v ssa.Value, seen map[ssa.Value]bool) {
+	if seen[v] {
+		return
+	}
+	seen[v] = true
+	switch v := v.(type) {
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			ef.trace(edge, seen)
+		}
+		return
+	case *ssa.Const, *ssa.Parameter:
+		return // nil, or passed through
+	case *ssa.ChangeInterface:
+		ef.trace(v.X, seen)
+		return
+	case *ssa.Extract:
+		if call, ok := v.Tuple.(*ssa.Call); ok {
+			ef.call(call, seen)
+			return
+		}
+	case *ssa.Call:
+		ef.call(v, seen)
+		return
+	case *ssa.MakeInterface:
+		if causes, ok := wrappedCalls(v, seen); ok {
+			ef.wrap(causes)
+			return
+		}
+	}
+	ef.Originates = append(ef.Originates, v)
+}
+
+// call records the propagation of the errors of call, or, if call
+// wraps errors of other calls, their wrapping.
+func (ef *ErrorFunc) call(call *ssa.Call, seen map[ssa.Value]bool) {
+	if causes, ok := wrappedCalls(call, seen); ok {
+		ef.wrap(causes)
+		return
+	}
+	ef.Edges = append(ef.Edges, &ErrorEdge{Call: call, Callee: call.Common().StaticCallee()})
+}
+
+func (ef *ErrorFunc) wrap(causes []*ssa.Call) {
+	for _, call := range causes {
+		ef.Edges = append(ef.Edges, &ErrorEdge{Call: call, Callee: call.Common().StaticCallee(), Wrapped: true})
+	}
+}
+
+// wrappedCalls reports whether the call or conversion v wraps errors:
+// whether any of the arguments of the call, variadic ones included,
+// or of the values stored in the composite literal it converts, is an
+// error.  It returns the calls whose error results those errors may
+// be.
+func wrappedCalls(v ssa.Value, seen map[ssa.Value]bool) ([]*ssa.Call, bool) {
+	var operands []ssa.Value // candidate wrapped errors
+	switch v := v.(type) {
+	case *ssa.Call:
+		for _, arg := range v.Call.Args {
+			if s, ok := arg.(*ssa.Slice); ok {
+				operands = append(operands, stored(s.X)...) // variadic arguments
+			} else {
+				operands = append(operands, arg)
+			}
+		}
+	case *ssa.MakeInterface:
+		operands = stored(v.X)
+	}
+	var causes []*ssa.Call
+	wraps := false
+	for _, op := range operands {
+		if ci, ok := op.(*ssa.MakeInterface); ok {
+			op = ci.X
+		} else if ci, ok := op.(*ssa.ChangeInterface); ok {
+			op = ci.X
+		}
+		if !types.Identical(op.Type(), errorType) {
+			continue
+		}
+		wraps = true
+		causes = append(causes, errorCalls(op, seen)...)
+	}
+	return causes, wraps
+}
+
+// stored returns the values stored in the fields or elements of the
+// allocation addr.
+func stored(addr ssa.Value) []ssa.Value {
+	if _, ok := addr.(*ssa.Alloc); !ok {
+		return nil
+	}
+	var values []ssa.Value
+	for _, ref := range *addr.Referrers() {
+		var a ssa.Value
+		switch ref := ref.(type) {
+		case *ssa.FieldAddr:
+			a = ref
+		case *ssa.IndexAddr:
+			a = ref
+		default:
+			continue
+		}
+		for _, use := range *a.Referrers() {
+			if store, ok := use.(*ssa.Store); ok && store.Addr == a {
+				values = append(values, store.Val)
+			}
+		}
+	}
+	return values
+}
+
+// errorCalls returns the calls whose error result the error value v
+// may be.
+func errorCalls(v ssa.Value, seen map[ssa.Value]bool) []*ssa.Call {
+	if seen[v] {
+		return nil
+	}
+	seen[v] = true
+	switch v := v.(type) {
+	case *ssa.Phi:
+		var calls []*ssa.Call
+		for _, edge := range v.Edges {
+			calls = append(calls, errorCalls(edge, seen)...)
+		}
+		return calls
+	case *ssa.Extract:
+		if call, ok := v.Tuple.(*ssa.Call); ok {
+			return []*ssa.Call{call}
+		}
+	case *ssa.Call:
+		return []*ssa.Call{v}
+	}
+	return nil
+}
+
+// swallowed returns the reason the error result of call, if any, is
+// dropped, or "" if it is not.
+func swallowed(call ssa.CallInstruction) string {
+	common := call.Common()
+	if !returnsError(common.Signature()) {
+		return ""
+	}
+	switch call.(type) {
+	case *ssa.Defer:
+		return "deferred"
+	case *ssa.Go:
+		return "discarded by go"
+	}
+	v := call.Value()
+	res := common.Signature().Results()
+	if res.Len() == 1 {
+		return dropped(v)
+	}
+	for _, ref := range *v.Referrers() {
+		if ext, ok := ref.(*ssa.Extract); ok && types.Identical(res.At(ext.Index).Type(), errorType) {
+			return dropped(ext)
+		}
+	}
+	return "ignored"
+}
+
+// dropped returns "ignored" if the error value v is not used,
+// "checked but dropped" if it is only compared with nil, and ""
+// otherwise.
+func dropped(v ssa.Value) string {
+	seen := make(map[ssa.Value]bool)
+	checked := false
+	var used func(v ssa.Value) bool
+	used = func(v ssa.Value) bool {
+		if seen[v] {
+			return false
+		}
+		seen[v] = true
+		for _, ref := range *v.Referrers() {
+			switch ref := ref.(type) {
+			case *ssa.DebugRef:
+				continue
+			case *ssa.Phi:
+				if used(ref) {
+					return true
+				}
+				continue
+			case *ssa.BinOp:
+				if ref.Op == token.EQL || ref.Op == token.NEQ {
+					if isNil(ref.X) || isNil(ref.Y) {
+						checked = true
+						continue
+					}
+				}
+			}
+			return true
+		}
+		return false
+	}
+	switch {
+	case used(v):
+		return ""
+	case checked:
+		return "checked but dropped"
+	}
+	return "ignored"
+}
+
+func isNil(v ssa.Value) bool {
+	c, ok := v.(*ssa.Const)
+	return ok && c.IsNil()
+}
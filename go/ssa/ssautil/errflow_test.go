@@ -0,0 +1,84 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil_test
+
+import (
+	"go/parser"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+func TestErrorFlow(t *testing.T) {
+	conf := loader.Config{ParserMode: parser.ParseComments}
+	f, err := conf.ParseFile("testdata/errflow.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prog := ssautil.CreateProgram(iprog, 0)
+	mainPkg := prog.Package(iprog.Created[0].Pkg)
+	mainPkg.Build()
+
+	origins := make(map[int]string)
+	swallows := make(map[int]string)
+	for _, c := range f.Comments {
+		text := strings.TrimSpace(c.Text())
+		line := prog.Fset.Position(c.Pos()).Line
+		if strings.HasPrefix(text, "origins:") {
+			origins[line] = strings.TrimSpace(strings.TrimPrefix(text, "origins:"))
+		} else if strings.HasPrefix(text, "swallowed: ") {
+			swallows[line] = strings.TrimPrefix(text, "swallowed: ")
+		}
+	}
+
+	fns := make(map[*ssa.Function]bool)
+	for _, mem := range mainPkg.Members {
+		if fn, ok := mem.(*ssa.Function); ok {
+			fns[fn] = true
+		}
+	}
+	g := ssautil.ErrorFlow(fns)
+
+	for fn := range g.Funcs {
+		line := prog.Fset.Position(fn.Pos()).Line
+		want, ok := origins[line]
+		if !ok {
+			t.Errorf("%s: no origins comment", fn)
+			continue
+		}
+		var names []string
+		for _, o := range g.Origins(fn) {
+			names = append(names, o.Name())
+		}
+		if got := strings.Join(names, " "); got != want {
+			t.Errorf("%s: got origins %q, want %q", fn, got, want)
+		}
+	}
+
+	got := make(map[int]string)
+	for _, s := range g.Swallowed {
+		got[prog.Fset.Position(s.Pos()).Line] = s.Reason
+	}
+	for line, reason := range swallows {
+		if got[line] != reason {
+			t.Errorf("line %d: got swallow %q, want %q", line, got[line], reason)
+		}
+	}
+	for line, reason := range got {
+		if swallows[line] == "" {
+			t.Errorf("line %d: unexpected swallow %q", line, reason)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil
+
+// This file implements an approximation of escape analysis: it
+// estimates which heap allocations of a function may outlive it.
+//
+// The SSA builder allocates a variable on the heap (Alloc.Heap) when
+// its address is taken or it is captured by a closure, and composite
+// literals, new(T), make, and closures always allocate; but many such
+// allocations never escape the function, and a compiler may place
+// them in its stack frame.  The analysis follows, within a single
+// function, the pointers derived from each allocation: through field
+// and element addresses, slicing, conversions, φ-nodes, closure
+// bindings, and loads from memory of the same function into which
+// they are stored.  An allocation escapes if such a pointer is
+// returned, sent on a channel, passed to panic, stored in a global
+// variable, through a parameter or other pointer of unknown origin,
+// or into another allocation that escapes, or passed to a function
+// other than a harmless built-in.
+//
+// The results are advisory.  They are conservative with respect to
+// calls, which the analysis does not look into, and are not those of
+// any particular compiler: for example, a compiler may heap-allocate
+// a very large object even if it does not escape.
+
+import (
+	"fmt"
+	"go/token"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// An Escape describes a heap allocation that may escape the function
+// in which it occurs.
+type Escape struct {
+	Alloc  ssa.Value       // *ssa.Alloc, *ssa.MakeSlice, *ssa.MakeMap, *ssa.MakeChan, or *ssa.MakeClosure
+	Use    ssa.Instruction // an instruction through which the allocation escapes
+	Reason string          // e.g. "returned", "stored in global variable g"
+}
+
+// Pos returns the position of the allocation: that of the function
+// literal, for a closure.
+func (e *Escape) Pos() token.Pos {
+	if c, ok := e.Alloc.(*ssa.MakeClosure); ok {
+		return c.Fn.Pos()
+	}
+	return e.Alloc.Pos()
+}
+
+func (e *Escape) String() string {
+	return fmt.Sprintf("%s escapes: %s", allocName(e.Alloc), e.Reason)
+}
+
+// Escapes returns the heap allocations of fn that may escape it, in
+// the order of fn's instructions.  Allocations within the anonymous
+// functions of fn are not included; call Escapes on each element of
+// fn.AnonFuncs to obtain theirs.
+//
+// Precondition: fn is built.
+//
+func Escapes(fn *ssa.Function) []Escape {
+	a := &escaper{memo: make(map[ssa.Value]*escapeResult)}
+	var escapes []Escape
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch v := instr.(type) {
+			case *ssa.Alloc:
+				if !v.Heap {
+					continue
+				}
+			case *ssa.MakeSlice, *ssa.MakeMap, *ssa.MakeChan, *ssa.MakeClosure:
+			default:
+				continue
+			}
+			v := instr.(ssa.Value)
+			if r := a.escapes(v); r != nil {
+				escapes = append(escapes, Escape{Alloc: v, Use: r.use, Reason: r.reason})
+			}
+		}
+	}
+	return escapes
+}
+
+// allocName returns a name for the allocation v: the name of the
+// variable it allocates, if any, or that of the SSA value.
+func allocName(v ssa.Value) string {
+	if alloc, ok := v.(*ssa.Alloc); ok && alloc.Comment != "" {
+		return alloc.Comment
+	}
+	return v.Name()
+}
+
+type escapeResult struct {
+	use    ssa.Instruction
+	reason string
+}
+
+// An escaper memoizes the results of escape queries.
+type escaper struct {
+	memo map[ssa.Value]*escapeResult // nil result: does not escape
+}
+
+// escapes reports how the allocation alloc may escape, or nil if it
+// does not.  A query for an allocation that is already being
+// answered, as happens when allocations are stored in one another,
+// is optimistically answered nil.
+func (a *escaper) escapes(alloc ssa.Value) *escapeResult {
+	if r, ok := a.memo[alloc]; ok {
+		return r
+	}
+	a.memo[alloc] = nil
+	r := a.flows(alloc)
+	a.memo[alloc] = r
+	return r
+}
+
+// flows reports how the pointer v, or a value derived from it, may
+// escape the function, or nil if none does.
+func (a *escaper) flows(v ssa.Value) *escapeResult {
+	seen := make(map[ssa.Value]bool)
+	queue := []ssa.Value{v}
+	derive := func(v ssa.Value) {
+		if !seen[v] {
+			seen[v] = true
+			queue = append(queue, v)
+		}
+	}
+	seen[v] = true
+	for len(queue) > 0 {
+		d := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		refs := d.Referrers()
+		if refs == nil {
+			continue
+		}
+		for _, instr := range *refs {
+			if r := a.use(d, instr, derive); r != nil {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// use reports how the use of the pointer d by instr may cause it to
+// escape, or nil.  It calls derive for the values derived from d by
+// instr.
+func (a *escaper) use(d ssa.Value, instr ssa.Instruction, derive func(ssa.Value)) *escapeResult {
+	escape := func(format string, args ...interface{}) *escapeResult {
+		return &escapeResult{instr, fmt.Sprintf(format, args...)}
+	}
+	switch instr := instr.(type) {
+	case *ssa.FieldAddr, *ssa.IndexAddr, *ssa.Field, *ssa.Index, *ssa.Slice,
+		*ssa.ChangeType, *ssa.Convert, *ssa.ChangeInterface, *ssa.MakeInterface,
+		*ssa.TypeAssert, *ssa.Phi, *ssa.Extract:
+		// Only the base operand of an address or
+		// slice operation yields a derived pointer;
+		// indices are integers.
+		derive(instr.(ssa.Value))
+
+	case *ssa.Store:
+		if instr.Val != d {
+			break // a store into the object
+		}
+		return a.store(instr, instr.Addr, derive, escape)
+
+	case *ssa.MapUpdate:
+		if instr.Key != d && instr.Value != d {
+			break
+		}
+		return a.store(instr, instr.Map, derive, escape)
+
+	case *ssa.Send:
+		if instr.X == d {
+			return escape("sent on a channel")
+		}
+
+	case *ssa.Select:
+		for _, st := range instr.States {
+			if st.Send == d {
+				return escape("sent on a channel")
+			}
+		}
+
+	case *ssa.Return:
+		return escape("returned")
+
+	case *ssa.Panic:
+		return escape("passed to panic")
+
+	case *ssa.MakeClosure:
+		derive(instr)
+		fn := instr.Fn.(*ssa.Function)
+		for i, b := range instr.Bindings {
+			if b == d {
+				if r := a.flows(fn.FreeVars[i]); r != nil {
+					return escape("captured by %s, in which it is %s", fn.Name(), r.reason)
+				}
+			}
+		}
+
+	case *ssa.Go:
+		return escape("used by a go statement")
+
+	case ssa.CallInstruction:
+		common := instr.Common()
+		if common.IsInvoke() && common.Value == d {
+			return escape("used as the receiver of a dynamic call to %s", common.Method.Name())
+		}
+		if common.Value == d {
+			break // a call of a closure
+		}
+		if b, ok := common.Value.(*ssa.Builtin); ok {
+			switch b.Name() {
+			case "len", "cap", "copy", "print", "println", "delete", "close":
+				return nil
+			case "append", "ssa:wrapnilchk":
+				if call, ok := instr.(*ssa.Call); ok {
+					derive(call)
+				}
+				return nil
+			}
+		}
+		if callee := common.StaticCallee(); callee != nil {
+			return escape("passed to %s", callee.Name())
+		}
+		return escape("passed to a %s", common.Description())
+	}
+	return nil
+}
+
+// store reports how the pointer stored by instr into the memory at
+// addr may escape, or nil.  If the memory belongs to an allocation of
+// the same function, the pointer escapes if that allocation does, and
+// the values loaded from it are derived from the pointer.
+func (a *escaper) store(instr ssa.Instruction, addr ssa.Value, derive func(ssa.Value), escape func(string, ...interface{}) *escapeResult) *escapeResult {
+	switch root := root(addr).(type) {
+	case *ssa.Global:
+		return escape("stored in global variable %s", root.Name())
+	case *ssa.Alloc, *ssa.MakeSlice, *ssa.MakeMap:
+		if root.Parent() != instr.Parent() {
+			break
+		}
+		// An allocation without a heap flag is a local
+		// variable of the frame; it does not escape.
+		if alloc, ok := root.(*ssa.Alloc); !ok || alloc.Heap {
+			if r := a.escapes(root); r != nil {
+				return escape("stored in %s, which escapes", allocName(root))
+			}
+		}
+		loads(root, derive)
+		return nil
+	}
+	return escape("stored through a pointer")
+}
+
+// root returns the value at the base of the address and slice
+// operations that yield addr.
+func root(addr ssa.Value) ssa.Value {
+	for {
+		switch v := addr.(type) {
+		case *ssa.FieldAddr:
+			addr = v.X
+		case *ssa.IndexAddr:
+			addr = v.X
+		case *ssa.Slice:
+			addr = v.X
+		case *ssa.ChangeType:
+			addr = v.X
+		default:
+			return addr
+		}
+	}
+}
+
+// loads calls derive for each value loaded from the memory of the
+// allocation alloc.
+func loads(alloc ssa.Value, derive func(ssa.Value)) {
+	seen := make(map[ssa.Value]bool)
+	var visit func(v ssa.Value)
+	visit = func(v ssa.Value) {
+		if seen[v] {
+			return
+		}
+		seen[v] = true
+		for _, instr := range *v.Referrers() {
+			switch instr := instr.(type) {
+			case *ssa.FieldAddr, *ssa.IndexAddr, *ssa.Slice, *ssa.ChangeType, *ssa.Phi:
+				visit(instr.(ssa.Value))
+			case *ssa.UnOp:
+				derive(instr) // *v or <-v
+			case *ssa.Lookup, *ssa.Next:
+				derive(instr.(ssa.Value))
+			case *ssa.Range:
+				visit(instr)
+			}
+		}
+	}
+	visit(alloc)
+}
@@ -0,0 +1,65 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil_test
+
+import (
+	"go/parser"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+func TestEscapes(t *testing.T) {
+	conf := loader.Config{ParserMode: parser.ParseComments}
+	f, err := conf.ParseFile("testdata/escape.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prog := ssautil.CreateProgram(iprog, 0)
+	mainPkg := prog.Package(iprog.Created[0].Pkg)
+	mainPkg.Build()
+
+	// Each "escapes: reason" comment must match an
+	// escape reported by the allocation on its line.
+	want := make(map[int][]string)
+	for _, c := range f.Comments {
+		text := strings.TrimSpace(c.Text())
+		if strings.HasPrefix(text, "escapes: ") {
+			line := prog.Fset.Position(c.Pos()).Line
+			want[line] = append(want[line], strings.TrimPrefix(text, "escapes: "))
+		}
+	}
+
+	got := make(map[int][]string)
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg != mainPkg {
+			continue
+		}
+		for _, e := range ssautil.Escapes(fn) {
+			line := prog.Fset.Position(e.Pos()).Line
+			got[line] = append(got[line], e.Reason)
+		}
+	}
+
+	for line, reasons := range want {
+		if g, w := strings.Join(got[line], "; "), strings.Join(reasons, "; "); g != w {
+			t.Errorf("line %d: got escapes %q, want %q", line, g, w)
+		}
+	}
+	for line, reasons := range got {
+		if want[line] == nil {
+			t.Errorf("line %d: unexpected escapes %q", line, reasons)
+		}
+	}
+}
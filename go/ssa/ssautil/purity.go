@@ -0,0 +1,220 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil
+
+// This file implements a best-effort interprocedural inference of the
+// side effects of functions.
+//
+// A function's own effect is determined by its instructions: a load
+// through a pointer, or a map lookup or iteration, on memory that it
+// did not allocate makes it read-only, at least; a store, map update,
+// or call of a mutating built-in (append, copy, delete, close) on
+// such memory, a channel operation, a go statement, or a print makes
+// it effectful.  Memory "allocated by the function" is that of its
+// Alloc, MakeSlice, MakeMap, and MakeChan instructions, and so
+// writes to a fresh object returned to the caller, as by a
+// constructor, are not effects; but memory reached by loading a
+// pointer, even from such an object, is never considered allocated.  The effect of a function is the
+// greatest of its own and those of its static callees, computed to a
+// fixed point, so that recursion is handled.  Dynamic calls (through
+// interfaces or function values) and calls of functions without
+// bodies, such as those implemented in assembly, are assumed to be
+// effectful.
+//
+// Panics are not effects, nor are allocations.  The analysis does not
+// distinguish memory reachable from the parameters from global memory.
+
+import (
+	"fmt"
+	"go/token"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types"
+)
+
+// An Effect classifies the observable side effects of a function.
+// Effects are ordered: each includes the preceding ones.
+type Effect int
+
+const (
+	Pure     Effect = iota // reads and writes only memory it allocates
+	ReadOnly               // may read other memory, but writes none
+	Impure                 // may write other memory, or communicate
+)
+
+func (e Effect) String() string {
+	switch e {
+	case Pure:
+		return "pure"
+	case ReadOnly:
+		return "readonly"
+	case Impure:
+		return "impure"
+	}
+	return fmt.Sprintf("Effect(%d)", int(e))
+}
+
+// A FuncEffect is the inferred effect of a function, and a reason.
+type FuncEffect struct {
+	Effect Effect
+	Instr  ssa.Instruction // an instruction responsible for Effect, or nil for Pure or a function without body
+}
+
+// Effects infers the effect of each of the specified functions and of
+// the functions they call, directly or not, and returns them.  Clients
+// may use the results to find calls whose results, if unused, make
+// them dead, or repeated calls that may be merged.
+//
+// Precondition: the functions are built.
+//
+func Effects(fns map[*ssa.Function]bool) map[*ssa.Function]*FuncEffect {
+	effects := make(map[*ssa.Function]*FuncEffect)
+	var order []*ssa.Function
+	var add func(fn *ssa.Function)
+	add = func(fn *ssa.Function) {
+		if effects[fn] != nil {
+			return
+		}
+		effects[fn] = &FuncEffect{}
+		order = append(order, fn)
+		if fn.Blocks == nil {
+			effects[fn].Effect = Impure // external, or not built
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				if call, ok := instr.(ssa.CallInstruction); ok {
+					if callee := call.Common().StaticCallee(); callee != nil {
+						add(callee)
+					}
+				}
+			}
+		}
+	}
+	for fn := range fns {
+		add(fn)
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, fn := range order {
+			cur := effects[fn]
+			if cur.Effect == Impure {
+				continue
+			}
+			e, instr := ownEffect(fn, func(callee *ssa.Function) Effect { return effects[callee].Effect }, cur.Effect)
+			if e > cur.Effect {
+				cur.Effect, cur.Instr = e, instr
+				changed = true
+			}
+		}
+	}
+	return effects
+}
+
+// ownEffect returns the effect of fn, given the current effects of
+// the functions it calls, and an instruction responsible for it.  It
+// returns the first instruction whose effect is greater than min.
+func ownEffect(fn *ssa.Function, callee func(*ssa.Function) Effect, min Effect) (Effect, ssa.Instruction) {
+	max, maxInstr := min, ssa.Instruction(nil)
+	effect := func(e Effect, instr ssa.Instruction) {
+		if e > max {
+			max, maxInstr = e, instr
+		}
+	}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *ssa.Store:
+				if !isLocal(fn, instr.Addr) {
+					effect(Impure, instr)
+				}
+			case *ssa.MapUpdate:
+				if !isLocal(fn, instr.Map) {
+					effect(Impure, instr)
+				}
+			case *ssa.UnOp:
+				switch instr.Op {
+				case token.MUL:
+					if !isLocal(fn, instr.X) {
+						effect(ReadOnly, instr)
+					}
+				case token.ARROW:
+					effect(Impure, instr)
+				}
+			case *ssa.Lookup:
+				if _, ok := instr.X.Type().Underlying().(*types.Map); ok && !isLocal(fn, instr.X) {
+					effect(ReadOnly, instr)
+				}
+			case *ssa.Range:
+				if _, ok := instr.X.Type().Underlying().(*types.Map); ok && !isLocal(fn, instr.X) {
+					effect(ReadOnly, instr)
+				}
+			case *ssa.Send, *ssa.Select, *ssa.Go:
+				effect(Impure, instr)
+			case ssa.CallInstruction:
+				effect(callEffect(fn, instr.Common(), callee), instr)
+			}
+		}
+	}
+	return max, maxInstr
+}
+
+// callEffect returns the effect of the call, made by fn.
+func callEffect(fn *ssa.Function, call *ssa.CallCommon, callee func(*ssa.Function) Effect) Effect {
+	if b, ok := call.Value.(*ssa.Builtin); ok {
+		switch b.Name() {
+		case "append", "copy":
+			// Both may write to the array of the slice operand.
+			if !isLocal(fn, call.Args[0]) {
+				return Impure
+			}
+		case "delete":
+			if !isLocal(fn, call.Args[0]) {
+				return Impure
+			}
+		case "close", "print", "println":
+			return Impure
+		}
+		return Pure
+	}
+	if g := call.StaticCallee(); g != nil {
+		return callee(g)
+	}
+	return Impure
+}
+
+// isLocal reports whether the memory addressed by the pointer, slice,
+// or map v was allocated by fn.
+func isLocal(fn *ssa.Function, v ssa.Value) bool {
+	seen := make(map[ssa.Value]bool)
+	var local func(v ssa.Value) bool
+	local = func(v ssa.Value) bool {
+		v = root(v)
+		if seen[v] {
+			return true
+		}
+		seen[v] = true
+		switch v := v.(type) {
+		case *ssa.Alloc, *ssa.MakeSlice, *ssa.MakeMap, *ssa.MakeChan:
+			return v.Parent() == fn
+		case *ssa.Phi:
+			for _, edge := range v.Edges {
+				if !local(edge) {
+					return false
+				}
+			}
+			return true
+		case *ssa.Call:
+			// The result of append is local if its operand is.
+			if b, ok := v.Call.Value.(*ssa.Builtin); ok && b.Name() == "append" {
+				return local(v.Call.Args[0])
+			}
+		case *ssa.Const:
+			return true // nil
+		}
+		return false
+	}
+	return local(v)
+}
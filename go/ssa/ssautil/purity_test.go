@@ -0,0 +1,63 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil_test
+
+import (
+	"go/parser"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+func TestEffects(t *testing.T) {
+	conf := loader.Config{ParserMode: parser.ParseComments}
+	f, err := conf.ParseFile("testdata/purity.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prog := ssautil.CreateProgram(iprog, 0)
+	mainPkg := prog.Package(iprog.Created[0].Pkg)
+	mainPkg.Build()
+
+	// Each "effect: E" comment gives the effect of the function
+	// declared on its line.
+	want := make(map[int]string)
+	for _, c := range f.Comments {
+		text := strings.TrimSpace(c.Text())
+		if strings.HasPrefix(text, "effect: ") {
+			want[prog.Fset.Position(c.Pos()).Line] = strings.TrimPrefix(text, "effect: ")
+		}
+	}
+
+	fns := make(map[*ssa.Function]bool)
+	for _, mem := range mainPkg.Members {
+		if fn, ok := mem.(*ssa.Function); ok && fn.Name() != "init" && fn.Name() != "main" {
+			fns[fn] = true
+		}
+	}
+	effects := ssautil.Effects(fns)
+	for fn := range fns {
+		e := effects[fn]
+		line := prog.Fset.Position(fn.Pos()).Line
+		if w, ok := want[line]; !ok {
+			t.Errorf("%s: no effect comment", fn)
+		} else if got := e.Effect.String(); got != w {
+			t.Errorf("%s: got effect %s, want %s", fn, got, w)
+		}
+		if (e.Effect == ssautil.Pure) != (e.Instr == nil) {
+			t.Errorf("%s: effect %s, but responsible instruction %v", fn, e.Effect, e.Instr)
+		}
+	}
+}
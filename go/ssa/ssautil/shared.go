@@ -0,0 +1,398 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil
+
+// This file implements an advisory check for variables shared between
+// goroutines without synchronization.
+//
+// Each go statement launches a goroutine that runs its static callee
+// and the functions it calls, transitively, except for those run by
+// further go statements; the goroutine is concurrent with the code of
+// the launching function that may execute after the go statement, and
+// with the goroutines of all other go statements, and, if the go
+// statement is within a loop, with the goroutines of its other
+// executions.  A package-level variable, or a local variable captured
+// by a closure, is shared if it is accessed by two such concurrent
+// pieces of code, one access being a write, and at least one of the
+// accesses is unsynchronized: not preceded, on every path from the
+// entry of its function, by a lock (a call of a Lock or RLock method,
+// or of a function of package sync) or a channel operation.
+//
+// The check is conservative in that it ignores the happens-before
+// relation a lock or channel operation actually establishes, and it
+// does not follow dynamic calls, nor pointers: only the accesses
+// through a variable's own name, which the SSA form represents as an
+// operation on a *ssa.Global, a captured *ssa.Alloc, or a *ssa.FreeVar
+// bound to it, are considered.
+
+import (
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// A SharedVar is a variable accessed by concurrent goroutines.
+type SharedVar struct {
+	Var      ssa.Value         // *ssa.Global, or *ssa.Alloc of a captured variable
+	Sites    []*ssa.Go         // the go statements whose goroutines access Var, in order of position
+	Accesses []ssa.Instruction // the unsynchronized accesses, in order of position
+}
+
+// Pos returns the position of the declaration of the variable.
+func (v *SharedVar) Pos() token.Pos { return v.Var.Pos() }
+
+// SharedVars returns the variables accessed by the goroutines launched
+// by the go statements of the specified functions, in order of
+// position, as described above.
+//
+// Precondition: the functions are built.
+//
+func SharedVars(fns map[*ssa.Function]bool) []*SharedVar {
+	s := &sharer{
+		freeVars: make(map[*ssa.FreeVar]ssa.Value),
+		memo:     make(map[*ssa.Function]varAccesses),
+	}
+	var gos []*ssa.Go
+	for fn := range fns {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Go:
+					gos = append(gos, instr)
+				case *ssa.MakeClosure:
+					for i, fv := range instr.Fn.(*ssa.Function).FreeVars {
+						s.freeVars[fv] = instr.Bindings[i]
+					}
+				}
+			}
+		}
+	}
+	sort.Sort(byGoPos(gos))
+
+	goroutines := make([]varAccesses, len(gos))
+	for i, g := range gos {
+		goroutines[i] = s.goroutine(g)
+	}
+	shared := make(map[ssa.Value]*SharedVar)
+	conflict := func(x, y varAccesses, gs ...*ssa.Go) {
+		for v, xs := range x {
+			if ys := y[v]; ys != nil {
+				addConflicts(shared, v, xs, ys, gs)
+			}
+		}
+	}
+	for i, g := range gos {
+		conflict(goroutines[i], s.after(g), g)
+		if inLoop(g) {
+			conflict(goroutines[i], goroutines[i], g)
+		}
+		for j := i + 1; j < len(gos); j++ {
+			conflict(goroutines[i], goroutines[j], g, gos[j])
+		}
+	}
+
+	var result []*SharedVar
+	for _, sv := range shared {
+		sort.Sort(byGoPos(sv.Sites))
+		sort.Sort(byInstrPos(sv.Accesses))
+		result = append(result, sv)
+	}
+	sort.Sort(bySharedPos(result))
+	return result
+}
+
+// addConflicts records in shared the conflicting pairs of the accesses
+// xs and ys to the variable v, made by the goroutines of gs.
+func addConflicts(shared map[ssa.Value]*SharedVar, v ssa.Value, xs, ys []access, gs []*ssa.Go) {
+	for _, x := range xs {
+		for _, y := range ys {
+			if !x.write && !y.write || x.synced && y.synced {
+				continue
+			}
+			sv := shared[v]
+			if sv == nil {
+				sv = &SharedVar{Var: v}
+				shared[v] = sv
+			}
+			for _, g := range gs {
+				sv.addSite(g)
+			}
+			for _, a := range []access{x, y} {
+				if !a.synced {
+					sv.addAccess(a.instr)
+				}
+			}
+		}
+	}
+}
+
+func (sv *SharedVar) addSite(g *ssa.Go) {
+	for _, site := range sv.Sites {
+		if site == g {
+			return
+		}
+	}
+	sv.Sites = append(sv.Sites, g)
+}
+
+func (sv *SharedVar) addAccess(instr ssa.Instruction) {
+	for _, a := range sv.Accesses {
+		if a == instr {
+			return
+		}
+	}
+	sv.Accesses = append(sv.Accesses, instr)
+}
+
+type byGoPos []*ssa.Go
+
+func (b byGoPos) Len() int           { return len(b) }
+func (b byGoPos) Less(i, j int) bool { return b[i].Pos() < b[j].Pos() }
+func (b byGoPos) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type byInstrPos []ssa.Instruction
+
+func (b byInstrPos) Len() int           { return len(b) }
+func (b byInstrPos) Less(i, j int) bool { return b[i].Pos() < b[j].Pos() }
+func (b byInstrPos) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type bySharedPos []*SharedVar
+
+func (b bySharedPos) Len() int           { return len(b) }
+func (b bySharedPos) Less(i, j int) bool { return b[i].Pos() < b[j].Pos() }
+func (b bySharedPos) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// An access is a load from or store to a variable.
+type access struct {
+	instr  ssa.Instruction
+	write  bool
+	synced bool // preceded by a synchronizing operation on every path
+}
+
+// varAccesses maps each variable to accesses to it.
+type varAccesses map[ssa.Value][]access
+
+func (va varAccesses) add(other varAccesses) {
+	for v, as := range other {
+		va[v] = append(va[v], as...)
+	}
+}
+
+// A sharer memoizes the accesses of functions.
+type sharer struct {
+	freeVars map[*ssa.FreeVar]ssa.Value    // binding of each free variable
+	memo     map[*ssa.Function]varAccesses // accesses by each function and its callees
+}
+
+// goroutine returns the accesses of the goroutine launched by g.
+func (s *sharer) goroutine(g *ssa.Go) varAccesses {
+	va := make(varAccesses)
+	if callee := g.Call.StaticCallee(); callee != nil {
+		va.add(s.accesses(callee))
+	}
+	return va
+}
+
+// after returns the accesses of the function containing g that may
+// execute after g, including those of the functions it calls.
+func (s *sharer) after(g *ssa.Go) varAccesses {
+	va := make(varAccesses)
+	fn := g.Parent()
+	synced := syncedInstrs(fn)
+	visit := func(instr ssa.Instruction) {
+		s.access(va, instr, synced[instr])
+		if call, ok := instr.(ssa.CallInstruction); ok {
+			if _, ok := instr.(*ssa.Go); !ok {
+				if callee := call.Common().StaticCallee(); callee != nil {
+					va.add(s.accesses(callee))
+				}
+			}
+		}
+	}
+	b := g.Block()
+	seen := make(map[*ssa.BasicBlock]bool)
+	var reach func(b *ssa.BasicBlock)
+	reach = func(b *ssa.BasicBlock) {
+		for _, succ := range b.Succs {
+			if !seen[succ] {
+				seen[succ] = true
+				for _, instr := range succ.Instrs {
+					visit(instr)
+				}
+				reach(succ)
+			}
+		}
+	}
+	if !inLoop(g) {
+		for _, instr := range b.Instrs[instrIndex(g)+1:] {
+			visit(instr)
+		}
+	}
+	reach(b)
+	return va
+}
+
+// accesses returns the accesses of fn and of the functions it calls,
+// transitively, except through go statements.
+func (s *sharer) accesses(fn *ssa.Function) varAccesses {
+	if va, ok := s.memo[fn]; ok {
+		return va
+	}
+	va := make(varAccesses)
+	s.memo[fn] = va // break cycles
+	synced := syncedInstrs(fn)
+	var callees []*ssa.Function
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			s.access(va, instr, synced[instr])
+			switch instr := instr.(type) {
+			case *ssa.Call, *ssa.Defer:
+				if callee := instr.(ssa.CallInstruction).Common().StaticCallee(); callee != nil {
+					callees = append(callees, callee)
+				}
+			}
+		}
+	}
+	for _, callee := range callees {
+		if callee != fn {
+			va.add(s.accesses(callee))
+		}
+	}
+	return va
+}
+
+// access records in va the access made by instr, if any.
+func (s *sharer) access(va varAccesses, instr ssa.Instruction, synced bool) {
+	var addr ssa.Value
+	write := false
+	switch instr := instr.(type) {
+	case *ssa.Store:
+		addr, write = instr.Addr, true
+	case *ssa.UnOp:
+		if instr.Op != token.MUL {
+			return
+		}
+		addr = instr.X
+	default:
+		return
+	}
+	if v := s.variable(addr); v != nil {
+		va[v] = append(va[v], access{instr, write, synced})
+	}
+}
+
+// variable returns the variable of which addr is the address of part,
+// or nil if it is not a package-level or captured variable.
+func (s *sharer) variable(addr ssa.Value) ssa.Value {
+	for i := 0; i < 100; i++ { // guard against cyclic bindings
+		switch v := root(addr).(type) {
+		case *ssa.Global:
+			return v
+		case *ssa.Alloc:
+			if v.Heap {
+				return v
+			}
+			return nil
+		case *ssa.FreeVar:
+			b, ok := s.freeVars[v]
+			if !ok {
+				return nil
+			}
+			addr = b
+			continue
+		}
+		return nil
+	}
+	return nil
+}
+
+// syncedInstrs returns the set of instructions of fn that are
+// preceded by a synchronizing operation on every path from its entry.
+func syncedInstrs(fn *ssa.Function) map[ssa.Instruction]bool {
+	syncs := make(map[*ssa.BasicBlock]int) // index of the first synchronizing instruction
+	for _, b := range fn.Blocks {
+		for i, instr := range b.Instrs {
+			if isSync(instr) {
+				syncs[b] = i
+				break
+			}
+		}
+	}
+	synced := make(map[ssa.Instruction]bool)
+	for _, b := range fn.Blocks {
+		for i, instr := range b.Instrs {
+			if j, ok := syncs[b]; ok && j < i {
+				synced[instr] = true
+				continue
+			}
+			for d := b.Idom(); d != nil; d = d.Idom() {
+				if _, ok := syncs[d]; ok {
+					synced[instr] = true
+					break
+				}
+			}
+		}
+	}
+	return synced
+}
+
+// isSync reports whether instr is a synchronizing operation: a channel
+// operation, or a call of a Lock or RLock method, or of a function or
+// method of package sync, such as WaitGroup.Wait.
+func isSync(instr ssa.Instruction) bool {
+	switch instr := instr.(type) {
+	case *ssa.UnOp:
+		return instr.Op == token.ARROW
+	case *ssa.Send, *ssa.Select:
+		return true
+	case *ssa.Call:
+		common := instr.Common()
+		if common.IsInvoke() {
+			return common.Method.Name() == "Lock" || common.Method.Name() == "RLock"
+		}
+		if b, ok := common.Value.(*ssa.Builtin); ok {
+			return b.Name() == "close"
+		}
+		if callee := common.StaticCallee(); callee != nil {
+			if callee.Name() == "Lock" || callee.Name() == "RLock" {
+				return callee.Signature.Recv() != nil
+			}
+			return callee.Pkg != nil && callee.Pkg.Object.Path() == "sync"
+		}
+	}
+	return false
+}
+
+// inLoop reports whether the go statement g may execute repeatedly.
+func inLoop(g *ssa.Go) bool {
+	seen := make(map[*ssa.BasicBlock]bool)
+	var reaches func(b *ssa.BasicBlock) bool
+	reaches = func(b *ssa.BasicBlock) bool {
+		for _, succ := range b.Succs {
+			if succ == g.Block() {
+				return true
+			}
+			if !seen[succ] {
+				seen[succ] = true
+				if reaches(succ) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return reaches(g.Block())
+}
+
+// instrIndex returns the index of instr in its block.
+func instrIndex(instr ssa.Instruction) int {
+	for i, x := range instr.Block().Instrs {
+		if x == instr {
+			return i
+		}
+	}
+	return -1
+}
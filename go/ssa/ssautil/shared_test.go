@@ -0,0 +1,66 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ssautil_test
+
+import (
+	"go/parser"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+func TestSharedVars(t *testing.T) {
+	conf := loader.Config{ParserMode: parser.ParseComments}
+	f, err := conf.ParseFile("testdata/shared.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.CreateFromFiles("main", f)
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prog := ssautil.CreateProgram(iprog, 0)
+	mainPkg := prog.Package(iprog.Created[0].Pkg)
+	mainPkg.Build()
+
+	want := make(map[int]string)
+	for _, c := range f.Comments {
+		text := strings.TrimSpace(c.Text())
+		if strings.HasPrefix(text, "unsynchronized: ") {
+			want[prog.Fset.Position(c.Pos()).Line] = strings.TrimPrefix(text, "unsynchronized: ")
+		}
+	}
+
+	got := make(map[int]string)
+	for _, sv := range ssautil.SharedVars(ssautil.AllFunctions(prog)) {
+		name := sv.Var.Name()
+		if alloc, ok := sv.Var.(*ssa.Alloc); ok {
+			name = alloc.Comment
+		}
+		for _, instr := range sv.Accesses {
+			got[prog.Fset.Position(instr.Pos()).Line] = name
+		}
+		if sv.Sites == nil {
+			t.Errorf("%s: no go statements", name)
+		}
+	}
+
+	for line, name := range want {
+		if got[line] != name {
+			t.Errorf("line %d: got unsynchronized access to %q, want %q", line, got[line], name)
+		}
+	}
+	for line, name := range got {
+		if want[line] == "" {
+			t.Errorf("line %d: unexpected unsynchronized access to %q", line, name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// This file is the input to TestContextDrops in context_test.go, which
+// supplies the context package "old/context".  Each call reported by
+// ContextDrops must be on a line with a comment of the form "drop:
+// reason"; no other call may be reported.
+
+import "old/context"
+
+type Server struct {
+	ctx context.Context
+}
+
+func Fetch(ctx context.Context, url string) error { return nil }
+
+func (s *Server) Handle(ctx context.Context, url string) error { return Fetch(ctx, url) }
+
+func Propagates(ctx context.Context) {
+	Fetch(ctx, "a")
+	sub, cancel := context.WithCancel(ctx)
+	defer cancel()
+	Fetch(sub, "b")
+	Fetch(context.WithValue(sub, "k", 1), "c")
+	var s Server
+	s.Handle(ctx, "d")
+}
+
+func Drops(ctx context.Context, s *Server) {
+	Fetch(context.Background(), "a") // drop: passes context.Background()
+	Fetch(nil, "b")                  // drop: passes nil
+	Fetch(s.ctx, "c")                // drop: passes a context not derived from ctx
+	s.Handle(context.TODO(), "d")    // drop: passes context.TODO()
+	sub, _ := context.WithCancel(context.Background())
+	Fetch(sub, "e") // drop: passes context.Background()
+	c := ctx
+	if s == nil {
+		c = context.Background()
+	}
+	Fetch(c, "f") // drop: passes context.Background()
+}
+
+func NoContext() {
+	Fetch(context.Background(), "a")
+}
+
+func main() {}
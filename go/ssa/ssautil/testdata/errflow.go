@@ -0,0 +1,85 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// This file is the input to TestErrorFlow in errflow_test.go.  Each
+// function that returns an error is declared on a line with a comment
+// of the form "origins: F G", listing the functions that originate the
+// errors it may return.  Each call whose error is swallowed must be on
+// a line with a comment of the form "swallowed: reason"; no other
+// call may be reported.
+
+type errorString struct{ s string }
+
+func (e *errorString) Error() string { return e.s }
+
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string { return e.msg + ": " + e.err.Error() }
+
+var EOF error = New("EOF")
+
+func New(s string) error { // origins: New
+	return &errorString{s}
+}
+
+func Sentinel() error { // origins: Sentinel
+	return EOF
+}
+
+func Open(name string) (int, error) { // origins: New
+	if name == "" {
+		return 0, New("empty name")
+	}
+	return 1, nil
+}
+
+func Read(fd int) (int, error) { // origins: New Sentinel
+	if fd < 0 {
+		return 0, New("bad fd")
+	}
+	return 0, Sentinel()
+}
+
+func ReadFile(name string) error { // origins: New Sentinel
+	fd, err := Open(name)
+	if err != nil {
+		return Wrap(err, "open")
+	}
+	_, err = Read(fd)
+	if err != nil {
+		return &wrapError{"read", err}
+	}
+	return nil
+}
+
+func Wrap(err error, msg string) error { // origins:
+	return &wrapError{msg, err}
+}
+
+func PassThrough(err error) error { // origins:
+	return err
+}
+
+func Close() error { // origins:
+	return nil
+}
+
+func Swallow() {
+	Close()             // swallowed: ignored
+	_, _ = Open("x")    // swallowed: ignored
+	if Close() != nil { // swallowed: checked but dropped
+		return
+	}
+	defer Close() // swallowed: deferred
+	go Close()    // swallowed: discarded by go
+	if err := ReadFile("x"); err != nil {
+		println(err.Error())
+	}
+}
+
+func main() {}
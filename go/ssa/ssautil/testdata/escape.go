@@ -0,0 +1,97 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// This file is the input to TestEscapes in escape_test.go.  Each
+// allocation reported by Escapes must be on a line with a comment of
+// the form "escapes: reason"; no other allocation may be reported.
+
+type T struct {
+	p *int
+	s []int
+}
+
+var global *int
+
+func Returned() *int {
+	x := 1 // escapes: returned
+	return &x
+}
+
+func Local() int {
+	x := 1
+	p := &x
+	*p++
+	return x
+}
+
+func Global() {
+	x := 1 // escapes: stored in global variable global
+	global = &x
+}
+
+func Field() int {
+	x := 1
+	t := &T{}
+	t.p = &x
+	_ = t
+	return *t.p
+}
+
+func Container() *T {
+	x := 1         // escapes: stored in complit, which escapes
+	t := &T{p: &x} // escapes: returned
+	return t
+}
+
+func Param(t *T) {
+	x := 1 // escapes: stored through a pointer
+	t.p = &x
+}
+
+func Slices() int {
+	s := make([]int, 10)
+	s[0] = 1
+	return len(s)
+}
+
+func SliceReturned() []int {
+	return make([]int, 10) // escapes: returned
+}
+
+func Chan(ch chan *int) {
+	x := 1 // escapes: sent on a channel
+	ch <- &x
+}
+
+func Call() {
+	x := 1 // escapes: passed to sink
+	sink(&x)
+}
+
+func sink(*int) {}
+
+func Closure() int {
+	x := 0
+	f := func() { x++ }
+	f()
+	return x
+}
+
+func ClosureReturned() func() int {
+	x := 0                              // escapes: returned
+	return func() int { x++; return x } // escapes: returned
+}
+
+func ClosureEscape() {
+	x := 0 // escapes: captured by ClosureEscape$1, in which it is stored in global variable global
+	func() { global = &x }()
+}
+
+func Goroutine() {
+	x := 0              // escapes: used by a go statement
+	go func() { x++ }() // escapes: used by a go statement
+}
+
+func main() {}
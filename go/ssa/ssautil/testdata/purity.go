@@ -0,0 +1,107 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// This file is the input to TestEffects in purity_test.go.  Each
+// function must be declared on a line with a comment of the form
+// "effect: E", where E is the effect inferred for it by Effects.
+
+type T struct {
+	n int
+	m map[string]int
+}
+
+var global int
+
+func Add(x, y int) int { // effect: pure
+	return x + y
+}
+
+func NewT() *T { // effect: pure
+	m := make(map[string]int)
+	m["a"] = 1
+	t := &T{m: m}
+	t.n = 1
+	return t
+}
+
+func Sum(n int) int { // effect: pure
+	s := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		s = append(s, Add(i, 1))
+	}
+	return len(s)
+}
+
+func Fact(n int) int { // effect: pure
+	if n == 0 {
+		return 1
+	}
+	return n * Fact(n-1)
+}
+
+func Get(t *T) int { // effect: readonly
+	return t.n
+}
+
+func Lookup(m map[string]int) int { // effect: readonly
+	return m["a"]
+}
+
+func Global() int { // effect: readonly
+	return global
+}
+
+func CallsGet(t *T) int { // effect: readonly
+	return Get(t) + Add(1, 2)
+}
+
+func Set(t *T) { // effect: impure
+	t.n = 1
+}
+
+func SetGlobal() { // effect: impure
+	global++
+}
+
+func Update(m map[string]int) { // effect: impure
+	m["a"] = 1
+}
+
+func Delete(m map[string]int) { // effect: impure
+	delete(m, "a")
+}
+
+func Append(s []int) []int { // effect: impure
+	return append(s, 1)
+}
+
+func Send(ch chan int) { // effect: impure
+	ch <- 1
+}
+
+func Print() { // effect: impure
+	println("hello")
+}
+
+func Dynamic(f func() int) int { // effect: impure
+	return f()
+}
+
+func Even(n int) bool { // effect: impure
+	if n == 0 {
+		return true
+	}
+	return Odd(n - 1)
+}
+
+func Odd(n int) bool { // effect: impure
+	if n == 0 {
+		SetGlobal()
+		return false
+	}
+	return Even(n - 1)
+}
+
+func main() {}
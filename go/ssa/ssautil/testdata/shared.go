@@ -0,0 +1,84 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// This file is the input to TestSharedVars in shared_test.go.  Each
+// unsynchronized access reported by SharedVars must be on a line with
+// a comment of the form "unsynchronized: v", naming its variable; no
+// other access may be reported.
+
+type Mutex struct{ state int }
+
+func (m *Mutex) Lock()   {}
+func (m *Mutex) Unlock() {}
+
+var (
+	counter int
+	config  string
+	locked  int
+	mu      Mutex
+)
+
+func Counter() {
+	go func() {
+		counter++ // unsynchronized: counter
+	}()
+	counter++ // unsynchronized: counter
+}
+
+func Config() {
+	config = "x" // happens before the go statement
+	go func() {
+		println(config)
+	}()
+	println(config) // read only
+}
+
+func Locked() {
+	go func() {
+		mu.Lock()
+		locked++
+		mu.Unlock()
+	}()
+	mu.Lock()
+	locked++
+	mu.Unlock()
+}
+
+func Captured() int {
+	n := 0
+	done := make(chan bool)
+	go func() {
+		n = 1 // unsynchronized: n
+		done <- true
+	}()
+	n = 2 // unsynchronized: n
+	<-done
+	return n
+}
+
+func Channel() int {
+	m := 0
+	done := make(chan bool)
+	go func() {
+		<-done
+		m = 1
+	}()
+	done <- true
+	return m
+}
+
+func Loop() {
+	for i := 0; i < 10; i++ {
+		go incr()
+	}
+}
+
+func incr() {
+	total++ // unsynchronized: total
+}
+
+var total int
+
+func main() {}
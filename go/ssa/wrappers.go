@@ -21,10 +21,113 @@ package ssa
 
 import (
 	"fmt"
+	"sort"
 
 	"golang.org/x/tools/go/types"
 )
 
+// -- enumeration ------------------------------------------------------
+
+// A WrapperKind identifies a kind of synthetic function that delegates
+// to a declared method.
+type WrapperKind int
+
+const (
+	NotWrapper    WrapperKind = iota // not a synthetic wrapper
+	MethodWrapper                    // method performing implicit indirections or field selections
+	Thunk                            // function for a method expression T.f
+	Bound                            // closure body for a method value x.f
+)
+
+var wrapperKindNames = [...]string{
+	NotWrapper:    "not a wrapper",
+	MethodWrapper: "wrapper",
+	Thunk:         "thunk",
+	Bound:         "bound",
+}
+
+func (k WrapperKind) String() string {
+	if 0 <= k && int(k) < len(wrapperKindNames) {
+		return wrapperKindNames[k]
+	}
+	return fmt.Sprintf("WrapperKind(%d)", int(k))
+}
+
+// A Wrapper describes a synthetic function that delegates to a
+// declared method, and links it to the source constructs from which
+// it originates.
+type Wrapper struct {
+	Func *Function
+	Kind WrapperKind
+
+	// Method is the declared (concrete or interface) method to
+	// which Func delegates; it is the same as Func.Object().
+	Method *types.Func
+
+	// Selection is the selection that gave rise to a MethodWrapper
+	// or Thunk: its receiver type and the path of embedded fields
+	// from the receiver to Method.  It is nil for a Bound.
+	Selection *types.Selection
+}
+
+// Wrapper returns the description of v if it is a synthetic wrapper,
+// or nil otherwise.
+func (v *Function) Wrapper() *Wrapper {
+	if v.wrapper == NotWrapper {
+		return nil
+	}
+	return &Wrapper{
+		Func:      v,
+		Kind:      v.wrapper,
+		Method:    v.object.(*types.Func),
+		Selection: v.method,
+	}
+}
+
+// Wrappers returns a new slice containing the synthetic wrappers
+// created so far for prog, in order of their String values.  Wrappers
+// are created on demand, as the building of functions requires them
+// and as the methods of types are requested (see Program.Method);
+// build all packages first to obtain all the wrappers that the program
+// needs.
+//
+// Thread-safe.
+//
+// EXCLUSIVE_LOCKS_ACQUIRED(prog.methodsMu)
+//
+func (prog *Program) Wrappers() []*Wrapper {
+	prog.methodsMu.Lock()
+	defer prog.methodsMu.Unlock()
+
+	var fns []*Function
+	prog.methodSets.Iterate(func(_ types.Type, v interface{}) {
+		for _, fn := range v.(*methodSet).mapping {
+			if fn.wrapper != NotWrapper {
+				fns = append(fns, fn)
+			}
+		}
+	})
+	for _, fn := range prog.thunks {
+		fns = append(fns, fn)
+	}
+	for _, fn := range prog.bounds {
+		fns = append(fns, fn)
+	}
+
+	wrappers := make([]*Wrapper, len(fns))
+	for i, fn := range fns {
+		wrappers[i] = fn.Wrapper()
+	}
+	sort.Sort(byWrapperName(wrappers))
+	return wrappers
+}
+
+type byWrapperName []*Wrapper
+
+func (a byWrapperName) Len() int           { return len(a) }
+func (a byWrapperName) Less(i, j int) bool { return a[i].Func.String() < a[j].Func.String() }
+func (a byWrapperName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // -- wrappers -----------------------------------------------------------
 
 // makeWrapper returns a synthetic method that delegates to the
@@ -64,9 +167,14 @@ func makeWrapper(prog *Program, sel *types.Selection) *Function {
 	if prog.mode&LogSource != 0 {
 		defer logStack("make %s to (%s)", description, recv.Type())()
 	}
+	kind := MethodWrapper
+	if sel.Kind() == types.MethodExpr {
+		kind = Thunk
+	}
 	fn := &Function{
 		name:      name,
 		method:    sel,
+		wrapper:   kind,
 		object:    obj,
 		Signature: sig,
 		Synthetic: description,
@@ -189,6 +297,7 @@ func makeBound(prog *Program, obj *types.Func) *Function {
 		}
 		fn = &Function{
 			name:      obj.Name() + "$bound",
+			wrapper:   Bound,
 			object:    obj,
 			Signature: changeRecv(obj.Type().(*types.Signature), nil), // drop receiver
 			Synthetic: description,
@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"sort"
 
 	"golang.org/x/tools/go/exact"
 )
@@ -55,6 +56,24 @@ type Error struct {
 	Pos  token.Pos      // error position
 	Msg  string         // error message
 	Soft bool           // if set, error is "soft"
+
+	// Related lists additional source positions that help explain
+	// the error, such as the declaration that determined the type
+	// an operand was converted to. Related may be nil.
+	Related []RelatedPos
+
+	// Suggestions lists plausible corrections for an undeclared
+	// or misspelled name, closest first. Suggestions may be nil.
+	// A qualified suggestion p.x names a member of a package p
+	// imported by the file or, if the file would have to import it,
+	// loaded by the importer (see Config.Packages).
+	Suggestions []string
+}
+
+// A RelatedPos describes a source position related to an Error.
+type RelatedPos struct {
+	Pos token.Pos // related position
+	Msg string    // describes how the position relates to the error
 }
 
 // Error returns an error string formatted as follows:
@@ -92,6 +111,17 @@ type Config struct {
 	//          Do not use casually!
 	FakeImportC bool
 
+	// If StubMissingImports is set, an import that cannot be found
+	// declares a stub package in its place (see Package.Stub), and
+	// the error reporting the failed import is soft.  A qualified
+	// identifier referring to a stub package denotes a member that
+	// is materialized on first use as a variable of invalid type, so
+	// that no errors follow from it.  Identifiers referring to a
+	// dot-imported stub package are still reported as undeclared.
+	// This feature is intended for editors, which must check
+	// packages whose dependencies are not yet available.
+	StubMissingImports bool
+
 	// Packages is used to look up (and thus canonicalize) packages by
 	// package path. If Packages is nil, it is set to a new empty map.
 	// During type-checking, imported packages are added to the map.
@@ -117,6 +147,21 @@ type Config struct {
 	// If DisableUnusedImportCheck is set, packages are not checked
 	// for unused imports.
 	DisableUnusedImportCheck bool
+
+	// If ConcurrentBodies > 1, function bodies are type-checked by
+	// up to that many goroutines once all package-level declarations
+	// are resolved.  The recorded information is the same as for
+	// sequential checking, and Error is called from the goroutine
+	// that called Check, with the same errors in the same order, but
+	// only once all bodies have been checked.  If Error is nil, all
+	// bodies are checked even if an early one has an error, and the
+	// recorded information may be correspondingly more complete.
+	ConcurrentBodies int
+
+	// If Strings != nil, the names of the objects declared in the
+	// checked files and the paths passed to Import are interned in
+	// Strings.  The files themselves are not modified.
+	Strings *StringTable
 }
 
 // DefaultImport is the default importer invoked if Config.Import == nil.
@@ -204,6 +249,47 @@ type Info struct {
 	//
 	Scopes map[ast.Node]*Scope
 
+	// Writes maps each left-hand side operand of an assignment,
+	// compound assignment, increment/decrement, short variable
+	// declaration, or range statement to the kind of write that
+	// the statement performs to it. The operand appears as written,
+	// possibly parenthesized.
+	Writes map[ast.Expr]WriteKind
+
+	// Untyped maps each formerly untyped expression, such as an
+	// untyped constant, that was given a typed final type to the
+	// context that determined that type, for explanations such as
+	// why a constant is an int rather than an int64. Untyped
+	// expressions that are operands of constant expressions are
+	// omitted, as they are never given a final type.
+	Untyped map[ast.Expr]UntypedContext
+
+	// Conversions maps each expression whose value is implicitly
+	// converted to another type, as by an assignment (including the
+	// passing of arguments and the returning of results), a
+	// comparison with an operand of another type, or the typing of
+	// an untyped value, to its conversion. The conversion of a
+	// multi-valued expression, such as a function call or a comma-ok
+	// expression, is that of the tuple of its values. An untyped
+	// constant that is converted to its default type and then to an
+	// interface is recorded as a single conversion from the untyped
+	// type, and has the default type in Types.
+	Conversions map[ast.Expr]Conversion
+
+	// Variadics maps each call of a variadic function, including the
+	// built-in append, to the passing of its arguments to the final
+	// (variadic) parameter.
+	Variadics map[*ast.CallExpr]Variadic
+
+	// Skipped lists the syntax that was not checked because the parser
+	// produced bad nodes for it (*ast.BadExpr, *ast.BadStmt, and
+	// *ast.BadDecl), sorted by position. Bad expressions have invalid
+	// types and cause no errors, nor do the operands, variables, and
+	// statements that depend on them, so that editors may check
+	// incomplete files and report which regions were skipped.
+	// Unlike the maps above, Skipped is always collected.
+	Skipped []ast.Node
+
 	// InitOrder is the list of package-level initializers in the order in which
 	// they must be executed. Initializers referring to variables related by an
 	// initialization dependency appear in topological order, the others appear
@@ -242,6 +328,162 @@ func (info *Info) ObjectOf(id *ast.Ident) Object {
 	return info.Uses[id]
 }
 
+// A SelectorKind classifies a selector expression x.f by what it
+// denotes.  Unlike the SelectionKind of a Selection, it distinguishes
+// qualified identifiers, which have no Selection.
+type SelectorKind int
+
+const (
+	InvalidSelector    SelectorKind = iota // x.f was not (successfully) checked
+	QualifiedIdent                         // x.f is a qualified identifier pkg.f
+	FieldSelector                          // x.f is a struct field selector
+	MethodSelector                         // x.f is a method selector
+	MethodExprSelector                     // x.f is a method expression
+)
+
+var selectorKindNames = [...]string{
+	InvalidSelector:    "invalid selector",
+	QualifiedIdent:     "qualified identifier",
+	FieldSelector:      "field selector",
+	MethodSelector:     "method selector",
+	MethodExprSelector: "method expression",
+}
+
+func (k SelectorKind) String() string {
+	if 0 <= k && int(k) < len(selectorKindNames) {
+		return selectorKindNames[k]
+	}
+	return fmt.Sprintf("SelectorKind(%d)", int(k))
+}
+
+// Selector reports what the selector expression e denotes, and the
+// object it selects: the package member of a qualified identifier,
+// or the field or method of a selection.  The object of a qualified
+// identifier whose member was not found is nil.
+//
+// Precondition: the Uses and Selections maps are populated.
+//
+func (info *Info) Selector(e *ast.SelectorExpr) (SelectorKind, Object) {
+	if sel, ok := info.Selections[e]; ok {
+		switch sel.Kind() {
+		case FieldVal:
+			return FieldSelector, sel.Obj()
+		case MethodVal:
+			return MethodSelector, sel.Obj()
+		case MethodExpr:
+			return MethodExprSelector, sel.Obj()
+		}
+	}
+	if x, ok := e.X.(*ast.Ident); ok {
+		if _, ok := info.Uses[x].(*PkgName); ok {
+			return QualifiedIdent, info.Uses[e.Sel]
+		}
+	}
+	return InvalidSelector, nil
+}
+
+// ReleaseBodies deletes from info the entries for syntax within the
+// bodies of functions and function literals declared in files, so
+// that the memory they occupy may be reclaimed.  Entries for
+// package-level syntax, including function signatures, are retained.
+// Clients that no longer need the body-level information of a package
+// should also call Package.ReleaseBodies.
+//
+func (info *Info) ReleaseBodies(files []*ast.File) {
+	// Collect the extents of all bodies, in order.
+	// Nested function literals lie within them.
+	var bodies []*ast.BlockStmt
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				if n.Body != nil {
+					bodies = append(bodies, n.Body)
+				}
+				return false
+			case *ast.FuncLit:
+				bodies = append(bodies, n.Body)
+				return false
+			}
+			return true
+		})
+	}
+	// Files may be in any order.
+	sort.Sort(byPos(bodies))
+	inBody := func(n ast.Node) bool {
+		pos := n.Pos()
+		i := sort.Search(len(bodies), func(i int) bool { return bodies[i].End() > pos })
+		return i < len(bodies) && bodies[i].Pos() <= pos
+	}
+
+	for x := range info.Types {
+		if inBody(x) {
+			delete(info.Types, x)
+		}
+	}
+	for id := range info.Defs {
+		if inBody(id) {
+			delete(info.Defs, id)
+		}
+	}
+	for id := range info.Uses {
+		if inBody(id) {
+			delete(info.Uses, id)
+		}
+	}
+	for n := range info.Implicits {
+		if inBody(n) {
+			delete(info.Implicits, n)
+		}
+	}
+	for x := range info.Selections {
+		if inBody(x) {
+			delete(info.Selections, x)
+		}
+	}
+	for n := range info.Scopes {
+		if inBody(n) {
+			delete(info.Scopes, n)
+		}
+	}
+	for x := range info.Writes {
+		if inBody(x) {
+			delete(info.Writes, x)
+		}
+	}
+	for x := range info.Untyped {
+		if inBody(x) {
+			delete(info.Untyped, x)
+		}
+	}
+	for x := range info.Conversions {
+		if inBody(x) {
+			delete(info.Conversions, x)
+		}
+	}
+	for call := range info.Variadics {
+		if inBody(call) {
+			delete(info.Variadics, call)
+		}
+	}
+	skipped := info.Skipped[:0]
+	for _, n := range info.Skipped {
+		if !inBody(n) {
+			skipped = append(skipped, n)
+		}
+	}
+	for i := len(skipped); i < len(info.Skipped); i++ {
+		info.Skipped[i] = nil // release the node
+	}
+	info.Skipped = skipped
+}
+
+type byPos []*ast.BlockStmt
+
+func (a byPos) Len() int           { return len(a) }
+func (a byPos) Less(i, j int) bool { return a[i].Pos() < a[j].Pos() }
+func (a byPos) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // TypeAndValue reports the type and value (for constants)
 // of the corresponding expression.
 type TypeAndValue struct {
@@ -305,6 +547,103 @@ func (tv TypeAndValue) HasOk() bool {
 	return tv.mode == commaok || tv.mode == mapindex
 }
 
+// A Conversion describes the implicit conversion of the value of an
+// expression (see Info.Conversions). From and To are not identical.
+type Conversion struct {
+	From Type // the type of the expression, possibly untyped
+	To   Type // the type to which its value is converted
+}
+
+// A Variadic describes the passing of the arguments of a call to the
+// variadic parameter ...T of the called function (see Info.Variadics).
+//
+// Unless the call has the form f(x, s...), in which the slice s is
+// passed as the parameter, the arguments after the regular parameters
+// are packed into a new slice of type []T.  The arguments are the
+// values of the multi-valued expression g() in the call f(g()).
+// The call append(b, s...) of a string s appends the bytes of s.
+type Variadic struct {
+	Ellipsis bool // the final argument is followed by ...
+	Args     int  // the number of arguments passed to the parameter; 1 if Ellipsis
+	Elem     Type // the element type T
+}
+
+// An UntypedContext describes the context that determined the final
+// type of a formerly untyped expression (see Info.Untyped).
+type UntypedContext struct {
+	Kind ContextKind
+
+	// Obj is, for an AssignmentContext or a DefaultContext, the
+	// variable, parameter, result, or constant assigned, if any.
+	// For a DefaultContext, it is a variable of interface type or
+	// whose type was inferred from the expression (var x = 1).
+	Obj Object
+
+	// Expr is, for an OperandContext, the other operand whose type
+	// was imposed, and, for a ConversionContext, the conversion.
+	Expr ast.Expr
+}
+
+// A ContextKind classifies the context that determined the final type
+// of an untyped expression.
+type ContextKind int
+
+const (
+	OtherContext      ContextKind = iota // some other context, such as an index or a built-in argument
+	AssignmentContext                    // assignment to a value of the type (var x int64 = 1, f(1) for f(int64))
+	DefaultContext                       // the default type, in the absence of a type (var x = 1, x == 1.0)
+	OperandContext                       // the type of the other operand of an operation (x + 1, x == 1)
+	ConversionContext                    // an explicit conversion (int64(1))
+)
+
+var contextKindNames = [...]string{
+	OtherContext:      "other",
+	AssignmentContext: "assignment",
+	DefaultContext:    "default",
+	OperandContext:    "operand",
+	ConversionContext: "conversion",
+}
+
+func (k ContextKind) String() string {
+	if 0 <= k && int(k) < len(contextKindNames) {
+		return contextKindNames[k]
+	}
+	return fmt.Sprintf("ContextKind(%d)", int(k))
+}
+
+// A WriteKind classifies the write performed to a left-hand side
+// operand of an assignment (see Info.Writes).
+type WriteKind int
+
+const (
+	DefineWrite   WriteKind = iota + 1 // declaration of a new variable by := (x := e)
+	RedefineWrite                      // assignment to an existing variable by := (x, y := e, f)
+	VarWrite                           // assignment to a variable (x = e, p.x = e for a package p)
+	FieldWrite                         // assignment to a struct field (x.f = e)
+	IndexWrite                         // assignment to an array or slice element (a[i] = e)
+	MapWrite                           // assignment to a map element (m[k] = e)
+	DerefWrite                         // assignment through a pointer indirection (*p = e)
+	BlankWrite                         // assignment to the blank identifier (_ = e)
+)
+
+var writeKindNames = [...]string{
+	DefineWrite:   "define",
+	RedefineWrite: "redefine",
+	VarWrite:      "var",
+	FieldWrite:    "field",
+	IndexWrite:    "index",
+	MapWrite:      "map",
+	DerefWrite:    "deref",
+	BlankWrite:    "blank",
+}
+
+func (k WriteKind) String() string {
+	if 0 < k && int(k) < len(writeKindNames) {
+		return writeKindNames[k]
+	}
+	return fmt.Sprintf("WriteKind(%d)", int(k))
+}
+
 // An Initializer describes a package-level variable, or a list of variables in case
 // of a multi-valued initialization expression, and the corresponding initialization
 // expression.
@@ -11,6 +11,7 @@ import (
 	"go/parser"
 	"go/token"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 
@@ -506,6 +507,797 @@ func TestScopesInfo(t *testing.T) {
 	}
 }
 
+func TestSkipped(t *testing.T) {
+	// Each file has a syntax error, for which the parser produces a bad node.
+	sources := []string{
+		"package p; 123",
+		"package p; func f() { x := ; _ = x }",
+		"package p; func g() { var x int = 1 +; _ = x }",
+		"package p; func h() { for i := range { _ = i } }",
+		"package p; func i() { go }",
+		"package p; func j() { var m map[string] ; _ = m }",
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range sources {
+		f, _ := parser.ParseFile(fset, fmt.Sprintf("p%d.go", i), src, 0)
+		files = append(files, f)
+	}
+	var errs []string
+	conf := Config{Error: func(err error) { errs = append(errs, err.Error()) }}
+	info := &Info{Types: make(map[ast.Expr]TypeAndValue)}
+	if _, err := conf.Check("p", fset, files, info); err != nil {
+		t.Errorf("Check failed: %s", err) // bad nodes are not errors
+	}
+	if errs != nil {
+		t.Errorf("unexpected errors:\n%s", strings.Join(errs, "\n"))
+	}
+
+	var got []string
+	for _, n := range info.Skipped {
+		got = append(got, fmt.Sprintf("%T %s", n, fset.Position(n.Pos())))
+	}
+	want := []string{
+		"*ast.BadDecl p0.go:1:12",
+		"*ast.BadExpr p1.go:1:28",
+		"*ast.BadExpr p2.go:1:38",
+		"*ast.BadExpr p3.go:1:38",
+		"*ast.BadStmt p4.go:1:23",
+		"*ast.BadExpr p5.go:1:41",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got skipped:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStubMissingImports(t *testing.T) {
+	const src = `package p
+
+import (
+	"missing/go-yaml.v2"
+	m "missing/other"
+)
+
+var x go_yaml_v2.T = m.F(1)
+
+func f() int {
+	var y int = x.Field
+	x.Method(y)
+	m.V = 2
+	return go_yaml_v2.C + 1
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []Error
+	conf := Config{
+		StubMissingImports: true,
+		Import: func(map[string]*Package, string) (*Package, error) {
+			return nil, fmt.Errorf("not found")
+		},
+		Error: func(err error) { errs = append(errs, err.(Error)) },
+	}
+	info := &Info{Uses: make(map[*ast.Ident]Object)}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, info)
+
+	// Only the failed imports are reported, as soft errors.
+	var got []string
+	for _, err := range errs {
+		got = append(got, fmt.Sprintf("%s soft=%t", err.Msg, err.Soft))
+	}
+	want := []string{
+		"could not import missing/go-yaml.v2 (not found) soft=true",
+		"could not import missing/other (not found) soft=true",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got errors:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The stubs hold the materialized members.
+	var stubs []string
+	for _, imp := range pkg.Imports() {
+		if !imp.Stub() {
+			t.Errorf("%s is not a stub", imp.Path())
+		}
+		stubs = append(stubs, fmt.Sprintf("%s %s %s", imp.Path(), imp.Name(), imp.Scope().Names()))
+	}
+	want = []string{
+		"missing/go-yaml.v2 go_yaml_v2 [C T]",
+		"missing/other other [F V]",
+	}
+	if got, want := strings.Join(stubs, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got stubs:\n%s\nwant:\n%s", got, want)
+	}
+	for id, obj := range info.Uses {
+		if obj.Pkg() != nil && obj.Pkg().Stub() && obj.Type() != Typ[Invalid] {
+			t.Errorf("%s: stub member %s has type %s", fset.Position(id.Pos()), obj, obj.Type())
+		}
+	}
+}
+
+func TestUntypedInfo(t *testing.T) {
+	const src = `package p
+
+const c int64 = 10
+
+var a = 20
+var b int32 = 30 + 31
+
+func f(x int8) int16 {
+	f(40)
+	_ = x + 50
+	_ = int64(60)
+	var e interface{} = 70
+	_ = e
+	switch x {
+	case 80:
+	}
+	_ = x == 90
+	_ = []int{100}
+	return 110
+}
+`
+	info := Info{Untyped: make(map[ast.Expr]UntypedContext)}
+	mustTypecheck(t, "UntypedInfo", src, &info)
+
+	var got []string
+	for e, ctx := range info.Untyped {
+		s := fmt.Sprintf("%s: %s", ExprString(e), ctx.Kind)
+		if ctx.Obj != nil {
+			s += fmt.Sprintf(" obj=%q", ctx.Obj.Name())
+		}
+		if ctx.Expr != nil {
+			s += " expr=" + ExprString(ctx.Expr)
+		}
+		got = append(got, s)
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"100: assignment",
+		"10: assignment obj=\"c\"",
+		"110: assignment obj=\"\"", // anonymous result
+		"20: default obj=\"a\"",
+		"30 + 31: assignment obj=\"b\"",
+		"40: assignment obj=\"x\"",
+		"50: operand expr=x",
+		"60: conversion expr=int64(60)",
+		"70: default obj=\"e\"",
+		"80: operand expr=x",
+		"90: operand expr=x",
+		"x == 90: default",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConversionsInfo(t *testing.T) {
+	const src = `package p
+
+type T int
+
+type S []int
+
+func (T) String() string { return "" }
+
+type Stringer interface {
+	String() string
+}
+
+func f(x int64, s ...interface{}) (error, interface{}) {
+	var t T
+	var p *T = nil
+	var e interface{} = 10
+	var u S = []int{20}
+	f(30, t, 40)
+	_ = p == nil
+	_ = e == t
+	_ = int64(50) + x
+	var str Stringer = t
+	_ = str == e
+	var m map[string]int
+	var v interface{}
+	v, _ = m["a"]
+	_, _ = v, u
+	e, _ = g()
+	return nil, 60
+}
+
+func g() (int, error) { return 0, nil }
+`
+	info := Info{Conversions: make(map[ast.Expr]Conversion)}
+	mustTypecheck(t, "ConversionsInfo", src, &info)
+
+	var got []string
+	for e, c := range info.Conversions {
+		got = append(got, fmt.Sprintf("%s: %s -> %s", ExprString(e), c.From, c.To))
+	}
+	sort.Strings(got)
+
+	want := []string{
+		`"": untyped string -> string`,
+		`"a": untyped string -> string`,
+		"([]int literal): []int -> p.S",
+		"0: untyped int -> int",
+		"10: untyped int -> interface{}",
+		"20: untyped int -> int",
+		"30: untyped int -> int64",
+		"40: untyped int -> interface{}",
+		"60: untyped int -> interface{}",
+		"e == t: untyped bool -> bool",
+		"g(): (int, error) -> (interface{}, error)",
+		`m["a"]: (int, untyped bool) -> (interface{}, bool)`,
+		"nil: untyped nil -> *p.T",
+		"nil: untyped nil -> *p.T",
+		"nil: untyped nil -> error",
+		"nil: untyped nil -> error",
+		"p == nil: untyped bool -> bool",
+		"str == e: untyped bool -> bool",
+		"str: p.Stringer -> interface{}",
+		"t: p.T -> interface{}",
+		"t: p.T -> interface{}",
+		"t: p.T -> p.Stringer",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestVariadicsInfo(t *testing.T) {
+	const src = `package p
+
+func f(format string, args ...interface{}) {}
+
+func g() (string, int, bool) { return "", 0, false }
+
+func _(s []interface{}, b []byte) {
+	f("a")
+	f("b", 1, 2)
+	f("c", s...)
+	f(g())
+	_ = append(b, 'x', 'y', 'z')
+	_ = append(b, "d"...)
+	_ = len(s)
+}
+`
+	info := Info{Variadics: make(map[*ast.CallExpr]Variadic)}
+	mustTypecheck(t, "VariadicsInfo", src, &info)
+
+	var got []string
+	for call, v := range info.Variadics {
+		got = append(got, fmt.Sprintf("%s: ellipsis=%t args=%d elem=%s", ExprString(call), v.Ellipsis, v.Args, v.Elem))
+	}
+	sort.Strings(got)
+
+	want := []string{
+		`append(b, "d"...): ellipsis=true args=1 elem=byte`,
+		"append(b, 'x', 'y', 'z'): ellipsis=false args=3 elem=byte",
+		`f("a"): ellipsis=false args=0 elem=interface{}`,
+		`f("b", 1, 2): ellipsis=false args=2 elem=interface{}`,
+		`f("c", s...): ellipsis=true args=1 elem=interface{}`,
+		"f(g()): ellipsis=false args=2 elem=interface{}",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWritesInfo(t *testing.T) {
+	const src = `package p
+
+type T struct{ f int }
+
+var g int
+
+func _(p *T, a [2]int, s []int, m map[string]int, ch chan int) {
+	x, _ := 1, 2
+	x, y := 3, 4
+	_ = y
+	g = x
+	p.f++
+	(p.f) += 1
+	a[0] = 1
+	s[1] = 2
+	m["k"] = 3
+	m["k"] |= 4
+	*p = T{}
+	for k, v := range m {
+		_, _ = k, v
+	}
+	for x = range s {
+	}
+}
+`
+	info := Info{Writes: make(map[ast.Expr]WriteKind)}
+	mustTypecheck(t, "WritesInfo", src, &info)
+
+	var got []string
+	for e, k := range info.Writes {
+		got = append(got, fmt.Sprintf("%s: %s", ExprString(e), k))
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"(p.f): field",
+		"*p: deref",
+		"_: blank",
+		"_: blank",
+		"_: blank",
+		"_: blank",
+		"a[0]: index",
+		"g: var",
+		"k: define",
+		"m[\"k\"]: map",
+		"m[\"k\"]: map",
+		"p.f: field",
+		"s[1]: index",
+		"v: define",
+		"x: define",
+		"x: redefine",
+		"x: var",
+		"y: define",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got writes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestConcurrentBodies(t *testing.T) {
+	const src = `package p
+
+var g int
+
+type T struct{ f int }
+
+func (t *T) m(x int) int { return t.f + x + g }
+
+func f1() int {
+	m := map[string]T{}
+	v, ok := m["a"]
+	_ = ok
+	return v.f
+}
+
+func f2() {
+	x := 1 // unused
+	var y string = 2
+	_ = y
+}
+
+func f3() (s string) {
+	for i := 0; i < 10; i++ {
+		s += "x"
+	}
+	g = len(s)
+	return undefined
+}
+
+func f4() {
+	func() {
+		var m map[[]int]bool
+		g++
+		_ = m
+	}()
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check returns the errors and recorded information of a
+	// check of f with the specified number of body goroutines.
+	check := func(bodies int) (errs, info []string) {
+		conf := Config{
+			ConcurrentBodies: bodies,
+			Error: func(err error) {
+				errs = append(errs, err.Error())
+			},
+		}
+		i := &Info{
+			Types:       make(map[ast.Expr]TypeAndValue),
+			Defs:        make(map[*ast.Ident]Object),
+			Uses:        make(map[*ast.Ident]Object),
+			Implicits:   make(map[ast.Node]Object),
+			Selections:  make(map[*ast.SelectorExpr]*Selection),
+			Scopes:      make(map[ast.Node]*Scope),
+			Writes:      make(map[ast.Expr]WriteKind),
+			Untyped:     make(map[ast.Expr]UntypedContext),
+			Conversions: make(map[ast.Expr]Conversion),
+			Variadics:   make(map[*ast.CallExpr]Variadic),
+		}
+		conf.Check("p", fset, []*ast.File{f}, i)
+
+		record := func(n ast.Node, format string, args ...interface{}) {
+			info = append(info, fmt.Sprintf("%s: %s", fset.Position(n.Pos()), fmt.Sprintf(format, args...)))
+		}
+		for x, tv := range i.Types {
+			record(x, "type %s %s %v", ExprString(x), tv.Type, tv.Value)
+		}
+		for id, obj := range i.Defs {
+			record(id, "def %s %v", id.Name, obj)
+		}
+		for id, obj := range i.Uses {
+			record(id, "use %s %v", id.Name, obj)
+		}
+		for n, obj := range i.Implicits {
+			record(n, "implicit %v", obj)
+		}
+		for x, sel := range i.Selections {
+			record(x, "selection %s", sel)
+		}
+		for n, scope := range i.Scopes {
+			record(n, "scope %d %s", scope.Len(), scope.Names())
+		}
+		for x, kind := range i.Writes {
+			record(x, "write %s %d", ExprString(x), kind)
+		}
+		for x, ctx := range i.Untyped {
+			record(x, "untyped %s %s %v", ExprString(x), ctx.Kind, ctx.Obj)
+		}
+		for x, c := range i.Conversions {
+			record(x, "conversion %s %s %s", ExprString(x), c.From, c.To)
+		}
+		for call, v := range i.Variadics {
+			record(call, "variadic %s %t %d %s", ExprString(call), v.Ellipsis, v.Args, v.Elem)
+		}
+		sort.Strings(info)
+		return
+	}
+
+	errs, info := check(0)
+	if len(errs) != 4 {
+		t.Errorf("got %d errors, want 4: %s", len(errs), strings.Join(errs, "\n"))
+	}
+	for _, bodies := range []int{2, 3, 8} {
+		errs2, info2 := check(bodies)
+		if got, want := strings.Join(errs2, "\n"), strings.Join(errs, "\n"); got != want {
+			t.Errorf("ConcurrentBodies=%d: got errors\n%s\nwant\n%s", bodies, got, want)
+		}
+		if got, want := strings.Join(info2, "\n"), strings.Join(info, "\n"); got != want {
+			t.Errorf("ConcurrentBodies=%d: got info\n%s\nwant\n%s", bodies, got, want)
+		}
+	}
+}
+
+// TestConcurrentBodiesSizes checks that bodies checked concurrently
+// may compute the sizes and offsets of the same struct types, as
+// "go test -race" verifies.
+func TestConcurrentBodiesSizes(t *testing.T) {
+	const n = 16
+	var buf bytes.Buffer
+	buf.WriteString("package p\n\nimport \"unsafe\"\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "\ntype T%d struct{ a, b int8; c int64; d struct{ e int32 } }\n\nvar t%d T%d\n", i, i, i)
+	}
+	// Each function computes the sizes of all types, in a different
+	// order, so that the workers compute them concurrently.
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "\nfunc f%d() {\n", i)
+		for j := 0; j < n; j++ {
+			k := (i + j) % n
+			fmt.Fprintf(&buf, "\tconst _, _ = unsafe.Sizeof(t%d), unsafe.Offsetof(t%d.c)\n", k, k)
+		}
+		buf.WriteString("}\n")
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", buf.String(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{Types: make(map[ast.Expr]TypeAndValue)}
+	conf := Config{ConcurrentBodies: 4}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+	for e, tv := range info.Types {
+		if call, ok := e.(*ast.CallExpr); ok {
+			want := "20" // Sizeof, without trailing padding
+			if sel := call.Fun.(*ast.SelectorExpr); sel.Sel.Name == "Offsetof" {
+				want = "8"
+			}
+			if tv.Value == nil || tv.Value.String() != want {
+				t.Errorf("%s = %v, want %s", ExprString(call), tv.Value, want)
+			}
+		}
+	}
+}
+
+func TestReleaseBodies(t *testing.T) {
+	const src = `package p
+
+type T struct{ F int }
+
+func (t T) M(x int) int {
+	y := x + len(append([]int(nil), t.F))
+	if y > 0 {
+		return y
+	}
+	return 0
+}
+
+var V = func(a int) int { b := a; go; return b }(1)
+
+123
+`
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, "p.go", src, 0) // with a bad statement and a bad declaration
+	info := &Info{
+		Types:  make(map[ast.Expr]TypeAndValue),
+		Defs:   make(map[*ast.Ident]Object),
+		Uses:   make(map[*ast.Ident]Object),
+		Scopes: make(map[ast.Node]*Scope),
+		Writes: make(map[ast.Expr]WriteKind),
+
+		Conversions: make(map[ast.Expr]Conversion),
+		Variadics:   make(map[*ast.CallExpr]Variadic),
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info.ReleaseBodies([]*ast.File{f})
+	pkg.ReleaseBodies()
+
+	var defs []string
+	for id := range info.Defs {
+		defs = append(defs, id.Name)
+	}
+	sort.Strings(defs)
+	if got, want := strings.Join(defs, " "), "F M T V a p t x"; got != want {
+		t.Errorf("Defs after ReleaseBodies: got %s, want %s", got, want)
+	}
+	for x := range info.Types {
+		if line := fset.Position(x.Pos()).Line; line >= 6 && line <= 10 {
+			t.Errorf("Types after ReleaseBodies: unexpected entry for %s", ExprString(x))
+		}
+	}
+	if len(info.Writes) != 0 {
+		t.Errorf("Writes after ReleaseBodies: got %d entries, want none", len(info.Writes))
+	}
+	for x := range info.Conversions {
+		if line := fset.Position(x.Pos()).Line; line >= 6 && line <= 10 {
+			t.Errorf("Conversions after ReleaseBodies: unexpected entry for %s", ExprString(x))
+		}
+	}
+	if len(info.Variadics) != 0 {
+		t.Errorf("Variadics after ReleaseBodies: got %d entries, want none", len(info.Variadics))
+	}
+	if len(info.Skipped) != 1 {
+		t.Errorf("Skipped after ReleaseBodies: got %d nodes, want 1", len(info.Skipped))
+	} else if _, ok := info.Skipped[0].(*ast.BadDecl); !ok {
+		t.Errorf("Skipped after ReleaseBodies: got %T, want *ast.BadDecl", info.Skipped[0])
+	}
+	if len(info.Scopes) != 3 { // file, M, and function literal
+		t.Errorf("Scopes after ReleaseBodies: got %d entries, want 3", len(info.Scopes))
+	}
+
+	if pkg.Scope().Lookup("T") == nil || pkg.Scope().Lookup("V") == nil {
+		t.Errorf("package scope lost its objects")
+	}
+	file := pkg.Scope().Child(0)
+	for i, n := 0, file.NumChildren(); i < n; i++ {
+		if fn := file.Child(i); fn.NumChildren() != 0 {
+			t.Errorf("function scope %d retains %d child scopes", i, fn.NumChildren())
+		}
+	}
+	if m := pkg.Scope().Lookup("T").Type().(*Named).Method(0); m.Name() != "M" ||
+		m.Type().(*Signature).Params().At(0).Name() != "x" {
+		t.Errorf("method T.M lost its signature: %s", m)
+	}
+}
+
+func TestStringTable(t *testing.T) {
+	const src = `package p; type T struct{ F int }; func f(x T) int { return x.F }`
+	conf := Config{Strings: NewStringTable()}
+	check := func() {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The 4 declared objects T, F, f, and x have distinct names.
+	check()
+	if got, want := conf.Strings.Stats(), (StringTableStats{Strings: 4, Bytes: 4, Lookups: 4, SavedBytes: 0}); got != want {
+		t.Errorf("first check: Stats() = %+v, want %+v", got, want)
+	}
+
+	// The second check finds all its names in the table.
+	check()
+	if got, want := conf.Strings.Stats(), (StringTableStats{Strings: 4, Bytes: 4, Lookups: 8, SavedBytes: 4}); got != want {
+		t.Errorf("second check: Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSelector(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+type T struct{ F int; E }
+type E struct{ G int }
+
+func (T) M() {}
+func (*E) N() {}
+
+var (
+	t T
+	_ = unsafe.Sizeof(t.F)
+	_ = t.G
+	_ = t.M
+	_ = t.N
+	_ = T.M
+	_ = (*T).N
+	_ = unsafe.Pointer(nil)
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{
+		Uses:       make(map[*ast.Ident]Object),
+		Selections: make(map[*ast.SelectorExpr]*Selection),
+	}
+	var conf Config
+	if _, err := conf.Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if e, ok := n.(*ast.SelectorExpr); ok {
+			kind, obj := info.Selector(e)
+			got = append(got, fmt.Sprintf("%s: %s %s", ExprString(e), kind, obj.Name()))
+		}
+		return true
+	})
+	want := []string{
+		"unsafe.Sizeof: qualified identifier Sizeof",
+		"t.F: field selector F",
+		"t.G: field selector G",
+		"t.M: method selector M",
+		"t.N: method selector N",
+		"T.M: method expression M",
+		"(*T).N: method expression N",
+		"unsafe.Pointer: qualified identifier Pointer",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// A selector that was not checked is invalid.
+	e := &ast.SelectorExpr{X: ast.NewIdent("x"), Sel: ast.NewIdent("f")}
+	if kind, obj := info.Selector(e); kind != InvalidSelector || obj != nil {
+		t.Errorf("Selector(unchecked) = %s, %v, want invalid selector, nil", kind, obj)
+	}
+}
+
+func TestObjectKind(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+const C = 0
+
+type T struct{ F int }
+
+type I interface{ M() }
+
+func (r T) M() {}
+
+func f(x int) (y int) {
+	var v int
+L:
+	goto L
+	return v
+}
+
+var _ = unsafe.Sizeof(C)
+var _ error = nil
+var _ = len("")
+
+func asm(x int) int
+
+func (T) Asm()
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{
+		Defs: make(map[*ast.Ident]Object),
+		Uses: make(map[*ast.Ident]Object),
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := make(map[string]ObjectKind)
+	for _, m := range []map[*ast.Ident]Object{info.Defs, info.Uses} {
+		for id, obj := range m {
+			if obj != nil {
+				kinds[id.Name] = obj.Kind()
+			}
+		}
+	}
+	// The abstract method I.M and the concrete method T.M are both methods.
+	iface := pkg.Scope().Lookup("I").Type().Underlying().(*Interface)
+	kinds["I.M"] = iface.Method(0).Kind()
+	kinds["I.M recv"] = iface.Method(0).Type().(*Signature).Recv().Kind()
+
+	for name, want := range map[string]ObjectKind{
+		"unsafe":   PkgNameObject,
+		"C":        ConstObject,
+		"T":        TypeNameObject,
+		"F":        FieldObject,
+		"r":        ParamObject,
+		"M":        MethodObject,
+		"I.M":      MethodObject,
+		"I.M recv": ParamObject,
+		"f":        FuncObject,
+		"asm":      ExternalObject,
+		"Asm":      ExternalObject,
+		"x":        ParamObject,
+		"y":        ResultObject,
+		"v":        VarObject,
+		"L":        LabelObject,
+		"Sizeof":   BuiltinObject,
+		"len":      BuiltinObject,
+		"nil":      NilObject,
+	} {
+		if got := kinds[name]; got != want {
+			t.Errorf("%s: got kind %s, want %s", name, got, want)
+		}
+	}
+
+	for _, test := range []struct {
+		kind          ObjectKind
+		isVar, isFunc bool
+	}{
+		{ConstObject, false, false},
+		{VarObject, true, false},
+		{FieldObject, true, false},
+		{ParamObject, true, false},
+		{ResultObject, true, false},
+		{FuncObject, false, true},
+		{MethodObject, false, true},
+		{ExternalObject, false, true},
+		{BuiltinObject, false, false},
+	} {
+		if got := test.kind.IsVar(); got != test.isVar {
+			t.Errorf("%s.IsVar() = %t", test.kind, got)
+		}
+		if got := test.kind.IsFunc(); got != test.isFunc {
+			t.Errorf("%s.IsFunc() = %t", test.kind, got)
+		}
+	}
+
+	// Variables of signatures built by clients are marked too.
+	p := NewParam(token.NoPos, nil, "p", Typ[Int])
+	r := NewParam(token.NoPos, nil, "r", Typ[Int])
+	NewSignature(nil, nil, NewTuple(p), NewTuple(r), false)
+	if p.Kind() != ParamObject || !p.IsParam() || r.Kind() != ResultObject || !r.IsResult() {
+		t.Errorf("NewSignature: got kinds %s, %s, want param, result", p.Kind(), r.Kind())
+	}
+}
+
 func TestInitOrderInfo(t *testing.T) {
 	var tests = []struct {
 		src   string
@@ -952,3 +1744,48 @@ func sameSlice(a, b []int) bool {
 	}
 	return true
 }
+
+func TestMemberOrder(t *testing.T) {
+	pkg, err := pkgFor("p.go", `package p
+type S struct{ z, a int; m string }
+func (S) Z() {}
+func (S) b() {}
+func (S) A() {}
+type I interface{ Z(); b(); A() }
+type J interface{ Z() }
+type K interface{ A() }
+`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(n int, at func(int) Object) string {
+		var s []string
+		for i := 0; i < n; i++ {
+			s = append(s, at(i).Name())
+		}
+		return strings.Join(s, " ")
+	}
+	S := pkg.Scope().Lookup("S").Type().(*Named)
+	st := S.Underlying().(*Struct)
+	iface := pkg.Scope().Lookup("I").Type().Underlying().(*Interface)
+	sorted := S.SortedMethods()
+	for _, test := range []struct {
+		what, got, want string
+	}{
+		{"fields", names(st.NumFields(), func(i int) Object { return st.Field(i) }), "z a m"},
+		{"methods", names(S.NumMethods(), func(i int) Object { return S.Method(i) }), "Z b A"},
+		{"sorted methods", names(len(sorted), func(i int) Object { return sorted[i] }), "A Z b"},
+		{"interface methods", names(iface.NumMethods(), func(i int) Object { return iface.Method(i) }), "A Z b"},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s: got %s, want %s", test.what, test.got, test.want)
+		}
+	}
+
+	// NewInterface sorts the embedded types too.
+	J := pkg.Scope().Lookup("J").Type().(*Named)
+	K := pkg.Scope().Lookup("K").Type().(*Named)
+	if e := NewInterface(nil, []*Named{K, J}).Embedded(0); e != J {
+		t.Errorf("NewInterface: got first embedded type %s, want %s", e, J)
+	}
+}
@@ -40,6 +40,7 @@ func (check *Checker) assignment(x *operand, T Type) bool {
 
 	if isUntyped(x.typ) {
 		target := T
+		ctx := UntypedContext{Kind: AssignmentContext, Obj: check.target}
 		// spec: "If an untyped constant is assigned to a variable of interface
 		// type or the blank identifier, the constant is first converted to type
 		// bool, rune, int, float64, complex128 or string respectively, depending
@@ -52,8 +53,9 @@ func (check *Checker) assignment(x *operand, T Type) bool {
 				return false
 			}
 			target = defaultType(x.typ)
+			ctx.Kind = DefaultContext
 		}
-		check.convertUntyped(x, target)
+		check.convertUntypedIn(x, target, ctx)
 		if x.mode == invalid {
 			return false
 		}
@@ -62,7 +64,47 @@ func (check *Checker) assignment(x *operand, T Type) bool {
 	// spec: "If a left-hand side is the blank identifier, any typed or
 	// non-constant value except for the predeclared identifier nil may
 	// be assigned to it."
-	return T == nil || x.assignableTo(check.conf, T)
+	if T == nil {
+		T = x.typ
+	} else if !x.assignableTo(check.conf, T) {
+		return false
+	}
+	check.recordOperandConversion(x, T)
+	return true
+}
+
+// assignmentTo is like assignment but also records obj as the object
+// whose declared type determined T, for use in error messages.
+func (check *Checker) assignmentTo(x *operand, T Type, obj Object) bool {
+	saved := check.target
+	check.target = obj
+	ok := check.assignment(x, T)
+	check.target = saved
+	return ok
+}
+
+// writeKind returns the kind of write performed by an assignment
+// to the (valid) lhs operand z denoted by the expression lhs.
+func (check *Checker) writeKind(lhs ast.Expr, z *operand) WriteKind {
+	if z.mode == mapindex {
+		return MapWrite
+	}
+	switch e := unparen(lhs).(type) {
+	case *ast.SelectorExpr:
+		if ident, _ := e.X.(*ast.Ident); ident != nil {
+			if _, obj := check.scope.LookupParent(ident.Name); obj != nil {
+				if _, ok := obj.(*PkgName); ok {
+					return VarWrite // qualified identifier
+				}
+			}
+		}
+		return FieldWrite
+	case *ast.IndexExpr:
+		return IndexWrite
+	case *ast.StarExpr:
+		return DerefWrite
+	}
+	return VarWrite
 }
 
 func (check *Checker) initConst(lhs *Const, x *operand) {
@@ -88,7 +130,7 @@ func (check *Checker) initConst(lhs *Const, x *operand) {
 		lhs.typ = x.typ
 	}
 
-	if !check.assignment(x, lhs.typ) {
+	if !check.assignmentTo(x, lhs.typ, lhs) {
 		if x.mode != invalid {
 			check.errorf(x.pos(), "cannot define constant %s (type %s) as %s", lhs.Name(), lhs.typ, x)
 		}
@@ -104,6 +146,7 @@ func (check *Checker) initVar(lhs *Var, x *operand, result bool) Type {
 		if lhs.typ == nil {
 			lhs.typ = Typ[Invalid]
 		}
+		lhs.used = true // avoid follow-on "declared but not used" errors
 		return nil
 	}
 
@@ -118,11 +161,12 @@ func (check *Checker) initVar(lhs *Var, x *operand, result bool) Type {
 				return nil
 			}
 			typ = defaultType(typ)
+			check.convertUntypedIn(x, typ, UntypedContext{Kind: DefaultContext, Obj: lhs})
 		}
 		lhs.typ = typ
 	}
 
-	if !check.assignment(x, lhs.typ) {
+	if !check.assignmentTo(x, lhs.typ, lhs) {
 		if x.mode != invalid {
 			if result {
 				// don't refer to lhs.name because it may be an anonymous result parameter
@@ -148,6 +192,7 @@ func (check *Checker) assignVar(lhs ast.Expr, x *operand) Type {
 	// Don't evaluate lhs if it is the blank identifier.
 	if ident != nil && ident.Name == "_" {
 		check.recordDef(ident, nil)
+		check.recordWrite(lhs, BlankWrite)
 		if !check.assignment(x, nil) {
 			assert(x.mode == invalid)
 			x.typ = nil
@@ -163,6 +208,9 @@ func (check *Checker) assignVar(lhs ast.Expr, x *operand) Type {
 	if ident != nil {
 		if _, obj := check.scope.LookupParent(ident.Name); obj != nil {
 			v, _ = obj.(*Var)
+			if v != nil && v.parent == check.pkg.scope {
+				v = nil // package-level variables are not marked
+			}
 			if v != nil {
 				v_used = v.used
 			}
@@ -190,8 +238,13 @@ func (check *Checker) assignVar(lhs ast.Expr, x *operand) Type {
 		check.errorf(z.pos(), "cannot assign to %s", &z)
 		return nil
 	}
+	check.recordWrite(lhs, check.writeKind(lhs, &z))
 
-	if !check.assignment(x, z.typ) {
+	var target Object
+	if v != nil {
+		target = v
+	}
+	if !check.assignmentTo(x, z.typ, target) {
 		if x.mode != invalid {
 			check.errorf(x.pos(), "cannot assign %s to %s", x, &z)
 		}
@@ -212,6 +265,7 @@ func (check *Checker) initVars(lhs []*Var, rhs []ast.Expr, returnPos token.Pos)
 			if obj.typ == nil {
 				obj.typ = Typ[Invalid]
 			}
+			obj.used = true // avoid follow-on "declared but not used" errors
 		}
 		if get == nil {
 			return // error reported by unpack
@@ -289,15 +343,19 @@ func (check *Checker) shortVarDecl(pos token.Pos, lhs, rhs []ast.Expr) {
 				// redeclared object must be a variable
 				if alt, _ := alt.(*Var); alt != nil {
 					obj = alt
+					check.recordWrite(lhs, RedefineWrite)
 				} else {
 					check.errorf(lhs.Pos(), "cannot assign to %s", lhs)
 				}
 				check.recordUse(ident, alt)
 			} else {
 				// declare new variable, possibly a blank (_) variable
-				obj = NewVar(ident.Pos(), check.pkg, name, nil)
+				obj = NewVar(ident.Pos(), check.pkg, check.intern(name), nil)
 				if name != "_" {
 					newVars = append(newVars, obj)
+					check.recordWrite(lhs, DefineWrite)
+				} else {
+					check.recordWrite(lhs, BlankWrite)
 				}
 				check.recordDef(ident, obj)
 			}
@@ -0,0 +1,237 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements concurrent type-checking of function bodies.
+
+package types
+
+import (
+	"go/ast"
+	"sync"
+)
+
+// Once all package-level declarations are resolved, function bodies
+// are independent of one another: each is checked by a worker, a
+// copy of the checker with its own Info maps, untyped expressions,
+// and delayed checks, and which buffers the errors it finds rather
+// than reporting them.  With the few effects of a body on shared
+// state (uses of imported packages) also buffered, the workers
+// may proceed in parallel; their results are then merged, and their
+// errors reported, in the order of the bodies, as if the bodies had
+// been checked sequentially.
+
+// A bodyResult holds the results of checking a function body that
+// are not recorded by its worker's Info.
+type bodyResult struct {
+	worker  *Checker
+	errs    []Error
+	delayed []func()
+}
+
+// A dotImportUse records the use of a dot-imported package in a file.
+type dotImportUse struct {
+	scope *Scope
+	pkg   *Package
+}
+
+// concurrentFunctionBodies typechecks all function bodies, using n
+// goroutines.
+func (check *Checker) concurrentFunctionBodies(n int) {
+	if n > len(check.funcs) {
+		n = len(check.funcs)
+	}
+	results := make([]bodyResult, len(check.funcs))
+	workers := make([]*Checker, n)
+	panics := make([]interface{}, n)
+
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for i := range workers {
+		w := check.newWorker()
+		workers[i] = w
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					panics[i] = p
+					for range next {
+						// drain
+					}
+				}
+			}()
+			for j := range next {
+				f := check.funcs[j]
+				errs := w.buffer(func() { w.funcBody(f.decl, f.name, f.sig, f.body) })
+				results[j] = bodyResult{w, errs, w.delayed}
+				w.delayed = nil
+			}
+		}(i)
+	}
+	for j := range check.funcs {
+		next <- j
+	}
+	close(next)
+	wg.Wait()
+
+	// Re-raise a panic other than a bailout in the calling goroutine.
+	for _, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+	}
+
+	for _, w := range workers {
+		check.merge(w)
+	}
+	for _, r := range results {
+		for _, f := range r.delayed {
+			w, f := r.worker, f
+			check.delay(func() {
+				for _, err := range w.buffer(f) {
+					check.report(err)
+				}
+			})
+		}
+	}
+	for _, r := range results {
+		for _, err := range r.errs {
+			check.report(err)
+		}
+	}
+}
+
+// newWorker returns a copy of check that records information in new
+// Info maps, for those that check records, and buffers errors.
+func (check *Checker) newWorker() *Checker {
+	w := *check
+	w.Info = &Info{}
+	if check.Types != nil {
+		w.Types = make(map[ast.Expr]TypeAndValue)
+	}
+	if check.Defs != nil {
+		w.Defs = make(map[*ast.Ident]Object)
+	}
+	if check.Uses != nil {
+		w.Uses = make(map[*ast.Ident]Object)
+	}
+	if check.Implicits != nil {
+		w.Implicits = make(map[ast.Node]Object)
+	}
+	if check.Selections != nil {
+		w.Selections = make(map[*ast.SelectorExpr]*Selection)
+	}
+	if check.Scopes != nil {
+		w.Scopes = make(map[ast.Node]*Scope)
+	}
+	if check.Writes != nil {
+		w.Writes = make(map[ast.Expr]WriteKind)
+	}
+	if check.Untyped != nil {
+		w.Untyped = make(map[ast.Expr]UntypedContext)
+	}
+	if check.Conversions != nil {
+		w.Conversions = make(map[ast.Expr]Conversion)
+	}
+	if check.Variadics != nil {
+		w.Variadics = make(map[*ast.CallExpr]Variadic)
+	}
+	w.unusedDotImports = nil
+	w.untyped = nil
+	w.delayed = nil
+	w.suggested = nil
+	w.firstErr = nil
+	w.isWorker = true
+	return &w
+}
+
+// buffer calls f and returns the errors it reports.  If the
+// configuration has no error handler, f stops at the first error.
+func (w *Checker) buffer(f func()) (errs []Error) {
+	w.errs = nil
+	defer func() {
+		if p := recover(); p != nil {
+			if _, ok := p.(bailout); !ok {
+				panic(p)
+			}
+		}
+		errs = w.errs
+		w.errs = nil
+	}()
+	f()
+	return
+}
+
+// merge adds the information recorded by the worker w to check.
+func (check *Checker) merge(w *Checker) {
+	for x, tv := range w.Types {
+		check.Types[x] = tv
+	}
+	for id, obj := range w.Defs {
+		check.Defs[id] = obj
+	}
+	for id, obj := range w.Uses {
+		check.Uses[id] = obj
+	}
+	for n, obj := range w.Implicits {
+		check.Implicits[n] = obj
+	}
+	for x, sel := range w.Selections {
+		check.Selections[x] = sel
+	}
+	for n, scope := range w.Scopes {
+		check.Scopes[n] = scope
+	}
+	for x, kind := range w.Writes {
+		check.Writes[x] = kind
+	}
+	for x, ctx := range w.Untyped {
+		check.Untyped[x] = ctx
+	}
+	for x, c := range w.Conversions {
+		check.Conversions[x] = c
+	}
+	for call, v := range w.Variadics {
+		check.Variadics[call] = v
+	}
+	for _, n := range w.Skipped {
+		check.recordSkipped(n)
+	}
+	for x, info := range w.untyped {
+		check.rememberUntyped(x, info.isLhs, info.mode, info.typ, info.val)
+	}
+	for pkg := range w.usedPkgNames {
+		pkg.used = true
+	}
+	for u := range w.usedDotImports {
+		delete(check.unusedDotImports[u.scope], u.pkg)
+	}
+	w.usedPkgNames = nil
+	w.usedDotImports = nil
+}
+
+// usePkgName marks the imported package pkg as used.
+func (check *Checker) usePkgName(pkg *PkgName) {
+	if check.isWorker {
+		if check.usedPkgNames == nil {
+			check.usedPkgNames = make(map[*PkgName]bool)
+		}
+		check.usedPkgNames[pkg] = true
+		return
+	}
+	pkg.used = true
+}
+
+// useDotImport records that the package pkg, if it is dot-imported in
+// the file with the specified scope, is used.
+func (check *Checker) useDotImport(scope *Scope, pkg *Package) {
+	if check.isWorker {
+		if check.usedDotImports == nil {
+			check.usedDotImports = make(map[dotImportUse]bool)
+		}
+		check.usedDotImports[dotImportUse{scope, pkg}] = true
+		return
+	}
+	delete(check.unusedDotImports[scope], pkg)
+}
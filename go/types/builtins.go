@@ -107,6 +107,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 					sig.variadic = true
 					check.recordBuiltinType(call.Fun, sig)
 				}
+				check.recordVariadic(call, makeSig(S, S, NewSlice(UniverseByte)), nargs)
 				x.mode = value
 				x.typ = S
 				break
@@ -215,11 +216,11 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			return
 		}
 
-		check.convertUntyped(x, y.typ)
+		check.convertUntypedIn(x, y.typ, UntypedContext{Kind: OperandContext, Expr: y.expr})
 		if x.mode == invalid {
 			return
 		}
-		check.convertUntyped(&y, x.typ)
+		check.convertUntypedIn(&y, x.typ, UntypedContext{Kind: OperandContext, Expr: x.expr})
 		if y.mode == invalid {
 			return
 		}
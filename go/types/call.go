@@ -31,7 +31,10 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 		case 1:
 			check.expr(x, e.Args[0])
 			if x.mode != invalid {
+				saved := check.untypedCtx
+				check.untypedCtx = UntypedContext{Kind: ConversionContext, Expr: e}
 				check.conversion(x, T)
+				check.untypedCtx = saved
 			}
 		default:
 			check.errorf(e.Args[n-1].Pos(), "too many arguments in conversion to %s", T)
@@ -147,6 +150,8 @@ func unpack(get getter, n int, allowCommaOk bool) (getter, int, bool) {
 				x.mode = value
 				x.expr = x0.expr
 				x.typ = t.At(i).typ
+				x.tuple = t
+				x.index = i
 			}, t.Len(), false
 		}
 
@@ -154,10 +159,14 @@ func unpack(get getter, n int, allowCommaOk bool) (getter, int, bool) {
 			// comma-ok value
 			if allowCommaOk {
 				a := [2]Type{x0.typ, Typ[UntypedBool]}
+				pos := x0.pos()
+				t := NewTuple(NewVar(pos, nil, "", a[0]), NewVar(pos, nil, "", a[1]))
 				return func(x *operand, i int) {
 					x.mode = value
 					x.expr = x0.expr
 					x.typ = a[i]
+					x.tuple = t
+					x.index = i
 				}, 2, true
 			}
 			x0.mode = value
@@ -206,6 +215,10 @@ func (check *Checker) arguments(x *operand, call *ast.CallExpr, sig *Signature,
 		}
 	}
 
+	if sig.variadic {
+		check.recordVariadic(call, sig, n)
+	}
+
 	// check argument count
 	if sig.variadic {
 		// a variadic function accepts an "empty"
@@ -223,13 +236,16 @@ func (check *Checker) arguments(x *operand, call *ast.CallExpr, sig *Signature,
 func (check *Checker) argument(sig *Signature, i int, x *operand, ellipsis token.Pos) {
 	n := sig.params.Len()
 
-	// determine parameter type
+	// determine parameter and its type
+	var param *Var
 	var typ Type
 	switch {
 	case i < n:
-		typ = sig.params.vars[i].typ
+		param = sig.params.vars[i]
+		typ = param.typ
 	case sig.variadic:
-		typ = sig.params.vars[n-1].typ
+		param = sig.params.vars[n-1]
+		typ = param.typ
 		if debug {
 			if _, ok := typ.(*Slice); !ok {
 				check.dump("%s: expected unnamed slice type, got %s", sig.params.vars[n-1].Pos(), typ)
@@ -261,7 +277,7 @@ func (check *Checker) argument(sig *Signature, i int, x *operand, ellipsis token
 		typ = typ.(*Slice).elem
 	}
 
-	if !check.assignment(x, typ) && x.mode != invalid {
+	if !check.assignmentTo(x, typ, param) && x.mode != invalid {
 		check.errorf(x.pos(), "cannot pass argument %s to parameter of type %s", x, typ)
 	}
 }
@@ -284,11 +300,21 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 		if pkg, _ := obj.(*PkgName); pkg != nil {
 			assert(pkg.pkg == check.pkg)
 			check.recordUse(ident, pkg)
-			pkg.used = true
+			check.usePkgName(pkg)
+			if pkg.imported.stub {
+				// Members of stub packages are invalid; don't report errors.
+				check.recordUse(e.Sel, pkg.imported.stubMember(sel))
+				goto Error
+			}
 			exp := pkg.imported.scope.Lookup(sel)
 			if exp == nil {
 				if !pkg.imported.fake {
-					check.errorf(e.Pos(), "%s not declared by package %s", sel, ident)
+					check.report(Error{
+						Fset:        check.fset,
+						Pos:         e.Pos(),
+						Msg:         check.sprintf("%s not declared by package %s", sel, ident),
+						Suggestions: suggest(sel, exportedNames(pkg.imported.scope)),
+					})
 				}
 				goto Error
 			}
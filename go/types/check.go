@@ -9,6 +9,7 @@ package types
 import (
 	"go/ast"
 	"go/token"
+	"sort"
 
 	"golang.org/x/tools/go/exact"
 )
@@ -81,10 +82,37 @@ type Checker struct {
 	funcs    []funcInfo            // list of functions to type-check
 	delayed  []func()              // delayed checks requiring fully setup types
 
+	// object whose declared type is the target of the assignment
+	// currently being checked, if any (used for error reporting)
+	target Object
+
+	stubs map[string]*Package // stub packages for missing imports, by path
+
+	// body of the function enclosing the statements of a Session
+	// input, whose top-level variables are bindings (see session.go)
+	bindings *ast.BlockStmt
+
+	// expression of an expression snippet, checked as an expression
+	// statement whose value may be unused (see snippet.go)
+	snippetExpr ast.Expr
+
+	// context that determines the final types of the untyped
+	// expressions currently being converted (see Info.Untyped)
+	untypedCtx UntypedContext
+
+	suggested map[suggestKey][]string // cache of suggestion candidates for undeclared names
+
 	// context within which the current object is type-checked
 	// (valid only for the duration of type-checking a specific object)
 	context
 
+	// state of a worker checking function bodies concurrently
+	// (see bodies.go)
+	isWorker       bool                  // errors are buffered and shared state is not modified
+	errs           []Error               // errors reported by the current function body
+	usedPkgNames   map[*PkgName]bool     // imported packages used by the worker's function bodies
+	usedDotImports map[dotImportUse]bool // dot-imported packages used by the worker's function bodies
+
 	// debugging
 	indent int // indentation for tracing
 }
@@ -182,6 +210,7 @@ func (check *Checker) initFiles(files []*ast.File) {
 	check.untyped = nil
 	check.funcs = nil
 	check.delayed = nil
+	check.suggested = nil
 
 	// determine package name and collect valid files
 	pkg := check.pkg
@@ -361,3 +390,93 @@ func (check *Checker) recordScope(node ast.Node, scope *Scope) {
 		m[node] = scope
 	}
 }
+
+func (check *Checker) recordUntypedContext(x ast.Expr) {
+	assert(x != nil)
+	if m := check.Untyped; m != nil {
+		m[x] = check.untypedCtx
+	}
+}
+
+// recordConversion records the conversion of the value of the
+// single-valued expression x from type from to type to, following
+// any conversion recorded for x before, from an untyped type.
+func (check *Checker) recordConversion(x ast.Expr, from, to Type) {
+	assert(x != nil)
+	if m := check.Conversions; m != nil {
+		if c, ok := m[x]; ok {
+			from = c.From
+		}
+		if !Identical(from, to) {
+			m[x] = Conversion{from, to}
+		}
+	}
+}
+
+// recordOperandConversion records the conversion of the value of
+// operand x to type T, if it has another type.  If x is a value of a
+// multi-valued expression, the conversion recorded is that of the
+// tuple of its values.
+func (check *Checker) recordOperandConversion(x *operand, T Type) {
+	m := check.Conversions
+	if m == nil || x.expr == nil {
+		return
+	}
+	if x.tuple == nil {
+		check.recordConversion(x.expr, x.typ, T)
+		return
+	}
+	if Identical(x.tuple.At(x.index).typ, T) {
+		return
+	}
+	c, ok := m[x.expr]
+	if !ok {
+		c = Conversion{x.tuple, x.tuple}
+	}
+	vars := append([]*Var(nil), c.To.(*Tuple).vars...)
+	v := vars[x.index]
+	vars[x.index] = NewVar(v.pos, v.pkg, v.name, T)
+	m[x.expr] = Conversion{c.From, NewTuple(vars...)}
+}
+
+// recordVariadic records the passing of the n arguments of call to the
+// variadic parameter of sig.
+func (check *Checker) recordVariadic(call *ast.CallExpr, sig *Signature, n int) {
+	assert(call != nil)
+	if m := check.Variadics; m != nil {
+		nparams := sig.params.Len()
+		v := Variadic{Ellipsis: call.Ellipsis.IsValid(), Args: 1}
+		if s, ok := sig.params.vars[nparams-1].typ.(*Slice); ok {
+			v.Elem = s.elem
+		}
+		if !v.Ellipsis {
+			v.Args = 0
+			if n >= nparams {
+				v.Args = n - (nparams - 1)
+			}
+		}
+		m[call] = v
+	}
+}
+
+func (check *Checker) recordSkipped(n ast.Node) {
+	assert(n != nil)
+	// Keep the list sorted by position, without duplicates:
+	// expressions may be checked more than once.
+	s := check.Skipped
+	i := sort.Search(len(s), func(i int) bool { return s[i].Pos() >= n.Pos() })
+	if i < len(s) && s[i] == n {
+		return
+	}
+	s = append(s, nil)
+	copy(s[i+1:], s[i:])
+	s[i] = n
+	check.Skipped = s
+}
+
+func (check *Checker) recordWrite(lhs ast.Expr, kind WriteKind) {
+	assert(lhs != nil)
+	if m := check.Writes; m != nil {
+		m[lhs] = kind
+	}
+}
@@ -226,7 +226,9 @@ func eliminate(t *testing.T, errmap map[string][]string, errlist []error) {
 	}
 }
 
-func checkFiles(t *testing.T, testfiles []string) {
+// checkFiles checks testfiles, checking function bodies with the
+// specified number of goroutines (see Config.ConcurrentBodies).
+func checkFiles(t *testing.T, testfiles []string, bodies int) {
 	// parse files and collect parser errors
 	files, errlist := parseFiles(t, testfiles)
 
@@ -244,6 +246,7 @@ func checkFiles(t *testing.T, testfiles []string) {
 
 	// typecheck and collect typechecker errors
 	var conf Config
+	conf.ConcurrentBodies = bodies
 	conf.Error = func(err error) {
 		if *listErrors {
 			t.Error(err)
@@ -285,12 +288,22 @@ func TestCheck(t *testing.T) {
 
 	// If explicit test files are specified, only check those.
 	if files := *testFiles; files != "" {
-		checkFiles(t, strings.Split(files, " "))
+		checkFiles(t, strings.Split(files, " "), 0)
 		return
 	}
 
 	// Otherwise, run all the tests.
 	for _, files := range tests {
-		checkFiles(t, files)
+		checkFiles(t, files, 0)
+	}
+}
+
+// TestCheckConcurrentBodies runs the tests of TestCheck, checking
+// function bodies concurrently.
+func TestCheckConcurrentBodies(t *testing.T) {
+	skipSpecialPlatforms(t)
+	DefPredeclaredTestFuncs()
+	for _, files := range tests {
+		checkFiles(t, files, 4)
 	}
 }
@@ -7,6 +7,7 @@ package types
 import (
 	"go/ast"
 	"go/token"
+	"strings"
 
 	"golang.org/x/tools/go/exact"
 )
@@ -27,7 +28,7 @@ func (check *Checker) declare(scope *Scope, id *ast.Ident, obj Object) {
 	// binding."
 	if obj.Name() != "_" {
 		if alt := scope.Insert(obj); alt != nil {
-			check.errorf(obj.Pos(), "%s redeclared in this block", obj.Name())
+			check.errorf(obj.Pos(), "%s redeclared in %s", obj.Name(), scope.describe())
 			check.reportAltDecl(alt)
 			return
 		}
@@ -288,6 +289,19 @@ func (check *Checker) addMethodDecls(obj *TypeName) {
 	}
 }
 
+// funcComment returns the description of the scope of the function
+// or method declared by fdecl, such as "function f" or "method (*T).m".
+func funcComment(fdecl *ast.FuncDecl) string {
+	if fdecl.Recv != nil && len(fdecl.Recv.List) > 0 {
+		recv := ExprString(unparen(fdecl.Recv.List[0].Type))
+		if strings.HasPrefix(recv, "*") {
+			recv = "(" + recv + ")"
+		}
+		return "method " + recv + "." + fdecl.Name.Name
+	}
+	return "function " + fdecl.Name.Name
+}
+
 func (check *Checker) funcDecl(obj *Func, decl *declInfo) {
 	assert(obj.typ == nil)
 
@@ -297,7 +311,7 @@ func (check *Checker) funcDecl(obj *Func, decl *declInfo) {
 	sig := new(Signature)
 	obj.typ = sig // guard against cycles
 	fdecl := decl.fdecl
-	check.funcType(sig, fdecl.Recv, fdecl.Type)
+	check.funcType(sig, fdecl.Recv, fdecl.Type, funcComment(fdecl))
 	if sig.recv == nil && obj.name == "init" && (sig.params.Len() > 0 || sig.results.Len() > 0) {
 		check.errorf(fdecl.Pos(), "func init must have no arguments and no return values")
 		// ok to continue
@@ -315,7 +329,8 @@ func (check *Checker) declStmt(decl ast.Decl) {
 
 	switch d := decl.(type) {
 	case *ast.BadDecl:
-		// ignore
+		// ignore - error reported before
+		check.recordSkipped(d)
 
 	case *ast.GenDecl:
 		var last *ast.ValueSpec // last ValueSpec with type or init exprs seen
@@ -335,7 +350,7 @@ func (check *Checker) declStmt(decl ast.Decl) {
 					// declare all constants
 					lhs := make([]*Const, len(s.Names))
 					for i, name := range s.Names {
-						obj := NewConst(name.Pos(), pkg, name.Name, nil, exact.MakeInt64(int64(iota)))
+						obj := NewConst(name.Pos(), pkg, check.intern(name.Name), nil, exact.MakeInt64(int64(iota)))
 						lhs[i] = obj
 
 						var init ast.Expr
@@ -355,7 +370,7 @@ func (check *Checker) declStmt(decl ast.Decl) {
 				case token.VAR:
 					lhs0 := make([]*Var, len(s.Names))
 					for i, name := range s.Names {
-						lhs0[i] = NewVar(name.Pos(), pkg, name.Name, nil)
+						lhs0[i] = NewVar(name.Pos(), pkg, check.intern(name.Name), nil)
 					}
 
 					// initialize all variables
@@ -404,7 +419,7 @@ func (check *Checker) declStmt(decl ast.Decl) {
 				}
 
 			case *ast.TypeSpec:
-				obj := NewTypeName(s.Name.Pos(), pkg, s.Name.Name, nil)
+				obj := NewTypeName(s.Name.Pos(), pkg, check.intern(s.Name.Name), nil)
 				check.declare(check.scope, s.Name, obj)
 				check.typeDecl(obj, s.Type, nil, nil)
 
@@ -0,0 +1,216 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements dumps of scope trees, a stable textual form
+// for golden-file tests.
+
+package types
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A DumpScope is the dump of a scope: the objects it contains and the
+// dumps of its children.  Unlike the output of Scope.WriteTo, a dump
+// depends only on the checked source, not on the addresses of scopes
+// or the directories of files, so that the dumps of a package before
+// and after a change of the type checker may be compared.
+//
+// The textual form of a dump, as written by WriteTo and read by
+// ParseDump, has one line for each scope, object, and method, indented
+// by tabs according to their depth, and one line to close each scope:
+//
+//	scope package "p" {
+//		const	K	untyped int	1	p.go:3:7
+//		type	T	struct{x int}		p.go:5:6
+//			method	M	func()		p.go:7:14
+//		scope file p.go {
+//			package	fmt	"fmt"		p.go:2:8
+//		}
+//	}
+//
+// The fields of an object line, separated by tabs, are those of a
+// DumpObject, in order.
+type DumpScope struct {
+	Comment  string        // e.g. `package "p"`, "file p.go", "function f", or "block"
+	Objects  []*DumpObject // in order of name
+	Children []*DumpScope
+}
+
+// A DumpObject is the dump of an object.
+type DumpObject struct {
+	Kind    string        // as by ObjectKind.String, such as "const", "var", or "param"
+	Name    string        // the name of the object
+	Type    string        // the type of the object, or, for a type name, its underlying type, or, for a package name, the quoted import path
+	Value   string        // for a constant, its value, or ""
+	Pos     string        // the position, as "file:line:column" with the base name of the file, or "-"
+	Methods []*DumpObject // for a type name, its methods, in order of Id
+}
+
+// NewDump returns the dump of the tree of the scopes of pkg, rooted at
+// the package scope, whose positions are described by fset.  Types are
+// qualified relative to pkg.
+func NewDump(fset *token.FileSet, pkg *Package) *DumpScope {
+	return newDumpScope(fset, pkg, pkg.scope)
+}
+
+func newDumpScope(fset *token.FileSet, pkg *Package, s *Scope) *DumpScope {
+	d := &DumpScope{Comment: s.comment}
+	if s.parent == pkg.scope {
+		d.Comment = "file " + filepath.Base(strings.TrimPrefix(s.comment, "file "))
+	}
+	for _, name := range s.Names() {
+		d.Objects = append(d.Objects, newDumpObject(fset, pkg, s.elems[name]))
+	}
+	for _, child := range s.children {
+		d.Children = append(d.Children, newDumpScope(fset, pkg, child))
+	}
+	return d
+}
+
+func newDumpObject(fset *token.FileSet, pkg *Package, obj Object) *DumpObject {
+	d := &DumpObject{Kind: obj.Kind().String(), Name: obj.Name(), Pos: "-"}
+	if pos := obj.Pos(); pos.IsValid() {
+		p := fset.Position(pos)
+		d.Pos = fmt.Sprintf("%s:%d:%d", filepath.Base(p.Filename), p.Line, p.Column)
+	}
+	switch obj := obj.(type) {
+	case *PkgName:
+		d.Type = strconv.Quote(obj.imported.path)
+	case *TypeName:
+		d.Type = TypeString(pkg, obj.typ.Underlying())
+		if named, ok := obj.typ.(*Named); ok {
+			for _, m := range named.SortedMethods() {
+				d.Methods = append(d.Methods, newDumpObject(fset, pkg, m))
+			}
+		}
+	default:
+		d.Type = TypeString(pkg, obj.Type())
+		if c, ok := obj.(*Const); ok {
+			d.Value = c.val.String()
+		}
+	}
+	return d
+}
+
+// WriteTo writes the textual form of the dump d to w.
+func (d *DumpScope) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	printf := func(format string, args ...interface{}) {
+		m, _ := fmt.Fprintf(bw, format, args...)
+		n += int64(m)
+	}
+	var scope func(d *DumpScope, indent string)
+	scope = func(d *DumpScope, indent string) {
+		printf("%sscope %s {\n", indent, d.Comment)
+		for _, obj := range d.Objects {
+			printf("%s\t%s\n", indent, obj.fields())
+			for _, m := range obj.Methods {
+				printf("%s\t\t%s\n", indent, m.fields())
+			}
+		}
+		for _, child := range d.Children {
+			scope(child, indent+"\t")
+		}
+		printf("%s}\n", indent)
+	}
+	scope(d, "")
+	return n, bw.Flush()
+}
+
+func (d *DumpObject) fields() string {
+	return strings.Join([]string{d.Kind, d.Name, d.Type, d.Value, d.Pos}, "\t")
+}
+
+// ParseDump parses the textual form of a dump, as written by
+// DumpScope.WriteTo.
+func ParseDump(r io.Reader) (*DumpScope, error) {
+	p := &dumpParser{scanner: bufio.NewScanner(r)}
+	p.next()
+	d := p.scope(0)
+	if p.err == nil && p.line != "" {
+		p.errorf("unexpected %q after root scope", p.line)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return d, nil
+}
+
+type dumpParser struct {
+	scanner *bufio.Scanner
+	lineno  int
+	depth   int    // the indentation of the current line
+	line    string // the current line, without indentation, or "" at EOF
+	err     error
+}
+
+// next advances to the next non-empty line.
+func (p *dumpParser) next() {
+	p.line, p.depth = "", 0
+	for p.scanner.Scan() {
+		p.lineno++
+		if line := p.scanner.Text(); strings.TrimSpace(line) != "" {
+			p.line = strings.TrimLeft(line, "\t")
+			p.depth = len(line) - len(p.line)
+			return
+		}
+	}
+	if err := p.scanner.Err(); err != nil && p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *dumpParser) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("dump:%d: %s", p.lineno, fmt.Sprintf(format, args...))
+	}
+}
+
+// scope parses a scope at the specified depth.
+func (p *dumpParser) scope(depth int) *DumpScope {
+	if p.depth != depth || !strings.HasPrefix(p.line, "scope ") || !strings.HasSuffix(p.line, " {") {
+		p.errorf("got %q, want scope at depth %d", p.line, depth)
+		return nil
+	}
+	d := &DumpScope{Comment: strings.TrimSuffix(strings.TrimPrefix(p.line, "scope "), " {")}
+	p.next()
+	for p.err == nil {
+		switch {
+		case p.line == "":
+			p.errorf("unterminated scope %s", d.Comment)
+		case p.line == "}" && p.depth == depth:
+			p.next()
+			return d
+		case strings.HasPrefix(p.line, "scope "):
+			d.Children = append(d.Children, p.scope(depth+1))
+		case p.depth == depth+1:
+			d.Objects = append(d.Objects, p.object())
+		case p.depth == depth+2 && len(d.Objects) > 0:
+			last := d.Objects[len(d.Objects)-1]
+			last.Methods = append(last.Methods, p.object())
+		default:
+			p.errorf("unexpected %q at depth %d", p.line, p.depth)
+		}
+	}
+	return nil
+}
+
+// object parses an object line.
+func (p *dumpParser) object() *DumpObject {
+	fields := strings.Split(p.line, "\t")
+	if len(fields) != 5 {
+		p.errorf("got %d fields, want 5", len(fields))
+		return nil
+	}
+	p.next()
+	return &DumpObject{Kind: fields[0], Name: fields[1], Type: fields[2], Value: fields[3], Pos: fields[4]}
+}
@@ -0,0 +1,103 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+const dumpSrc = `package p
+
+const K = 1 << 2
+
+type T struct{ x int }
+
+func (T) m()        {}
+func (t *T) M() int { return t.x }
+
+func F(a int) (b int) {
+	if c := a; c > K {
+		b = c
+	}
+	return
+}
+`
+
+const dumpWant = `scope package "p" {
+	func	F	func(a int) (b int)		p.go:10:6
+	const	K	untyped int	4	p.go:3:7
+	type	T	struct{x int}		p.go:5:6
+		method	M	func() int		p.go:8:13
+		method	m	func()		p.go:7:10
+	scope file p.go {
+		scope method T.m {
+		}
+		scope method (*T).M {
+			param	t	*T		p.go:8:7
+		}
+		scope function F {
+			param	a	int		p.go:10:8
+			result	b	int		p.go:10:16
+			scope if statement {
+				var	c	int		p.go:11:5
+				scope block {
+				}
+			}
+		}
+	}
+}
+`
+
+func TestDump(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "/some/dir/p.go", dumpSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := NewDump(fset, pkg)
+	var buf bytes.Buffer
+	if _, err := dump.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != dumpWant {
+		t.Errorf("got dump:\n%s\nwant:\n%s", got, dumpWant)
+	}
+
+	parsed, err := ParseDump(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, dump) {
+		t.Errorf("parsed dump differs from original")
+	}
+}
+
+func TestParseDumpErrors(t *testing.T) {
+	for _, test := range []struct{ src, err string }{
+		{"", `dump:0: got "", want scope at depth 0`},
+		{"scope x {\n", `dump:1: unterminated scope x`},
+		{"scope x {\n\tvar\ta\n}\n", `dump:2: got 2 fields, want 5`},
+		{"scope x {\n}\n}\n", `dump:3: unexpected "}" after root scope`},
+		{"scope x {\n\t\t\tvar\ta\tint\t\t-\n}\n", `dump:2: unexpected "var\ta\tint\t\t-" at depth 3`},
+	} {
+		_, err := ParseDump(strings.NewReader(test.src))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("ParseDump(%q): got error %v, want %s", test.src, err, test.err)
+		}
+	}
+}
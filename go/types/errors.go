@@ -60,7 +60,17 @@ func (check *Checker) dump(format string, args ...interface{}) {
 }
 
 func (check *Checker) err(pos token.Pos, msg string, soft bool) {
-	err := Error{check.fset, pos, msg, soft}
+	check.report(Error{Fset: check.fset, Pos: pos, Msg: msg, Soft: soft})
+}
+
+func (check *Checker) report(err Error) {
+	if check.isWorker {
+		check.errs = append(check.errs, err)
+		if check.conf.Error == nil {
+			panic(bailout{}) // report only first error
+		}
+		return
+	}
 	if check.firstErr == nil {
 		check.firstErr = err
 	}
@@ -0,0 +1,137 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+// checkErrors type-checks src and returns all errors reported.
+func checkErrors(t *testing.T, src string) (*token.FileSet, []Error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []Error
+	conf := Config{Error: func(err error) { errs = append(errs, err.(Error)) }}
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	return fset, errs
+}
+
+func TestConstOverflowError(t *testing.T) {
+	const src = `package p
+
+func f(b uint8) {}
+
+var _ = func() int {
+	f(300)
+	return 0
+}
+
+const c int8 = -129
+`
+	fset, errs := checkErrors(t, src)
+	for _, test := range []struct {
+		msg, related string
+		line         int // line of related position
+	}{
+		{"300 (untyped int constant) overflows uint8 (range 0 .. 255)", "type uint8 determined by declaration of b", 3},
+		{"-129 (untyped int constant) overflows int8 (range -128 .. 127)", "type int8 determined by declaration of c", 10},
+	} {
+		var found *Error
+		for i := range errs {
+			if strings.Contains(errs[i].Msg, test.msg) {
+				found = &errs[i]
+				break
+			}
+		}
+		if found == nil {
+			t.Errorf("no error containing %q; got %v", test.msg, errs)
+			continue
+		}
+		if len(found.Related) != 1 {
+			t.Errorf("%s: got %d related positions, want 1", found.Msg, len(found.Related))
+			continue
+		}
+		rel := found.Related[0]
+		if rel.Msg != test.related || fset.Position(rel.Pos).Line != test.line {
+			t.Errorf("%s: got related %q at line %d, want %q at line %d",
+				found.Msg, rel.Msg, fset.Position(rel.Pos).Line, test.related, test.line)
+		}
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	const src = `package p
+
+import "lib"
+
+var counter int
+
+func f(value int) {
+	_ = valeu
+	_ = countr
+	_ = lib.Prinln
+	_ = Println
+	_ = zzzzzz
+	_ = Indx
+}
+`
+	// lib is a fake package exporting Println and Print.
+	lib := NewPackage("lib", "lib")
+	for _, name := range []string{"Println", "Print"} {
+		lib.Scope().Insert(NewVar(token.NoPos, lib, name, Typ[Int]))
+	}
+	lib.MarkComplete()
+	// strs, exporting Index, is loaded but not imported.
+	strs := NewPackage("strs", "strs")
+	strs.Scope().Insert(NewVar(token.NoPos, strs, "Index", Typ[Int]))
+	strs.MarkComplete()
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]string)
+	conf := Config{
+		Packages: map[string]*Package{"strs": strs},
+		Import: func(pkgs map[string]*Package, path string) (*Package, error) {
+			pkgs[path] = lib // as by gcimporter
+			return lib, nil
+		},
+		Error: func(err error) {
+			e := err.(Error)
+			got[e.Msg] = e.Suggestions
+		},
+	}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+
+	for msg, want := range map[string]string{
+		"undeclared name: valeu":             "[value]",
+		"undeclared name: countr":            "[counter]",
+		"Prinln not declared by package lib": "[Println Print]",
+		"undeclared name: Println":           "[lib.Println println lib.Print]",
+		"undeclared name: zzzzzz":            "[]",
+		"undeclared name: Indx":              "[strs.Index]",
+	} {
+		s, ok := got[msg]
+		if !ok {
+			t.Errorf("missing error %q", msg)
+			continue
+		}
+		if fmt.Sprint(s) != want {
+			t.Errorf("%s: got suggestions %v, want %s", msg, s, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements explanations for assignability and convertibility.
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A Reason is a node in a tree explaining why a value of one type is or
+// is not assignable or convertible to another type. Each node describes
+// a rule of the spec that was considered and whether it applied; the
+// children of a node, if any, refine it.
+type Reason struct {
+	Rule string    // description of the rule considered
+	OK   bool      // whether the rule applies
+	Sub  []*Reason // more specific reasons, if any
+}
+
+func (r *Reason) add(ok bool, format string, args ...interface{}) *Reason {
+	s := &Reason{Rule: fmt.Sprintf(format, args...), OK: ok}
+	r.Sub = append(r.Sub, s)
+	return s
+}
+
+// String returns an indented, multi-line rendering of the reason tree.
+func (r *Reason) String() string {
+	var buf bytes.Buffer
+	r.writeTo(&buf, 0)
+	return buf.String()
+}
+
+func (r *Reason) writeTo(buf *bytes.Buffer, n int) {
+	mark := "no "
+	if r.OK {
+		mark = "yes"
+	}
+	fmt.Fprintf(buf, "%s%s: %s\n", strings.Repeat(".  ", n), mark, r.Rule)
+	for _, s := range r.Sub {
+		s.writeTo(buf, n+1)
+	}
+}
+
+// ExplainAssignable explains whether a (non-constant) value of type V
+// is assignable to a variable of type T. The OK field of the result
+// agrees with AssignableTo(V, T); the children of the result list the
+// assignability rules that were tested.
+func ExplainAssignable(V, T Type) *Reason {
+	r := &Reason{Rule: fmt.Sprintf("%s is assignable to %s", V, T)}
+	x := operand{mode: value, typ: V}
+	r.OK = x.assignableTo(nil, T) // config not needed for non-constant x
+	if V == Typ[Invalid] || T == Typ[Invalid] {
+		r.add(true, "invalid types are assignable to avoid follow-on errors")
+		return r
+	}
+
+	r.add(Identical(V, T), "%s and %s are identical", V, T)
+
+	Vu := V.Underlying()
+	Tu := T.Underlying()
+
+	if Ti, ok := Tu.(*Interface); ok {
+		s := r.add(Implements(V, Ti), "%s implements %s", V, T)
+		if m, wrongType := MissingMethod(V, Ti, true); m != nil {
+			if wrongType {
+				s.add(false, "method %s has wrong type", m.name)
+			} else {
+				s.add(false, "missing method %s", m.name)
+			}
+		}
+	}
+
+	if Identical(Vu, Tu) {
+		s := r.add(!isNamed(V) || !isNamed(T), "%s and %s have identical underlying types and at least one is not a named type", V, T)
+		s.add(true, "identical underlying types")
+		s.add(!isNamed(V) || !isNamed(T), "at least one of %s and %s is not a named type", V, T)
+	} else {
+		r.add(false, "%s and %s have identical underlying types", V, T)
+	}
+
+	if Vc, ok := Vu.(*Chan); ok {
+		if Tc, ok := Tu.(*Chan); ok {
+			bidi := Vc.dir == SendRecv
+			elem := Identical(Vc.elem, Tc.elem)
+			s := r.add(bidi && elem && (!isNamed(V) || !isNamed(T)), "%s is a bidirectional channel assignable to channel type %s", V, T)
+			s.add(bidi, "%s is a bidirectional channel", V)
+			s.add(elem, "channel element types %s and %s are identical", Vc.elem, Tc.elem)
+			s.add(!isNamed(V) || !isNamed(T), "at least one of %s and %s is not a named type", V, T)
+		}
+	}
+
+	if x.isNil() {
+		r.add(hasNil(T), "%s is a pointer, function, slice, map, channel, or interface type", T)
+	} else if isUntyped(Vu) {
+		r.add(r.OK, "untyped value of type %s may be used as %s", V, T)
+	}
+
+	return r
+}
+
+// ExplainConvertible explains whether a (non-constant) value of type V
+// is convertible to a value of type T. The OK field of the result agrees
+// with ConvertibleTo(V, T); its first child is the explanation returned
+// by ExplainAssignable(V, T), and the remaining children list the
+// additional conversion rules that were tested.
+func ExplainConvertible(V, T Type) *Reason {
+	r := &Reason{Rule: fmt.Sprintf("%s is convertible to %s", V, T)}
+	x := operand{mode: value, typ: V}
+	r.OK = x.convertibleTo(nil, T) // config not needed for non-constant x
+
+	a := ExplainAssignable(V, T)
+	r.Sub = append(r.Sub, a)
+	if a.OK {
+		return r
+	}
+
+	Vu := V.Underlying()
+	Tu := T.Underlying()
+
+	r.add(Identical(Vu, Tu), "%s and %s have identical underlying types", V, T)
+
+	if V, ok := V.(*Pointer); ok {
+		if T, ok := T.(*Pointer); ok {
+			r.add(Identical(V.base.Underlying(), T.base.Underlying()), "%s and %s are unnamed pointer types with identical base underlying types", V, T)
+		}
+	}
+
+	vnum := isInteger(V) || isFloat(V)
+	tnum := isInteger(T) || isFloat(T)
+	if vnum || tnum {
+		s := r.add(vnum && tnum, "%s and %s are both integer or floating point types", V, T)
+		s.add(vnum, "%s is an integer or floating point type", V)
+		s.add(tnum, "%s is an integer or floating point type", T)
+	}
+
+	if isComplex(V) || isComplex(T) {
+		r.add(isComplex(V) && isComplex(T), "%s and %s are both complex types", V, T)
+	}
+
+	if isString(T) {
+		r.add(isInteger(V) || isBytesOrRunes(Vu), "%s is an integer or a slice of bytes or runes and %s is a string type", V, T)
+	}
+	if isBytesOrRunes(Tu) {
+		r.add(isString(V), "%s is a string and %s is a slice of bytes or runes", V, T)
+	}
+
+	if isUnsafePointer(T) {
+		r.add(isPointer(Vu) || isUintptr(Vu), "%s is a pointer or uintptr and %s is unsafe.Pointer", V, T)
+	}
+	if isUnsafePointer(V) {
+		r.add(isPointer(Tu) || isUintptr(Tu), "%s is unsafe.Pointer and %s is a pointer or uintptr", V, T)
+	}
+
+	return r
+}
@@ -0,0 +1,64 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestExplain(t *testing.T) {
+	const src = `package p
+
+type (
+	I interface{ m() }
+	J interface{ m(); n() }
+	T int
+	U int
+	P *int
+	C chan int
+	S []int
+)
+
+func (T) m() {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []Type
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		types = append(types, scope.Lookup(name).Type())
+	}
+	types = append(types,
+		Typ[Int], Typ[Float64], Typ[String], Typ[UnsafePointer], Typ[Uintptr], Typ[UntypedNil],
+		NewPointer(Typ[Int]), NewSlice(Typ[Byte]), NewChan(SendRecv, Typ[Int]), NewChan(RecvOnly, Typ[Int]),
+	)
+
+	// The explanations must agree with the predicates.
+	for _, V := range types {
+		for _, T := range types {
+			if got, want := ExplainAssignable(V, T).OK, AssignableTo(V, T); got != want {
+				t.Errorf("ExplainAssignable(%s, %s).OK = %t, want %t", V, T, got, want)
+			}
+			if T == Typ[UntypedNil] {
+				continue // not a valid conversion target
+			}
+			if got, want := ExplainConvertible(V, T).OK, ConvertibleTo(V, T); got != want {
+				t.Errorf("ExplainConvertible(%s, %s).OK = %t, want %t", V, T, got, want)
+			}
+		}
+	}
+
+	// An unsatisfied interface names the missing method.
+	I := scope.Lookup("I").Type()
+	J := scope.Lookup("J").Type()
+	if s := ExplainAssignable(I, J).String(); !strings.Contains(s, "no : missing method n") {
+		t.Errorf("ExplainAssignable(I, J) does not report missing method n:\n%s", s)
+	}
+}
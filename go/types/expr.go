@@ -333,18 +333,47 @@ func (check *Checker) representable(x *operand, typ *Basic) {
 			// float   -> float   : overflows
 			//
 			if !isInteger(x.typ) && isInteger(typ) {
-				msg = "%s truncated to %s"
+				msg = check.sprintf("%s truncated to %s", x, typ)
 			} else {
-				msg = "%s overflows %s"
+				msg = check.sprintf("%s overflows %s", x, typ)
+				if r := check.constRange(typ); r != "" {
+					msg += " (range " + r + ")"
+				}
 			}
 		} else {
-			msg = "cannot convert %s to %s"
+			msg = check.sprintf("cannot convert %s to %s", x, typ)
+		}
+		err := Error{Fset: check.fset, Pos: x.pos(), Msg: msg}
+		if obj := check.target; obj != nil && obj.Pos().IsValid() {
+			err.Related = []RelatedPos{{obj.Pos(), check.sprintf("type %s determined by declaration of %s", obj.Type(), obj.Name())}}
 		}
-		check.errorf(x.pos(), msg, x, typ)
+		check.report(err)
 		x.mode = invalid
 	}
 }
 
+// constRange returns a description of the range of values representable
+// by the numeric type typ, or the empty string if typ is not numeric.
+func (check *Checker) constRange(typ *Basic) string {
+	switch {
+	case isInteger(typ) && typ.info&IsUntyped == 0:
+		s := uint(check.conf.sizeof(typ)) * 8
+		one := exact.MakeInt64(1)
+		if isUnsigned(typ) {
+			max := exact.BinaryOp(exact.Shift(one, token.SHL, s), token.SUB, one)
+			return fmt.Sprintf("0 .. %s", max)
+		}
+		max := exact.BinaryOp(exact.Shift(one, token.SHL, s-1), token.SUB, one)
+		min := exact.UnaryOp(token.SUB, exact.Shift(one, token.SHL, s-1), 0)
+		return fmt.Sprintf("%s .. %s", min, max)
+	case typ.kind == Float32 || typ.kind == Complex64:
+		return fmt.Sprintf("-%g .. %g", math.MaxFloat32, math.MaxFloat32)
+	case typ.kind == Float64 || typ.kind == Complex128:
+		return fmt.Sprintf("-%g .. %g", math.MaxFloat64, math.MaxFloat64)
+	}
+	return ""
+}
+
 // updateExprType updates the type of x to typ and invokes itself
 // recursively for the operands of x, depending on expression kind.
 // If typ is still an untyped and not the final type, updateExprType
@@ -452,6 +481,21 @@ func (check *Checker) updateExprType(x ast.Expr, typ Type, final bool) {
 
 	// Everything's fine, record final type and value for x.
 	check.recordTypeAndValue(x, old.mode, typ, old.val)
+	if isTyped(typ) {
+		check.recordUntypedContext(x)
+		if check.untypedCtx.Kind != ConversionContext {
+			check.recordConversion(x, old.typ, typ)
+		}
+	}
+}
+
+// updateExprTypeIn is like updateExprType but records ctx in
+// Info.Untyped as the context that determined the final types.
+func (check *Checker) updateExprTypeIn(x ast.Expr, typ Type, final bool, ctx UntypedContext) {
+	saved := check.untypedCtx
+	check.untypedCtx = ctx
+	check.updateExprType(x, typ, final)
+	check.untypedCtx = saved
 }
 
 // updateExprVal updates the value of x to val.
@@ -462,6 +506,15 @@ func (check *Checker) updateExprVal(x ast.Expr, val exact.Value) {
 	}
 }
 
+// convertUntypedIn is like convertUntyped but records ctx in
+// Info.Untyped as the context that determined the final types.
+func (check *Checker) convertUntypedIn(x *operand, target Type, ctx UntypedContext) {
+	saved := check.untypedCtx
+	check.untypedCtx = ctx
+	check.convertUntyped(x, target)
+	check.untypedCtx = saved
+}
+
 // convertUntyped attempts to set the type of an untyped value to the target type.
 func (check *Checker) convertUntyped(x *operand, target Type) {
 	if x.mode == invalid || isTyped(x.typ) || target == Typ[Invalid] {
@@ -606,8 +659,17 @@ func (check *Checker) comparison(x, y *operand, op token.Token) {
 		// time will be materialized. Update the expression trees.
 		// If the current types are untyped, the materialized type
 		// is the respective default type.
-		check.updateExprType(x.expr, defaultType(x.typ), true)
-		check.updateExprType(y.expr, defaultType(y.typ), true)
+		check.updateExprTypeIn(x.expr, defaultType(x.typ), true, UntypedContext{Kind: DefaultContext})
+		check.updateExprTypeIn(y.expr, defaultType(y.typ), true, UntypedContext{Kind: DefaultContext})
+		// One operand, nil or not of interface type if the other
+		// is, is converted to the type of the other.
+		if !Identical(x.typ, y.typ) {
+			if x.isNil() || !y.isNil() && !y.assignableTo(check.conf, x.typ) {
+				check.recordOperandConversion(x, y.typ)
+			} else {
+				check.recordOperandConversion(y, x.typ)
+			}
+		}
 	}
 
 	// spec: "Comparison operators compare two operands and yield
@@ -731,23 +793,29 @@ func (check *Checker) binary(x *operand, lhs, rhs ast.Expr, op token.Token) {
 		return
 	}
 
+	check.binaryOp(x, &y, op)
+}
+
+// binaryOp checks the binary operation x op y of valid operands, and
+// sets x to its result.
+func (check *Checker) binaryOp(x, y *operand, op token.Token) {
 	if isShift(op) {
-		check.shift(x, &y, op)
+		check.shift(x, y, op)
 		return
 	}
 
-	check.convertUntyped(x, y.typ)
+	check.convertUntypedIn(x, y.typ, UntypedContext{Kind: OperandContext, Expr: y.expr})
 	if x.mode == invalid {
 		return
 	}
-	check.convertUntyped(&y, x.typ)
+	check.convertUntypedIn(y, x.typ, UntypedContext{Kind: OperandContext, Expr: x.expr})
 	if y.mode == invalid {
 		x.mode = invalid
 		return
 	}
 
 	if isComparison(op) {
-		check.comparison(x, &y, op)
+		check.comparison(x, y, op)
 		return
 	}
 
@@ -905,6 +973,7 @@ func (check *Checker) rawExpr(x *operand, e ast.Expr, hint Type) exprKind {
 		}()
 	}
 
+	x.tuple = nil
 	kind := check.exprInternal(x, e, hint)
 
 	// convert x into a user-friendly set of values
@@ -946,6 +1015,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 
 	switch e := e.(type) {
 	case *ast.BadExpr:
+		check.recordSkipped(e)
 		goto Error // error was reported before
 
 	case *ast.Ident:
@@ -965,17 +1035,16 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		}
 
 	case *ast.FuncLit:
-		if sig, ok := check.typ(e.Type).(*Signature); ok {
-			// Anonymous functions are considered part of the
-			// init expression/func declaration which contains
-			// them: use existing package-level declaration info.
-			check.funcBody(check.decl, "", sig, e.Body)
-			x.mode = value
-			x.typ = sig
-		} else {
-			check.invalidAST(e.Pos(), "invalid function literal %s", e)
-			goto Error
-		}
+		// Check the type as by check.typ, but describe its scope,
+		// which is also that of the body, as a function literal.
+		sig := check.funcType(new(Signature), nil, e.Type, "function literal")
+		check.recordTypeAndValue(e.Type, typexpr, sig, nil)
+		// Anonymous functions are considered part of the
+		// init expression/func declaration which contains
+		// them: use existing package-level declaration info.
+		check.funcBody(check.decl, "", sig, e.Body)
+		x.mode = value
+		x.typ = sig
 
 	case *ast.CompositeLit:
 		typ := hint
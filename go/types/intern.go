@@ -0,0 +1,74 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements string interning.
+
+package types
+
+import "sync"
+
+// A StringTable interns strings, such as identifier names and import
+// paths, so that equal strings obtained from different files or
+// packages share storage.  When the same table is used to check many
+// packages (see Config.Strings), the names of their objects, which
+// repeat heavily, are each stored once.  Importers may call Intern on
+// the names they read from export data so that imported objects
+// share the table too.
+//
+// A StringTable is safe for concurrent use.  The zero value is an
+// empty table ready to use.
+//
+type StringTable struct {
+	mu      sync.Mutex
+	strings map[string]string
+	stats   StringTableStats
+}
+
+// StringTableStats holds statistics about the use of a StringTable.
+type StringTableStats struct {
+	Strings int // number of distinct strings in the table
+	Bytes   int // total length of the distinct strings
+
+	Lookups    int // number of calls to Intern
+	SavedBytes int // total length of the strings for which Intern found an equal one
+}
+
+// NewStringTable returns a new, empty StringTable.
+func NewStringTable() *StringTable {
+	return new(StringTable)
+}
+
+// Intern returns the string in t equal to s, adding s to t first if
+// there is none.
+func (t *StringTable) Intern(s string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Lookups++
+	if s0, ok := t.strings[s]; ok {
+		t.stats.SavedBytes += len(s)
+		return s0
+	}
+	if t.strings == nil {
+		t.strings = make(map[string]string)
+	}
+	t.strings[s] = s
+	t.stats.Strings++
+	t.stats.Bytes += len(s)
+	return s
+}
+
+// Stats returns statistics about the use of t.
+func (t *StringTable) Stats() StringTableStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// intern returns s, interned in the configured string table, if any.
+func (check *Checker) intern(s string) string {
+	if t := check.conf.Strings; t != nil {
+		return t.Intern(s)
+	}
+	return s
+}
@@ -12,7 +12,7 @@ import (
 // labels checks correct label use in body.
 func (check *Checker) labels(body *ast.BlockStmt) {
 	// set of all labels in this body
-	all := NewScope(nil, "label")
+	all := NewScope(nil, body.Pos(), body.End(), "labels")
 
 	fwdJumps := check.blockBranches(all, nil, nil, body.List)
 
@@ -131,7 +131,7 @@ func (check *Checker) blockBranches(all *Scope, parent *block, lstmt *ast.Labele
 		case *ast.LabeledStmt:
 			// declare non-blank label
 			if name := s.Label.Name; name != "_" {
-				lbl := NewLabel(s.Label.Pos(), check.pkg, name)
+				lbl := NewLabel(s.Label.Pos(), check.pkg, check.intern(name))
 				if alt := all.Insert(lbl); alt != nil {
 					check.softErrorf(lbl.pos, "label %s already declared", name)
 					check.reportAltDecl(alt)
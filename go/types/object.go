@@ -31,6 +31,9 @@ type Object interface {
 	// String returns a human-readable string of the object.
 	String() string
 
+	// Kind returns the kind of object.
+	Kind() ObjectKind
+
 	// order reflects a package-level object's source order: if object
 	// a is before object b in the source, then a.order() < b.order().
 	// order returns a value > 0 for package-level objects; it returns
@@ -160,6 +163,8 @@ type Var struct {
 	anonymous bool // if set, the variable is an anonymous struct field, and name is the type name
 	visited   bool // for initialization cycle detection
 	isField   bool // var is struct field
+	isParam   bool // var is a parameter or receiver of a signature
+	isResult  bool // var is a result of a signature
 	used      bool // set if the variable was used
 }
 
@@ -179,11 +184,20 @@ func (obj *Var) Anonymous() bool { return obj.anonymous }
 
 func (obj *Var) IsField() bool { return obj.isField }
 
+// IsParam reports whether the variable is a parameter or receiver of
+// a function or method signature.
+func (obj *Var) IsParam() bool { return obj.isParam }
+
+// IsResult reports whether the variable is a result of a function or
+// method signature.
+func (obj *Var) IsResult() bool { return obj.isResult }
+
 // A Func represents a declared function, concrete method, or abstract
 // (interface) method.  Its Type() is always a *Signature.
 // An abstract method may belong to many interfaces due to embedding.
 type Func struct {
 	object
+	external bool // declared without a body
 }
 
 func NewFunc(pos token.Pos, pkg *Package, name string, sig *Signature) *Func {
@@ -192,9 +206,16 @@ func NewFunc(pos token.Pos, pkg *Package, name string, sig *Signature) *Func {
 	if sig != nil {
 		typ = sig
 	}
-	return &Func{object{nil, pos, pkg, name, typ, 0}}
+	return &Func{object{nil, pos, pkg, name, typ, 0}, false}
 }
 
+// External reports whether the function or method was declared
+// without a body, and so is implemented externally, typically in
+// assembly.  Such functions are distinct from ordinary ones: they have
+// no syntax for their implementation, and their Kind is ExternalObject.
+// Only functions and methods declared in source are external.
+func (obj *Func) External() bool { return obj.external }
+
 // FullName returns the package- or receiver-type-qualified name of
 // function or method obj.
 func (obj *Func) FullName() string {
@@ -233,6 +254,89 @@ type Nil struct {
 	object
 }
 
+// An ObjectKind describes the kind of an Object, distinguishing the
+// kinds of variables and functions, so that clients need not switch
+// on the concrete type of the object and inspect it further.
+type ObjectKind int
+
+const (
+	ConstObject    ObjectKind = iota // a constant
+	VarObject                        // a variable other than a field, parameter, or result
+	FieldObject                      // a struct field
+	ParamObject                      // a parameter or receiver of a signature
+	ResultObject                     // a result of a signature
+	TypeNameObject                   // a type name
+	FuncObject                       // a function
+	MethodObject                     // a concrete or abstract (interface) method
+	LabelObject                      // a label
+	PkgNameObject                    // an imported package name
+	BuiltinObject                    // a built-in function
+	NilObject                        // the predeclared nil
+	ExternalObject                   // a function or method declared without a body (see Func.External)
+)
+
+var objectKindNames = [...]string{
+	ConstObject:    "const",
+	VarObject:      "var",
+	FieldObject:    "field",
+	ParamObject:    "param",
+	ResultObject:   "result",
+	TypeNameObject: "type",
+	FuncObject:     "func",
+	MethodObject:   "method",
+	LabelObject:    "label",
+	PkgNameObject:  "package",
+	BuiltinObject:  "builtin",
+	NilObject:      "nil",
+	ExternalObject: "external",
+}
+
+func (k ObjectKind) String() string {
+	if 0 <= k && int(k) < len(objectKindNames) {
+		return objectKindNames[k]
+	}
+	return fmt.Sprintf("ObjectKind(%d)", int(k))
+}
+
+// IsVar reports whether k is a kind of *Var.
+func (k ObjectKind) IsVar() bool {
+	return k == VarObject || k == FieldObject || k == ParamObject || k == ResultObject
+}
+
+// IsFunc reports whether k is a kind of *Func.
+func (k ObjectKind) IsFunc() bool {
+	return k == FuncObject || k == MethodObject || k == ExternalObject
+}
+
+func (*PkgName) Kind() ObjectKind  { return PkgNameObject }
+func (*Const) Kind() ObjectKind    { return ConstObject }
+func (*TypeName) Kind() ObjectKind { return TypeNameObject }
+func (*Label) Kind() ObjectKind    { return LabelObject }
+func (*Builtin) Kind() ObjectKind  { return BuiltinObject }
+func (*Nil) Kind() ObjectKind      { return NilObject }
+
+func (obj *Var) Kind() ObjectKind {
+	switch {
+	case obj.isField:
+		return FieldObject
+	case obj.isParam:
+		return ParamObject
+	case obj.isResult:
+		return ResultObject
+	}
+	return VarObject
+}
+
+func (obj *Func) Kind() ObjectKind {
+	if obj.external {
+		return ExternalObject
+	}
+	if sig, _ := obj.typ.(*Signature); sig != nil && sig.recv != nil {
+		return MethodObject
+	}
+	return FuncObject
+}
+
 func writeObject(buf *bytes.Buffer, this *Package, obj Object) {
 	typ := obj.Type()
 	switch obj := obj.(type) {
@@ -53,6 +53,12 @@ type operand struct {
 	typ  Type
 	val  exact.Value
 	id   builtinId
+
+	// For a value of a multi-valued expression (a function call or
+	// comma-ok expression), the values of the expression, and the
+	// index of the operand among them.
+	tuple *Tuple
+	index int
 }
 
 // pos returns the position of the expression corresponding to x.
@@ -0,0 +1,109 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements UnaryOp and BinaryOp, which evaluate operators
+// on operands outside of an expression.
+
+package types
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+)
+
+// UnaryOp returns the type and, if constant, the value of the unary
+// expression op x, as the type checker computes them, or an error if
+// the operation is invalid.  The sizes of conf, which may be nil, are
+// used to fold constants, as by Check.
+//
+// An operand is usually the type and value that Check recorded for an
+// expression in Info.Types.  An operand constructed by a client, whose
+// mode is not known, is a constant if its Value is non-nil, and a
+// non-addressable value otherwise; the address operator & requires an
+// operand recorded as a variable.
+//
+// The result of an operation is a constant only if its operands are
+// constants.  Like Eval, UnaryOp does not know the context in which the
+// result is used: an untyped result remains untyped.
+//
+func (conf *Config) UnaryOp(op token.Token, x TypeAndValue) (_ TypeAndValue, err error) {
+	check := newOpChecker(conf)
+	defer check.handleOpBailout(&err)
+	o := check.opOperand(x, "x")
+	check.unary(&o, op)
+	return o.typeAndValue(), nil
+}
+
+// BinaryOp returns the type and, if constant, the value of the binary
+// expression x op y, which may be a comparison or a shift, as the type
+// checker computes them, or an error if the operation is invalid.
+// The operands and result are as for UnaryOp.
+//
+// As by Check, untyped operands of a binary operation are converted to
+// the type of the other operand; a non-constant shift whose left
+// operand is an untyped constant yields an untyped result, which must
+// be converted to an integer type when it is used.
+//
+func (conf *Config) BinaryOp(x TypeAndValue, op token.Token, y TypeAndValue) (_ TypeAndValue, err error) {
+	check := newOpChecker(conf)
+	defer check.handleOpBailout(&err)
+	ox := check.opOperand(x, "x")
+	oy := check.opOperand(y, "y")
+	check.binaryOp(&ox, &oy, op)
+	return ox.typeAndValue(), nil
+}
+
+// newOpChecker returns a checker for evaluating operators, with the
+// sizes of conf.
+func newOpChecker(conf *Config) *Checker {
+	var sizes Sizes
+	if conf != nil {
+		sizes = conf.Sizes
+	}
+	return NewChecker(&Config{Sizes: sizes}, token.NewFileSet(), nil, nil)
+}
+
+// opOperand returns the operand for tv, as the expression with the
+// given name, so that errors about it read like those of Check.
+func (check *Checker) opOperand(tv TypeAndValue, name string) operand {
+	x := operand{mode: tv.mode, expr: &ast.Ident{Name: name}, typ: tv.Type, val: tv.Value}
+	if x.typ == nil {
+		x.typ = Typ[Invalid]
+	}
+	if x.mode == invalid && x.typ != Typ[Invalid] {
+		x.mode = value
+		if x.val != nil {
+			x.mode = constant
+		}
+	}
+	if t, ok := x.typ.(*Basic); ok && isUntyped(t) && (x.mode == constant || x.mode == value) {
+		// The shift check expects untyped operands to be recorded.
+		check.rememberUntyped(x.expr, false, x.mode, t, x.val)
+	}
+	return x
+}
+
+// typeAndValue returns the type and value of the result x of an
+// operation, whose val is left over from a constant operand if x is
+// not a constant.
+func (x *operand) typeAndValue() TypeAndValue {
+	if x.mode != constant {
+		x.val = nil
+	}
+	return TypeAndValue{x.mode, x.typ, x.val}
+}
+
+// handleOpBailout is like handleBailout, for UnaryOp and BinaryOp, but
+// drops the (meaningless) position of the error.
+func (check *Checker) handleOpBailout(err *error) {
+	switch p := recover().(type) {
+	case nil, bailout:
+		if e, ok := check.firstErr.(Error); ok {
+			*err = errors.New(e.Msg)
+		}
+	default:
+		panic(p)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for UnaryOp and BinaryOp.
+
+package types_test
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/exact"
+	. "golang.org/x/tools/go/types"
+)
+
+// opString returns the type and value of the result of an operation,
+// or its error.
+func opString(tv TypeAndValue, err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	if tv.Value != nil {
+		return tv.Type.String() + " " + tv.Value.String()
+	}
+	return tv.Type.String()
+}
+
+func TestOperators(t *testing.T) {
+	c := func(T BasicKind, v exact.Value) TypeAndValue { return TypeAndValue{Type: Typ[T], Value: v} }
+	v := func(T Type) TypeAndValue { return TypeAndValue{Type: T} }
+	i := exact.MakeInt64
+	s := exact.MakeString
+
+	var conf Config
+	for _, test := range []struct {
+		op   token.Token
+		x    TypeAndValue
+		want string
+	}{
+		{token.XOR, c(Uint8, i(1)), "uint8 254"},
+		{token.SUB, c(UntypedFloat, i(2)), "untyped float -2"},
+		{token.NOT, v(Typ[Bool]), "bool"},
+		{token.ARROW, v(NewChan(SendRecv, Typ[String])), "string"},
+		{token.SUB, v(Typ[String]), "error: invalid operation: operator - not defined for x (value of type string)"},
+		{token.AND, v(Typ[Int]), "error: invalid operation: cannot take address of x (value of type int)"},
+		{token.ARROW, v(NewChan(SendOnly, Typ[Int])), "error: invalid operation: cannot receive from send-only channel x (value of type chan<- int)"},
+	} {
+		if got := opString(conf.UnaryOp(test.op, test.x)); got != test.want {
+			t.Errorf("%s%s: got %s, want %s", test.op, test.x.Type, got, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		x    TypeAndValue
+		op   token.Token
+		y    TypeAndValue
+		want string
+	}{
+		// constant operations
+		{c(UntypedInt, i(7)), token.QUO, c(UntypedInt, i(2)), "untyped int 3"},
+		{c(UntypedInt, i(7)), token.QUO, c(UntypedFloat, i(2)), "untyped float 7/2"},
+		{c(Int8, i(100)), token.ADD, c(UntypedInt, i(27)), "int8 127"},
+		{c(UntypedInt, i(1)), token.SHL, c(UntypedInt, i(10)), "untyped int 1024"},
+		{c(UntypedString, s("a")), token.LSS, c(String, s("b")), "untyped bool true"},
+
+		// non-constant operations
+		{v(Typ[Int]), token.ADD, c(UntypedInt, i(1)), "int"},
+		{v(Typ[Int]), token.EQL, v(Typ[Int]), "untyped bool"},
+		{c(UntypedInt, i(1)), token.SHL, v(Typ[Uint]), "untyped int"},
+		{v(NewSlice(Typ[Int])), token.EQL, v(Typ[UntypedNil]), "untyped bool"},
+
+		// invalid operations
+		{c(Int8, i(100)), token.ADD, c(UntypedInt, i(28)), "error: x (constant 128 of type int8) overflows int8 (range -128 .. 127)"},
+		{v(Typ[Int]), token.ADD, v(Typ[String]), "error: invalid operation: mismatched types int and string"},
+		{v(Typ[Int]), token.QUO, c(UntypedInt, i(0)), "error: invalid operation: division by zero"},
+		{v(Typ[Float64]), token.SHL, v(Typ[Uint]), "error: invalid operation: shifted operand x (value of type float64) must be integer"},
+		{v(NewSlice(Typ[Int])), token.EQL, v(NewSlice(Typ[Int])), "error: cannot compare x == y (operator == not defined for []int)"},
+	} {
+		if got := opString(conf.BinaryOp(test.x, test.op, test.y)); got != test.want {
+			t.Errorf("%s %s %s: got %s, want %s", test.x.Type, test.op, test.y.Type, got, test.want)
+		}
+	}
+}
+
+// TestOperatorsInfo checks that the operands recorded by Check may be
+// passed to UnaryOp and BinaryOp.
+func TestOperatorsInfo(t *testing.T) {
+	const src = `package p
+
+var x int
+var _ = &x
+const k = 1 << 3
+const _ = k
+`
+	info := &Info{Types: make(map[ast.Expr]TypeAndValue)}
+	mustTypecheck(t, "p", src, info)
+	operands := make(map[string]TypeAndValue)
+	for e, tv := range info.Types {
+		if id, ok := e.(*ast.Ident); ok {
+			operands[id.Name] = tv
+		}
+	}
+
+	var conf Config
+	if got, want := opString(conf.UnaryOp(token.AND, operands["x"])), "*int"; got != want {
+		t.Errorf("&x: got %s, want %s", got, want)
+	}
+	if got, want := opString(conf.BinaryOp(operands["k"], token.ADD, operands["x"])), "int"; got != want {
+		t.Errorf("k + x: got %s, want %s", got, want)
+	}
+	if got, want := opString(conf.BinaryOp(operands["k"], token.MUL, operands["k"])), "untyped int 64"; got != want {
+		t.Errorf("k * k: got %s, want %s", got, want)
+	}
+}
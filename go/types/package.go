@@ -4,7 +4,11 @@
 
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"go/token"
+	"sync"
+)
 
 // A Package describes a Go package.
 type Package struct {
@@ -14,8 +18,13 @@ type Package struct {
 	complete bool
 	imports  []*Package
 	fake     bool // scope lookup errors are silently dropped if package is fake (internal use only)
+	stub     bool // package is a stub for a missing import (see Config.StubMissingImports)
 }
 
+// stubMu guards the scopes of stub packages, whose members are
+// materialized on demand, possibly by concurrent workers.
+var stubMu sync.Mutex
+
 // NewPackage returns a new Package for the given package path and name;
 // the name must not be the blank identifier.
 // The package is not complete and contains no explicit imports.
@@ -23,7 +32,7 @@ func NewPackage(path, name string) *Package {
 	if name == "_" {
 		panic("invalid package name _")
 	}
-	scope := NewScope(Universe, fmt.Sprintf("package %q", path))
+	scope := NewScope(Universe, token.NoPos, token.NoPos, fmt.Sprintf("package %q", path))
 	return &Package{path: path, name: name, scope: scope}
 }
 
@@ -45,6 +54,24 @@ func (pkg *Package) Complete() bool { return pkg.complete }
 // MarkComplete marks a package as complete.
 func (pkg *Package) MarkComplete() { pkg.complete = true }
 
+// Stub reports whether pkg is a stub declared in place of a package
+// that could not be imported (see Config.StubMissingImports).  The
+// scope of a stub package holds the members materialized so far.
+func (pkg *Package) Stub() bool { return pkg.stub }
+
+// stubMember returns the member of the stub package pkg with the given
+// name, materializing it as a variable of invalid type on first use.
+func (pkg *Package) stubMember(name string) Object {
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	obj := pkg.scope.Lookup(name)
+	if obj == nil {
+		obj = NewVar(token.NoPos, pkg, name, Typ[Invalid])
+		pkg.scope.Insert(obj)
+	}
+	return obj
+}
+
 // Imports returns the list of packages directly imported by
 // pkg; the list is in source order. Package unsafe is excluded.
 //
@@ -57,6 +84,26 @@ func (pkg *Package) Imports() []*Package { return pkg.imports }
 // It is the caller's responsibility to make sure list elements are unique.
 func (pkg *Package) SetImports(list []*Package) { pkg.imports = list }
 
+// ReleaseBodies discards the scopes of the blocks within function
+// bodies, including those of nested function literals, from the scope
+// tree of pkg, so that the memory they and their objects occupy may
+// be reclaimed.  The package scope, file scopes, package-level
+// objects, and the scopes of package-level function signatures are
+// retained.  A function literal in a package-level declaration keeps
+// its scope, which, like that of a declared function, holds its
+// parameters and results and the objects declared at the top level
+// of its body; only the scopes nested within its body are discarded.
+// Clients should also call Info.ReleaseBodies for the information
+// recorded about pkg.
+//
+func (pkg *Package) ReleaseBodies() {
+	for _, file := range pkg.scope.children {
+		for _, fn := range file.children {
+			fn.children = nil
+		}
+	}
+}
+
 func (pkg *Package) String() string {
 	return fmt.Sprintf("package %s (%q)", pkg.name, pkg.path)
 }
@@ -122,6 +122,41 @@ func (check *Checker) filename(fileNo int) string {
 	return fmt.Sprintf("file[%d]", fileNo)
 }
 
+// stub returns the stub package for the import path of a missing
+// package, creating it on first use.
+func (check *Checker) stub(path string) *Package {
+	if pkg := check.stubs[path]; pkg != nil {
+		return pkg
+	}
+	if check.stubs == nil {
+		check.stubs = make(map[string]*Package)
+	}
+	pkg := NewPackage(path, stubName(path))
+	pkg.fake = true
+	pkg.stub = true
+	check.stubs[path] = pkg
+	return pkg
+}
+
+// stubName returns the name of a stub package: the last element of its
+// import path, with characters other than letters and digits replaced
+// by underscores, as in "yaml_v2" for "gopkg.in/yaml.v2".
+func stubName(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	name := []rune(path)
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || string(name) == "_" {
+		return "stub"
+	}
+	return string(name)
+}
+
 // collectObjects collects all file and package objects and inserts them
 // into their respective scopes. It also performs imports and associates
 // methods with receiver base type names.
@@ -153,13 +188,21 @@ func (check *Checker) collectObjects() {
 		// but there is no corresponding package object.
 		check.recordDef(file.Name, nil)
 
-		fileScope := NewScope(check.pkg.scope, check.filename(fileNo))
+		// The file scope extends over the whole file, including
+		// comments before the package clause and after the last
+		// declaration.
+		pos, end := file.Pos(), file.End()
+		if f := check.fset.File(file.Pos()); f != nil {
+			pos, end = token.Pos(f.Base()), token.Pos(f.Base()+f.Size())
+		}
+		fileScope := NewScope(check.pkg.scope, pos, end, "file "+check.filename(fileNo))
 		check.recordScope(file, fileScope)
 
 		for _, decl := range file.Decls {
 			switch d := decl.(type) {
 			case *ast.BadDecl:
-				// ignore
+				// ignore - error reported before
+				check.recordSkipped(d)
 
 			case *ast.GenDecl:
 				var last *ast.ValueSpec // last ValueSpec with type or init exprs seen
@@ -173,6 +216,7 @@ func (check *Checker) collectObjects() {
 							check.errorf(s.Path.Pos(), "invalid import path (%s)", err)
 							continue
 						}
+						path = check.intern(path)
 						if path == "C" && check.conf.FakeImportC {
 							// TODO(gri) shouldn't create a new one each time
 							imp = NewPackage("C", "C")
@@ -184,8 +228,12 @@ func (check *Checker) collectObjects() {
 								err = errors.New("Config.Import returned nil but no error")
 							}
 							if err != nil {
-								check.errorf(s.Path.Pos(), "could not import %s (%s)", path, err)
-								continue
+								if !check.conf.StubMissingImports {
+									check.errorf(s.Path.Pos(), "could not import %s (%s)", path, err)
+									continue
+								}
+								check.softErrorf(s.Path.Pos(), "could not import %s (%s)", path, err)
+								imp = check.stub(path)
 							}
 						}
 
@@ -209,7 +257,7 @@ func (check *Checker) collectObjects() {
 							}
 						}
 
-						obj := NewPkgName(s.Pos(), pkg, name, imp)
+						obj := NewPkgName(s.Pos(), pkg, check.intern(name), imp)
 						if s.Name != nil {
 							// in a dot-import, the dot represents the package
 							check.recordDef(s.Name, obj)
@@ -257,7 +305,7 @@ func (check *Checker) collectObjects() {
 
 							// declare all constants
 							for i, name := range s.Names {
-								obj := NewConst(name.Pos(), pkg, name.Name, nil, exact.MakeInt64(int64(iota)))
+								obj := NewConst(name.Pos(), pkg, check.intern(name.Name), nil, exact.MakeInt64(int64(iota)))
 
 								var init ast.Expr
 								if i < len(last.Values) {
@@ -286,7 +334,7 @@ func (check *Checker) collectObjects() {
 
 							// declare all variables
 							for i, name := range s.Names {
-								obj := NewVar(name.Pos(), pkg, name.Name, nil)
+								obj := NewVar(name.Pos(), pkg, check.intern(name.Name), nil)
 								lhs[i] = obj
 
 								d := d1
@@ -309,7 +357,7 @@ func (check *Checker) collectObjects() {
 						}
 
 					case *ast.TypeSpec:
-						obj := NewTypeName(s.Name.Pos(), pkg, s.Name.Name, nil)
+						obj := NewTypeName(s.Name.Pos(), pkg, check.intern(s.Name.Name), nil)
 						check.declarePkgObj(s.Name, obj, &declInfo{file: fileScope, typ: s.Type})
 
 					default:
@@ -319,7 +367,7 @@ func (check *Checker) collectObjects() {
 
 			case *ast.FuncDecl:
 				name := d.Name.Name
-				obj := NewFunc(d.Name.Pos(), pkg, name, nil)
+				obj := NewFunc(d.Name.Pos(), pkg, check.intern(name), nil)
 				if d.Recv == nil {
 					// regular function
 					if name == "init" {
@@ -350,6 +398,7 @@ func (check *Checker) collectObjects() {
 						}
 					}
 				}
+				obj.external = d.Body == nil && name != "init"
 				info := &declInfo{file: fileScope, fdecl: d}
 				check.objMap[obj] = info
 				obj.setOrder(uint32(len(check.objMap)))
@@ -402,6 +451,10 @@ func (check *Checker) packageObjects(objList []Object) {
 
 // functionBodies typechecks all function bodies.
 func (check *Checker) functionBodies() {
+	if n := check.conf.ConcurrentBodies; n > 1 && len(check.funcs) > 1 {
+		check.concurrentFunctionBodies(n)
+		return
+	}
 	for _, f := range check.funcs {
 		check.funcBody(f.decl, f.name, f.sig, f.body)
 	}
@@ -106,7 +106,8 @@ func TestResolveIdents(t *testing.T) {
 	var conf Config
 	uses := make(map[*ast.Ident]Object)
 	defs := make(map[*ast.Ident]Object)
-	_, err := conf.Check("testResolveIdents", fset, files, &Info{Defs: defs, Uses: uses})
+	info := &Info{Defs: defs, Uses: uses, Selections: make(map[*ast.SelectorExpr]*Selection)}
+	_, err := conf.Check("testResolveIdents", fset, files, info)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -118,23 +119,16 @@ func TestResolveIdents(t *testing.T) {
 		}
 	}
 
-	// check that qualified identifiers are resolved
+	// check that qualified identifiers and selections are resolved
 	for _, f := range files {
 		ast.Inspect(f, func(n ast.Node) bool {
 			if s, ok := n.(*ast.SelectorExpr); ok {
-				if x, ok := s.X.(*ast.Ident); ok {
-					obj := uses[x]
-					if obj == nil {
-						t.Errorf("%s: unresolved qualified identifier %s", fset.Position(x.Pos()), x.Name)
-						return false
-					}
-					if _, ok := obj.(*PkgName); ok && uses[s.Sel] == nil {
-						t.Errorf("%s: unresolved selector %s", fset.Position(s.Sel.Pos()), s.Sel.Name)
-						return false
-					}
-					return false
+				switch kind, obj := info.Selector(s); {
+				case kind == InvalidSelector:
+					t.Errorf("%s: unresolved selector expression %s", fset.Position(s.Pos()), ExprString(s))
+				case obj == nil:
+					t.Errorf("%s: unresolved selector %s", fset.Position(s.Sel.Pos()), s.Sel.Name)
 				}
-				return false
 			}
 			return true
 		})
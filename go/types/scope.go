@@ -9,14 +9,12 @@ package types
 import (
 	"bytes"
 	"fmt"
+	"go/token"
 	"io"
 	"sort"
 	"strings"
 )
 
-// TODO(gri) Provide scopes with a name or other mechanism so that
-//           objects can use that information for better printing.
-
 // A Scope maintains a set of objects and links to its containing
 // (parent) and contained (children) scopes. Objects may be inserted
 // and looked up by name. The zero value for Scope is a ready-to-use
@@ -24,14 +22,18 @@ import (
 type Scope struct {
 	parent   *Scope
 	children []*Scope
-	comment  string            // for debugging only
+	pos, end token.Pos         // scope extent; may be invalid
+	comment  string            // description, e.g. "function f"; for printing only
 	elems    map[string]Object // lazily allocated
+	shared   bool              // if set, elems is shared with a fork and must be copied before modification
 }
 
 // NewScope returns a new, empty scope contained in the given parent
-// scope, if any.  The comment is for debugging only.
-func NewScope(parent *Scope, comment string) *Scope {
-	s := &Scope{parent: parent, comment: comment}
+// scope, if any, and extending over the source interval [pos, end),
+// if the interval is valid.  The comment describes the scope, as in
+// "file a.go", "function f", or "if statement", for printing only.
+func NewScope(parent *Scope, pos, end token.Pos, comment string) *Scope {
+	s := &Scope{parent: parent, pos: pos, end: end, comment: comment}
 	// don't add children to Universe scope!
 	if parent != nil && parent != Universe {
 		parent.children = append(parent.children, s)
@@ -63,6 +65,49 @@ func (s *Scope) NumChildren() int { return len(s.children) }
 // Child returns the i'th child scope for 0 <= i < NumChildren().
 func (s *Scope) Child(i int) *Scope { return s.children[i] }
 
+// Pos and End describe the scope's source code extent [pos, end).
+// The results are guaranteed to be valid only if the type-checked
+// AST has complete position information.  The extent is undefined
+// for Universe and package scopes, whose declarations may be spread
+// over several files.
+func (s *Scope) Pos() token.Pos { return s.pos }
+func (s *Scope) End() token.Pos { return s.end }
+
+// Contains returns true if pos is within the scope's extent.
+// The result is guaranteed to be valid only if the type-checked
+// AST has complete position information.
+func (s *Scope) Contains(pos token.Pos) bool {
+	return s.pos <= pos && pos < s.end
+}
+
+// Innermost returns the innermost (child) scope containing pos, which
+// may be s itself.  If pos is not within any scope, the result is nil.
+// The result is also nil for the Universe scope.  If s is a package
+// scope, Innermost searches the scopes of its files.
+// The result is guaranteed to be valid only if the type-checked
+// AST has complete position information.
+func (s *Scope) Innermost(pos token.Pos) *Scope {
+	// Package scopes do not have extents since they may be
+	// discontiguous, so iterate over the package's files.
+	if s.parent == Universe {
+		for _, s := range s.children {
+			if r := s.Innermost(pos); r != nil {
+				return r
+			}
+		}
+	}
+
+	if s.Contains(pos) {
+		for _, s := range s.children {
+			if s.Contains(pos) {
+				return s.Innermost(pos)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
 // Lookup returns the object in scope s with the given name if such an
 // object exists; otherwise the result is nil.
 func (s *Scope) Lookup(name string) Object {
@@ -86,6 +131,46 @@ func (s *Scope) LookupParent(name string) (*Scope, Object) {
 	return nil, nil
 }
 
+// Fork returns a copy-on-write copy of s: the new scope has the same
+// parent, comment, children, and elements as s, but subsequent
+// modifications of either scope are not visible in the other. The
+// fork is not recorded as a child of s's parent. Forking is cheap;
+// the elements are copied only when one of the scopes is modified.
+//
+// Fork is intended for speculative operations such as checking
+// whether inserting a declaration would cause a conflict. Note that
+// Insert sets the parent of a parentless object to the fork, as usual.
+func (s *Scope) Fork() *Scope {
+	f := &Scope{
+		parent:   s.parent,
+		children: append([]*Scope(nil), s.children...),
+		pos:      s.pos,
+		end:      s.end,
+		comment:  s.comment,
+		elems:    s.elems,
+	}
+	if s.elems != nil {
+		s.shared = true
+		f.shared = true
+	}
+	return f
+}
+
+// own ensures that s has its own copy of elems before s is modified.
+// (If both s and its fork are modified, the second one to be modified
+// copies the elements unnecessarily; forks are typically short-lived.)
+func (s *Scope) own() {
+	if !s.shared {
+		return
+	}
+	m := make(map[string]Object, len(s.elems)+1)
+	for name, obj := range s.elems {
+		m[name] = obj
+	}
+	s.elems = m
+	s.shared = false
+}
+
 // Insert attempts to insert an object obj into scope s.
 // If s already contains an alternative object alt with
 // the same name, Insert leaves s unchanged and returns alt.
@@ -99,6 +184,7 @@ func (s *Scope) Insert(obj Object) Object {
 	if s.elems == nil {
 		s.elems = make(map[string]Object)
 	}
+	s.own()
 	s.elems[name] = obj
 	if obj.Parent() == nil {
 		obj.setParent(s)
@@ -106,6 +192,33 @@ func (s *Scope) Insert(obj Object) Object {
 	return nil
 }
 
+// Remove removes the object with the given name from scope s and
+// returns it. If s contains no such object, Remove leaves s unchanged
+// and returns nil. If s was the parent scope of the removed object,
+// the object's parent is cleared.
+func (s *Scope) Remove(name string) Object {
+	obj := s.elems[name]
+	if obj == nil {
+		return nil
+	}
+	s.own()
+	delete(s.elems, name)
+	if obj.Parent() == s {
+		obj.setParent(nil)
+	}
+	return obj
+}
+
+// Replace inserts an object obj into scope s, replacing the object
+// alt with the same name, if any, and returns alt (or nil). As with
+// Insert, the parent of obj is set to s if not already set; the parent
+// of alt is cleared if it was s.
+func (s *Scope) Replace(obj Object) Object {
+	alt := s.Remove(obj.Name())
+	s.Insert(obj)
+	return alt
+}
+
 // WriteTo writes a string representation of the scope to w,
 // with the scope elements sorted by name.
 // The level of indentation is controlled by n >= 0, with
@@ -115,8 +228,12 @@ func (s *Scope) WriteTo(w io.Writer, n int, recurse bool) {
 	const ind = ".  "
 	indn := strings.Repeat(ind, n)
 
-	fmt.Fprintf(w, "%s%s scope %p {", indn, s.comment, s)
-	if len(s.elems) == 0 {
+	if s.comment != "" {
+		fmt.Fprintf(w, "%s%s scope {", indn, s.comment)
+	} else {
+		fmt.Fprintf(w, "%sscope %p {", indn, s)
+	}
+	if len(s.elems) == 0 && (!recurse || len(s.children) == 0) {
 		fmt.Fprintf(w, "}\n")
 		return
 	}
@@ -129,12 +246,19 @@ func (s *Scope) WriteTo(w io.Writer, n int, recurse bool) {
 
 	if recurse {
 		for _, s := range s.children {
-			fmt.Fprintln(w)
 			s.WriteTo(w, n+1, recurse)
 		}
 	}
 
-	fmt.Fprintf(w, "%s}", indn)
+	fmt.Fprintf(w, "%s}\n", indn)
+}
+
+// describe returns the description of the scope for error messages.
+func (s *Scope) describe() string {
+	if s.comment == "" {
+		return "this block"
+	}
+	return s.comment
 }
 
 // String returns a string representation of the scope, for debugging.
@@ -0,0 +1,244 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func newVar(name string) *Var { return NewVar(0, nil, name, Typ[Int]) }
+
+func TestScopeFork(t *testing.T) {
+	s := NewScope(nil, token.NoPos, token.NoPos, "test")
+	s.Insert(newVar("a"))
+
+	f := s.Fork()
+	if f.Parent() != s.Parent() || f.Len() != 1 || f.Lookup("a") == nil {
+		t.Fatalf("fork differs from original: %s", f)
+	}
+
+	// speculative insertion into the fork must not affect s
+	if alt := f.Insert(newVar("b")); alt != nil {
+		t.Fatalf("unexpected conflict with %s", alt)
+	}
+	if s.Lookup("b") != nil {
+		t.Errorf("insertion into fork is visible in original scope")
+	}
+
+	// insertion into s must not affect the fork
+	s.Insert(newVar("c"))
+	if f.Lookup("c") != nil {
+		t.Errorf("insertion into original scope is visible in fork")
+	}
+
+	// conflicts are reported by the fork as by the original
+	if alt := f.Insert(newVar("a")); alt == nil || alt != s.Lookup("a") {
+		t.Errorf("got conflict %v, want %s", alt, s.Lookup("a"))
+	}
+}
+
+func TestScopeRemoveReplace(t *testing.T) {
+	s := NewScope(nil, token.NoPos, token.NoPos, "test")
+	a := newVar("a")
+	s.Insert(a)
+
+	if obj := s.Remove("x"); obj != nil {
+		t.Errorf("Remove of missing name returned %s", obj)
+	}
+	if obj := s.Remove("a"); obj != a {
+		t.Errorf("Remove returned %v, want %s", obj, a)
+	}
+	if s.Lookup("a") != nil || a.Parent() != nil {
+		t.Errorf("removed object still in scope or has parent")
+	}
+
+	s.Insert(a)
+	a2 := newVar("a")
+	if alt := s.Replace(a2); alt != a {
+		t.Errorf("Replace returned %v, want %s", alt, a)
+	}
+	if s.Lookup("a") != a2 || a2.Parent() != s || a.Parent() != nil {
+		t.Errorf("Replace did not update scope or parents")
+	}
+
+	// removal from a fork doesn't affect the original
+	f := s.Fork()
+	f.Remove("a")
+	if s.Lookup("a") != a2 || a2.Parent() != s {
+		t.Errorf("removal from fork is visible in original scope")
+	}
+}
+
+func TestScopeInnermost(t *testing.T) {
+	const src = `// comment before the package clause /*file*/
+package p
+
+var v int
+
+func f(x /*sig*/ int) {
+	a := 1
+	for i := 0; i < a; i++ {
+		b := /*for*/ i
+		_ = b /*block*/
+	}
+	_ = a /*body*/
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := fset.File(f.Pos()).Base()
+	pos := func(marker string) token.Pos {
+		return token.Pos(base + strings.Index(src, marker))
+	}
+	for _, test := range []struct {
+		marker       string
+		local, outer string // a name declared in the innermost scope, and one not
+	}{
+		{"/*file*/", "", "v"},
+		{"/*sig*/", "x", "v"},
+		{"/*body*/", "a", "b"},
+		{"/*for*/", "b", "a"},
+		{"/*block*/", "b", "i"},
+	} {
+		s := pkg.Scope().Innermost(pos(test.marker))
+		if s == nil {
+			t.Errorf("%s: no innermost scope", test.marker)
+			continue
+		}
+		if !s.Contains(pos(test.marker)) {
+			t.Errorf("%s: innermost scope [%d, %d) does not contain it", test.marker, s.Pos(), s.End())
+		}
+		if test.local != "" && s.Lookup(test.local) == nil {
+			t.Errorf("%s: innermost scope does not declare %s", test.marker, test.local)
+		}
+		if s.Lookup(test.outer) != nil {
+			t.Errorf("%s: innermost scope declares %s", test.marker, test.outer)
+		}
+	}
+	if s := pkg.Scope().Innermost(token.Pos(base + len(src) + 1)); s != nil {
+		t.Errorf("position beyond the file has innermost scope %s", s)
+	}
+	if s := Universe.Innermost(f.Pos()); s != nil {
+		t.Errorf("Universe has innermost scope %s", s)
+	}
+}
+
+// TestScopeComments checks the descriptions of scopes, as printed and
+// as reported for redeclarations.
+func TestScopeComments(t *testing.T) {
+	const src = `package p
+
+type T int
+
+func (*T) m(x, x int) {}
+
+func f() {
+	a := 1
+	if b := a; b > 0 {
+		var b int
+		_ = b
+	}
+	var a string
+	_ = func(c, c int) {}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "/some/dir/p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errors []string
+	conf := Config{Error: func(err error) { errors = append(errors, err.(Error).Msg) }}
+	info := &Info{Scopes: make(map[ast.Node]*Scope)}
+	conf.Check("p", fset, []*ast.File{f}, info)
+
+	want := []string{
+		"x redeclared in method (*T).m",
+		"a redeclared in function f",
+		"c redeclared in function literal",
+	}
+	var got []string
+	for _, msg := range errors {
+		if strings.Contains(msg, "redeclared") {
+			got = append(got, msg)
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got redeclarations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if got, want := info.Scopes[f].String(), "file /some/dir/p.go scope {}\n"; got != want {
+		t.Errorf("file scope: got %q, want %q", got, want)
+	}
+	var ifScope *Scope
+	for node, scope := range info.Scopes {
+		if _, ok := node.(*ast.IfStmt); ok {
+			ifScope = scope
+		}
+	}
+	if got := ifScope.String(); !strings.HasPrefix(got, "if statement scope {") {
+		t.Errorf("if scope: got %q", got)
+	}
+}
+
+func TestScopeWriteTo(t *testing.T) {
+	const src = `package p
+
+func f(x int) {
+	if y := x; y > 0 {
+		for {
+		}
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file scope has no elements, but its children are written.
+	var buf bytes.Buffer
+	pkg.Scope().WriteTo(&buf, 0, true)
+	const want = `package "p" scope {
+.  func p.f(x int)
+.  file a.go scope {
+.  .  function f scope {
+.  .  .  var x int
+.  .  .  if statement scope {
+.  .  .  .  var y int
+.  .  .  .  block scope {
+.  .  .  .  .  for statement scope {
+.  .  .  .  .  .  block scope {}
+.  .  .  .  .  }
+.  .  .  .  }
+.  .  .  }
+.  .  }
+.  }
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
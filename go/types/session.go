@@ -0,0 +1,225 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Session, for checking the inputs of a REPL.
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// A Session type-checks a sequence of inputs, as entered in an
+// interactive Go environment (a REPL), against an accumulating
+// package scope.  Each input is a list of declarations or of
+// statements.  The package-level objects declared by declarations,
+// and the variables and other objects declared by the top-level
+// statements of an input, become bindings in the package scope that
+// later inputs may refer to.  A binding shadows any prior binding of
+// the same name; references checked earlier continue to denote the
+// prior binding.
+//
+// The imports of an input are visible to all later inputs; unused
+// imports are not reported.  Methods must be declared in the same
+// input as their receiver type.  Expressions may be evaluated in the
+// session using Eval with the session's Package and Scope.
+//
+type Session struct {
+	fset    *token.FileSet
+	check   *Checker
+	imports bytes.Buffer // import declarations of all inputs so far
+	n       int          // number of inputs checked so far
+}
+
+// NewSession returns a new session for checking inputs as a package
+// named main with the specified path, recording type information in
+// info (which may be nil).  Positions of the inputs are recorded in
+// fset, with file names "input1", "input2", and so on.
+func NewSession(conf *Config, fset *token.FileSet, path string, info *Info) *Session {
+	c := Config{}
+	if conf != nil {
+		c = *conf
+	}
+	c.DisableUnusedImportCheck = true
+	pkg := NewPackage(path, "main")
+	return &Session{fset: fset, check: NewChecker(&c, fset, pkg, info)}
+}
+
+// Package returns the package of the session, whose scope holds the
+// bindings of the inputs checked so far.
+func (s *Session) Package() *Package { return s.check.pkg }
+
+// Scope returns the scope in which the next input is checked, which
+// holds the imports of the inputs checked so far and is nested within
+// the package scope.  It is the package scope if no input has been
+// checked.
+func (s *Session) Scope() *Scope {
+	scope := s.check.pkg.scope
+	if n := len(scope.children); n > 0 {
+		return scope.children[n-1] // the file scope of the last input
+	}
+	return scope
+}
+
+// Check parses and checks the input src, a list of declarations if it
+// starts with an import, const, var, type, or func keyword, and a list
+// of statements otherwise.  It returns the objects bound by the input
+// in source order, and the first error, if any.  As for Checker.Files,
+// all errors are reported to the configuration's Error function, if
+// any.  Bindings are made even if the input has type errors, but not
+// if it has syntax errors.
+func (s *Session) Check(src string) ([]Object, error) {
+	s.n++
+	filename := fmt.Sprintf("input%d", s.n)
+
+	// The statements of an input are checked as the body of a
+	// function.
+	decls := isDecls(src)
+	var prefix, suffix string
+	if !decls {
+		prefix, suffix = "func _() {\n", "}\n"
+	}
+	f, err := s.parse(filename, prefix, src+"\n"+suffix)
+	if err != nil {
+		return nil, err
+	}
+	var body *ast.BlockStmt
+	if !decls {
+		body = f.Decls[len(f.Decls)-1].(*ast.FuncDecl).Body
+	}
+
+	check := s.check
+	pkg := check.pkg
+	if body == nil {
+		// Declarations shadow prior bindings; imports are added
+		// to the text of subsequent inputs.
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.ImportSpec:
+						if spec.Name != nil {
+							fmt.Fprintf(&s.imports, "import %s %s\n", spec.Name.Name, spec.Path.Value)
+						} else {
+							fmt.Fprintf(&s.imports, "import %s\n", spec.Path.Value)
+						}
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							pkg.scope.Remove(name.Name)
+						}
+					case *ast.TypeSpec:
+						pkg.scope.Remove(spec.Name.Name)
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					pkg.scope.Remove(decl.Name.Name)
+				}
+			}
+		}
+	}
+
+	check.bindings = body
+	err = check.Files([]*ast.File{f})
+	check.bindings = nil
+
+	var bound []Object
+	if body == nil {
+		for _, decl := range f.Decls {
+			bound = append(bound, s.declared(decl)...)
+		}
+	} else {
+		// Move the top-level objects of the statements from the
+		// function scope into the package scope.
+		for _, stmt := range body.List {
+			bound = append(bound, s.bound(stmt)...)
+		}
+		for _, obj := range bound {
+			pkg.scope.Replace(obj)
+		}
+	}
+	return bound, err
+}
+
+// parse parses the text of an input, preceded by the package clause,
+// the imports of prior inputs, and prefix (see parseWrapped).
+func (s *Session) parse(filename, prefix, text string) (*ast.File, error) {
+	return parseWrapped(s.fset, filename, "package main\n"+s.imports.String()+prefix, text, 0)
+}
+
+// declared returns the package-level objects declared by decl.
+func (s *Session) declared(decl ast.Decl) []Object {
+	var objs []Object
+	def := func(id *ast.Ident) {
+		if obj := s.check.pkg.scope.Lookup(id.Name); obj != nil && obj.Pos() == id.Pos() {
+			objs = append(objs, obj)
+		}
+	}
+	switch decl := decl.(type) {
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					def(name)
+				}
+			case *ast.TypeSpec:
+				def(spec.Name)
+			}
+		}
+	case *ast.FuncDecl:
+		if decl.Recv == nil {
+			def(decl.Name)
+		}
+	}
+	return objs
+}
+
+// bound returns the objects declared by the top-level statement stmt
+// of an input, which are the session's new bindings.
+func (s *Session) bound(stmt ast.Stmt) []Object {
+	var ids []*ast.Ident
+	switch stmt := stmt.(type) {
+	case *ast.AssignStmt:
+		if stmt.Tok == token.DEFINE {
+			for _, lhs := range stmt.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+	case *ast.DeclStmt:
+		if decl, ok := stmt.Decl.(*ast.GenDecl); ok {
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					ids = append(ids, spec.Names...)
+				case *ast.TypeSpec:
+					ids = append(ids, spec.Name)
+				}
+			}
+		}
+	}
+	var objs []Object
+	for _, id := range ids {
+		if id.Name == "_" {
+			continue
+		}
+		if obj := s.funcScope().Lookup(id.Name); obj != nil && obj.Pos() == id.Pos() {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// funcScope returns the scope of the function enclosing the
+// statements of the last input.
+func (s *Session) funcScope() *Scope {
+	file := s.Scope()
+	return file.children[len(file.children)-1]
+}
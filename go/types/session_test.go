@@ -0,0 +1,64 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestSession(t *testing.T) {
+	fset := token.NewFileSet()
+	var errs []string
+	conf := Config{Error: func(err error) { errs = append(errs, err.Error()) }}
+	s := NewSession(&conf, fset, "repl", nil)
+
+	for _, test := range []struct {
+		input string
+		bound string // the objects bound by the input
+		err   string // the first error, if any
+	}{
+		{"x := 1", "[var repl.x int]", ""},
+		{"const c = 2.5", "[const repl.c untyped float]", ""},
+		{"y := float64(x) * c", "[var repl.y float64]", ""},
+		{"type T struct{ f int }\nfunc (t T) M() int { return t.f }", "[type repl.T struct{f int}]", ""},
+		{"t := T{x}; n := t.M()", "[var repl.t repl.T var repl.n int]", ""},
+		{"x := \"hello\"", "[var repl.x string]", ""}, // shadows x
+		{"n = len(x) + n", "[]", ""},
+		{"var z = y", "[var repl.z float64]", ""},
+		{"func f() string { return x }", "[func repl.f() string]", ""},
+		{"x = 1", "[]", "input10:1:5: cannot convert 1 (untyped int constant) to string"},
+		{"w := undefined", "[var repl.w invalid type]", "input11:1:6: undeclared name: undefined"},
+		{"for {", "[]", "input12:2:3: expected '}', found 'EOF'"},
+		{"import \"unsafe\"", "[]", ""},
+		{"s := unsafe.Sizeof(x)", "[var repl.s uintptr]", ""},
+	} {
+		errs = nil
+		bound, err := s.Check(test.input)
+		if got := fmt.Sprint(bound); got != test.bound {
+			t.Errorf("%q: got bindings %s, want %s", test.input, got, test.bound)
+		}
+		var got string
+		if err != nil {
+			got = err.Error()
+		}
+		if got != test.err {
+			t.Errorf("%q: got error %q, want %q (all errors: %s)", test.input, got, test.err, strings.Join(errs, "; "))
+		}
+	}
+
+	// Expressions may be evaluated in the session.
+	tv, err := Eval("f() + x", s.Package(), s.Scope())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tv.Type.String(), "string"; got != want {
+		t.Errorf("Eval: got type %s, want %s", got, want)
+	}
+}
@@ -132,12 +132,7 @@ func (s *StdSizes) Sizeof(T Type) int64 {
 		if n == 0 {
 			return 0
 		}
-		offsets := t.offsets
-		if t.offsets == nil {
-			// compute offsets on demand
-			offsets = s.Offsetsof(t.fields)
-			t.offsets = offsets
-		}
+		offsets := s.Offsetsof(t.fields)
 		return offsets[n-1] + s.Sizeof(t.fields[n-1].typ)
 	case *Interface:
 		return s.WordSize * 2
@@ -158,10 +153,12 @@ func (conf *Config) alignof(T Type) int64 {
 	return stdSizes.Alignof(T)
 }
 
+// offsetsof returns the offsets of the fields of T.  They are not
+// cached in T, which may be shared by concurrent checks, with other
+// sizes.
 func (conf *Config) offsetsof(T *Struct) []int64 {
-	offsets := T.offsets
-	if offsets == nil && T.NumFields() > 0 {
-		// compute offsets on demand
+	var offsets []int64
+	if T.NumFields() > 0 {
 		if s := conf.Sizes; s != nil {
 			offsets = s.Offsetsof(T.fields)
 			// sanity checks
@@ -176,7 +173,6 @@ func (conf *Config) offsetsof(T *Struct) []int64 {
 		} else {
 			offsets = stdSizes.Offsetsof(T.fields)
 		}
-		T.offsets = offsets
 	}
 	return offsets
 }
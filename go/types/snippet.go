@@ -0,0 +1,153 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements CheckSnippet, for checking code fragments.
+
+package types
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strconv"
+)
+
+// A SnippetKind describes the kind of a code fragment.
+type SnippetKind int
+
+const (
+	DeclSnippet SnippetKind = iota // a list of declarations
+	StmtSnippet                    // a list of statements
+	ExprSnippet                    // an expression
+)
+
+// A Snippet is a code fragment checked by CheckSnippet.
+type Snippet struct {
+	Kind SnippetKind
+	File *ast.File // the synthetic file in which the fragment was checked
+	Pkg  *Package  // the synthetic package in which the fragment was checked
+
+	Decls []ast.Decl // for a DeclSnippet, the declarations of the fragment
+	Stmts []ast.Stmt // for a StmtSnippet, the statements of the fragment
+	Expr  ast.Expr   // for an ExprSnippet, the expression
+}
+
+// CheckSnippet parses and type-checks the code fragment src, which,
+// lacking a package clause, is a list of declarations if it starts
+// with an import, const, var, type, or func keyword and parses as one,
+// an expression if it parses as one, and a list of statements
+// otherwise.  Playgrounds and documentation testing tools may use it
+// to check code snippets.
+//
+// The fragment is wrapped in a synthetic file of a package main
+// importing the specified packages, and statements and expressions in
+// a function body.  An expression is checked as an expression
+// statement, but its value may be unused, in which case it has its
+// default type if untyped, as if assigned to the blank identifier.
+// Positions within the fragment, including those of errors, are
+// reported relative to it, as positions within the file filename,
+// which starts at line 1.  Unused imports, and unused variables
+// declared by top-level statements, are not reported.
+//
+// CheckSnippet returns the checked fragment, if it could be parsed,
+// and the first error, if any, as for Config.Check; type information
+// is recorded in info, which may be nil.
+//
+func CheckSnippet(conf *Config, fset *token.FileSet, filename, src string, imports []string, info *Info) (*Snippet, error) {
+	var header bytes.Buffer
+	header.WriteString("package main\n")
+	for _, path := range imports {
+		header.WriteString("import " + strconv.Quote(path) + "\n")
+	}
+
+	kind := StmtSnippet
+	if isDecls(src) {
+		kind = DeclSnippet
+		// A fragment starting with a declaration keyword may yet be
+		// a list of statements, such as a var declaration and its uses.
+		if _, err := parseSnippet(token.NewFileSet(), filename, header.String(), src, DeclSnippet); err != nil {
+			if _, err := parseSnippet(token.NewFileSet(), filename, header.String(), src, StmtSnippet); err == nil {
+				kind = StmtSnippet
+			}
+		}
+	} else if _, err := parser.ParseExpr(src); err == nil {
+		kind = ExprSnippet
+	}
+	f, err := parseSnippet(fset, filename, header.String(), src, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snippet{Kind: kind, File: f}
+	var body *ast.BlockStmt
+	switch kind {
+	case DeclSnippet:
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.IMPORT && decl.Pos() < f.Package+token.Pos(header.Len()) {
+				continue // synthetic import
+			}
+			s.Decls = append(s.Decls, decl)
+		}
+	case StmtSnippet:
+		body = f.Decls[len(f.Decls)-1].(*ast.FuncDecl).Body
+		s.Stmts = body.List
+	case ExprSnippet:
+		body = f.Decls[len(f.Decls)-1].(*ast.FuncDecl).Body
+		s.Expr = body.List[0].(*ast.ExprStmt).X
+	}
+
+	var c Config
+	if conf != nil {
+		c = *conf
+	}
+	c.DisableUnusedImportCheck = true
+	s.Pkg = NewPackage("snippet", "main")
+	check := NewChecker(&c, fset, s.Pkg, info)
+	check.bindings = body
+	check.snippetExpr = s.Expr
+	return s, check.Files([]*ast.File{f})
+}
+
+// parseSnippet parses the code fragment src of the specified kind,
+// wrapped as described at CheckSnippet, following header.
+func parseSnippet(fset *token.FileSet, filename, header, src string, kind SnippetKind) (*ast.File, error) {
+	if kind == DeclSnippet {
+		return parseWrapped(fset, filename, header, src+"\n", 0)
+	}
+	return parseWrapped(fset, filename, header+"func _() {\n", src+"\n}\n", 0)
+}
+
+// isDecls reports whether the code fragment src starts with a keyword
+// that starts a declaration.
+func isDecls(src string) bool {
+	var sc scanner.Scanner
+	fset := token.NewFileSet()
+	sc.Init(fset.AddFile("", -1, len(src)), []byte(src), nil, 0)
+	switch _, tok, _ := sc.Scan(); tok {
+	case token.IMPORT, token.CONST, token.VAR, token.TYPE, token.FUNC:
+		return true
+	}
+	return false
+}
+
+// parseWrapped parses the source prefix+text as the file filename.
+// Positions within text, including those of syntax errors, are
+// reported relative to it, so that line 1 is its first line; prefix
+// must end with a newline.
+func parseWrapped(fset *token.FileSet, filename, prefix, text string, mode parser.Mode) (*ast.File, error) {
+	f, err := parser.ParseFile(fset, filename, prefix+text, mode)
+	if f == nil {
+		return nil, err
+	}
+	file := fset.File(f.Pos())
+	file.AddLineInfo(len(prefix), filename, 1)
+	if list, ok := err.(scanner.ErrorList); ok {
+		for _, e := range list {
+			e.Pos = file.Position(file.Pos(e.Pos.Offset))
+		}
+	}
+	return f, err
+}
@@ -0,0 +1,76 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestCheckSnippet(t *testing.T) {
+	for _, test := range []struct {
+		src     string
+		imports []string
+		kind    SnippetKind
+		n       int    // number of statements or declarations
+		typ     string // for expressions, the type
+		err     string // the first error, if any
+	}{
+		{"1 + 2.5", nil, ExprSnippet, 0, "float64", ""},
+		{"struct{ x int }{1}.x", nil, ExprSnippet, 0, "int", ""},
+		{"unsafe.Sizeof(0)", []string{"unsafe"}, ExprSnippet, 0, "uintptr", ""},
+		{"println(1)", nil, ExprSnippet, 0, "", ""},
+		{"x := 1\ny := x * 2", nil, StmtSnippet, 2, "", ""},
+		{"var s []int\ns = append(s, 1)", nil, StmtSnippet, 2, "", ""},
+		{"type T int\nfunc (T) M() {}", nil, DeclSnippet, 2, "", ""},
+		{"import \"unsafe\"\nconst c = unsafe.Sizeof(0)", nil, DeclSnippet, 2, "", ""},
+		{"const c = unsafe.Sizeof(0)", []string{"unsafe"}, DeclSnippet, 1, "", ""},
+		{"x := 1\nx = \"s\"", nil, StmtSnippet, 2, "", "snip:2:5: cannot convert \"s\" (untyped string constant) to int"},
+		{"1 + undefined", nil, ExprSnippet, 0, "", "snip:1:5: undeclared name: undefined"},
+		{"for {", nil, StmtSnippet, 0, "", "snip:2:3: expected '}', found 'EOF'"},
+	} {
+		fset := token.NewFileSet()
+		info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+		conf := Config{Error: func(error) {}}
+		s, err := CheckSnippet(&conf, fset, "snip", test.src, test.imports, &info)
+		var gotErr string
+		if err != nil {
+			gotErr = err.Error()
+		}
+		if gotErr != test.err {
+			t.Errorf("%q: got error %q, want %q", test.src, gotErr, test.err)
+		}
+		if s == nil {
+			continue
+		}
+		if s.Kind != test.kind {
+			t.Errorf("%q: got kind %d, want %d", test.src, s.Kind, test.kind)
+			continue
+		}
+		switch s.Kind {
+		case ExprSnippet:
+			if test.typ == "" {
+				break
+			}
+			if got := info.Types[s.Expr].Type.String(); got != test.typ {
+				t.Errorf("%q: got type %s, want %s", test.src, got, test.typ)
+			}
+			if pos := fset.Position(s.Expr.Pos()); pos.Line != 1 || pos.Column != 1 {
+				t.Errorf("%q: expression at %s, want snip:1:1", test.src, pos)
+			}
+		case StmtSnippet:
+			if len(s.Stmts) != test.n {
+				t.Errorf("%q: got %d statements, want %d", test.src, len(s.Stmts), test.n)
+			}
+		case DeclSnippet:
+			if len(s.Decls) != test.n {
+				t.Errorf("%q: got %d declarations, want %d", test.src, len(s.Decls), test.n)
+			}
+		}
+	}
+}
@@ -36,6 +36,9 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 	}
 	check.indent = 0
 
+	// The function scope extends over the body as well as the signature.
+	sig.scope.end = body.End()
+
 	check.stmtList(0, body.List)
 
 	if check.hasLabel {
@@ -50,6 +53,15 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 	// declare a variable inside a function body if the variable is never used."
 	// (One could check each scope after use, but that distributes this check
 	// over several places because CloseScope is not always called explicitly.)
+	if body == check.bindings {
+		// The top-level variables of a Session input may be used by
+		// later inputs.
+		for _, obj := range sig.scope.elems {
+			if v, _ := obj.(*Var); v != nil {
+				v.used = true
+			}
+		}
+	}
 	check.usage(sig.scope)
 }
 
@@ -119,7 +131,7 @@ func (check *Checker) multipleDefaults(list []ast.Stmt) {
 }
 
 func (check *Checker) openScope(s ast.Stmt, comment string) {
-	scope := NewScope(check.scope, comment)
+	scope := NewScope(check.scope, s.Pos(), s.End(), comment)
 	check.recordScope(s, scope)
 	check.scope = scope
 }
@@ -162,11 +174,11 @@ func (check *Checker) caseValues(x operand /* copy argument (not *operand!) */,
 		}
 		// TODO(gri) The convertUntyped call pair below appears in other places. Factor!
 		// Order matters: By comparing y against x, error positions are at the case values.
-		check.convertUntyped(&y, x.typ)
+		check.convertUntypedIn(&y, x.typ, UntypedContext{Kind: OperandContext, Expr: x.expr})
 		if y.mode == invalid {
 			return
 		}
-		check.convertUntyped(&x, y.typ)
+		check.convertUntypedIn(&x, y.typ, UntypedContext{Kind: OperandContext, Expr: y.expr})
 		if x.mode == invalid {
 			return
 		}
@@ -218,7 +230,11 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 	inner := ctxt &^ fallthroughOk
 	switch s := s.(type) {
-	case *ast.BadStmt, *ast.EmptyStmt:
+	case *ast.BadStmt:
+		// ignore - error reported before
+		check.recordSkipped(s)
+
+	case *ast.EmptyStmt:
 		// ignore
 
 	case *ast.DeclStmt:
@@ -240,6 +256,10 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			if kind == statement {
 				return
 			}
+			if s.X == check.snippetExpr && x.mode != novalue {
+				check.assignment(&x, nil) // may be unused; use default type
+				return
+			}
 			msg = "is not used"
 		case builtin:
 			msg = "must be called"
@@ -373,7 +393,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		check.stmtList(inner, s.List)
 
 	case *ast.IfStmt:
-		check.openScope(s, "if")
+		check.openScope(s, "if statement")
 		defer check.closeScope()
 
 		check.simpleStmt(s.Init)
@@ -389,7 +409,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 	case *ast.SwitchStmt:
 		inner |= breakOk
-		check.openScope(s, "switch")
+		check.openScope(s, "switch statement")
 		defer check.closeScope()
 
 		check.simpleStmt(s.Init)
@@ -416,7 +436,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			if x.mode != invalid {
 				check.caseValues(x, clause.List)
 			}
-			check.openScope(clause, "case")
+			check.openScope(clause, "case clause")
 			inner := inner
 			if i+1 < len(s.Body.List) {
 				inner |= fallthroughOk
@@ -427,7 +447,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 	case *ast.TypeSwitchStmt:
 		inner |= breakOk
-		check.openScope(s, "type switch")
+		check.openScope(s, "type switch statement")
 		defer check.closeScope()
 
 		check.simpleStmt(s.Init)
@@ -501,7 +521,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 			// Check each type in this type switch case.
 			T := check.caseTypes(&x, xtyp, clause.List, seen)
-			check.openScope(clause, "case")
+			check.openScope(clause, "case clause")
 			// If lhs exists, declare a corresponding variable in the case-local scope.
 			if lhs != nil {
 				// spec: "The TypeSwitchGuard may include a short variable declaration.
@@ -512,7 +532,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				if len(clause.List) != 1 || T == nil {
 					T = x.typ
 				}
-				obj := NewVar(lhs.Pos(), check.pkg, lhs.Name, T)
+				obj := NewVar(lhs.Pos(), check.pkg, check.intern(lhs.Name), T)
 				check.declare(check.scope, nil, obj)
 				check.recordImplicit(clause, obj)
 				// For the "declared but not used" error, all lhs variables act as
@@ -575,7 +595,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				continue
 			}
 
-			check.openScope(s, "case")
+			check.openScope(s, "select case")
 			if clause.Comm != nil {
 				check.stmt(inner, clause.Comm)
 			}
@@ -585,7 +605,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 	case *ast.ForStmt:
 		inner |= breakOk | continueOk
-		check.openScope(s, "for")
+		check.openScope(s, "for statement")
 		defer check.closeScope()
 
 		check.simpleStmt(s.Init)
@@ -607,7 +627,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 
 	case *ast.RangeStmt:
 		inner |= breakOk | continueOk
-		check.openScope(s, "for")
+		check.openScope(s, "for statement")
 		defer check.closeScope()
 
 		// check expression to iterate over
@@ -651,7 +671,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 		}
 
-		if key == nil {
+		if key == nil && x.mode != invalid {
 			check.errorf(x.pos(), "cannot range over %s", &x)
 			// ok to continue
 		}
@@ -678,11 +698,14 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				if ident, _ := lhs.(*ast.Ident); ident != nil {
 					// declare new variable
 					name := ident.Name
-					obj = NewVar(ident.Pos(), check.pkg, name, nil)
+					obj = NewVar(ident.Pos(), check.pkg, check.intern(name), nil)
 					check.recordDef(ident, obj)
 					// _ variables don't count as new variables
 					if name != "_" {
 						vars = append(vars, obj)
+						check.recordWrite(lhs, DefineWrite)
+					} else {
+						check.recordWrite(lhs, BlankWrite)
 					}
 				} else {
 					check.errorf(lhs.Pos(), "cannot declare %s", lhs)
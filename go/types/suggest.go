@@ -0,0 +1,230 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements "did you mean" suggestions for undeclared names.
+
+package types
+
+import "sort"
+
+// maxSuggestions is the maximum number of suggestions reported per error.
+const maxSuggestions = 3
+
+// suggestNames returns suggestions for the undeclared name, taken from
+// the objects visible in the current scope and the exported members of
+// the packages imported by the current file or otherwise loaded by the
+// importer (the latter qualified with the package name).
+func (check *Checker) suggestNames(name string) []string {
+	// Candidates from function-local scopes are collected each time.
+	var candidates []string
+	s := check.scope
+	for s != nil && s != Universe && s != check.pkg.scope && s.parent != check.pkg.scope {
+		for cand := range s.elems {
+			candidates = append(candidates, cand)
+		}
+		s = s.parent
+	}
+
+	// s is a file scope, the package scope, or the universe. Their
+	// contents don't change while expressions are checked, so their
+	// candidates are computed only once per scope and name.
+	if s != nil {
+		key := suggestKey{s, name}
+		global, found := check.suggested[key]
+		if !found {
+			global = append(globalCandidates(s, name), check.loadedCandidates(s, name)...)
+			if check.suggested == nil {
+				check.suggested = make(map[suggestKey][]string)
+			}
+			check.suggested[key] = global
+		}
+		candidates = append(candidates, global...)
+	}
+
+	return suggest(name, candidates)
+}
+
+// A suggestKey identifies a name and the scope in which the
+// search for its suggestion candidates started.
+type suggestKey struct {
+	scope *Scope
+	name  string
+}
+
+// globalCandidates returns the names in scope s and its parents that
+// are near name, including the exported members of packages imported
+// into these scopes.
+func globalCandidates(s *Scope, name string) []string {
+	var candidates []string
+	for ; s != nil; s = s.parent {
+		for cand, obj := range s.elems {
+			if near(name, cand) {
+				candidates = append(candidates, cand)
+			}
+			if pkg, _ := obj.(*PkgName); pkg != nil {
+				for _, m := range exportedNames(pkg.imported.scope) {
+					if near(name, m) {
+						candidates = append(candidates, cand+"."+m)
+					}
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// loadedCandidates returns the exported members, near name, of the
+// packages loaded by the importer (see Config.Packages) that are not
+// imported into scope s or its parents, qualified with the package
+// name.  Using one of them requires a new import.
+func (check *Checker) loadedCandidates(s *Scope, name string) []string {
+	imported := make(map[*Package]bool)
+	for ; s != nil; s = s.parent {
+		for _, obj := range s.elems {
+			if pkg, _ := obj.(*PkgName); pkg != nil {
+				imported[pkg.imported] = true
+			}
+		}
+	}
+	var candidates []string
+	for _, pkg := range check.conf.Packages {
+		if pkg == check.pkg || imported[pkg] {
+			continue
+		}
+		for _, m := range exportedNames(pkg.scope) {
+			if near(name, m) {
+				candidates = append(candidates, pkg.name+"."+m)
+			}
+		}
+	}
+	return candidates
+}
+
+// exportedNames returns the names of the exported objects in scope s.
+func exportedNames(s *Scope) []string {
+	var names []string
+	for name, obj := range s.elems {
+		if obj.Exported() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// suggest returns the candidates closest to name, closest first.
+// A qualified candidate p.x is compared by its unqualified name x.
+func suggest(name string, candidates []string) []string {
+	var list []suggestion
+	seen := make(map[string]bool)
+	for _, cand := range candidates {
+		if seen[cand] || cand == "_" {
+			continue
+		}
+		seen[cand] = true
+		if u := unqualified(cand); near(name, u) {
+			list = append(list, suggestion{cand, distance(name, u)})
+		}
+	}
+	sort.Sort(byDistance(list))
+	if len(list) > maxSuggestions {
+		list = list[:maxSuggestions]
+	}
+	var res []string
+	for _, s := range list {
+		res = append(res, s.name)
+	}
+	return res
+}
+
+func unqualified(name string) string {
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '.' {
+			return name[i+1:]
+		}
+	}
+	return name
+}
+
+// near reports whether cand is a plausible suggestion for name.
+func near(name, cand string) bool {
+	t := threshold(name)
+	if d := len(name) - len(cand); d > t || -d > t {
+		return false // too many insertions or deletions
+	}
+	return distance(name, cand) <= t
+}
+
+// threshold returns the maximum edit distance for a suggestion for name.
+func threshold(name string) int {
+	if t := len(name) / 3; t > 1 {
+		return t
+	}
+	return 1
+}
+
+// distance returns the edit distance between a and b, comparing bytes
+// and counting insertions, deletions, substitutions, and transpositions
+// of adjacent bytes as one edit each. A difference in case only counts
+// as half an edit, rounded down, so that e.g. println and Println have
+// distance 0. (All costs are doubled internally.)
+func distance(a, b string) int {
+	prev2 := make([]int, len(b)+1) // row i-2
+	prev := make([]int, len(b)+1)  // row i-1
+	curr := make([]int, len(b)+1)  // row i
+	for j := range prev {
+		prev[j] = 2 * j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = 2 * i
+		for j := 1; j <= len(b); j++ {
+			cost := 0
+			if x, y := a[i-1], b[j-1]; x != y {
+				cost = 2
+				if lower(x) == lower(y) {
+					cost = 1
+				}
+			}
+			d := min3(prev[j]+2, curr[j-1]+2, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && prev2[j-2]+2 < d {
+				d = prev2[j-2] + 2
+			}
+			curr[j] = d
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)] / 2
+}
+
+func lower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+type suggestion struct {
+	name string
+	dist int
+}
+
+type byDistance []suggestion
+
+func (s byDistance) Len() int      { return len(s) }
+func (s byDistance) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byDistance) Less(i, j int) bool {
+	if s[i].dist != s[j].dist {
+		return s[i].dist < s[j].dist
+	}
+	return s[i].name < s[j].name
+}
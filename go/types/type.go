@@ -119,11 +119,11 @@ func NewSlice(elem Type) *Slice { return &Slice{elem} }
 func (s *Slice) Elem() Type { return s.elem }
 
 // A Struct represents a struct type.
+// Its fields are in the order given to NewStruct, which, for a struct
+// type checked from source, is the order of their declaration.
 type Struct struct {
 	fields []*Var
 	tags   []string // field tags; nil if there are no tags
-	// TODO(gri) access to offsets is not threadsafe - fix this
-	offsets []int64 // field offsets in bytes, lazily initialized
 }
 
 // NewStruct returns a new struct with the given fields and corresponding field tags.
@@ -146,7 +146,8 @@ func NewStruct(fields []*Var, tags []string) *Struct {
 // NumFields returns the number of fields in the struct (including blank and anonymous fields).
 func (s *Struct) NumFields() int { return len(s.fields) }
 
-// Field returns the i'th field for 0 <= i < NumFields().
+// Field returns the i'th field for 0 <= i < NumFields(),
+// in source order.
 func (s *Struct) Field(i int) *Var { return s.fields[i] }
 
 // Tag returns the i'th field tag for 0 <= i < NumFields().
@@ -219,7 +220,26 @@ func NewSignature(scope *Scope, recv *Var, params, results *Tuple, variadic bool
 			panic("types.NewSignature: variadic parameter must be of unnamed slice type")
 		}
 	}
-	return &Signature{scope, recv, params, results, variadic}
+	sig := &Signature{scope, recv, params, results, variadic}
+	sig.markParams()
+	return sig
+}
+
+// markParams marks the receiver, parameters, and results of s as such.
+func (s *Signature) markParams() {
+	if s.recv != nil {
+		s.recv.isParam = true
+	}
+	if s.params != nil {
+		for _, v := range s.params.vars {
+			v.isParam = true
+		}
+	}
+	if s.results != nil {
+		for _, v := range s.results.vars {
+			v.isResult = true
+		}
+	}
 }
 
 // Recv returns the receiver of signature s (if a method), or nil if a
@@ -263,7 +283,7 @@ func NewInterface(methods []*Func, embeddeds []*Named) *Interface {
 	}
 	sort.Sort(byUniqueMethodName(methods))
 
-	if embeddeds == nil {
+	if embeddeds != nil {
 		sort.Sort(byUniqueTypeName(embeddeds))
 	}
 
@@ -403,8 +423,22 @@ func (t *Named) Obj() *TypeName { return t.obj }
 func (t *Named) NumMethods() int { return len(t.methods) }
 
 // Method returns the i'th method of named type t for 0 <= i < t.NumMethods().
+// The methods are in the order in which they were added: for a type
+// checked from source, the order of their declaration, with the files
+// in the order given to the checker. Use SortedMethods for an order
+// independent of the source.
 func (t *Named) Method(i int) *Func { return t.methods[i] }
 
+// SortedMethods returns a new slice of the explicit methods whose
+// receiver is named type t, ordered by their unique Id, as are the
+// methods of interfaces and method sets.
+func (t *Named) SortedMethods() []*Func {
+	methods := make([]*Func, len(t.methods))
+	copy(methods, t.methods)
+	sort.Sort(byUniqueMethodName(methods))
+	return methods
+}
+
 // SetUnderlying sets the underlying type and marks t as complete.
 // TODO(gri) determine if there's a better solution rather than providing this function
 func (t *Named) SetUnderlying(underlying Type) {
@@ -40,10 +40,78 @@ func TypeString(this *Package, typ Type) string {
 // Named types are printed package-qualified if they
 // do not belong to this package.
 func WriteType(buf *bytes.Buffer, this *Package, typ Type) {
-	writeType(buf, this, typ, make([]Type, 8))
+	newTypeWriter(buf, this).typ(typ, make([]Type, 8))
 }
 
-func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
+// A TypeFormat specifies a representation of types, for clients such
+// as error messages and hover text that need more or less detail than
+// that of TypeString.  The zero TypeFormat specifies the
+// representation of TypeString.
+type TypeFormat struct {
+	// Named types are printed package-qualified if they do not
+	// belong to This.
+	This *Package
+
+	// Expand is the number of levels of named types that are
+	// printed with their underlying type, as in T(struct{x int}): 1
+	// expands the named types of the printed type, but not those of
+	// their underlying types.
+	Expand int
+
+	// OmitTags causes struct tags to be omitted.
+	OmitTags bool
+
+	// MethodSets causes a named type, or pointer to one, to be
+	// followed by its methods and those of the pointer to it, if
+	// any, one per line, as in "func (*T) M()", in order of Id.
+	MethodSets bool
+
+	// Width, if positive, is the width beyond which the parameters
+	// and results of a function type are printed one per line.
+	Width int
+}
+
+// String returns the representation of typ specified by f.
+func (f *TypeFormat) String(typ Type) string {
+	var buf bytes.Buffer
+	f.Write(&buf, typ)
+	return buf.String()
+}
+
+// Write writes the representation of typ specified by f to buf.
+func (f *TypeFormat) Write(buf *bytes.Buffer, typ Type) {
+	w := newTypeWriter(buf, f.This)
+	w.expand = f.Expand
+	w.omitTags = f.OmitTags
+	if sig, ok := typ.(*Signature); ok && f.Width > 0 {
+		var line bytes.Buffer
+		w1 := *w
+		w1.buf = &line
+		w1.signature(sig, make([]Type, 8))
+		w.wrap = len("func")+line.Len() > f.Width
+	}
+	w.typ(typ, make([]Type, 8))
+	if f.MethodSets {
+		w.methodSets(typ)
+	}
+}
+
+// A typeWriter writes the representations of types.
+type typeWriter struct {
+	buf       *bytes.Buffer
+	this      *Package
+	expand    int      // levels of named types still to expand
+	expanding []*Named // the named types being expanded
+	omitTags  bool
+	wrap      bool // wrap the parameters and results of the next signature
+}
+
+func newTypeWriter(buf *bytes.Buffer, this *Package) *typeWriter {
+	return &typeWriter{buf: buf, this: this}
+}
+
+func (w *typeWriter) typ(typ Type, visited []Type) {
+	buf, this := w.buf, w.this
 	// Theoretically, this is a quadratic lookup algorithm, but in
 	// practice deeply nested composite types with unnamed component
 	// types are uncommon. This code is likely more efficient than
@@ -54,7 +122,9 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 			return
 		}
 	}
-	visited = append(visited, typ)
+	if _, ok := typ.(*Named); !ok {
+		visited = append(visited, typ) // named types break cycles
+	}
 
 	switch t := typ.(type) {
 	case nil:
@@ -77,11 +147,11 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 
 	case *Array:
 		fmt.Fprintf(buf, "[%d]", t.len)
-		writeType(buf, this, t.elem, visited)
+		w.typ(t.elem, visited)
 
 	case *Slice:
 		buf.WriteString("[]")
-		writeType(buf, this, t.elem, visited)
+		w.typ(t.elem, visited)
 
 	case *Struct:
 		buf.WriteString("struct{")
@@ -93,8 +163,8 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 				buf.WriteString(f.name)
 				buf.WriteByte(' ')
 			}
-			writeType(buf, this, f.typ, visited)
-			if tag := t.Tag(i); tag != "" {
+			w.typ(f.typ, visited)
+			if tag := t.Tag(i); tag != "" && !w.omitTags {
 				fmt.Fprintf(buf, " %q", tag)
 			}
 		}
@@ -102,14 +172,14 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 
 	case *Pointer:
 		buf.WriteByte('*')
-		writeType(buf, this, t.base, visited)
+		w.typ(t.base, visited)
 
 	case *Tuple:
-		writeTuple(buf, this, t, false, visited)
+		w.tuple(t, false, false, visited)
 
 	case *Signature:
 		buf.WriteString("func")
-		writeSignature(buf, this, t, visited)
+		w.signature(t, visited)
 
 	case *Interface:
 		// We write the source-level methods and embedded types rather
@@ -132,7 +202,7 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 					buf.WriteString("; ")
 				}
 				buf.WriteString(m.name)
-				writeSignature(buf, this, m.typ.(*Signature), visited)
+				w.signature(m.typ.(*Signature), visited)
 			}
 		} else {
 			// print explicit interface methods and embedded types
@@ -141,22 +211,22 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 					buf.WriteString("; ")
 				}
 				buf.WriteString(m.name)
-				writeSignature(buf, this, m.typ.(*Signature), visited)
+				w.signature(m.typ.(*Signature), visited)
 			}
 			for i, typ := range t.embeddeds {
 				if i > 0 || len(t.methods) > 0 {
 					buf.WriteString("; ")
 				}
-				writeType(buf, this, typ, visited)
+				w.typ(typ, visited)
 			}
 		}
 		buf.WriteByte('}')
 
 	case *Map:
 		buf.WriteString("map[")
-		writeType(buf, this, t.key, visited)
+		w.typ(t.key, visited)
 		buf.WriteByte(']')
-		writeType(buf, this, t.elem, visited)
+		w.typ(t.elem, visited)
 
 	case *Chan:
 		var s string
@@ -179,7 +249,7 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 		if parens {
 			buf.WriteByte('(')
 		}
-		writeType(buf, this, t.elem, visited)
+		w.typ(t.elem, visited)
 		if parens {
 			buf.WriteByte(')')
 		}
@@ -197,6 +267,15 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 			s = obj.name
 		}
 		buf.WriteString(s)
+		if w.expand > 0 && t.underlying != nil && !w.isExpanding(t) {
+			w.expand--
+			w.expanding = append(w.expanding, t)
+			buf.WriteByte('(')
+			w.typ(t.underlying, visited)
+			buf.WriteByte(')')
+			w.expanding = w.expanding[:len(w.expanding)-1]
+			w.expand++
+		}
 
 	default:
 		// For externally defined implementations of Type.
@@ -204,11 +283,25 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 	}
 }
 
-func writeTuple(buf *bytes.Buffer, this *Package, tup *Tuple, variadic bool, visited []Type) {
+func (w *typeWriter) isExpanding(t *Named) bool {
+	for _, x := range w.expanding {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+// tuple writes the tuple, with one element per line if wrap is set.
+func (w *typeWriter) tuple(tup *Tuple, variadic, wrap bool, visited []Type) {
+	buf := w.buf
+	wrap = wrap && tup.Len() > 0
 	buf.WriteByte('(')
 	if tup != nil {
 		for i, v := range tup.vars {
-			if i > 0 {
+			if wrap {
+				buf.WriteString("\n\t")
+			} else if i > 0 {
 				buf.WriteString(", ")
 			}
 			if v.name != "" {
@@ -226,14 +319,23 @@ func writeTuple(buf *bytes.Buffer, this *Package, tup *Tuple, variadic bool, vis
 					if t, ok := typ.Underlying().(*Basic); !ok || t.kind != String {
 						panic("internal error: string type expected")
 					}
-					writeType(buf, this, typ, visited)
+					w.typ(typ, visited)
 					buf.WriteString("...")
+					if wrap {
+						buf.WriteByte(',')
+					}
 					continue
 				}
 			}
-			writeType(buf, this, typ, visited)
+			w.typ(typ, visited)
+			if wrap {
+				buf.WriteByte(',')
+			}
 		}
 	}
+	if wrap {
+		buf.WriteByte('\n')
+	}
 	buf.WriteByte(')')
 }
 
@@ -242,11 +344,13 @@ func writeTuple(buf *bytes.Buffer, this *Package, tup *Tuple, variadic bool, vis
 // Named types are printed package-qualified if they
 // do not belong to this package.
 func WriteSignature(buf *bytes.Buffer, this *Package, sig *Signature) {
-	writeSignature(buf, this, sig, make([]Type, 8))
+	newTypeWriter(buf, this).signature(sig, make([]Type, 8))
 }
 
-func writeSignature(buf *bytes.Buffer, this *Package, sig *Signature, visited []Type) {
-	writeTuple(buf, this, sig.params, sig.variadic, visited)
+func (w *typeWriter) signature(sig *Signature, visited []Type) {
+	wrap := w.wrap
+	w.wrap = false // only the outermost signature is wrapped
+	w.tuple(sig.params, sig.variadic, wrap, visited)
 
 	n := sig.results.Len()
 	if n == 0 {
@@ -254,13 +358,46 @@ func writeSignature(buf *bytes.Buffer, this *Package, sig *Signature, visited []
 		return
 	}
 
-	buf.WriteByte(' ')
+	w.buf.WriteByte(' ')
 	if n == 1 && sig.results.vars[0].name == "" {
 		// single unnamed result
-		writeType(buf, this, sig.results.vars[0].typ, visited)
+		w.typ(sig.results.vars[0].typ, visited)
 		return
 	}
 
 	// multiple or named result(s)
-	writeTuple(buf, this, sig.results, false, visited)
+	w.tuple(sig.results, false, wrap, visited)
+}
+
+// methodSets writes the methods of the named type typ, or of the
+// named type to which typ points, if any, and those of the pointer
+// to it.
+func (w *typeWriter) methodSets(typ Type) {
+	if ptr, ok := typ.(*Pointer); ok {
+		typ = ptr.base
+	}
+	named, ok := typ.(*Named)
+	if !ok {
+		return
+	}
+	w.expand = 0
+	write := func(T Type, exclude *MethodSet) {
+		mset := NewMethodSet(T)
+		for i := 0; i < mset.Len(); i++ {
+			m := mset.At(i).Obj()
+			if exclude != nil && exclude.Lookup(m.Pkg(), m.Name()) != nil {
+				continue
+			}
+			w.buf.WriteString("\nfunc (")
+			w.typ(T, make([]Type, 8))
+			w.buf.WriteString(") ")
+			w.buf.WriteString(m.Name())
+			w.signature(m.Type().(*Signature), make([]Type, 8))
+		}
+	}
+	mset := NewMethodSet(named)
+	write(named, nil)
+	if _, ok := named.underlying.(*Interface); !ok {
+		write(NewPointer(named), mset)
+	}
 }
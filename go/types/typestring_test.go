@@ -158,3 +158,41 @@ func TestQualifiedTypeString(t *testing.T) {
 		}
 	}
 }
+
+func TestTypeFormat(t *testing.T) {
+	p, err := pkgFor("p.go", `package p
+type Point struct{ X, Y int `+"`json:\"-\"`"+` }
+type Path []Point
+type List struct {
+	next *List
+	Path Path
+}
+func (Point) Len() float64 { return 0 }
+func (*Point) Scale(f float64) {}
+type Handler func(name string, points []Point, verbose bool) (int, error)
+`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(name string) Type { return p.Scope().Lookup(name).Type() }
+	for _, test := range []struct {
+		format TypeFormat
+		typ    Type
+		want   string
+	}{
+		{TypeFormat{}, lookup("Path"), "p.Path"},
+		{TypeFormat{This: p}, lookup("Path"), "Path"},
+		{TypeFormat{This: p, Expand: 1}, lookup("Path"), "Path([]Point)"},
+		{TypeFormat{This: p, Expand: 2}, lookup("Path"), "Path([]Point(struct{X int \"json:\\\"-\\\"\"; Y int \"json:\\\"-\\\"\"}))"},
+		{TypeFormat{This: p, Expand: 2, OmitTags: true}, lookup("Path"), "Path([]Point(struct{X int; Y int}))"},
+		{TypeFormat{This: p, Expand: 5}, lookup("List"), "List(struct{next *List; Path Path([]Point(struct{X int \"json:\\\"-\\\"\"; Y int \"json:\\\"-\\\"\"}))})"},
+		{TypeFormat{This: p, MethodSets: true}, NewPointer(lookup("Point")), "*Point\nfunc (Point) Len() float64\nfunc (*Point) Scale(f float64)"},
+		{TypeFormat{This: p, MethodSets: true}, lookup("Path"), "Path"},
+		{TypeFormat{This: p, Width: 80}, lookup("Handler").Underlying(), "func(name string, points []Point, verbose bool) (int, error)"},
+		{TypeFormat{This: p, Width: 40}, lookup("Handler").Underlying(), "func(\n\tname string,\n\tpoints []Point,\n\tverbose bool,\n) (\n\tint,\n\terror,\n)"},
+	} {
+		if got := test.format.String(test.typ); got != test.want {
+			t.Errorf("%+v.String(%s) = %q, want %q", test.format, test.typ, got, test.want)
+		}
+	}
+}
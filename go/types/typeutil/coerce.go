@@ -0,0 +1,177 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines Coerce, which inserts the conversions needed to
+// use a value as a value of another type.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A CoerceError reports that a value of type V cannot be made
+// assignable to type T by any of the wrappings Coerce considers.
+type CoerceError struct {
+	V, T   types.Type
+	Reason *types.Reason // why V is not convertible to T
+}
+
+func (e *CoerceError) Error() string {
+	return fmt.Sprintf("cannot use value of type %s as %s", e.V, e.T)
+}
+
+// Coerce returns the expression that results from wrapping the
+// expression x, of type V, as little as needed to make it assignable
+// to a variable of type T, for refactoring and code generation tools.
+// It returns, in order of preference:
+//
+//	x	if V is assignable to T;
+//	*x	if V is a pointer whose element type is assignable to T;
+//	&x	if *V is assignable to T, in which case x must be addressable;
+//	T(x)	if V is convertible to T.
+//
+// Type expressions in the result refer to named types of other
+// packages than pkg by their package names, whose imports the caller
+// must ensure.  Otherwise, Coerce returns a *CoerceError explaining
+// why V is not even convertible to T.  x is not modified, but it may
+// be shared by the result.
+//
+func Coerce(pkg *types.Package, x ast.Expr, V, T types.Type) (ast.Expr, error) {
+	if types.AssignableTo(V, T) {
+		return x, nil
+	}
+	if ptr, ok := V.Underlying().(*types.Pointer); ok && types.AssignableTo(ptr.Elem(), T) {
+		return &ast.StarExpr{X: operand(x)}, nil
+	}
+	if types.AssignableTo(types.NewPointer(V), T) {
+		return &ast.UnaryExpr{Op: token.AND, X: operand(x)}, nil
+	}
+	if types.ConvertibleTo(V, T) {
+		fun, err := TypeExpr(pkg, T)
+		if err != nil {
+			return nil, err
+		}
+		switch t := fun.(type) {
+		case *ast.StarExpr, *ast.FuncType:
+			// (*T)(x), not *T(x)
+			fun = &ast.ParenExpr{X: fun}
+		case *ast.ChanType:
+			if t.Dir == ast.RECV {
+				// (<-chan T)(x), not <-chan T(x)
+				fun = &ast.ParenExpr{X: fun}
+			}
+		}
+		return &ast.CallExpr{Fun: fun, Args: []ast.Expr{x}}, nil
+	}
+	return nil, &CoerceError{V, T, types.ExplainConvertible(V, T)}
+}
+
+// operand returns x, parenthesized if it is not a primary expression
+// and so cannot be the operand of a unary expression as is.
+func operand(x ast.Expr) ast.Expr {
+	switch x.(type) {
+	case *ast.Ident, *ast.BasicLit, *ast.CompositeLit, *ast.FuncLit,
+		*ast.ParenExpr, *ast.SelectorExpr, *ast.IndexExpr, *ast.SliceExpr,
+		*ast.TypeAssertExpr, *ast.CallExpr:
+		return x
+	}
+	return &ast.ParenExpr{X: x}
+}
+
+// TypeExpr returns a type expression denoting T within package pkg.
+// Named types of other packages than pkg are qualified by their
+// package names, except within struct, function, and interface types,
+// which are written as by types.TypeString and so refer to them by
+// their package paths.  It returns an error for types that cannot be
+// written, such as those of untyped values, and for struct, function,
+// and interface types that refer to packages whose paths are not
+// identifiers.
+//
+func TypeExpr(pkg *types.Package, T types.Type) (ast.Expr, error) {
+	switch T := T.(type) {
+	case *types.Basic:
+		if T.Info()&types.IsUntyped != 0 || T.Kind() == types.Invalid {
+			break
+		}
+		if T.Kind() == types.UnsafePointer {
+			return &ast.SelectorExpr{X: ast.NewIdent("unsafe"), Sel: ast.NewIdent("Pointer")}, nil
+		}
+		return ast.NewIdent(T.Name()), nil
+
+	case *types.Named:
+		obj := T.Obj()
+		if obj.Pkg() == nil || obj.Pkg() == pkg {
+			return ast.NewIdent(obj.Name()), nil
+		}
+		return &ast.SelectorExpr{X: ast.NewIdent(obj.Pkg().Name()), Sel: ast.NewIdent(obj.Name())}, nil
+
+	case *types.Pointer:
+		elem, err := TypeExpr(pkg, T.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &ast.StarExpr{X: elem}, nil
+
+	case *types.Slice:
+		elem, err := TypeExpr(pkg, T.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &ast.ArrayType{Elt: elem}, nil
+
+	case *types.Array:
+		elem, err := TypeExpr(pkg, T.Elem())
+		if err != nil {
+			return nil, err
+		}
+		n := &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(T.Len(), 10)}
+		return &ast.ArrayType{Len: n, Elt: elem}, nil
+
+	case *types.Map:
+		key, err := TypeExpr(pkg, T.Key())
+		if err != nil {
+			return nil, err
+		}
+		elem, err := TypeExpr(pkg, T.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &ast.MapType{Key: key, Value: elem}, nil
+
+	case *types.Chan:
+		elem, err := TypeExpr(pkg, T.Elem())
+		if err != nil {
+			return nil, err
+		}
+		var dir ast.ChanDir
+		switch T.Dir() {
+		case types.SendRecv:
+			dir = ast.SEND | ast.RECV
+		case types.SendOnly:
+			dir = ast.SEND
+		case types.RecvOnly:
+			dir = ast.RECV
+		}
+		if c, ok := elem.(*ast.ChanType); ok && dir == ast.SEND|ast.RECV && c.Dir == ast.RECV {
+			// chan (<-chan T), not chan <-chan T
+			elem = &ast.ParenExpr{X: elem}
+		}
+		return &ast.ChanType{Dir: dir, Value: elem}, nil
+
+	case *types.Struct, *types.Signature, *types.Interface:
+		// Parse the type string, which is the syntax of the type if
+		// it refers to no named types of other packages.
+		if e, err := parser.ParseExpr(types.TypeString(pkg, T)); err == nil {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot write type %s", T)
+}
@@ -0,0 +1,94 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestCoerce(t *testing.T) {
+	const src = `package p
+
+type T int
+
+type S struct{ f int }
+
+type U S
+
+var (
+	t   T
+	i   int
+	p   *int
+	s   S
+	str string
+	c   chan int
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeOf := func(name string) types.Type {
+		return pkg.Scope().Lookup(name).Type()
+	}
+	intT := types.Typ[types.Int]
+	recv := types.NewChan(types.RecvOnly, intT)
+	byteSlice := types.NewSlice(types.Universe.Lookup("byte").Type())
+
+	for _, test := range []struct {
+		x    string
+		V, T types.Type
+		want string // or "error"
+	}{
+		{"i", intT, intT, "i"},
+		{"t", typeOf("t"), intT, "int(t)"},
+		{"i + 1", intT, typeOf("T"), "T(i + 1)"},
+		{"p", typeOf("p"), intT, "*p"},
+		{"s", typeOf("s"), types.NewPointer(typeOf("S")), "&s"},
+		{"c", typeOf("c"), recv, "c"},
+		{"t", typeOf("t"), types.NewPointer(intT), "error"},
+		{"p", types.NewPointer(typeOf("T")), types.NewPointer(intT), "(*int)(p)"},
+		{"b", byteSlice, typeOf("str"), "string(b)"},
+		{"str", typeOf("str"), byteSlice, "[]byte(str)"},
+		{"s", typeOf("s"), typeOf("s").Underlying(), "s"},
+		{"s", typeOf("s"), typeOf("U"), "U(s)"},
+		{"c", typeOf("c"), types.NewChan(types.SendRecv, recv), "error"},
+		{"<-c", recv.Elem(), typeOf("T"), "T(<-c)"},
+		{"str", typeOf("str"), types.NewPointer(typeOf("U").Underlying()), "error"},
+	} {
+		x, err := parser.ParseExpr(test.x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := typeutil.Coerce(pkg, x, test.V, test.T)
+		var got string
+		if err != nil {
+			if _, ok := err.(*typeutil.CoerceError); !ok {
+				t.Errorf("Coerce(%s, %s): unexpected error %v", test.V, test.T, err)
+			}
+			got = "error"
+		} else {
+			var buf bytes.Buffer
+			printer.Fprint(&buf, token.NewFileSet(), e)
+			got = buf.String()
+		}
+		if got != test.want {
+			t.Errorf("Coerce(%s, %s, %s) = %s, want %s", test.x, test.V, test.T, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines CompactInfo, a position-indexed encoding of the
+// Defs, Uses, and Types maps of a types.Info.
+
+import (
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A CompactInfo records the identifier definitions and uses and the
+// types of expressions of a types.Info in arrays sorted by source
+// position, rather than in maps keyed by syntax trees.  It is smaller
+// than the maps it encodes and, since it refers to no syntax, it may
+// outlive the trees, which is useful for indexers and other tools
+// that discard syntax after type checking.
+//
+// Positions are interpreted by the FileSet used for type checking,
+// which must be retained.  The ObjectAt and TypeAt methods answer
+// queries by file name and byte offset, so that a long-running tool
+// need retain neither the syntax trees nor the Info maps, which are
+// typically the bulk of its resident memory.
+//
+type CompactInfo struct {
+	Defs  []PosObject // sorted by Pos; Obj may be nil (see types.Info.Defs)
+	Uses  []PosObject // sorted by Pos
+	Types []PosType   // sorted by Pos, then by End
+}
+
+// A PosObject is the object denoted by the identifier at position Pos.
+type PosObject struct {
+	Pos token.Pos
+	Obj types.Object
+}
+
+// A PosType is the type and value of the expression that spans
+// [Pos, End).
+type PosType struct {
+	Pos, End token.Pos
+	types.TypeAndValue
+}
+
+// Compact returns the compact encoding of info.Defs, info.Uses, and
+// info.Types, any of which may be nil.  info is not modified.
+func Compact(info *types.Info) *CompactInfo {
+	c := &CompactInfo{
+		Defs:  make([]PosObject, 0, len(info.Defs)),
+		Uses:  make([]PosObject, 0, len(info.Uses)),
+		Types: make([]PosType, 0, len(info.Types)),
+	}
+	for id, obj := range info.Defs {
+		c.Defs = append(c.Defs, PosObject{id.Pos(), obj})
+	}
+	for id, obj := range info.Uses {
+		c.Uses = append(c.Uses, PosObject{id.Pos(), obj})
+	}
+	for x, tv := range info.Types {
+		c.Types = append(c.Types, PosType{x.Pos(), x.End(), tv})
+	}
+	sort.Sort(byPos(c.Defs))
+	sort.Sort(byPos(c.Uses))
+	sort.Sort(byRange(c.Types))
+	return c
+}
+
+// Def returns the object defined by the identifier at pos, or nil.
+func (c *CompactInfo) Def(pos token.Pos) types.Object {
+	return lookupPos(c.Defs, pos)
+}
+
+// Use returns the object used by the identifier at pos, or nil.
+func (c *CompactInfo) Use(pos token.Pos) types.Object {
+	return lookupPos(c.Uses, pos)
+}
+
+// ObjectOf returns the object defined or used by the identifier at
+// pos, or nil; it is the analogue of types.Info.ObjectOf.
+func (c *CompactInfo) ObjectOf(pos token.Pos) types.Object {
+	if obj := c.Def(pos); obj != nil {
+		return obj
+	}
+	return c.Use(pos)
+}
+
+// TypeOf returns the type and value of the expression that spans
+// exactly [pos, end), and whether there is one.
+func (c *CompactInfo) TypeOf(pos, end token.Pos) (types.TypeAndValue, bool) {
+	i := sort.Search(len(c.Types), func(i int) bool {
+		t := &c.Types[i]
+		return t.Pos > pos || t.Pos == pos && t.End >= end
+	})
+	if i < len(c.Types) && c.Types[i].Pos == pos && c.Types[i].End == end {
+		return c.Types[i].TypeAndValue, true
+	}
+	return types.TypeAndValue{}, false
+}
+
+// ObjectAt returns the object defined or used by the identifier that
+// includes the specified byte offset of the named file, or nil.  It
+// needs no syntax, only the FileSet of the checked files.
+func (c *CompactInfo) ObjectAt(fset *token.FileSet, filename string, offset int) types.Object {
+	pos := posFor(fset, filename, offset)
+	if pos == token.NoPos {
+		return nil
+	}
+	for _, objs := range [][]PosObject{c.Defs, c.Uses} {
+		// The identifier is the last one that starts at or before pos.
+		i := sort.Search(len(objs), func(i int) bool { return objs[i].Pos > pos }) - 1
+		if i >= 0 {
+			if obj := objs[i].Obj; obj != nil && pos < objs[i].Pos+token.Pos(len(obj.Name())) {
+				return obj
+			}
+		}
+	}
+	return nil
+}
+
+// TypeAt returns the extent, type, and value of the innermost
+// expression that includes the specified byte offset of the named
+// file, and whether there is one.  It needs no syntax, only the
+// FileSet of the checked files.
+func (c *CompactInfo) TypeAt(fset *token.FileSet, filename string, offset int) (pos, end token.Pos, tv types.TypeAndValue, ok bool) {
+	p := posFor(fset, filename, offset)
+	if p == token.NoPos {
+		return
+	}
+	// The extents of expressions are nested or disjoint, so the
+	// innermost enclosing one is the first found by scanning
+	// backwards from the last one that starts at or before p,
+	// except that among those that start at the same position,
+	// the shortest is innermost.
+	i := sort.Search(len(c.Types), func(i int) bool { return c.Types[i].Pos > p }) - 1
+	for ; i >= 0; i-- {
+		t := &c.Types[i]
+		if p < t.End {
+			for i > 0 && c.Types[i-1].Pos == t.Pos && p < c.Types[i-1].End {
+				i--
+				t = &c.Types[i]
+			}
+			return t.Pos, t.End, t.TypeAndValue, true
+		}
+	}
+	return
+}
+
+// posFor returns the position of the specified byte offset of the
+// named file, or NoPos if fset has no such file or it has no such
+// offset.
+func posFor(fset *token.FileSet, filename string, offset int) token.Pos {
+	pos := token.NoPos
+	fset.Iterate(func(f *token.File) bool {
+		if f.Name() == filename {
+			if 0 <= offset && offset < f.Size() {
+				pos = f.Pos(offset)
+			}
+			return false
+		}
+		return true
+	})
+	return pos
+}
+
+func lookupPos(objs []PosObject, pos token.Pos) types.Object {
+	i := sort.Search(len(objs), func(i int) bool { return objs[i].Pos >= pos })
+	if i < len(objs) && objs[i].Pos == pos {
+		return objs[i].Obj
+	}
+	return nil
+}
+
+type byPos []PosObject
+
+func (s byPos) Len() int           { return len(s) }
+func (s byPos) Less(i, j int) bool { return s[i].Pos < s[j].Pos }
+func (s byPos) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type byRange []PosType
+
+func (s byRange) Len() int { return len(s) }
+func (s byRange) Less(i, j int) bool {
+	if s[i].Pos != s[j].Pos {
+		return s[i].Pos < s[j].Pos
+	}
+	return s[i].End < s[j].End
+}
+func (s byRange) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,139 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestCompact(t *testing.T) {
+	const src = `package p
+
+type T struct{ F int }
+
+func (t *T) M(x int) (y int) {
+	y = x + t.F
+	switch v := interface{}(y).(type) {
+	case int:
+		return (v)
+	}
+	return 0
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	if _, err := new(types.Config).Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	c := typeutil.Compact(info)
+	if len(c.Defs) != len(info.Defs) || len(c.Uses) != len(info.Uses) || len(c.Types) != len(info.Types) {
+		t.Fatalf("Compact: got %d/%d/%d entries, want %d/%d/%d",
+			len(c.Defs), len(c.Uses), len(c.Types), len(info.Defs), len(info.Uses), len(info.Types))
+	}
+	for id, obj := range info.Defs {
+		if got := c.Def(id.Pos()); got != obj {
+			t.Errorf("Def(%s) = %v, want %v", fset.Position(id.Pos()), got, obj)
+		}
+	}
+	for id, obj := range info.Uses {
+		if got := c.Use(id.Pos()); got != obj {
+			t.Errorf("Use(%s) = %v, want %v", fset.Position(id.Pos()), got, obj)
+		}
+		if got := c.ObjectOf(id.Pos()); got != obj {
+			t.Errorf("ObjectOf(%s) = %v, want %v", fset.Position(id.Pos()), got, obj)
+		}
+	}
+	for x, tv := range info.Types {
+		got, ok := c.TypeOf(x.Pos(), x.End())
+		if !ok || got.Type != tv.Type || got.Value != tv.Value {
+			t.Errorf("TypeOf(%s) = %v, %t, want %v", types.ExprString(x), got.Type, ok, tv.Type)
+		}
+	}
+
+	// There is no identifier at the position of "type", and no
+	// expression spanning the whole declaration.
+	pos := f.Decls[0].Pos()
+	if obj := c.ObjectOf(pos); obj != nil {
+		t.Errorf("ObjectOf(%s) = %v, want nil", fset.Position(pos), obj)
+	}
+	if _, ok := c.TypeOf(pos, f.Decls[0].End()); ok {
+		t.Errorf("TypeOf(first decl) succeeded unexpectedly")
+	}
+}
+
+func TestCompactAt(t *testing.T) {
+	const src = `package p
+
+var total = 1 + len("abc")*2
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	if _, err := new(types.Config).Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+	c := typeutil.Compact(info)
+	f, info = nil, nil // the queries need no syntax
+
+	for _, test := range []struct {
+		substr string // the offset is that of the last byte of the first occurrence of substr
+		obj    string // ObjectAt; "" => nil
+		expr   string // extent of TypeAt; "" => none
+	}{
+		{"package", "", ""},
+		{"tot", "var p.total int", ""},
+		{"total", "var p.total int", ""},
+		{"total ", "", ""},
+		{"1", "", "1"},
+		{"1 +", "", `1 + len("abc")*2`},
+		{"le", "builtin len", "len"},
+		{`"ab`, "", `"abc"`},
+		{`")`, "", `len("abc")`},
+		{"*", "", `len("abc")*2`},
+	} {
+		offset := strings.Index(src, test.substr) + len(test.substr) - 1
+		var obj string
+		if o := c.ObjectAt(fset, "p.go", offset); o != nil {
+			obj = o.String()
+		}
+		if obj != test.obj {
+			t.Errorf("ObjectAt(%q) = %q, want %q", test.substr, obj, test.obj)
+		}
+		var expr string
+		if pos, end, _, ok := c.TypeAt(fset, "p.go", offset); ok {
+			expr = src[fset.Position(pos).Offset:fset.Position(end).Offset]
+		}
+		if expr != test.expr {
+			t.Errorf("TypeAt(%q) = %q, want %q", test.substr, expr, test.expr)
+		}
+	}
+
+	if c.ObjectAt(fset, "q.go", 0) != nil {
+		t.Errorf("ObjectAt in unknown file succeeded unexpectedly")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines TypeHierarchy, the supertypes and subtypes of the
+// named types of a program, for type-hierarchy views.
+
+import (
+	"sync"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A TypeHierarchy relates the package-level named types of a set of
+// packages, such as the packages of a loaded program, and the
+// predeclared error type, by their supertypes and subtypes:
+//
+//  - the supertypes of an interface are the named interfaces it
+//    embeds, and its subtypes are the other named types that
+//    implement it, directly or by pointer;
+//  - the supertypes of a concrete type are the named, non-empty
+//    interfaces that it implements, directly or by pointer, and its
+//    subtypes are the named struct types that embed it, or a pointer
+//    to it.
+//
+// Each relation is computed when first requested, and cached, so that
+// clients may expand the trees of supertypes and subtypes lazily.  The
+// trees may be infinite, for distinct interfaces of the same method
+// set are subtypes of each other; clients should stop expanding at
+// types they have already displayed.
+//
+// A TypeHierarchy is safe for concurrent use.
+//
+type TypeHierarchy struct {
+	named []*types.Named // the types of the hierarchy, in order of package and name
+
+	mu     sync.Mutex
+	supers map[*types.Named][]*types.Named
+	subs   map[*types.Named][]*types.Named
+}
+
+// NewTypeHierarchy returns the hierarchy of the package-level named
+// types of pkgs, whose clients typically pass all the packages of a
+// program.
+func NewTypeHierarchy(pkgs ...*types.Package) *TypeHierarchy {
+	h := &TypeHierarchy{
+		supers: make(map[*types.Named][]*types.Named),
+		subs:   make(map[*types.Named][]*types.Named),
+	}
+	for _, pkg := range pkgs {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			if tname, ok := scope.Lookup(name).(*types.TypeName); ok {
+				if named, ok := tname.Type().(*types.Named); ok {
+					h.named = append(h.named, named)
+				}
+			}
+		}
+	}
+	h.named = append(h.named, types.Universe.Lookup("error").Type().(*types.Named))
+	return h
+}
+
+// Supertypes returns the supertypes of T within the hierarchy, in order
+// of package and name, except those of an interface, which are in the
+// order of types.Interface.Embedded.
+func (h *TypeHierarchy) Supertypes(T *types.Named) []*types.Named {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if supers, ok := h.supers[T]; ok {
+		return supers
+	}
+	var supers []*types.Named
+	if iface, ok := T.Underlying().(*types.Interface); ok {
+		for i := 0; i < iface.NumEmbeddeds(); i++ {
+			supers = append(supers, iface.Embedded(i))
+		}
+	} else {
+		for _, U := range h.named {
+			if iface, ok := U.Underlying().(*types.Interface); ok && implements(T, iface) {
+				supers = append(supers, U)
+			}
+		}
+	}
+	h.supers[T] = supers
+	return supers
+}
+
+// Subtypes returns the subtypes of T within the hierarchy, in order of
+// package and name.
+func (h *TypeHierarchy) Subtypes(T *types.Named) []*types.Named {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if subs, ok := h.subs[T]; ok {
+		return subs
+	}
+	var subs []*types.Named
+	iface, isInterface := T.Underlying().(*types.Interface)
+	for _, U := range h.named {
+		if U == T {
+			continue
+		}
+		if isInterface {
+			if implements(U, iface) {
+				subs = append(subs, U)
+			}
+		} else if embeds(U, T) {
+			subs = append(subs, U)
+		}
+	}
+	h.subs[T] = subs
+	return subs
+}
+
+// implements reports whether T, or a pointer to T, implements iface,
+// a non-empty interface other than T.
+func implements(T types.Type, iface *types.Interface) bool {
+	if iface.NumMethods() == 0 || T.Underlying() == iface {
+		return false
+	}
+	return types.Implements(T, iface) ||
+		!types.IsInterface(T) && types.Implements(types.NewPointer(T), iface)
+}
+
+// embeds reports whether the struct type U embeds T or *T.
+func embeds(U, T *types.Named) bool {
+	st, ok := U.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Anonymous() {
+			continue
+		}
+		E := f.Type()
+		if ptr, ok := E.(*types.Pointer); ok {
+			E = ptr.Elem()
+		}
+		if E == T {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestTypeHierarchy(t *testing.T) {
+	const src = `package p
+
+type Reader interface{ Read() }
+
+type Closer interface{ Close() }
+
+type ReadCloser interface {
+	Reader
+	Closer
+}
+
+type Empty interface{}
+
+type File struct{}
+
+func (*File) Read()  {}
+func (*File) Close() {}
+
+type LoggedFile struct {
+	*File
+	log []string
+}
+
+type Buffer []byte
+
+func (Buffer) Read() {}
+
+func (Buffer) Error() string { return "" }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := typeutil.NewTypeHierarchy(pkg)
+	names := func(types []*types.Named) string {
+		var names []string
+		for _, T := range types {
+			names = append(names, T.Obj().Name())
+		}
+		return strings.Join(names, " ")
+	}
+	var got []string
+	for _, name := range []string{"Reader", "ReadCloser", "Empty", "File", "LoggedFile", "Buffer"} {
+		T := pkg.Scope().Lookup(name).Type().(*types.Named)
+		got = append(got, fmt.Sprintf("%s: super [%s] sub [%s]", name, names(h.Supertypes(T)), names(h.Subtypes(T))))
+	}
+	want := []string{
+		"Reader: super [] sub [Buffer File LoggedFile ReadCloser]",
+		"ReadCloser: super [Closer Reader] sub [File LoggedFile]",
+		"Empty: super [] sub []",
+		"File: super [Closer ReadCloser Reader] sub [LoggedFile]",
+		"LoggedFile: super [Closer ReadCloser Reader] sub []",
+		"Buffer: super [Reader error] sub []",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
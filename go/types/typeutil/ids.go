@@ -0,0 +1,227 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines stable identifiers of packages and objects, by
+// which results computed in one session, and cached on disk, may be
+// correlated with the objects of another.
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/types"
+)
+
+// ObjectID returns a stable identifier of obj, of the form
+//
+//	path#objpath@fingerprint
+//
+// where path is the import path of its package, objpath is the path of
+// the object within the package, and fingerprint is a hash of its
+// declaration, such as
+//
+//	net/http#Client.Do@9c1e0bd4a87f2e36
+//
+// The identifiers of objects loaded in different sessions, even from
+// export data, are equal only if the objects are the same member,
+// field, or method of the same package with the same declaration.
+//
+// The path of a package-level object is its name; that of a method,
+// or of a field of a struct type, is the path of the type, a dot, and
+// the name of the method or field.  Objects with no such path, the
+// local and predeclared objects and the fields and methods of unnamed
+// types not declared by package-level objects, have no identifier.
+//
+func ObjectID(obj types.Object) (string, error) {
+	objpath, err := objectPath(obj)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s#%s@%s", obj.Pkg().Path(), objpath, fingerprint(obj)), nil
+}
+
+// PackageID returns a stable identifier of pkg, of the form
+// path@fingerprint, where fingerprint is a hash of the declarations of
+// its exported members and of their exported fields and methods.
+func PackageID(pkg *types.Package) string {
+	h := fnv.New64a()
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		fmt.Fprintln(h, types.ObjectString(pkg, obj))
+		if tname, ok := obj.(*types.TypeName); ok {
+			for _, m := range exportedMembers(tname) {
+				fmt.Fprintln(h, types.ObjectString(pkg, m))
+			}
+		}
+	}
+	return fmt.Sprintf("%s@%016x", pkg.Path(), h.Sum64())
+}
+
+// LookupID returns the object of pkg whose identifier, as by ObjectID,
+// is id.  It fails if id is not of an object of pkg, or if the
+// declaration of the object has changed.
+func LookupID(pkg *types.Package, id string) (types.Object, error) {
+	hash := strings.LastIndex(id, "#")
+	at := strings.LastIndex(id, "@")
+	if hash < 0 || at < hash {
+		return nil, fmt.Errorf("invalid object ID %q", id)
+	}
+	if id[:hash] != pkg.Path() {
+		return nil, fmt.Errorf("object ID %q is not in package %s", id, pkg.Path())
+	}
+	names := strings.Split(id[hash+1:at], ".")
+	obj := pkg.Scope().Lookup(names[0])
+	for _, name := range names[1:] {
+		if obj == nil {
+			break
+		}
+		obj = member(obj, name)
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("object ID %q: no such object", id)
+	}
+	if fingerprint(obj) != id[at+1:] {
+		return nil, fmt.Errorf("object ID %q: declaration of %s has changed", id, id[hash+1:at])
+	}
+	return obj, nil
+}
+
+// member returns the field or method name of obj, a type or a field of
+// struct type, or nil if there is none.
+func member(obj types.Object, name string) types.Object {
+	if tname, ok := obj.(*types.TypeName); ok {
+		for _, m := range methods(tname) {
+			if m.Name() == name {
+				return m
+			}
+		}
+	}
+	if st := fields(obj); st != nil {
+		for i := 0; i < st.NumFields(); i++ {
+			if f := st.Field(i); f.Name() == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// methods returns the declared methods of the type of tname: those of
+// a named type, and the explicit methods of an interface.
+func methods(tname *types.TypeName) []*types.Func {
+	var result []*types.Func
+	if named, ok := tname.Type().(*types.Named); ok {
+		for i := 0; i < named.NumMethods(); i++ {
+			result = append(result, named.Method(i))
+		}
+	}
+	if iface, ok := tname.Type().Underlying().(*types.Interface); ok {
+		for i := 0; i < iface.NumExplicitMethods(); i++ {
+			result = append(result, iface.ExplicitMethod(i))
+		}
+	}
+	return result
+}
+
+// fields returns the struct whose fields have paths relative to that
+// of obj: the underlying struct of a type, or the struct type literal
+// of a field, or nil.
+func fields(obj types.Object) *types.Struct {
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		st, _ := obj.Type().Underlying().(*types.Struct)
+		return st
+	case *types.Var:
+		if obj.IsField() {
+			st, _ := obj.Type().(*types.Struct)
+			return st
+		}
+	}
+	return nil
+}
+
+// objectPath returns the path of obj within its package.
+func objectPath(obj types.Object) (string, error) {
+	pkg := obj.Pkg()
+	if pkg == nil {
+		return "", fmt.Errorf("predeclared %s has no ID", obj.Name())
+	}
+	if obj.Parent() == pkg.Scope() {
+		return obj.Name(), nil
+	}
+
+	// Search the members of the package-level types.
+	var find func(parent types.Object, path string) string
+	find = func(parent types.Object, path string) string {
+		if tname, ok := parent.(*types.TypeName); ok {
+			for _, m := range methods(tname) {
+				if m == obj {
+					return path + "." + m.Name()
+				}
+			}
+		}
+		if st := fields(parent); st != nil {
+			for i := 0; i < st.NumFields(); i++ {
+				f := st.Field(i)
+				if f == obj {
+					return path + "." + f.Name()
+				}
+				if p := find(f, path+"."+f.Name()); p != "" {
+					return p
+				}
+			}
+		}
+		return ""
+	}
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if tname, ok := scope.Lookup(name).(*types.TypeName); ok {
+			if path := find(tname, name); path != "" {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%s has no ID", types.ObjectString(pkg, obj))
+}
+
+// fingerprint returns a hash of the declaration of obj.
+func fingerprint(obj types.Object) string {
+	h := fnv.New64a()
+	fmt.Fprint(h, types.ObjectString(obj.Pkg(), obj))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// exportedMembers returns the exported methods and fields of the type
+// of tname, in order of name.
+func exportedMembers(tname *types.TypeName) []types.Object {
+	var members []types.Object
+	for _, m := range methods(tname) {
+		if m.Exported() {
+			members = append(members, m)
+		}
+	}
+	if st := fields(tname); st != nil {
+		for i := 0; i < st.NumFields(); i++ {
+			if f := st.Field(i); f.Exported() {
+				members = append(members, f)
+			}
+		}
+	}
+	sort.Sort(byObjName(members))
+	return members
+}
+
+type byObjName []types.Object
+
+func (b byObjName) Len() int           { return len(b) }
+func (b byObjName) Less(i, j int) bool { return b[i].Name() < b[j].Name() }
+func (b byObjName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
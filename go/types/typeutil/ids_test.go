@@ -0,0 +1,94 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestObjectID(t *testing.T) {
+	// check type-checks src anew, as in a new session.
+	check := func(src string) *types.Package {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := new(types.Config).Check("example.com/p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg
+	}
+	const src = `package p
+type T struct {
+	A int
+	B struct{ C string }
+}
+func (T) M() {}
+type I interface{ N() }
+func F(x int) { var local int; _ = local }
+`
+	pkg1, pkg2 := check(src), check(src)
+	T := pkg1.Scope().Lookup("T")
+	F := pkg1.Scope().Lookup("F").(*types.Func)
+	st := T.Type().Underlying().(*types.Struct)
+	iface := pkg1.Scope().Lookup("I").Type().Underlying().(*types.Interface)
+	for _, test := range []struct {
+		obj     types.Object
+		objpath string
+	}{
+		{T, "T"},
+		{F, "F"},
+		{st.Field(0), "T.A"},
+		{st.Field(1).Type().(*types.Struct).Field(0), "T.B.C"},
+		{T.Type().(*types.Named).Method(0), "T.M"},
+		{iface.ExplicitMethod(0), "I.N"},
+	} {
+		id, err := typeutil.ObjectID(test.obj)
+		if err != nil {
+			t.Errorf("ObjectID(%s): %v", test.obj, err)
+			continue
+		}
+		if !strings.HasPrefix(id, "example.com/p#"+test.objpath+"@") {
+			t.Errorf("ObjectID(%s) = %s, want path %s", test.obj, id, test.objpath)
+		}
+		// The ID denotes the same object in another session.
+		obj2, err := typeutil.LookupID(pkg2, id)
+		if err != nil {
+			t.Errorf("LookupID(%s): %v", id, err)
+		} else if obj2 == test.obj || types.ObjectString(pkg2, obj2) != types.ObjectString(pkg1, test.obj) {
+			t.Errorf("LookupID(%s) = %s, want the corresponding object of the second session", id, obj2)
+		}
+	}
+
+	param := F.Type().(*types.Signature).Params().At(0)
+	if id, err := typeutil.ObjectID(param); err == nil {
+		t.Errorf("ObjectID(param x) = %s, want error", id)
+	}
+
+	// A change of declaration changes the IDs of the package and
+	// of the changed object, but not those of other objects.
+	pkg3 := check(strings.Replace(src, "A int", "A int64", 1))
+	if typeutil.PackageID(pkg1) != typeutil.PackageID(pkg2) || typeutil.PackageID(pkg1) == typeutil.PackageID(pkg3) {
+		t.Errorf("PackageID: got %s, %s, %s; want first two equal to each other only",
+			typeutil.PackageID(pkg1), typeutil.PackageID(pkg2), typeutil.PackageID(pkg3))
+	}
+	idA, _ := typeutil.ObjectID(st.Field(0))
+	if _, err := typeutil.LookupID(pkg3, idA); err == nil || !strings.Contains(err.Error(), "declaration of T.A has changed") {
+		t.Errorf("LookupID(%s) of changed field: got error %v", idA, err)
+	}
+	idF, _ := typeutil.ObjectID(F)
+	if _, err := typeutil.LookupID(pkg3, idF); err != nil {
+		t.Errorf("LookupID(%s) of unchanged func: %v", idF, err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines utilities for the provenance of the methods of
+// interfaces that embed other interfaces.
+
+import "golang.org/x/tools/go/types"
+
+// A MethodOrigin describes the provenance of a method of an
+// interface's complete method set.
+type MethodOrigin struct {
+	Method *types.Func
+
+	// Embedding is the chain of embedded interfaces through which
+	// the method was inherited, outermost first: for
+	//
+	//	type A interface{ B }
+	//	type B interface{ C }
+	//	type C interface{ M() }
+	//
+	// the method M of A has the chain [B C].  It is empty for a
+	// method declared explicitly by the interface itself.
+	Embedding []*types.Named
+}
+
+// Declarer returns the embedded interface that explicitly declares
+// the method, the last of the embedding chain, or nil if the method is
+// declared by the interface itself.
+func (o *MethodOrigin) Declarer() *types.Named {
+	if n := len(o.Embedding); n > 0 {
+		return o.Embedding[n-1]
+	}
+	return nil
+}
+
+// InterfaceMethods returns the origin of each method of the complete
+// method set of the interface T, in the order of T.Method.
+//
+// Documentation tools and "go to declaring interface" features may
+// use it to find which embedded interface contributed each method.
+//
+func InterfaceMethods(T *types.Interface) []*MethodOrigin {
+	origins := make(map[*types.Func][]*types.Named)
+	seen := make(map[*types.Interface]bool) // guards against invalid cycles
+	var visit func(iface *types.Interface, chain []*types.Named)
+	visit = func(iface *types.Interface, chain []*types.Named) {
+		if seen[iface] {
+			return
+		}
+		seen[iface] = true
+		for i, n := 0, iface.NumExplicitMethods(); i < n; i++ {
+			m := iface.ExplicitMethod(i)
+			if _, ok := origins[m]; !ok {
+				origins[m] = chain
+			}
+		}
+		for i, n := 0, iface.NumEmbeddeds(); i < n; i++ {
+			e := iface.Embedded(i)
+			if embedded, ok := e.Underlying().(*types.Interface); ok {
+				// Copy the chain so that siblings don't share it.
+				sub := append(chain[:len(chain):len(chain)], e)
+				visit(embedded, sub)
+			}
+		}
+	}
+	visit(T, nil)
+
+	result := make([]*MethodOrigin, T.NumMethods())
+	for i := range result {
+		m := T.Method(i)
+		result[i] = &MethodOrigin{Method: m, Embedding: origins[m]}
+	}
+	return result
+}
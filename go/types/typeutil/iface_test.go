@@ -0,0 +1,65 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestInterfaceMethods(t *testing.T) {
+	const src = `package p
+
+type A interface {
+	B
+	D
+	M()
+}
+
+type B interface{ C }
+
+type C interface{ N() }
+
+type D interface {
+	O()
+	P()
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	A := pkg.Scope().Lookup("A").Type().Underlying().(*types.Interface)
+	var got []string
+	for _, o := range typeutil.InterfaceMethods(A) {
+		var chain []string
+		for _, e := range o.Embedding {
+			chain = append(chain, e.Obj().Name())
+		}
+		got = append(got, fmt.Sprintf("%s %v %v", o.Method.Name(), chain, o.Declarer()))
+	}
+	want := []string{
+		"M [] <nil>",
+		"N [B C] p.C",
+		"O [D] p.D",
+		"P [D] p.D",
+	}
+	if got, want := strings.Join(got, "\n"), strings.Join(want, "\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines InlayHints, inferred information for display
+// inline in the source text of a file.
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types"
+)
+
+// An InlayHint is a label to display at a position in the source text.
+type InlayHint struct {
+	Pos   token.Pos
+	Kind  InlayHintKind
+	Label string
+}
+
+// An InlayHintKind describes the information that an InlayHint
+// displays.
+type InlayHintKind int
+
+const (
+	TypeHint       InlayHintKind = iota // the type of a variable declared by :=, after its name, such as " int"
+	ParameterHint                       // the name of the parameter of an argument, before it, such as "n:"
+	ConversionHint                      // an implicit conversion to an interface, "T(" before the operand and ")" after it
+)
+
+// InlayHints returns the hints for file f of package pkg, in order of
+// position:
+//
+//  - the types of the variables declared by short variable
+//    declarations and range statements;
+//  - the names of the parameters of the arguments of function calls,
+//    except arguments whose names are those of their parameters; and
+//  - the implicit conversions of values to interface types by
+//    arguments, assignments, variable declarations, and returns.
+//
+// Types are printed relative to pkg.
+//
+// Precondition: info.Types, info.Defs, and info.Uses are populated.
+//
+func InlayHints(pkg *types.Package, info *types.Info, f *ast.File) []InlayHint {
+	h := &hinter{pkg: pkg, info: info}
+	h.visit(f, nil)
+	sort.Stable(byHintPos(h.hints))
+	return h.hints
+}
+
+type hinter struct {
+	pkg   *types.Package
+	info  *types.Info
+	hints []InlayHint
+}
+
+// visit adds the hints for n, within a function of signature sig.
+func (h *hinter) visit(n ast.Node, sig *types.Signature) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				if obj := h.info.Defs[n.Name]; obj != nil {
+					h.visit(n.Body, obj.Type().(*types.Signature))
+				}
+			}
+			return false
+
+		case *ast.FuncLit:
+			if T, ok := h.info.Types[n].Type.(*types.Signature); ok {
+				h.visit(n.Body, T)
+			}
+			return false
+
+		case *ast.AssignStmt:
+			switch n.Tok {
+			case token.DEFINE:
+				for _, lhs := range n.Lhs {
+					h.typeHint(lhs)
+				}
+			case token.ASSIGN:
+				if len(n.Lhs) == len(n.Rhs) {
+					for i, rhs := range n.Rhs {
+						h.conversion(rhs, h.info.TypeOf(n.Lhs[i]))
+					}
+				}
+			}
+
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE {
+				h.typeHint(n.Key)
+				h.typeHint(n.Value)
+			}
+
+		case *ast.ValueSpec:
+			if n.Type != nil && len(n.Names) == len(n.Values) {
+				for _, value := range n.Values {
+					h.conversion(value, h.info.TypeOf(n.Type))
+				}
+			}
+
+		case *ast.ReturnStmt:
+			if sig != nil && sig.Results().Len() == len(n.Results) {
+				for i, result := range n.Results {
+					h.conversion(result, sig.Results().At(i).Type())
+				}
+			}
+
+		case *ast.CallExpr:
+			h.call(n)
+		}
+		return true
+	})
+}
+
+// typeHint adds the hint of the type of the variable declared by the
+// identifier e, if any.
+func (h *hinter) typeHint(e ast.Expr) {
+	id, ok := e.(*ast.Ident)
+	if !ok {
+		return
+	}
+	if obj := h.info.Defs[id]; obj != nil {
+		h.hints = append(h.hints, InlayHint{id.End(), TypeHint, " " + types.TypeString(h.pkg, obj.Type())})
+	}
+}
+
+// call adds the parameter and conversion hints of the arguments of a
+// call of a function.
+func (h *hinter) call(call *ast.CallExpr) {
+	tv, ok := h.info.Types[call.Fun]
+	if !ok || tv.IsType() || tv.IsBuiltin() {
+		return // a conversion or a call of a built-in
+	}
+	sig, ok := tv.Type.Underlying().(*types.Signature)
+	if !ok {
+		return
+	}
+	params := sig.Params()
+	if len(call.Args) == 1 {
+		if _, ok := h.info.TypeOf(call.Args[0]).(*types.Tuple); ok {
+			return // f(g()) for a g of several results
+		}
+	}
+	for i, arg := range call.Args {
+		var param *types.Var
+		var T types.Type // the type of the parameter, or of its elements
+		switch {
+		case sig.Variadic() && i >= params.Len()-1:
+			param = params.At(params.Len() - 1)
+			T = param.Type()
+			if call.Ellipsis == token.NoPos {
+				T = T.(*types.Slice).Elem()
+			}
+			if i > params.Len()-1 {
+				param = nil // name only the first variadic argument
+			}
+		case i < params.Len():
+			param = params.At(i)
+			T = param.Type()
+		}
+		if param != nil && param.Name() != "" && param.Name() != "_" {
+			if id, ok := astutil.Unparen(arg).(*ast.Ident); !ok || id.Name != param.Name() {
+				h.hints = append(h.hints, InlayHint{arg.Pos(), ParameterHint, param.Name() + ":"})
+			}
+		}
+		if T != nil {
+			h.conversion(arg, T)
+		}
+	}
+}
+
+// conversion adds the hints of the implicit conversion of e to T, if
+// T is an interface type and the type of e is not.
+func (h *hinter) conversion(e ast.Expr, T types.Type) {
+	if T == nil || !types.IsInterface(T) {
+		return
+	}
+	tv, ok := h.info.Types[e]
+	if !ok || tv.Type == nil || types.IsInterface(tv.Type) || tv.IsNil() {
+		return
+	}
+	if b, ok := tv.Type.(*types.Basic); ok && b.Kind() == types.Invalid {
+		return
+	}
+	h.hints = append(h.hints,
+		InlayHint{e.Pos(), ConversionHint, types.TypeString(h.pkg, T) + "("},
+		InlayHint{e.End(), ConversionHint, ")"})
+}
+
+type byHintPos []InlayHint
+
+func (b byHintPos) Len() int           { return len(b) }
+func (b byHintPos) Less(i, j int) bool { return b[i].Pos < b[j].Pos }
+func (b byHintPos) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,84 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestInlayHints(t *testing.T) {
+	const src = `package p
+
+type Stringer interface{ String() string }
+
+type T int
+
+func (T) String() string { return "" }
+
+func show(s Stringer, width int, flags ...string) {}
+
+func f(width int) Stringer {
+	t := T(1)
+	for i, c := range "ab" {
+		show(t, width, "x", "y")
+		_, _ = i, c
+	}
+	var s Stringer = t
+	s = t
+	_ = s
+	return t
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Render the hints inline.
+	var buf []byte
+	last := 0
+	for _, hint := range typeutil.InlayHints(pkg, info, f) {
+		offset := fset.Position(hint.Pos).Offset
+		buf = append(buf, src[last:offset]...)
+		buf = append(buf, "«"+hint.Label+"»"...)
+		last = offset
+	}
+	buf = append(buf, src[last:]...)
+	got := string(buf)
+	got = got[strings.Index(got, "func f"):]
+
+	const want = `func f(width int) Stringer {
+	t« T» := T(1)
+	for i« int», c« rune» := range "ab" {
+		show(«s:»«Stringer(»t«)», width, «flags:»"x", "y")
+		_, _ = i, c
+	}
+	var s Stringer = «Stringer(»t«)»
+	s = «Stringer(»t«)»
+	_ = s
+	return «Stringer(»t«)»
+}
+`
+	if got != want {
+		t.Errorf("got hints:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines SelectionRanges, the hierarchy of ranges enclosing
+// a source position, for editors' expand-selection features.
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types"
+)
+
+// A SelectionRange is a range of source text enclosing a position,
+// that of a syntax node.
+type SelectionRange struct {
+	Pos, End token.Pos
+	Node     ast.Node     // the outermost node of the range
+	Kind     string       // "identifier", "expression", "statement", "block", "function", "declaration", "list", or "file"
+	Scope    *types.Scope // the scope of the node, if it has one and info.Scopes is populated
+}
+
+// SelectionRanges returns the ranges enclosing position pos within
+// file f, from the innermost, each strictly enclosing the previous
+// one: typically those of an identifier, the expressions, statements,
+// and blocks enclosing it, its function and declaration, and the file.
+//
+// Of the nodes that span the same range, such as an expression
+// statement and its call, the outermost determines the kind.  The
+// Scope of a function is that recorded in info.Scopes for its type,
+// which extends over the whole function; info may be nil.
+//
+func SelectionRanges(info *types.Info, f *ast.File, pos token.Pos) []SelectionRange {
+	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+	var ranges []SelectionRange
+	for _, n := range path {
+		r := SelectionRange{Pos: n.Pos(), End: n.End(), Node: n, Kind: selectionKind(n)}
+		if info != nil {
+			r.Scope = info.Scopes[n]
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				r.Scope = info.Scopes[n.Type]
+			case *ast.FuncLit:
+				r.Scope = info.Scopes[n.Type]
+			}
+		}
+		if len(ranges) > 0 {
+			if last := &ranges[len(ranges)-1]; last.Pos == r.Pos && last.End == r.End {
+				if r.Scope == nil {
+					r.Scope = last.Scope
+				}
+				*last = r
+				continue
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+func selectionKind(n ast.Node) string {
+	switch n.(type) {
+	case *ast.Ident:
+		return "identifier"
+	case *ast.FuncDecl, *ast.FuncLit:
+		return "function"
+	case *ast.BlockStmt:
+		return "block"
+	case *ast.File:
+		return "file"
+	case *ast.FieldList:
+		return "list"
+	case ast.Decl, ast.Spec, *ast.Field:
+		return "declaration"
+	case ast.Stmt:
+		return "statement"
+	}
+	return "expression"
+}
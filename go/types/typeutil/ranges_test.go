@@ -0,0 +1,70 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestSelectionRanges(t *testing.T) {
+	const src = `package p
+
+func f(xs []int) int {
+	for _, x := range xs {
+		if x > 0 {
+			g(x + 1)
+		}
+	}
+	return 0
+}
+
+func g(int) {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Scopes: make(map[ast.Node]*types.Scope)}
+	if _, err := new(types.Config).Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	pos := f.Pos() + token.Pos(strings.Index(src, "x + 1"))
+	var got []string
+	for _, r := range typeutil.SelectionRanges(info, f, pos) {
+		s := fmt.Sprintf("%s %q", r.Kind, src[r.Pos-f.Pos():r.End-f.Pos()])
+		if len(s) > 30 {
+			s = s[:30] + "..."
+		}
+		if r.Scope != nil {
+			s += " (scope)"
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		`identifier "x"`,
+		`expression "x + 1"`,
+		`statement "g(x + 1)"`,
+		`block "{\n\t\t\tg(x + 1)\n\t\t... (scope)`,
+		`statement "if x > 0 {\n\t\t\tg... (scope)`,
+		`block "{\n\t\tif x > 0 {\n\t\t... (scope)`,
+		`statement "for _, x := range x... (scope)`,
+		`block "{\n\tfor _, x := range ...`,
+		`function "func f(xs []int) int... (scope)`,
+		`file "package p\n\nfunc f(xs [... (scope)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got ranges:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines utilities for computing the shape of the values
+// of a type: the types of the memory reachable from them.
+
+import "golang.org/x/tools/go/types"
+
+// Reachable returns the types reachable from the specified root
+// types, including the roots themselves, in depth-first preorder.
+//
+// A type reaches the elements of its pointer, slice, array, and
+// channel types, the keys and values of its map types, and the fields
+// of its struct types; a named type reaches its underlying type.  The
+// types of interface values and the free variables of closures are
+// not known statically, so interface and function types reach no
+// other types.  Each type appears once, even if it is reachable from
+// itself.
+//
+func Reachable(roots ...types.Type) []types.Type {
+	var result []types.Type
+	var seen Map
+	var visit func(T types.Type)
+	visit = func(T types.Type) {
+		if seen.At(T) != nil {
+			return
+		}
+		seen.Set(T, true)
+		result = append(result, T)
+		for _, U := range successors(T) {
+			visit(U)
+		}
+	}
+	for _, T := range roots {
+		visit(T)
+	}
+	return result
+}
+
+// Recursive returns the named types reachable from the specified root
+// types that are reachable from themselves, such as a linked list
+// node type, in the order of Reachable.  Values of such types may
+// form cycles in memory, or be arbitrarily deep.
+//
+func Recursive(roots ...types.Type) []*types.Named {
+	var result []*types.Named
+	for _, T := range Reachable(roots...) {
+		if named, ok := T.(*types.Named); ok {
+			for _, U := range Reachable(named.Underlying()) {
+				if types.Identical(U, named) {
+					result = append(result, named)
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+// successors returns the types directly reachable from T.
+func successors(T types.Type) []types.Type {
+	switch T := T.(type) {
+	case *types.Named:
+		return []types.Type{T.Underlying()}
+	case *types.Pointer:
+		return []types.Type{T.Elem()}
+	case *types.Slice:
+		return []types.Type{T.Elem()}
+	case *types.Array:
+		return []types.Type{T.Elem()}
+	case *types.Chan:
+		return []types.Type{T.Elem()}
+	case *types.Map:
+		return []types.Type{T.Key(), T.Elem()}
+	case *types.Struct:
+		var fields []types.Type
+		for i, n := 0, T.NumFields(); i < n; i++ {
+			fields = append(fields, T.Field(i).Type())
+		}
+		return fields
+	}
+	return nil // *Basic, *Interface, *Signature, *Tuple
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestReachable(t *testing.T) {
+	const src = `package p
+
+type List struct {
+	Value int
+	Next  *List
+}
+
+type T struct {
+	m  map[string][]*List
+	ch chan [2]byte
+	f  func(U) V
+	e  error
+}
+
+type U struct{}
+
+type V int
+
+type Tree struct{ Kids []Forest }
+
+type Forest []Tree
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	str := func(ts []types.Type) string {
+		var names []string
+		for _, T := range ts {
+			names = append(names, T.String())
+		}
+		return strings.Join(names, "; ")
+	}
+	lookup := func(name string) types.Type {
+		return pkg.Scope().Lookup(name).Type()
+	}
+
+	for _, test := range []struct {
+		root, want string
+	}{
+		{"List", "p.List; struct{Value int; Next *p.List}; int; *p.List"},
+		{"T", "p.T; struct{m map[string][]*p.List; ch chan [2]byte; f func(p.U) p.V; e error}; " +
+			"map[string][]*p.List; string; []*p.List; *p.List; p.List; " +
+			"struct{Value int; Next *p.List}; int; chan [2]byte; [2]byte; byte; func(p.U) p.V; error; interface{Error() string}"},
+		{"U", "p.U; struct{}"},
+	} {
+		if got := str(typeutil.Reachable(lookup(test.root))); got != test.want {
+			t.Errorf("Reachable(%s) = %s, want %s", test.root, got, test.want)
+		}
+	}
+
+	var got []string
+	for _, named := range typeutil.Recursive(lookup("T"), lookup("U"), lookup("Tree")) {
+		got = append(got, named.String())
+	}
+	if got, want := strings.Join(got, " "), "p.List p.Tree p.Forest"; got != want {
+		t.Errorf("Recursive = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines utilities for the receivers of methods.
+
+import "golang.org/x/tools/go/types"
+
+// RecvBase returns the named base type of the receiver of the method
+// signature sig, that is, T for a receiver of type T or *T, and
+// reports whether the receiver is a pointer.  For an abstract method
+// the base type is the interface's named type, if any.  RecvBase
+// returns nil if sig is not a method signature or its base type is
+// not a named type, as for a method of an unnamed interface.
+//
+// Tools that group methods by their receiver types may use it.
+//
+func RecvBase(sig *types.Signature) (named *types.Named, ptr bool) {
+	recv := sig.Recv()
+	if recv == nil {
+		return nil, false
+	}
+	T := recv.Type()
+	if p, ok := T.(*types.Pointer); ok {
+		T, ptr = p.Elem(), true
+	}
+	named, _ = T.(*types.Named)
+	return named, ptr
+}
+
+// MethodRecvBase is like RecvBase for the signature of method m.
+func MethodRecvBase(m *types.Func) (named *types.Named, ptr bool) {
+	return RecvBase(m.Type().(*types.Signature))
+}
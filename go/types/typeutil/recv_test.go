@@ -0,0 +1,60 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestRecvBase(t *testing.T) {
+	const src = `package p
+
+type T int
+
+func (T) A()  {}
+func (*T) B() {}
+
+type I interface{ C() }
+
+var _ interface{ D() }
+
+func f() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	if _, err := new(types.Config).Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for id, obj := range info.Defs {
+		if fn, ok := obj.(*types.Func); ok {
+			named, ptr := typeutil.MethodRecvBase(fn)
+			got[id.Name] = fmt.Sprint(named, " ", ptr)
+		}
+	}
+	for name, want := range map[string]string{
+		"A": "p.T false",
+		"B": "p.T true",
+		"C": "p.I false",
+		"D": "<nil> false",
+		"f": "<nil> false",
+	} {
+		if got[name] != want {
+			t.Errorf("MethodRecvBase(%s) = %s, want %s", name, got[name], want)
+		}
+	}
+}
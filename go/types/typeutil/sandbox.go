@@ -0,0 +1,126 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines Sandbox, a check of the capabilities used by
+// untrusted code snippets, for services that compile user-submitted
+// code.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A Sandbox is a policy restricting the packages, and the members of
+// packages, that code may use.  Code may use only the packages listed
+// by the policy, so that, unless they are listed, package unsafe,
+// package reflect, and packages that make system calls, such as
+// syscall, os, and net, are rejected, as are cgo and any package
+// through which user code could reach them.
+type Sandbox struct {
+	// Packages maps the import path of each package that code may
+	// use to a function selecting the members of the package it
+	// may use, as for Trim, or to nil for all its members.
+	Packages map[string]func(obj types.Object) bool
+
+	// Import imports the packages; nil means types.DefaultImport.
+	Import types.Importer
+}
+
+// A Violation is a use of a capability that a Sandbox denies.
+type Violation struct {
+	Pos token.Position // the position of the import or use, or the zero Position for a predeclared import
+	Msg string
+}
+
+func (v *Violation) String() string {
+	if !v.Pos.IsValid() {
+		return v.Msg
+	}
+	return fmt.Sprintf("%s: %s", v.Pos, v.Msg)
+}
+
+// CheckSnippet checks the code fragment src, as by types.CheckSnippet,
+// importing the specified packages, and returns the checked fragment,
+// if it could be parsed, the violations of the policy, in order of
+// position, and the first type-checking error, if any.  The code
+// should be rejected if there are either violations or an error.
+//
+// A violation is reported for each import of a package the policy does
+// not list, and for each use of a member of a listed package that its
+// function does not select.  Fields and methods are not restricted,
+// but they can only be used through values of a package's types.
+//
+func (sb *Sandbox) CheckSnippet(conf *types.Config, fset *token.FileSet, filename, src string, imports []string, info *types.Info) (*types.Snippet, []*Violation, error) {
+	var violations []*Violation
+	for _, path := range imports {
+		if _, ok := sb.Packages[path]; !ok {
+			violations = append(violations, &Violation{Msg: fmt.Sprintf("import of disallowed package %q", path)})
+		}
+	}
+
+	var c types.Config
+	if conf != nil {
+		c = *conf
+	}
+	if sb.Import != nil {
+		c.Import = sb.Import
+	}
+	if info == nil {
+		info = new(types.Info)
+	}
+	if info.Uses == nil {
+		info.Uses = make(map[*ast.Ident]types.Object)
+	}
+	s, err := types.CheckSnippet(&c, fset, filename, src, imports, info)
+	if s == nil {
+		return nil, violations, err
+	}
+
+	// Check the imports of the fragment itself.
+	for _, decl := range s.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.ImportSpec)
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if _, ok := sb.Packages[path]; !ok {
+				violations = append(violations, &Violation{fset.Position(spec.Pos()), fmt.Sprintf("import of disallowed package %q", path)})
+			}
+		}
+	}
+
+	// Check the uses of package members.
+	for id, obj := range info.Uses {
+		pkg := obj.Pkg()
+		if pkg == nil || pkg == s.Pkg || obj.Parent() != pkg.Scope() {
+			continue // predeclared, local, or not a package member
+		}
+		if keep := sb.Packages[pkg.Path()]; keep != nil && !keep(obj) {
+			violations = append(violations, &Violation{fset.Position(id.Pos()), fmt.Sprintf("use of disallowed %s.%s", pkg.Path(), obj.Name())})
+		}
+	}
+	sort.Stable(byViolationPos(violations))
+	return s, violations, err
+}
+
+type byViolationPos []*Violation
+
+func (b byViolationPos) Len() int { return len(b) }
+func (b byViolationPos) Less(i, j int) bool {
+	x, y := b[i].Pos, b[j].Pos
+	if x.Line != y.Line {
+		return x.Line < y.Line
+	}
+	return x.Column < y.Column
+}
+func (b byViolationPos) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,74 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestSandbox(t *testing.T) {
+	pkgs := make(map[string]*types.Package)
+	for path, src := range map[string]string{
+		"api":     `package api; func Register(name string) {}; func Shutdown() {}; type Plugin struct{}; func (Plugin) Close() {}`,
+		"syscall": `package syscall; func Exit(code int) {}`,
+	} {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, path+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := new(types.Config).Check(path, fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgs[path] = pkg
+	}
+	sb := typeutil.Sandbox{
+		Packages: map[string]func(types.Object) bool{"api": typeutil.KeepNames("Register", "Plugin")},
+		Import: func(imports map[string]*types.Package, path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			if pkg := pkgs[path]; pkg != nil {
+				return pkg, nil
+			}
+			return nil, fmt.Errorf("no package %s", path)
+		},
+	}
+	for _, test := range []struct {
+		src     string
+		imports []string
+		want    []string
+	}{
+		{`api.Register("x"); api.Plugin{}.Close()`, []string{"api"}, nil},
+		{`api.Register("x")
+api.Shutdown()`, []string{"api"}, []string{`snippet.go:2:5: use of disallowed api.Shutdown`}},
+		{`syscall.Exit(1)`, []string{"api", "syscall"}, []string{`import of disallowed package "syscall"`}},
+		{`import "unsafe"
+
+var size = unsafe.Sizeof(0)`, nil, []string{`snippet.go:1:8: import of disallowed package "unsafe"`}},
+	} {
+		_, violations, err := sb.CheckSnippet(nil, token.NewFileSet(), "snippet.go", test.src, test.imports, nil)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		var got []string
+		for _, v := range violations {
+			got = append(got, v.String())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got violations %q, want %q", test.src, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines SemanticTokens, a classification of the
+// identifiers of a file by the objects they denote, for precise
+// syntax highlighting.
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types"
+)
+
+// A SemanticToken is an identifier classified by the object it
+// denotes.
+type SemanticToken struct {
+	Pos, End  token.Pos
+	Kind      types.ObjectKind // the kind of the object, such as a type name, a parameter, or a label
+	Modifiers TokenModifiers
+}
+
+// TokenModifiers is a set of modifiers of a SemanticToken.
+type TokenModifiers int
+
+const (
+	DefinitionModifier  TokenModifiers = 1 << iota // the identifier declares the object
+	ExportedModifier                               // the object is exported
+	MutableModifier                                // the object is a variable assigned after its declaration
+	PredeclaredModifier                            // the object is predeclared, such as int or true
+)
+
+var tokenModifierNames = []string{"definition", "exported", "mutable", "predeclared"}
+
+func (m TokenModifiers) String() string {
+	var names []string
+	for i, name := range tokenModifierNames {
+		if m&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// SemanticTokens returns the classification of the identifiers of
+// file f that denote objects, and of the name of its package clause,
+// in order of position.
+//
+// A variable or field is mutable if any operand of an assignment, as
+// recorded in info.Writes, writes it after its declaration, so package
+// variables and fields are mutable only if files other than f, but
+// checked with info, assign them.
+//
+// Precondition: info.Defs and info.Uses are populated.
+//
+func SemanticTokens(info *types.Info, f *ast.File) []SemanticToken {
+	mutable := make(map[types.Object]bool)
+	for lhs, kind := range info.Writes {
+		if kind == types.DefineWrite || kind == types.BlankWrite {
+			continue
+		}
+		switch lhs := astutil.Unparen(lhs).(type) {
+		case *ast.Ident:
+			mutable[info.ObjectOf(lhs)] = true
+		case *ast.SelectorExpr:
+			mutable[info.ObjectOf(lhs.Sel)] = true
+		}
+	}
+
+	tokens := []SemanticToken{{
+		Pos:       f.Name.Pos(),
+		End:       f.Name.End(),
+		Kind:      types.PkgNameObject,
+		Modifiers: DefinitionModifier,
+	}}
+	ast.Inspect(f, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id == f.Name {
+			return true
+		}
+		var mods TokenModifiers
+		obj := info.Defs[id]
+		if obj != nil {
+			mods |= DefinitionModifier
+		} else if obj = info.Uses[id]; obj == nil {
+			return true
+		}
+		if obj.Exported() && obj.Pkg() != nil {
+			mods |= ExportedModifier
+		}
+		if obj.Pkg() == nil {
+			mods |= PredeclaredModifier
+		}
+		if mutable[obj] {
+			mods |= MutableModifier
+		}
+		tokens = append(tokens, SemanticToken{id.Pos(), id.End(), obj.Kind(), mods})
+		return true
+	})
+	return tokens
+}
@@ -0,0 +1,97 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestSemanticTokens(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+const Max = 10
+
+type T struct{ n int }
+
+func (t *T) Add(k int) int {
+	total := len("")
+	for i := 0; i < k; i++ {
+		t.n += i
+	}
+loop:
+	for {
+		break loop
+	}
+	return total + int(unsafe.Sizeof(Max))
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Defs:   make(map[*ast.Ident]types.Object),
+		Uses:   make(map[*ast.Ident]types.Object),
+		Writes: make(map[ast.Expr]types.WriteKind),
+	}
+	conf := types.Config{Import: func(map[string]*types.Package, string) (*types.Package, error) {
+		return types.Unsafe, nil
+	}}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, tok := range typeutil.SemanticTokens(info, f) {
+		s := fmt.Sprintf("%s %s", src[tok.Pos-f.Pos():tok.End-f.Pos()], tok.Kind)
+		if tok.Modifiers != 0 {
+			s += " " + tok.Modifiers.String()
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"p package definition",
+		"Max const definition,exported",
+		"T type definition,exported",
+		"n field definition,mutable",
+		"int type predeclared",
+		"t param definition",
+		"T type exported",
+		"Add method definition,exported",
+		"k param definition",
+		"int type predeclared",
+		"int type predeclared",
+		"total var definition",
+		"len builtin predeclared",
+		"i var definition,mutable",
+		"i var mutable",
+		"k param",
+		"i var mutable",
+		"t param",
+		"n field mutable",
+		"i var mutable",
+		"loop label definition",
+		"loop label",
+		"total var",
+		"int type predeclared",
+		"unsafe package",
+		"Sizeof builtin exported",
+		"Max const exported",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got tokens:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
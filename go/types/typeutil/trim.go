@@ -0,0 +1,64 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines trimmed views of packages, which expose a subset
+// of their members, for checking code against a restricted API.
+
+import "golang.org/x/tools/go/types"
+
+// Trim returns a view of pkg: a new, complete package with the same
+// path, name, and imports as pkg, whose scope contains only the
+// members of pkg for which keep returns true.  The members are the
+// objects of pkg, not copies, so that the types of the view are
+// identical to those of pkg; in particular, the fields and methods of
+// a kept type are all accessible.
+func Trim(pkg *types.Package, keep func(obj types.Object) bool) *types.Package {
+	view := types.NewPackage(pkg.Path(), pkg.Name())
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if obj := scope.Lookup(name); keep(obj) {
+			view.Scope().Insert(obj) // obj keeps its parent, the scope of pkg
+		}
+	}
+	view.SetImports(pkg.Imports())
+	view.MarkComplete()
+	return view
+}
+
+// KeepNames returns a function for Trim that keeps the members of
+// the specified names.
+func KeepNames(names ...string) func(obj types.Object) bool {
+	set := make(map[string]bool)
+	for _, name := range names {
+		set[name] = true
+	}
+	return func(obj types.Object) bool { return set[obj.Name()] }
+}
+
+// TrimImporter returns an importer that imports packages with imp,
+// and returns, for the import paths in views, the view of the package
+// trimmed by the corresponding function, as by Trim.  Each package
+// has a single view, so that the code checked against it may use the
+// same package in several files.
+func TrimImporter(imp types.Importer, views map[string]func(obj types.Object) bool) types.Importer {
+	trimmed := make(map[*types.Package]*types.Package) // maps each package to its view
+	isView := make(map[*types.Package]bool)
+	return func(imports map[string]*types.Package, path string) (*types.Package, error) {
+		pkg, err := imp(imports, path)
+		keep, ok := views[path]
+		if err != nil || !ok || isView[pkg] {
+			return pkg, err
+		}
+		view := trimmed[pkg]
+		if view == nil {
+			view = Trim(pkg, keep)
+			trimmed[pkg] = view
+			isView[view] = true
+		}
+		imports[path] = view
+		return view, nil
+	}
+}
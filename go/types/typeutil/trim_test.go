@@ -0,0 +1,65 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestTrim(t *testing.T) {
+	fset := token.NewFileSet()
+	check := func(path, src string, imp types.Importer) (*types.Package, error) {
+		f, err := parser.ParseFile(fset, path+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf := types.Config{Import: imp}
+		return conf.Check(path, fset, []*ast.File{f}, nil)
+	}
+	api, err := check("api", `package api
+type Plugin struct{ Name string }
+func (p *Plugin) Run() {}
+func Register(p *Plugin) {}
+func Shutdown() {}
+`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view := typeutil.Trim(api, typeutil.KeepNames("Plugin", "Register"))
+	if got := strings.Join(view.Scope().Names(), " "); got != "Plugin Register" {
+		t.Errorf("Trim: got members %s, want Plugin Register", got)
+	}
+	if view.Path() != "api" || !view.Complete() || view.Scope().Lookup("Plugin") != api.Scope().Lookup("Plugin") {
+		t.Errorf("Trim: view is not of package api")
+	}
+
+	imp := typeutil.TrimImporter(func(imports map[string]*types.Package, path string) (*types.Package, error) {
+		if path == "api" {
+			return api, nil
+		}
+		return nil, fmt.Errorf("no package %s", path)
+	}, map[string]func(types.Object) bool{"api": typeutil.KeepNames("Plugin", "Register")})
+	for _, test := range []struct {
+		body, err string
+	}{
+		{`api.Register(&api.Plugin{Name: "x"})`, ""},
+		{`new(api.Plugin).Run()`, ""},
+		{`api.Shutdown()`, "Shutdown not declared by package api"},
+	} {
+		_, err := check("user", `package user; import "api"; func _() { `+test.body+` }`, imp)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: got error %v, want %q", test.body, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines utilities for checking the exhaustiveness of type
+// switches.
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/types"
+)
+
+// Implementations returns the concrete types of the specified
+// packages that implement the interface iface: each package-level
+// named non-interface type T, and the pointer type *T, that does.
+// Both T and *T are returned if both implement iface, since either may
+// be the dynamic type of an interface value.
+func Implementations(iface *types.Interface, pkgs ...*types.Package) []types.Type {
+	var result []types.Type
+	for _, pkg := range pkgs {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			tname, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			T := tname.Type()
+			if types.IsInterface(T) {
+				continue
+			}
+			if types.Implements(T, iface) {
+				result = append(result, T)
+			}
+			if ptr := types.NewPointer(T); types.Implements(ptr, iface) {
+				result = append(result, ptr)
+			}
+		}
+	}
+	return result
+}
+
+// Uncovered returns the types among impls, typically the result of
+// Implementations, that implement the interface of the type switch
+// sw but are matched by none of its cases.  A case matches a
+// type if it names the same type, or an interface that the type
+// implements.  A default case is ignored, so that visitor-pattern
+// code may find the types its default case handles.  The types of the
+// switch and its cases are taken from info.Types.
+//
+// Uncovered returns nil if the switch was not well typed.
+//
+func Uncovered(info *types.Info, sw *ast.TypeSwitchStmt, impls []types.Type) []types.Type {
+	var x ast.Expr
+	switch s := sw.Assign.(type) {
+	case *ast.ExprStmt: // x.(type)
+		x = s.X
+	case *ast.AssignStmt: // y := x.(type)
+		if len(s.Rhs) == 1 {
+			x = s.Rhs[0]
+		}
+	}
+	assert, ok := x.(*ast.TypeAssertExpr)
+	if !ok {
+		return nil
+	}
+	X := info.TypeOf(assert.X)
+	if X == nil {
+		return nil
+	}
+	iface, ok := X.Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+
+	var cases []types.Type
+	for _, stmt := range sw.Body.List {
+		for _, e := range stmt.(*ast.CaseClause).List {
+			tv, ok := info.Types[e]
+			if !ok || !tv.IsType() {
+				continue // nil, or ill-typed
+			}
+			cases = append(cases, tv.Type)
+		}
+	}
+
+	var result []types.Type
+	for _, T := range impls {
+		if !types.Implements(T, iface) {
+			continue
+		}
+		if !covered(T, cases) {
+			result = append(result, T)
+		}
+	}
+	return result
+}
+
+// covered reports whether values of the concrete type T are matched by
+// a case of the specified types.
+func covered(T types.Type, cases []types.Type) bool {
+	for _, C := range cases {
+		if types.Identical(T, C) {
+			return true
+		}
+		if iface, ok := C.Underlying().(*types.Interface); ok && types.Implements(T, iface) {
+			return true
+		}
+	}
+	return false
+}
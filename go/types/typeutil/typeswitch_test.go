@@ -0,0 +1,90 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestUncovered(t *testing.T) {
+	const src = `package p
+
+type Node interface{ node() }
+
+type Expr interface {
+	Node
+	expr()
+}
+
+type (
+	File  struct{}
+	Ident struct{}
+	Call  struct{}
+	Lit   int
+)
+
+func (*File) node()  {}
+func (*Ident) node() {}
+func (*Ident) expr() {}
+func (*Call) node()  {}
+func (*Call) expr()  {}
+func (Lit) node()    {}
+func (Lit) expr()    {}
+
+func walk(n Node) {
+	switch n := n.(type) {
+	case *Ident, nil:
+	case Expr:
+		_ = n
+	}
+	switch n.(type) {
+	case *File, Lit:
+	default:
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := pkg.Scope().Lookup("Node").Type().Underlying().(*types.Interface)
+	impls := typeutil.Implementations(node, pkg)
+	if got, want := fmt.Sprint(impls), "[*p.Call *p.File *p.Ident p.Lit *p.Lit]"; got != want {
+		t.Errorf("Implementations(Node) = %s, want %s", got, want)
+	}
+
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sw, ok := n.(*ast.TypeSwitchStmt); ok {
+			got = append(got, fmt.Sprint(typeutil.Uncovered(info, sw, impls)))
+		}
+		return true
+	})
+	want := []string{
+		"[*p.File]",
+		"[*p.Call *p.Ident *p.Lit]",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Uncovered: got %v, want %v", got, want)
+	}
+}
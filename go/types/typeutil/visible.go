@@ -0,0 +1,98 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines utilities for computing the objects visible at a
+// source position, for use by completion and similar tools.
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types"
+)
+
+// A VisibleScope lists the objects provided by a single scope that
+// are visible at a position.
+type VisibleScope struct {
+	Scope   *types.Scope
+	Objects []VisibleObject // sorted by name
+}
+
+// A VisibleObject is an object visible at a position.
+// It is shadowed if a nearer scope declares an object of the same name.
+type VisibleObject struct {
+	Obj      types.Object
+	Shadowed bool
+}
+
+// Visible returns the objects visible at position pos within file f of
+// package pkg, grouped by the scope providing them. The innermost scope
+// appears first; the Universe scope appears last.
+//
+// Objects declared in function-local scopes whose scope begins after
+// pos are omitted: the scope of a local constant or variable begins at
+// the end of its declaration, so in x := x the x on the right is the
+// outer one.  Objects hidden by a declaration of the same name in a
+// nearer scope are included but marked as shadowed.
+//
+func Visible(pkg *types.Package, f *ast.File, pos token.Pos) []VisibleScope {
+	var result []VisibleScope
+	seen := make(map[string]bool)
+	for s := pkg.Scope().Innermost(pos); s != nil; s = s.Parent() {
+		// Package-level and file-level declarations are visible
+		// throughout; local declarations only once their scope begins.
+		local := s != types.Universe && s != pkg.Scope() && s.Parent() != pkg.Scope()
+		vs := VisibleScope{Scope: s}
+		for _, name := range s.Names() {
+			obj := s.Lookup(name)
+			if local && scopeStart(f, obj) > pos {
+				continue
+			}
+			vs.Objects = append(vs.Objects, VisibleObject{obj, seen[name]})
+			seen[name] = true
+		}
+		result = append(result, vs)
+	}
+	return result
+}
+
+// scopeStart returns the position at which the scope of obj, a local
+// object declared in file f, begins: the end of the specification or
+// short variable declaration that declares it, the body of a range
+// statement that declares it, or otherwise its own position.
+func scopeStart(f *ast.File, obj types.Object) token.Pos {
+	path, _ := astutil.PathEnclosingInterval(f, obj.Pos(), obj.Pos())
+	if len(path) < 2 {
+		return obj.Pos()
+	}
+	switch n := path[1].(type) {
+	case *ast.ValueSpec:
+		return n.End()
+	case *ast.AssignStmt:
+		return n.End()
+	case *ast.RangeStmt:
+		return n.Body.Pos()
+	}
+	return obj.Pos()
+}
+
+// ScopeNodes returns the inverse of info.Scopes: the syntax node that
+// defines each scope recorded there, such as the *ast.File of a file
+// scope or the *ast.BlockStmt of a block.  The node of the scope of a
+// function is its *ast.FuncType.  Scopes are linked to their children
+// by Scope.Child, so clients may walk the tree of scopes, downward from
+// a package scope, and find the node of each.
+//
+// Precondition: info.Scopes is populated.
+//
+func ScopeNodes(info *types.Info) map[*types.Scope]ast.Node {
+	nodes := make(map[*types.Scope]ast.Node, len(info.Scopes))
+	for n, s := range info.Scopes {
+		nodes[s] = n
+	}
+	return nodes
+}
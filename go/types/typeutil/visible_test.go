@@ -0,0 +1,160 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestVisible(t *testing.T) {
+	const src = `package p
+
+var x, y int
+
+func f(x string) {
+	z := 1
+	{
+		var y bool
+		_ = y /*here*/
+	}
+	w := 2
+	_, _ = z, w
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := f.Pos() + token.Pos(strings.Index(src, "/*here*/"))
+
+	var got []string
+	for _, vs := range typeutil.Visible(pkg, f, pos) {
+		if vs.Scope == types.Universe {
+			continue
+		}
+		var names []string
+		for _, v := range vs.Objects {
+			name := v.Obj.Name()
+			if v.Shadowed {
+				name += "(shadowed)"
+			}
+			names = append(names, name)
+		}
+		got = append(got, fmt.Sprint(names))
+	}
+	want := []string{
+		"[y]",                         // block
+		"[x z]",                       // function (w is declared later)
+		"[]",                          // file
+		"[f x(shadowed) y(shadowed)]", // package
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestVisibleRedeclared checks that a local variable is not visible
+// within its own declaration.
+func TestVisibleRedeclared(t *testing.T) {
+	const src = `package p
+
+func f(x int) {
+	{
+		x := x /*short*/
+		_ = x
+	}
+	{
+		var x = x /*var*/
+		_ = x
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	param := pkg.Scope().Lookup("f").Type().(*types.Signature).Params().At(0)
+
+	for _, marker := range []string{" /*short*/", " /*var*/"} {
+		pos := f.Pos() + token.Pos(strings.Index(src, marker)) - 1 // the right-hand x
+		var x *typeutil.VisibleObject
+		for _, vs := range typeutil.Visible(pkg, f, pos) {
+			for i, v := range vs.Objects {
+				if v.Obj.Name() == "x" {
+					x = &vs.Objects[i]
+					break
+				}
+			}
+			if x != nil {
+				break
+			}
+		}
+		if x == nil || x.Obj != param || x.Shadowed {
+			t.Errorf("%s: got visible x %v, want the unshadowed parameter", marker, x)
+		}
+	}
+}
+
+func TestScopeNodes(t *testing.T) {
+	const src = `package p
+
+func f(x int) {
+	if y := x; y > 0 {
+		_ = func() {}
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Scopes: make(map[ast.Node]*types.Scope)}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Walk the scope tree downward from the package scope.
+	nodes := typeutil.ScopeNodes(info)
+	var got []string
+	var walk func(s *types.Scope, depth int)
+	walk = func(s *types.Scope, depth int) {
+		for i := 0; i < s.NumChildren(); i++ {
+			child := s.Child(i)
+			got = append(got, fmt.Sprintf("%s%T %v", strings.Repeat(". ", depth), nodes[child], child.Names()))
+			walk(child, depth+1)
+		}
+	}
+	walk(pkg.Scope(), 0)
+	want := []string{
+		"*ast.File []",
+		". *ast.FuncType [x]",
+		". . *ast.IfStmt [y]",
+		". . . *ast.BlockStmt []",
+		". . . . *ast.FuncType []",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got scope tree:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
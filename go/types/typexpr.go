@@ -28,7 +28,12 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 		if e.Name == "_" {
 			check.errorf(e.Pos(), "cannot use _ as value or type")
 		} else {
-			check.errorf(e.Pos(), "undeclared name: %s", e.Name)
+			check.report(Error{
+				Fset:        check.fset,
+				Pos:         e.Pos(),
+				Msg:         "undeclared name: " + e.Name,
+				Suggestions: check.suggestNames(e.Name),
+			})
 		}
 		return
 	}
@@ -43,7 +48,7 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 	// (This code is only needed for dot-imports. Without them,
 	// we only have to mark variables, see *Var case below).
 	if pkg := obj.Pkg(); pkg != check.pkg && pkg != nil {
-		delete(check.unusedDotImports[scope], pkg)
+		check.useDotImport(scope, pkg)
 	}
 
 	switch obj := obj.(type) {
@@ -87,7 +92,10 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 		}
 
 	case *Var:
-		if obj.pkg == check.pkg {
+		// Package-level variables need not be used, and
+		// are shared by concurrently checked function bodies:
+		// only local variables are marked.
+		if obj.pkg == check.pkg && obj.parent != check.pkg.scope {
 			obj.used = true
 		}
 		check.addDeclDep(obj)
@@ -142,8 +150,10 @@ func (check *Checker) typ(e ast.Expr) Type {
 }
 
 // funcType type-checks a function or method type and returns its signature.
-func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast.FuncType) *Signature {
-	scope := NewScope(check.scope, "function")
+// The comment describes the scope of its parameters, which is also that of
+// the function body, if any.
+func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast.FuncType, comment string) *Signature {
+	scope := NewScope(check.scope, ftyp.Pos(), ftyp.End(), comment)
 	check.recordScope(ftyp, scope)
 
 	recvList, _ := check.collectParams(scope, recvPar, false)
@@ -203,6 +213,7 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 	sig.params = NewTuple(params...)
 	sig.results = NewTuple(results...)
 	sig.variadic = variadic
+	sig.markParams()
 
 	return sig
 }
@@ -214,6 +225,7 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 	switch e := e.(type) {
 	case *ast.BadExpr:
 		// ignore - error reported before
+		check.recordSkipped(e)
 
 	case *ast.Ident:
 		var x operand
@@ -282,7 +294,7 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 	case *ast.FuncType:
 		typ := new(Signature)
 		def.setUnderlying(typ)
-		check.funcType(typ, nil, e)
+		check.funcType(typ, nil, e, "function type")
 		return typ
 
 	case *ast.InterfaceType:
@@ -415,7 +427,7 @@ func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicO
 					check.invalidAST(name.Pos(), "anonymous parameter")
 					// ok to continue
 				}
-				par := NewParam(name.Pos(), check.pkg, name.Name, typ)
+				par := NewParam(name.Pos(), check.pkg, check.intern(name.Name), typ)
 				check.declare(scope, name, par)
 				params = append(params, par)
 			}
@@ -500,7 +512,8 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 			// also the T4 and T5 tests in testdata/cycles2.src.
 			sig := new(Signature)
 			sig.recv = NewVar(pos, check.pkg, "", recvTyp)
-			m := NewFunc(pos, check.pkg, name.Name, sig)
+			sig.recv.isParam = true
+			m := NewFunc(pos, check.pkg, check.intern(name.Name), sig)
 			if check.declareInSet(&mset, pos, m) {
 				iface.methods = append(iface.methods, m)
 				iface.allMethods = append(iface.allMethods, m)
@@ -639,7 +652,7 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType, path []*TypeNa
 		}
 
 		name := ident.Name
-		fld := NewField(pos, check.pkg, name, typ, anonymous != nil)
+		fld := NewField(pos, check.pkg, check.intern(name), typ, anonymous != nil)
 		// spec: "Within a struct, non-blank field names must be unique."
 		if name == "_" || check.declareInSet(&fset, pos, fld) {
 			fields = append(fields, fld)
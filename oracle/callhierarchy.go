@@ -0,0 +1,313 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oracle
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/oracle/serial"
+)
+
+// callHierarchy reports one level of the call hierarchy of the
+// selected function, or of the function declaration enclosing the
+// query position: its incoming calls, grouped by the functions that
+// make them, and its outgoing calls, grouped by the functions they
+// call.  Clients expand the hierarchy lazily by querying at the
+// position of a caller or callee.
+//
+// Calls are resolved by the type checker, as references to functions
+// and methods within the loaded program, so a call of an interface
+// method calls only the abstract method, unless q.CallGraph is "cha",
+// in which case it also calls the concrete methods of the types that
+// implement the interface (class hierarchy analysis).
+//
+func callHierarchy(q *Query) error {
+	var cha bool
+	switch q.CallGraph {
+	case "":
+	case "cha":
+		cha = true
+	default:
+		return fmt.Errorf("invalid call graph algorithm: %q", q.CallGraph)
+	}
+
+	lprog, err := loadQueryPackage(q)
+	if err != nil {
+		return err
+	}
+	q.Fset = lprog.Fset
+
+	qpos, err := parseQueryPos(lprog, q.Pos, false)
+	if err != nil {
+		return err
+	}
+
+	// Find the selected function.
+	var fn *types.Func
+	if id, ok := qpos.path[0].(*ast.Ident); ok {
+		fn, _ = qpos.info.ObjectOf(id).(*types.Func)
+	}
+	if fn == nil {
+		for _, n := range qpos.path {
+			if decl, ok := n.(*ast.FuncDecl); ok {
+				fn, _ = qpos.info.Defs[decl.Name].(*types.Func)
+				break
+			}
+		}
+	}
+	if fn == nil {
+		return fmt.Errorf("no function here")
+	}
+
+	r := &callHierarchyResult{fn: fn}
+	var impls *implementations
+	if cha {
+		impls = newImplementations(lprog)
+	}
+	incoming := make(map[*types.Func]*callHierarchyItem)
+	outgoing := make(map[*types.Func]*callHierarchyItem)
+	var inits map[*types.Package]*callHierarchyItem // incoming calls by package initializers
+	for _, site := range callSites(lprog) {
+		callees := []*types.Func{site.callee}
+		if impls != nil {
+			callees = append(callees, impls.methods(site.callee)...)
+		}
+		for _, callee := range callees {
+			if callee == fn {
+				var item *callHierarchyItem
+				if site.caller == nil {
+					if inits == nil {
+						inits = make(map[*types.Package]*callHierarchyItem)
+					}
+					item = inits[site.pkg]
+					if item == nil {
+						item = &callHierarchyItem{pkg: site.pkg}
+						inits[site.pkg] = item
+						r.incoming = append(r.incoming, item)
+					}
+				} else {
+					item = incoming[site.caller]
+					if item == nil {
+						item = &callHierarchyItem{fn: site.caller, pkg: site.pkg}
+						incoming[site.caller] = item
+						r.incoming = append(r.incoming, item)
+					}
+				}
+				item.calls = append(item.calls, site.call)
+			}
+			if site.caller == fn {
+				item := outgoing[callee]
+				if item == nil {
+					item = &callHierarchyItem{fn: callee, pkg: callee.Pkg()}
+					outgoing[callee] = item
+					r.outgoing = append(r.outgoing, item)
+				}
+				item.calls = append(item.calls, site.call)
+			}
+		}
+	}
+	sort.Sort(byFirstCall{q.Fset, r.incoming})
+	sort.Sort(byFirstCall{q.Fset, r.outgoing})
+
+	q.result = r
+	return nil
+}
+
+// A callSite is a call of a function or method, the callee, within the
+// declaration of another, the caller, or within the initializer of a
+// package-level variable, if caller is nil.
+type callSite struct {
+	pkg    *types.Package // the package of the call
+	caller *types.Func
+	callee *types.Func
+	call   *ast.CallExpr
+}
+
+// callSites returns the calls of functions and methods within the
+// packages of lprog, as resolved by the type checker: those whose
+// operand is an identifier or selector that refers to a function.
+// Calls of function values and function literals are omitted.
+func callSites(lprog *loader.Program) []callSite {
+	var sites []callSite
+	for _, info := range lprog.AllPackages {
+		for _, f := range info.Files {
+			for _, decl := range f.Decls {
+				var caller *types.Func
+				if decl, ok := decl.(*ast.FuncDecl); ok {
+					caller, _ = info.Defs[decl.Name].(*types.Func)
+				}
+				ast.Inspect(decl, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					var id *ast.Ident
+					switch fun := unparen(call.Fun).(type) {
+					case *ast.Ident:
+						id = fun
+					case *ast.SelectorExpr:
+						id = fun.Sel
+					}
+					if id != nil {
+						if callee, ok := info.Uses[id].(*types.Func); ok {
+							sites = append(sites, callSite{info.Pkg, caller, callee, call})
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+	return sites
+}
+
+// implementations records, for class hierarchy analysis, the concrete
+// methods of the named types of a program that implement its abstract
+// methods.
+type implementations struct {
+	named []types.Type                  // the non-interface named types of the program
+	cache map[*types.Func][]*types.Func // by abstract method
+}
+
+func newImplementations(lprog *loader.Program) *implementations {
+	impls := &implementations{cache: make(map[*types.Func][]*types.Func)}
+	for _, info := range lprog.AllPackages {
+		for _, obj := range info.Defs {
+			if obj, ok := obj.(*types.TypeName); ok && !isInterface(obj.Type()) {
+				impls.named = append(impls.named, obj.Type())
+			}
+		}
+	}
+	return impls
+}
+
+// methods returns the concrete methods that implement m, if it is an
+// abstract method, in no particular order.
+func (impls *implementations) methods(m *types.Func) []*types.Func {
+	recv := m.Type().(*types.Signature).Recv()
+	if recv == nil || !isInterface(recv.Type()) {
+		return nil
+	}
+	if methods, ok := impls.cache[m]; ok {
+		return methods
+	}
+	iface := recv.Type().Underlying().(*types.Interface)
+	var methods []*types.Func
+	for _, T := range impls.named {
+		if !types.Implements(T, iface) {
+			if T = types.NewPointer(T); !types.Implements(T, iface) {
+				continue
+			}
+		}
+		obj, _, _ := types.LookupFieldOrMethod(T, false, m.Pkg(), m.Name())
+		if concrete, ok := obj.(*types.Func); ok {
+			methods = append(methods, concrete)
+		}
+	}
+	impls.cache[m] = methods
+	return methods
+}
+
+type callHierarchyResult struct {
+	fn       *types.Func          // the selected function
+	incoming []*callHierarchyItem // by caller
+	outgoing []*callHierarchyItem // by callee
+}
+
+// A callHierarchyItem is a caller or callee of the selected function,
+// with the calls it makes of it, or that it makes of the callee.
+type callHierarchyItem struct {
+	fn    *types.Func    // nil for the initializer of package pkg
+	pkg   *types.Package // the package of the function
+	calls []*ast.CallExpr
+}
+
+func (item *callHierarchyItem) name() string {
+	if item.fn == nil {
+		return item.pkg.Path() + ".init"
+	}
+	return item.fn.FullName()
+}
+
+func (r *callHierarchyResult) display(printf printfFunc) {
+	if len(r.incoming) == 0 {
+		printf(r.fn, "%s is not called.", r.fn.FullName())
+	} else {
+		printf(r.fn, "%s is called by %s:", r.fn.FullName(), functions(len(r.incoming)))
+		for _, item := range r.incoming {
+			printf(item.calls[0].Lparen, "\tfrom %s%s", item.name(), numCalls(len(item.calls)))
+		}
+	}
+	if len(r.outgoing) == 0 {
+		printf(r.fn, "%s calls no functions.", r.fn.FullName())
+	} else {
+		printf(r.fn, "%s calls %s:", r.fn.FullName(), functions(len(r.outgoing)))
+		for _, item := range r.outgoing {
+			printf(item.calls[0].Lparen, "\tto %s%s", item.name(), numCalls(len(item.calls)))
+		}
+	}
+}
+
+// functions returns "1 function" or "n functions".
+func functions(n int) string {
+	if n == 1 {
+		return "1 function"
+	}
+	return fmt.Sprintf("%d functions", n)
+}
+
+// numCalls returns the number of calls, if more than one, for display
+// after the caller or callee that makes them.
+func numCalls(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d calls)", n)
+}
+
+func (r *callHierarchyResult) toSerial(res *serial.Result, fset *token.FileSet) {
+	items := func(items []*callHierarchyItem) []*serial.CallHierarchyItem {
+		var result []*serial.CallHierarchyItem
+		for _, item := range items {
+			sitem := &serial.CallHierarchyItem{Name: item.name()}
+			if item.fn != nil && item.fn.Pos().IsValid() {
+				posn := fset.Position(item.fn.Pos())
+				sitem.Pos = posn.String()
+				sitem.Query = fmt.Sprintf("%s:#%d", posn.Filename, posn.Offset)
+			}
+			for _, call := range item.calls {
+				sitem.Calls = append(sitem.Calls, fset.Position(call.Lparen).String())
+			}
+			result = append(result, sitem)
+		}
+		return result
+	}
+	res.CallHierarchy = &serial.CallHierarchy{
+		Pos:      fset.Position(r.fn.Pos()).String(),
+		Name:     r.fn.FullName(),
+		Incoming: items(r.incoming),
+		Outgoing: items(r.outgoing),
+	}
+}
+
+type byFirstCall struct {
+	fset  *token.FileSet
+	items []*callHierarchyItem
+}
+
+func (b byFirstCall) Len() int      { return len(b.items) }
+func (b byFirstCall) Swap(i, j int) { b.items[i], b.items[j] = b.items[j], b.items[i] }
+func (b byFirstCall) Less(i, j int) bool {
+	x, y := b.items[i].calls[0].Lparen, b.items[j].calls[0].Lparen
+	if x == y {
+		return b.items[i].name() < b.items[j].name() // the callees of a dynamic call
+	}
+	return lessPos(b.fset, x, y)
+}
@@ -0,0 +1,174 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oracle
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+	"golang.org/x/tools/oracle/serial"
+)
+
+// completion reports the candidates for completing the identifier
+// before the query position: the fields and methods of the operand, or
+// the members of the package, if the identifier is the selector of a
+// selector expression, and otherwise the objects visible at the query
+// position.  Only the candidates that begin with the part of the
+// identifier before the query position are reported.
+//
+func completion(q *Query) error {
+	lprog, err := loadQueryPackage(q)
+	if err != nil {
+		return err
+	}
+	q.Fset = lprog.Fset
+
+	qpos, err := parseQueryPos(lprog, q.Pos, false)
+	if err != nil {
+		return err
+	}
+
+	// The prefix of the identifier to complete, if any.
+	var prefix string
+	id, _ := qpos.path[0].(*ast.Ident)
+	if id != nil && qpos.start > id.Pos() {
+		prefix = id.Name
+		if n := int(qpos.start - id.Pos()); n < len(prefix) {
+			prefix = prefix[:n]
+		}
+	}
+
+	var candidates []types.Object
+	if sel, ok := qpos.path[1].(*ast.SelectorExpr); ok && id != nil && sel.Sel == id { // (an Ident is never the root of path)
+		candidates = selectorCandidates(qpos.info, sel.X)
+	} else {
+		file := qpos.path[len(qpos.path)-1].(*ast.File)
+		for _, vs := range typeutil.Visible(qpos.info.Pkg, file, qpos.start) {
+			for _, vo := range vs.Objects {
+				if !vo.Shadowed {
+					candidates = append(candidates, vo.Obj)
+				}
+			}
+		}
+	}
+
+	var objs []types.Object
+	for _, obj := range candidates {
+		if strings.HasPrefix(obj.Name(), prefix) && obj.Name() != "_" {
+			objs = append(objs, obj)
+		}
+	}
+	sort.Sort(byName(objs))
+
+	q.result = &completionResult{
+		qpos:       qpos,
+		prefix:     prefix,
+		candidates: objs,
+	}
+	return nil
+}
+
+// selectorCandidates returns the members of the package denoted by x,
+// or the fields and methods of the type of x, accessible from the
+// package of info.
+func selectorCandidates(info *loader.PackageInfo, x ast.Expr) []types.Object {
+	pkg := info.Pkg
+	var objs []types.Object
+	if id, ok := unparen(x).(*ast.Ident); ok {
+		if pkgName, ok := info.Uses[id].(*types.PkgName); ok {
+			scope := pkgName.Imported().Scope()
+			for _, name := range scope.Names() {
+				if obj := scope.Lookup(name); obj.Exported() {
+					objs = append(objs, obj)
+				}
+			}
+			return objs
+		}
+	}
+
+	T := info.TypeOf(x)
+	if T == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, name := range fieldNames(deref(T).Underlying(), make(map[*types.Struct]bool)) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		// LookupFieldOrMethod discards ambiguous and shadowed fields.
+		if obj, _, _ := types.LookupFieldOrMethod(T, true, pkg, name); obj != nil {
+			if _, ok := obj.(*types.Var); ok && isAccessibleFrom(obj, pkg) {
+				objs = append(objs, obj)
+			}
+		}
+	}
+	for _, meth := range accessibleMethods(deref(T), pkg) {
+		objs = append(objs, meth.Obj())
+	}
+	return objs
+}
+
+// fieldNames returns the names of the fields of T, if it is a struct,
+// including the promoted ones.
+func fieldNames(T types.Type, visited map[*types.Struct]bool) []string {
+	st, ok := T.(*types.Struct)
+	if !ok || visited[st] {
+		return nil
+	}
+	visited[st] = true
+	var names []string
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		names = append(names, f.Name())
+		if f.Anonymous() {
+			names = append(names, fieldNames(deref(f.Type()).Underlying(), visited)...)
+		}
+	}
+	return names
+}
+
+type byName []types.Object
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Less(i, j int) bool { return b[i].Name() < b[j].Name() }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+type completionResult struct {
+	qpos       *queryPos
+	prefix     string
+	candidates []types.Object
+}
+
+func (r *completionResult) display(printf printfFunc) {
+	if len(r.candidates) == 0 {
+		printf(r.qpos, "no completions of %q", r.prefix)
+		return
+	}
+	printf(r.qpos, "%d completions of %q:", len(r.candidates), r.prefix)
+	for _, obj := range r.candidates {
+		printf(obj, "\t%s", r.qpos.objectString(obj))
+	}
+}
+
+func (r *completionResult) toSerial(res *serial.Result, fset *token.FileSet) {
+	completion := &serial.Completion{
+		Pos:    fset.Position(r.qpos.start).String(),
+		Prefix: r.prefix,
+	}
+	for _, obj := range r.candidates {
+		completion.Candidates = append(completion.Candidates, &serial.CompletionCandidate{
+			Name: obj.Name(),
+			Kind: obj.Kind().String(),
+			Desc: r.qpos.objectString(obj),
+		})
+	}
+	res.Completion = completion
+}
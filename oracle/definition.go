@@ -9,7 +9,6 @@ import (
 	"go/ast"
 	"go/token"
 
-	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/go/types"
 	"golang.org/x/tools/oracle/serial"
 )
@@ -20,15 +19,7 @@ import (
 // resolution might be enough; we should start with that.
 //
 func definition(q *Query) error {
-	lconf := loader.Config{Build: q.Build}
-	allowErrors(&lconf)
-
-	if err := importQueryPackage(q.Pos, &lconf); err != nil {
-		return err
-	}
-
-	// Load/parse/type-check the program.
-	lprog, err := lconf.Load()
+	lprog, err := loadQueryPackage(q)
 	if err != nil {
 		return err
 	}
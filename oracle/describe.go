@@ -28,15 +28,7 @@ import (
 // - its type and method set (for an expression or type expression)
 //
 func describe(q *Query) error {
-	lconf := loader.Config{Build: q.Build}
-	allowErrors(&lconf)
-
-	if err := importQueryPackage(q.Pos, &lconf); err != nil {
-		return err
-	}
-
-	// Load/parse/type-check the program.
-	lprog, err := lconf.Load()
+	lprog, err := loadQueryPackage(q)
 	if err != nil {
 		return err
 	}
@@ -11,7 +11,6 @@ import (
 	"go/token"
 	"sort"
 
-	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/go/types"
 	"golang.org/x/tools/oracle/serial"
 )
@@ -30,15 +29,7 @@ import (
 // bands.
 //
 func freevars(q *Query) error {
-	lconf := loader.Config{Build: q.Build}
-	allowErrors(&lconf)
-
-	if err := importQueryPackage(q.Pos, &lconf); err != nil {
-		return err
-	}
-
-	// Load/parse/type-check the program.
-	lprog, err := lconf.Load()
+	lprog, err := loadQueryPackage(q)
 	if err != nil {
 		return err
 	}
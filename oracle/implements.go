@@ -12,7 +12,6 @@ import (
 	"sort"
 	"strings"
 
-	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/go/types"
 	"golang.org/x/tools/oracle/serial"
 )
@@ -24,15 +23,7 @@ import (
 // by an implements query on the receiver type.
 //
 func implements(q *Query) error {
-	lconf := loader.Config{Build: q.Build}
-	allowErrors(&lconf)
-
-	if err := importQueryPackage(q.Pos, &lconf); err != nil {
-		return err
-	}
-
-	// Load/parse/type-check the program.
-	lprog, err := lconf.Load()
+	lprog, err := loadQueryPackage(q)
 	if err != nil {
 		return err
 	}
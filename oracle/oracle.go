@@ -81,9 +81,15 @@ type Query struct {
 	PTALog     io.Writer // (optional) pointer-analysis log file
 	Reflection bool      // model reflection soundly (currently slow).
 
+	// CallGraph is the algorithm by which callhierarchy queries
+	// resolve calls of interface methods: "" for none, or "cha".
+	CallGraph string
+
 	// Populated during Run()
 	Fset   *token.FileSet
 	result queryResult
+
+	lprog *loader.Program // the program of the Server answering the query, if any
 }
 
 // Serial returns an instance of serial.Result, which implements the
@@ -113,6 +119,10 @@ func Run(q *Query) error {
 		return callers(q)
 	case "callstack":
 		return callstack(q)
+	case "callhierarchy":
+		return callHierarchy(q)
+	case "completion":
+		return completion(q)
 	case "peers":
 		return peers(q)
 	case "pointsto":
@@ -187,6 +197,24 @@ func setupPTA(prog *ssa.Program, lprog *loader.Program, ptaLog io.Writer, reflec
 	}, nil
 }
 
+// loadQueryPackage loads the package containing the query position,
+// or, if the query is answered by a Server, returns its program.
+func loadQueryPackage(q *Query) (*loader.Program, error) {
+	if q.lprog != nil {
+		return q.lprog, nil
+	}
+
+	lconf := loader.Config{Build: q.Build}
+	allowErrors(&lconf)
+
+	if err := importQueryPackage(q.Pos, &lconf); err != nil {
+		return nil, err
+	}
+
+	// Load/parse/type-check the program.
+	return lconf.Load()
+}
+
 // importQueryPackage finds the package P containing the
 // query position and tells conf to import it.
 func importQueryPackage(pos string, conf *loader.Config) error {
@@ -38,13 +38,16 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
 	"testing"
 
+	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/oracle"
+	"golang.org/x/tools/oracle/serial"
 )
 
 var updateFlag = flag.Bool("update", false, "Update the golden files.")
@@ -203,6 +206,7 @@ func TestOracle(t *testing.T) {
 	}
 
 	for _, filename := range []string{
+		"testdata/src/callhierarchy/main.go",
 		"testdata/src/calls/main.go",
 		"testdata/src/describe/main.go",
 		"testdata/src/freevars/main.go",
@@ -268,3 +272,216 @@ func TestOracle(t *testing.T) {
 		}
 	}
 }
+
+func TestServer(t *testing.T) {
+	const filename = "testdata/src/server/main.go"
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pos returns the query position at the end of the first
+	// occurrence of substr.
+	pos := func(substr string) string {
+		return fmt.Sprintf("%s:#%d", filename, bytes.Index(src, []byte(substr))+len(substr))
+	}
+
+	conf := loader.Config{Build: &build.Default}
+	conf.CreateFromFilenames("server", filename)
+	server, err := oracle.NewServer(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var in bytes.Buffer
+	enc := json.NewEncoder(&in)
+	for i, req := range []serial.Request{
+		{Mode: "completion", Pos: pos("t.Gr")},
+		{Mode: "definition", Pos: pos("return t.Gr")},
+		{Mode: "referrers", Pos: pos("t.Na")},
+		{Mode: "callers", Pos: pos("t.Gr")},
+	} {
+		req.ID = i + 1
+		enc.Encode(&req)
+	}
+	var out bytes.Buffer
+	if err := server.Serve(&in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(&out)
+	var got []string
+	for {
+		var resp serial.Response
+		if err := dec.Decode(&resp); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		r := resp.Result
+		switch {
+		case resp.Diagnostics != nil:
+			// ignore
+		case resp.Error != "":
+			got = append(got, fmt.Sprintf("%d: error: %s", resp.ID, resp.Error))
+		case r.Completion != nil:
+			var names []string
+			for _, c := range r.Completion.Candidates {
+				names = append(names, c.Name)
+			}
+			got = append(got, fmt.Sprintf("%d: %q: %s", resp.ID, r.Completion.Prefix, strings.Join(names, " ")))
+		case r.Definition != nil:
+			got = append(got, fmt.Sprintf("%d: %s", resp.ID, r.Definition.Desc))
+		case r.Referrers != nil:
+			got = append(got, fmt.Sprintf("%d: %s: %d refs", resp.ID, r.Referrers.Desc, len(r.Referrers.Refs)))
+		}
+	}
+	want := []string{
+		`1: "Gr": Greet Grow`,
+		`2: func (*server.T).Greet() string`,
+		`3: field Name string: 1 refs`,
+		`4: error: invalid server mode: "callers"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got responses:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestServerInvalidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := dir + "/p.go"
+	write := func(src string) {
+		if err := ioutil.WriteFile(filename, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// describe describes the identifier x.
+	describe := func(server *oracle.Server, src string) string {
+		q, err := server.Query("describe", fmt.Sprintf("%s:#%d", filename, strings.Index(src, "x")))
+		if err != nil {
+			return err.Error()
+		}
+		return q.Serial().Describe.Value.Type
+	}
+
+	const src1 = `package p; var x int`
+	write(src1)
+	conf := loader.Config{Build: &build.Default}
+	conf.CreateFromFilenames("p", filename)
+	server, err := oracle.NewServer(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := describe(server, src1); got != "int" {
+		t.Errorf("describe x: got %s, want int", got)
+	}
+
+	const src2 = `package p; const c = ""; var x = c`
+	write(src2)
+	if err := server.Invalidate(filename); err != nil {
+		t.Fatal(err)
+	}
+	if got := describe(server, src2); got != "string" {
+		t.Errorf("describe x after Invalidate: got %s, want string", got)
+	}
+}
+
+func TestServerDiagnostics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := dir + "/p.go"
+	write := func(src string) {
+		if err := ioutil.WriteFile(filename, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("package p\nvar x int = \"\"\n")
+	conf := loader.Config{Build: &build.Default}
+	conf.CreateFromFilenames("p", filename)
+	server, err := oracle.NewServer(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fix the error, and notify the server.
+	write("package p\nvar x int\n")
+	in := strings.NewReader(fmt.Sprintf(`{"id": 1, "mode": "invalidate", "files": [%q]}`, filename))
+	var out bytes.Buffer
+	if err := server.Serve(in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(&out)
+	var got []string
+	for {
+		var resp serial.Response
+		if err := dec.Decode(&resp); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if d := resp.Diagnostics; d != nil {
+			var msgs []string
+			for _, diag := range d.Diagnostics {
+				msgs = append(msgs, strings.TrimPrefix(diag.Pos, filename)+": "+diag.Msg)
+			}
+			got = append(got, fmt.Sprintf("%s %s v%d: [%s]", d.Package, filepath.Base(d.File), d.Version, strings.Join(msgs, "; ")))
+		} else {
+			got = append(got, fmt.Sprintf("response %d: %q", resp.ID, resp.Error))
+		}
+	}
+	want := []string{
+		`p p.go v1: [:2:13: cannot convert "" (untyped string constant) to int]`,
+		`p p.go v2: []`,
+		`response 1: ""`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got stream:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCallHierarchyCHA(t *testing.T) {
+	const filename = "testdata/src/callhierarchy/main.go"
+	var got bytes.Buffer
+	for _, q := range parseQueries(t, filename) {
+		if q.id != "ch-concrete" && q.id != "ch-enclosing" {
+			continue
+		}
+		buildContext := build.Default
+		buildContext.GOPATH = "testdata"
+		query := oracle.Query{
+			Mode:      q.verb,
+			Pos:       q.queryPos,
+			Build:     &buildContext,
+			CallGraph: "cha",
+		}
+		if err := oracle.Run(&query); err != nil {
+			t.Fatal(err)
+		}
+		WriteResult(&got, &query)
+	}
+	want := `callhierarchy.total is called by 2 functions:
+	from callhierarchy.init
+	from callhierarchy.main (2 calls)
+callhierarchy.total calls 3 functions:
+	to (*callhierarchy.Rect).Area
+	to (callhierarchy.Shape).Area
+	to (callhierarchy.Square).Area
+
+(callhierarchy.Square).Area is called by 2 functions:
+	from callhierarchy.total
+	from callhierarchy.area
+(callhierarchy.Square).Area calls no functions.
+
+`
+	if got.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got.String(), want)
+	}
+}
@@ -68,12 +68,13 @@ func parsePosFlag(posFlag string) (filename string, startOffset, endOffset int,
 // of bounds.
 //
 func findQueryPos(fset *token.FileSet, filename string, startOffset, endOffset int) (start, end token.Pos, err error) {
+	// A Server's file set may contain older versions of
+	// reloaded files; use the most recently added one.
 	var file *token.File
 	fset.Iterate(func(f *token.File) bool {
 		if sameFile(filename, f.Name()) {
 			// (f.Name() is absolute)
 			file = f
-			return false // done
 		}
 		return true // continue
 	})
@@ -27,20 +27,25 @@ func referrers(q *Query) error {
 	lconf := loader.Config{Build: q.Build}
 	allowErrors(&lconf)
 
-	if err := importQueryPackage(q.Pos, &lconf); err != nil {
-		return err
+	// A Server's program is the scope of the query.
+	if q.lprog == nil {
+		if err := importQueryPackage(q.Pos, &lconf); err != nil {
+			return err
+		}
 	}
 
 	var id *ast.Ident
 	var obj types.Object
-	var lprog *loader.Program
-	var pass2 bool
+	lprog := q.lprog
+	pass2 := lprog != nil
 	for {
 		// Load/parse/type-check the program.
-		var err error
-		lprog, err = lconf.Load()
-		if err != nil {
-			return err
+		if q.lprog == nil {
+			var err error
+			lprog, err = lconf.Load()
+			if err != nil {
+				return err
+			}
 		}
 		q.Fset = lprog.Fset
 
@@ -218,6 +218,40 @@ type Describe struct {
 	Value   *DescribeValue   `json:"value,omitempty"`
 }
 
+// A CallHierarchy is the result of a 'callhierarchy' query.
+// It is one level of the call hierarchy of the selected function: the
+// functions that call it and those that it calls, in order of their
+// first calls.  Clients expand the hierarchy by querying at the Query
+// position of a caller or callee.
+type CallHierarchy struct {
+	Pos      string               `json:"pos"`                // location of the selected function
+	Name     string               `json:"name"`               // full name of the selected function
+	Incoming []*CallHierarchyItem `json:"incoming,omitempty"` // functions that call it
+	Outgoing []*CallHierarchyItem `json:"outgoing,omitempty"` // functions that it calls
+}
+
+type CallHierarchyItem struct {
+	Name  string   `json:"name"`            // full name of the function, or "path.init" for a package initializer
+	Pos   string   `json:"pos,omitempty"`   // location of the function
+	Query string   `json:"query,omitempty"` // query position expanding the function, as for the -pos flag
+	Calls []string `json:"calls"`           // locations of the calls
+}
+
+// A Completion is the result of a 'completion' query.
+// It lists the candidates for completing the selected identifier,
+// in order of name.
+type Completion struct {
+	Pos        string                 `json:"pos"`                  // location of the query
+	Prefix     string                 `json:"prefix"`               // the part of the identifier before the query position
+	Candidates []*CompletionCandidate `json:"candidates,omitempty"` // objects whose names begin with the prefix
+}
+
+type CompletionCandidate struct {
+	Name string `json:"name"` // name of the object
+	Kind string `json:"kind"` // kind of the object, such as "func" or "field"
+	Desc string `json:"desc"` // description of the object
+}
+
 // A WhichErrs is the result of a 'whicherrs' query.
 // It contains the position of the queried error and the possible globals,
 // constants, and types it may point to.
@@ -243,16 +277,55 @@ type Result struct {
 
 	// Exactly one of the following fields is populated:
 	// the one specified by 'mode'.
-	Callees    *Callees    `json:"callees,omitempty"`
-	Callers    []Caller    `json:"callers,omitempty"`
-	Callstack  *CallStack  `json:"callstack,omitempty"`
-	Definition *Definition `json:"definition,omitempty"`
-	Describe   *Describe   `json:"describe,omitempty"`
-	Freevars   []*FreeVar  `json:"freevars,omitempty"`
-	Implements *Implements `json:"implements,omitempty"`
-	Peers      *Peers      `json:"peers,omitempty"`
-	PointsTo   []PointsTo  `json:"pointsto,omitempty"`
-	Referrers  *Referrers  `json:"referrers,omitempty"`
-	What       *What       `json:"what,omitempty"`
-	WhichErrs  *WhichErrs  `json:"whicherrs,omitempty"`
+	Callees       *Callees       `json:"callees,omitempty"`
+	Callers       []Caller       `json:"callers,omitempty"`
+	Callstack     *CallStack     `json:"callstack,omitempty"`
+	CallHierarchy *CallHierarchy `json:"callhierarchy,omitempty"`
+	Completion    *Completion    `json:"completion,omitempty"`
+	Definition    *Definition    `json:"definition,omitempty"`
+	Describe      *Describe      `json:"describe,omitempty"`
+	Freevars      []*FreeVar     `json:"freevars,omitempty"`
+	Implements    *Implements    `json:"implements,omitempty"`
+	Peers         *Peers         `json:"peers,omitempty"`
+	PointsTo      []PointsTo     `json:"pointsto,omitempty"`
+	Referrers     *Referrers     `json:"referrers,omitempty"`
+	What          *What          `json:"what,omitempty"`
+	WhichErrs     *WhichErrs     `json:"whicherrs,omitempty"`
+}
+
+// A Request is a query made of an oracle server, or, if its mode is
+// "invalidate", a notification that files have changed.
+type Request struct {
+	ID    int      `json:"id"`              // identifies the response to the request
+	Mode  string   `json:"mode"`            // mode of the query
+	Pos   string   `json:"pos,omitempty"`   // query position, as for the -pos flag
+	Files []string `json:"files,omitempty"` // changed, added, or deleted files, for "invalidate"
+}
+
+// A Response is the reply of an oracle server to the request of the
+// same ID: either the result of the query or the error that prevented
+// it.  A Response with an ID of zero is instead a notification of the
+// diagnostics of a file.
+type Response struct {
+	ID          int          `json:"id"`
+	Result      *Result      `json:"result,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// A Diagnostics holds the diagnostics of a file of a package, as of a
+// version of the program loaded by an oracle server; they replace those
+// of earlier versions.  A File of "" holds the errors of the package
+// that have no position.
+type Diagnostics struct {
+	Package     string        `json:"package"`               // import path of the package
+	File        string        `json:"file"`                  // name of the file
+	Version     int           `json:"version"`               // version of the program
+	Diagnostics []*Diagnostic `json:"diagnostics,omitempty"` // errors in the file, if any
+}
+
+type Diagnostic struct {
+	Pos  string `json:"pos"`            // location of the error, or "-" if none
+	Msg  string `json:"msg"`            // error message
+	Soft bool   `json:"soft,omitempty"` // the error does not prevent analysis, such as an unused variable
 }
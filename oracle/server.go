@@ -0,0 +1,251 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package oracle
+
+// This file defines Server, which answers repeated queries about a
+// program loaded once.
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"go/scanner"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/oracle/serial"
+)
+
+// serverModes is the set of query modes answered by a Server: those
+// that need only type information.
+var serverModes = map[string]bool{
+	"callhierarchy": true,
+	"completion":    true,
+	"definition":    true,
+	"describe":      true,
+	"freevars":      true,
+	"implements":    true,
+	"referrers":     true,
+}
+
+// A Server answers queries about a program that it loads once, so that
+// clients such as editors may make many queries without the costs of
+// starting a process and loading the program for each.
+//
+// A Server answers the callhierarchy, completion, definition, describe,
+// freevars, implements, and referrers queries; the scope of each is the loaded
+// program, whatever the package of the query position.  Clients must
+// call Invalidate when source files change.
+//
+// Each load of the program has a version number, starting at 1, and
+// the diagnostics of the packages loaded by the latest version are
+// available from Diagnostics.
+//
+// Its methods are safe for concurrent use.
+type Server struct {
+	mu      sync.Mutex
+	conf    loader.Config // the configuration, as before the first load
+	lprog   *loader.Program
+	version int                   // the number of loads
+	changed []*loader.PackageInfo // the packages loaded, not reused, by the latest load
+}
+
+// NewServer returns a server for the program loaded by conf, which
+// it loads ignoring type errors.  A nil conf.Build means
+// build.Default, and a nil conf.Cache means a new cache of unbounded
+// capacity, in which the server retains the packages it has loaded.
+func NewServer(conf *loader.Config) (*Server, error) {
+	if conf.Build == nil {
+		conf.Build = &build.Default
+	}
+	if conf.Cache == nil {
+		conf.Cache = loader.NewCache(math.MaxInt32)
+	}
+	allowErrors(conf)
+	s := &Server{conf: *conf}
+	lprog, err := conf.Load()
+	if err != nil {
+		return nil, err
+	}
+	s.setProgram(lprog)
+	return s, nil
+}
+
+// Invalidate brings the program up to date with the specified files,
+// which have been changed, added, or deleted, by reloading the packages
+// in their directories and those that depend on them, as well as the
+// packages created from files (see loader.Config.CreateFromFilenames),
+// which are not cached; it reuses the other packages.
+// If the program cannot be reloaded, the server retains the old one.
+func (s *Server) Invalidate(files ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conf.Cache.Invalidate(files...)
+	conf := s.conf
+	lprog, err := conf.Load()
+	if err != nil {
+		return err
+	}
+	s.setProgram(lprog)
+	return nil
+}
+
+// setProgram makes lprog the next version of the program.
+func (s *Server) setProgram(lprog *loader.Program) {
+	old := make(map[*types.Package]bool)
+	if s.lprog != nil {
+		for pkg := range s.lprog.AllPackages {
+			old[pkg] = true
+		}
+	}
+	s.changed = nil
+	for pkg, info := range lprog.AllPackages {
+		if !old[pkg] {
+			s.changed = append(s.changed, info)
+		}
+	}
+	sort.Sort(byPkgPath(s.changed))
+	s.lprog = lprog
+	s.version++
+}
+
+type byPkgPath []*loader.PackageInfo
+
+func (b byPkgPath) Len() int           { return len(b) }
+func (b byPkgPath) Less(i, j int) bool { return b[i].Pkg.Path() < b[j].Pkg.Path() }
+func (b byPkgPath) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// Diagnostics returns the diagnostics of the packages loaded by the
+// latest version of the program, in order of import path: one for each
+// of their files, with its errors, if any, so that clients may replace
+// those of earlier versions, and one for the errors of a package
+// without a position, if any.  The packages of the first version are
+// all its packages; those of later versions are the packages that
+// Invalidate reloaded.
+func (s *Server) Diagnostics() []*serial.Diagnostics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fset := s.lprog.Fset
+	var result []*serial.Diagnostics
+	for _, info := range s.changed {
+		byFile := make(map[string]*serial.Diagnostics)
+		add := func(file string) *serial.Diagnostics {
+			d := byFile[file]
+			if d == nil {
+				d = &serial.Diagnostics{Package: info.Pkg.Path(), File: file, Version: s.version}
+				byFile[file] = d
+				result = append(result, d)
+			}
+			return d
+		}
+		for _, f := range info.Files {
+			add(fset.File(f.Pos()).Name())
+		}
+		for _, err := range info.Errors {
+			for _, diag := range diagnostics(err) {
+				d := add(diag.file)
+				d.Diagnostics = append(d.Diagnostics, &serial.Diagnostic{Pos: diag.pos, Msg: diag.msg, Soft: diag.soft})
+			}
+		}
+	}
+	return result
+}
+
+type diagnostic struct {
+	file, pos, msg string
+	soft           bool
+}
+
+// diagnostics returns the diagnostics of an error reported by the
+// loader: a parse or type error, or an error without a position.
+func diagnostics(err error) []diagnostic {
+	switch err := err.(type) {
+	case types.Error:
+		posn := err.Fset.Position(err.Pos)
+		return []diagnostic{{posn.Filename, posn.String(), err.Msg, err.Soft}}
+	case scanner.ErrorList:
+		var diags []diagnostic
+		for _, err := range err {
+			diags = append(diags, diagnostics(err)...)
+		}
+		return diags
+	case *scanner.Error:
+		return []diagnostic{{err.Pos.Filename, err.Pos.String(), err.Msg, false}}
+	}
+	return []diagnostic{{"", "-", err.Error(), false}}
+}
+
+// Program returns the program about which s answers queries.
+func (s *Server) Program() *loader.Program {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lprog
+}
+
+// Query runs the query of the specified mode at the position pos,
+// as for Query.Mode and Query.Pos, and returns it with its result.
+func (s *Server) Query(mode, pos string) (*Query, error) {
+	if !serverModes[mode] {
+		return nil, fmt.Errorf("invalid server mode: %q", mode)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := &Query{Mode: mode, Pos: pos, Build: s.conf.Build, lprog: s.lprog}
+	if err := Run(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Serve answers the queries read from r, a stream of JSON-encoded
+// serial.Requests, writing a JSON-encoded serial.Response to w for each,
+// until r is exhausted.  A request of mode "invalidate" calls
+// Invalidate for its files.
+//
+// Serve also publishes diagnostics, as Responses whose ID is zero: those
+// of the current version of the program, when it starts, and those of
+// each version loaded by an "invalidate" request, before its response.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	publish := func() error {
+		for _, d := range s.Diagnostics() {
+			if err := enc.Encode(&serial.Response{Diagnostics: d}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := publish(); err != nil {
+		return err
+	}
+	for {
+		var req serial.Request
+		if err := dec.Decode(&req); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		resp := &serial.Response{ID: req.ID}
+		if req.Mode == "invalidate" {
+			if err := s.Invalidate(req.Files...); err != nil {
+				resp.Error = err.Error()
+			} else if err := publish(); err != nil {
+				return err
+			}
+		} else if q, err := s.Query(req.Mode, req.Pos); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Result = q.Serial()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
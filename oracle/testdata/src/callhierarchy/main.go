@@ -0,0 +1,35 @@
+package main
+
+// Tests of 'callhierarchy' query.
+// See go.tools/oracle/oracle_test.go for explanation.
+// See main.golden for expected query results.
+
+type Shape interface {
+	Area() int // @callhierarchy ch-abstract "Area"
+}
+
+type Square int
+
+func (s Square) Area() int { return int(s * s) }
+
+type Rect struct{ W, H int }
+
+func (r *Rect) Area() int { return r.W * r.H }
+
+func total(shapes ...Shape) int {
+	n := 0
+	for _, s := range shapes {
+		n += s.Area() // @callhierarchy ch-enclosing "n"
+	}
+	return n
+}
+
+func area(s Square) int { return s.Area() } // @callhierarchy ch-concrete "Area"
+
+var size = total(Square(2), &Rect{1, 2})
+
+func main() {
+	println(total(Square(1)), area(3)) // @callhierarchy ch-total "total"
+	println(total())
+	println(len("x")) // @callhierarchy ch-builtin "len"
+}
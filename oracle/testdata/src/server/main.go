@@ -0,0 +1,17 @@
+package server
+
+// Tests of the oracle server.
+// See go.tools/oracle/oracle_test.go for explanation.
+// See TestServer in oracle_test.go for the queries.
+
+type T struct{ Name string }
+
+func (t *T) Greet() string { return "hello, " + t.Name }
+
+func (t *T) Grow() {}
+
+func use() string {
+	t := new(T)
+	t.Grow()
+	return t.Greet()
+}
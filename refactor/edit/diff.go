@@ -0,0 +1,186 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edit
+
+// This file computes unified diffs of the lines of files, by the
+// algorithm of Myers, "An O(ND) Difference Algorithm and Its
+// Variations", Algorithmica 1(2), 1986.
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const context = 3 // number of unchanged lines around each hunk
+
+// A diffOp is a line of a diff: an unchanged (' '), deleted ('-'), or
+// inserted ('+') line.
+type diffOp struct {
+	kind byte
+	line string // including its newline, if any
+}
+
+// unified returns the unified diff of old and new, the contents of
+// filename, or nil if they are the same.
+func unified(filename string, old, new []byte) []byte {
+	ops := diffLines(splitLines(old), splitLines(new))
+
+	var buf bytes.Buffer
+	// Line numbers (0-based) of old and new before each op.
+	aline := make([]int, len(ops)+1)
+	bline := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aline[i+1], bline[i+1] = aline[i], bline[i]
+		if op.kind != '+' {
+			aline[i+1]++
+		}
+		if op.kind != '-' {
+			bline[i+1]++
+		}
+	}
+	for i := 0; i < len(ops); {
+		// Find the next change.
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", filename, filename)
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk over the changes separated by at most
+		// twice the context.
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				if run-end > context {
+					run = end + context
+				}
+				end = run
+				break
+			}
+			end = run
+		}
+
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			hunkRange(aline[start], aline[end]-aline[start]),
+			hunkRange(bline[start], bline[end]-bline[start]))
+		for _, op := range ops[start:end] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return buf.Bytes()
+}
+
+// hunkRange returns the range of count lines from the 0-based line
+// start, in the form of a hunk header.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits text into lines, each including its newline
+// except perhaps the last.
+func splitLines(text []byte) []string {
+	var lines []string
+	for len(text) > 0 {
+		i := bytes.IndexByte(text, '\n') + 1
+		if i == 0 {
+			i = len(text)
+		}
+		lines = append(lines, string(text[:i]))
+		text = text[i:]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script that turns a into b.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1            // offset of diagonal 0 in v
+	v := make([]int, 2*max+3) // furthest x on each diagonal k = x - y
+	var trace [][]int         // v before each round d
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+				x = v[off+k+1] // down: insert b[y]
+			} else {
+				x = v[off+k-1] + 1 // right: delete a[x]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Follow the trace back from (n, m).
+	var ops []diffOp // in reverse
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{'+', b[y]})
+			} else {
+				x--
+				ops = append(ops, diffOp{'-', a[x]})
+			}
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,237 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package edit applies sets of textual edits, such as the suggested
+// fixes of refactoring and analysis tools, to files.  It detects
+// edits that conflict, formats the Go files that it edits as gofmt
+// does, and either reports the changes as a unified diff or writes all
+// the edited files, or none of them.
+//
+// THIS INTERFACE IS EXPERIMENTAL AND MAY CHANGE OR BE REMOVED IN FUTURE.
+//
+package edit // import "golang.org/x/tools/refactor/edit"
+
+import (
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// An Edit replaces the text of a file between two byte offsets.
+// An Edit whose Start and End are equal inserts New.
+type Edit struct {
+	Filename   string
+	Start, End int    // byte offsets of the replaced text, Start <= End
+	New        string // replacement text
+}
+
+// A Fix is a set of edits, in one or more files, that must be applied
+// together or not at all.
+type Fix struct {
+	Message string // description of the fix, for display
+	Edits   []Edit
+}
+
+// A Conflict records a fix that was not applied.
+type Conflict struct {
+	Fix  *Fix
+	Edit Edit // the edit of Fix that cannot be applied
+	With *Fix // the earlier fix whose edit overlaps Edit, or nil
+	Err  error
+}
+
+func (c *Conflict) Error() string {
+	return fmt.Sprintf("%s: cannot apply fix %q: %v", c.Edit.Filename, c.Fix.Message, c.Err)
+}
+
+// A Result holds the changes that Apply made to files.
+type Result struct {
+	Files     []string          // names of the changed files, in order
+	Old, New  map[string][]byte // contents of the changed files before and after
+	Applied   []*Fix            // the fixes applied, in order
+	Conflicts []*Conflict       // the fixes not applied, in order
+}
+
+// An edit is an Edit accepted by Apply, and the fix it belongs to.
+type edit struct {
+	Edit
+	fix *Fix
+}
+
+// Apply applies the edits of fixes to the contents of files, as
+// returned by read, or by ioutil.ReadFile if read is nil, without
+// writing them.
+//
+// Fixes are applied in order.  A fix is not applied, but recorded as a
+// Conflict, if any of its edits is out of the range of its file, or
+// overlaps another of its edits or an edit of an earlier fix that was
+// applied.  Edits overlap if they replace a common byte, if one
+// inserts within the text that another replaces, or if both insert at
+// the same offset; identical edits do not conflict, so that fixes that
+// make the same change, such as adding the same import, may both be
+// applied.
+//
+// The edited files whose names end in ".go" are formatted as by gofmt.
+// Apply fails if an edited file cannot be read, or cannot be formatted.
+//
+func Apply(read func(filename string) ([]byte, error), fixes ...*Fix) (*Result, error) {
+	if read == nil {
+		read = ioutil.ReadFile
+	}
+	r := &Result{Old: make(map[string][]byte), New: make(map[string][]byte)}
+	accepted := make(map[string][]edit) // by filename, in order of offset
+
+	for _, fix := range fixes {
+		// Check the fix against its file contents and earlier fixes.
+		var conflict *Conflict
+		var edits []edit // edits of fix that are not duplicates
+	check:
+		for _, e := range fix.Edits {
+			old, ok := r.Old[e.Filename]
+			if !ok {
+				var err error
+				if old, err = read(e.Filename); err != nil {
+					return nil, err
+				}
+				r.Old[e.Filename] = old
+			}
+			if e.Start < 0 || e.Start > e.End || e.End > len(old) {
+				conflict = &Conflict{Fix: fix, Edit: e, Err: fmt.Errorf("invalid range [%d, %d) of %d bytes", e.Start, e.End, len(old))}
+				break
+			}
+			for _, prev := range edits {
+				if prev.Filename == e.Filename && overlap(prev.Edit, e) {
+					conflict = &Conflict{Fix: fix, Edit: e, Err: fmt.Errorf("overlaps another edit of the fix")}
+					break check
+				}
+			}
+			for _, prev := range accepted[e.Filename] {
+				if prev.Edit == e {
+					continue check // a duplicate
+				}
+				if overlap(prev.Edit, e) {
+					conflict = &Conflict{Fix: fix, Edit: e, With: prev.fix, Err: fmt.Errorf("overlaps an edit of fix %q", prev.fix.Message)}
+					break check
+				}
+			}
+			edits = append(edits, edit{e, fix})
+		}
+		if conflict != nil {
+			r.Conflicts = append(r.Conflicts, conflict)
+			continue
+		}
+
+		for _, e := range edits {
+			accepted[e.Filename] = append(accepted[e.Filename], e)
+		}
+		r.Applied = append(r.Applied, fix)
+	}
+
+	// Apply the edits of each file.
+	for filename, edits := range accepted {
+		sort.Sort(byOffset(edits))
+		old := r.Old[filename]
+		var buf []byte
+		last := 0
+		for _, e := range edits {
+			buf = append(buf, old[last:e.Start]...)
+			buf = append(buf, e.New...)
+			last = e.End
+		}
+		buf = append(buf, old[last:]...)
+		if strings.HasSuffix(filename, ".go") {
+			formatted, err := format.Source(buf)
+			if err != nil {
+				return nil, fmt.Errorf("%s: cannot format edited file: %v", filename, err)
+			}
+			buf = formatted
+		}
+		if string(buf) != string(old) {
+			r.Files = append(r.Files, filename)
+			r.New[filename] = buf
+		}
+	}
+	sort.Strings(r.Files)
+	for filename := range r.Old {
+		if _, ok := r.New[filename]; !ok {
+			delete(r.Old, filename)
+		}
+	}
+	return r, nil
+}
+
+// overlap reports whether edits x and y, of the same file, overlap.
+func overlap(x, y Edit) bool {
+	if x.Start == x.End && y.Start == y.End {
+		return x.Start == y.Start // two insertions at the same offset
+	}
+	return x.Start < y.End && y.Start < x.End
+}
+
+// Diff returns the changes of r, as a unified diff of each changed file
+// in order.
+func (r *Result) Diff() []byte {
+	var buf []byte
+	for _, filename := range r.Files {
+		buf = append(buf, unified(filename, r.Old[filename], r.New[filename])...)
+	}
+	return buf
+}
+
+// Write writes the changed files of r, keeping their permissions.  It
+// first writes the new contents of each file to a temporary file in
+// the same directory, and renames the temporary files over the
+// originals only if it has written them all, so that it changes no
+// files if it cannot write one.
+func (r *Result) Write() error {
+	temps := make(map[string]string) // temporary file of each changed file
+	defer func() {
+		for _, temp := range temps {
+			os.Remove(temp)
+		}
+	}()
+	for _, filename := range r.Files {
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename))
+		if err != nil {
+			return err
+		}
+		temps[filename] = f.Name()
+		_, err = f.Write(r.New[filename])
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+		if err == nil {
+			err = os.Chmod(f.Name(), fi.Mode())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, filename := range r.Files {
+		if err := os.Rename(temps[filename], filename); err != nil {
+			return err
+		}
+		delete(temps, filename)
+	}
+	return nil
+}
+
+type byOffset []edit
+
+func (b byOffset) Len() int { return len(b) }
+func (b byOffset) Less(i, j int) bool {
+	if b[i].Start != b[j].Start {
+		return b[i].Start < b[j].Start
+	}
+	return b[i].End < b[j].End // an insertion precedes a replacement at its offset
+}
+func (b byOffset) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,230 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edit_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/refactor/edit"
+)
+
+const src = `package p
+
+func f() int {
+	x := 1
+	return x
+}
+
+func g() {}
+`
+
+// offset returns the offset of the first occurrence of substr in src.
+func offset(substr string) int { return strings.Index(src, substr) }
+
+func TestApply(t *testing.T) {
+	read := func(filename string) ([]byte, error) {
+		if filename != "p.go" {
+			return nil, fmt.Errorf("no file %s", filename)
+		}
+		return []byte(src), nil
+	}
+	rename := &edit.Fix{Message: "rename x", Edits: []edit.Edit{
+		{"p.go", offset("x :="), offset("x :=") + 1, "count"},
+		{"p.go", offset("x\n}"), offset("x\n}") + 1, "count"},
+	}}
+	conflicting := &edit.Fix{Message: "inline x", Edits: []edit.Edit{
+		{"p.go", offset("x\n}"), offset("x\n}") + 1, "1"},
+	}}
+	comment := &edit.Fix{Message: "document g", Edits: []edit.Edit{
+		{"p.go", offset("func g"), offset("func g"), "// g does nothing.\n"},
+	}}
+	duplicate := &edit.Fix{Message: "document g again", Edits: []edit.Edit{
+		{"p.go", offset("func g"), offset("func g"), "// g does nothing.\n"},
+		{"p.go", offset("{}"), offset("{}") + 2, "{    }"}, // gofmt undoes the spaces
+	}}
+	badRange := &edit.Fix{Message: "truncated", Edits: []edit.Edit{
+		{"p.go", len(src), len(src) + 1, ""},
+	}}
+
+	r, err := edit.Apply(read, rename, conflicting, comment, duplicate, badRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied, conflicts []string
+	for _, fix := range r.Applied {
+		applied = append(applied, fix.Message)
+	}
+	for _, c := range r.Conflicts {
+		conflicts = append(conflicts, c.Error())
+	}
+	if got, want := strings.Join(applied, "; "), "rename x; document g; document g again"; got != want {
+		t.Errorf("applied %s, want %s", got, want)
+	}
+	const wantConflicts = `p.go: cannot apply fix "inline x": overlaps an edit of fix "rename x"; ` +
+		`p.go: cannot apply fix "truncated": invalid range [59, 60) of 59 bytes`
+	if got := strings.Join(conflicts, "; "); got != wantConflicts {
+		t.Errorf("conflicts %s, want %s", got, wantConflicts)
+	}
+
+	const wantDiff = `--- p.go
++++ p.go
+@@ -1,8 +1,9 @@
+ package p
+ 
+ func f() int {
+-	x := 1
+-	return x
++	count := 1
++	return count
+ }
+ 
++// g does nothing.
+ func g() {}
+`
+	if got := string(r.Diff()); got != wantDiff {
+		t.Errorf("got diff:\n%s\nwant:\n%s", got, wantDiff)
+	}
+
+	// A fix that does not parse is an error.
+	if _, err := edit.Apply(read, &edit.Fix{Edits: []edit.Edit{{"p.go", 0, 7, "packet"}}}); err == nil {
+		t.Errorf("Apply of a syntax error succeeded")
+	}
+}
+
+func TestDiffHunks(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	old := strings.Join(lines, "\n") + "\n"
+	read := func(string) ([]byte, error) { return []byte(old), nil }
+	at := func(s string) int { return strings.Index(old, s) }
+	r, err := edit.Apply(read, &edit.Fix{Edits: []edit.Edit{
+		{"lines.txt", at("line 2\n"), at("line 3\n"), ""},
+		{"lines.txt", at("line 18\n"), at("line 18\n"), "new\n"},
+		{"lines.txt", len(old) - 1, len(old), ""},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `--- lines.txt
++++ lines.txt
+@@ -1,5 +1,4 @@
+ line 1
+-line 2
+ line 3
+ line 4
+ line 5
+@@ -15,6 +14,7 @@
+ line 15
+ line 16
+ line 17
++new
+ line 18
+ line 19
+-line 20
++line 20
+\ No newline at end of file
+`
+	if got := string(r.Diff()); got != want {
+		t.Errorf("got diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.txt")
+	if err := ioutil.WriteFile(filename, []byte("hello, world\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := edit.Apply(nil, &edit.Fix{Edits: []edit.Edit{{filename, 0, 5, "goodbye"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Write(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "goodbye, world\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if fi, err := os.Stat(filename); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("mode of written file: %v, %v; want 0600", fi.Mode(), err)
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "*")); len(names) != 1 {
+		t.Errorf("files after Write: %s", names)
+	}
+}
+
+func TestRebase(t *testing.T) {
+	// The file has changed since the fixes were computed against src:
+	// a line was inserted before f, and the body of g was changed.
+	const cur = `package p
+
+// f returns one.
+func f() int {
+	x := 1
+	return x
+}
+
+func g() { println() }
+`
+	base := func(string) ([]byte, error) { return []byte(src), nil }
+	read := func(string) ([]byte, error) { return []byte(cur), nil }
+	rename := &edit.Fix{Message: "rename x", Edits: []edit.Edit{
+		{"p.go", offset("x :="), offset("x :=") + 1, "count"},
+		{"p.go", offset("x\n}"), offset("x\n}") + 1, "count"},
+	}}
+	body := &edit.Fix{Message: "fill in g", Edits: []edit.Edit{
+		{"p.go", offset("{}"), offset("{}") + 2, "{ panic(0) }"},
+	}}
+	eof := &edit.Fix{Message: "append h", Edits: []edit.Edit{
+		{"p.go", len(src), len(src), "\nfunc h() {}\n"},
+	}}
+	fixes, conflicts, err := edit.Rebase(base, read, rename, body, eof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range conflicts {
+		got = append(got, c.Error())
+	}
+	if want := `p.go: cannot apply fix "fill in g": line 8 has changed`; strings.Join(got, "; ") != want {
+		t.Errorf("got conflicts %q, want %q", got, want)
+	}
+
+	r, err := edit.Apply(read, fixes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `package p
+
+// f returns one.
+func f() int {
+	count := 1
+	return count
+}
+
+func g() { println() }
+
+func h() {}
+`
+	if got := string(r.New["p.go"]); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
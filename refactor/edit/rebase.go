@@ -0,0 +1,138 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edit
+
+// This file defines Rebase, which re-anchors edits computed against
+// old contents of files to their current contents.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// Rebase re-anchors the edits of fixes, computed against the contents
+// of their files returned by base, such as the contents that an
+// analysis saw, to the current contents returned by read, or by
+// ioutil.ReadFile if read is nil, so that the fixes may be applied to
+// files that have changed since.
+//
+// Rebase compares the base and current contents of each file line by
+// line.  An edit moves with the lines it touches if none of them has
+// changed, and nothing has been inserted between them; otherwise its
+// fix cannot be rebased, and is recorded as a Conflict whose error
+// reports the base line at which the file changed.  The fixes returned
+// are copies of those rebased, in order.
+//
+func Rebase(base, read func(filename string) ([]byte, error), fixes ...*Fix) ([]*Fix, []*Conflict, error) {
+	if read == nil {
+		read = ioutil.ReadFile
+	}
+	anchors := make(map[string]*anchors)
+	var rebased []*Fix
+	var conflicts []*Conflict
+	for _, fix := range fixes {
+		rfix := &Fix{Message: fix.Message}
+		var conflict *Conflict
+		for _, e := range fix.Edits {
+			a := anchors[e.Filename]
+			if a == nil {
+				old, err := base(e.Filename)
+				if err != nil {
+					return nil, nil, err
+				}
+				cur, err := read(e.Filename)
+				if err != nil {
+					return nil, nil, err
+				}
+				a = newAnchors(old, cur)
+				anchors[e.Filename] = a
+			}
+			delta, err := a.delta(e.Start, e.End)
+			if err != nil {
+				conflict = &Conflict{Fix: fix, Edit: e, Err: err}
+				break
+			}
+			e.Start += delta
+			e.End += delta
+			rfix.Edits = append(rfix.Edits, e)
+		}
+		if conflict != nil {
+			conflicts = append(conflicts, conflict)
+		} else {
+			rebased = append(rebased, rfix)
+		}
+	}
+	return rebased, conflicts, nil
+}
+
+// anchors maps the lines of the base contents of a file to those of
+// its current contents.
+type anchors struct {
+	same       bool  // the contents are identical
+	baseStart  []int // offset of each base line, and of the end
+	curStart   []int // offset of each current line, and of the end
+	cur        []int // current line of each base line (and of the end), or -1 if changed
+	baseLength int
+}
+
+func newAnchors(base, cur []byte) *anchors {
+	a := &anchors{same: string(base) == string(cur), baseLength: len(base)}
+	if a.same {
+		return a
+	}
+	baseLines, curLines := splitLines(base), splitLines(cur)
+	a.baseStart = lineStarts(baseLines)
+	a.curStart = lineStarts(curLines)
+	a.cur = make([]int, len(baseLines)+1)
+	i, j := 0, 0 // base and current line
+	for _, op := range diffLines(baseLines, curLines) {
+		switch op.kind {
+		case ' ':
+			a.cur[i] = j
+			i++
+			j++
+		case '-':
+			a.cur[i] = -1
+			i++
+		case '+':
+			j++
+		}
+	}
+	a.cur[len(baseLines)] = len(curLines) // the end moves to the end
+	return a
+}
+
+func lineStarts(lines []string) []int {
+	starts := make([]int, len(lines)+1)
+	for i, line := range lines {
+		starts[i+1] = starts[i] + len(line)
+	}
+	return starts
+}
+
+// delta returns the distance by which the base interval [start, end)
+// has moved in the current contents.
+func (a *anchors) delta(start, end int) (int, error) {
+	if start < 0 || start > end || end > a.baseLength {
+		return 0, fmt.Errorf("invalid range [%d, %d) of %d bytes", start, end, a.baseLength)
+	}
+	if a.same {
+		return 0, nil
+	}
+	line := func(offset int) int { // the base line containing offset
+		return sort.SearchInts(a.baseStart, offset+1) - 1
+	}
+	first, last := line(start), line(start)
+	if end > start {
+		last = line(end - 1)
+	}
+	for i := first; i <= last; i++ {
+		if a.cur[i] < 0 || a.cur[i] != a.cur[first]+i-first {
+			return 0, fmt.Errorf("line %d has changed", i+1)
+		}
+	}
+	return a.curStart[a.cur[first]] - a.baseStart[first], nil
+}
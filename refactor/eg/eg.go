@@ -52,6 +52,15 @@ So, the transform above would change this input:
 to this output:
 	err := errors.New("error: " + msg)
 
+With the -find flag, the tool makes no replacements, and the template
+need not define 'after'.  Instead it prints the position and text of
+each occurrence of the pattern, so that the 'before' function serves
+as a structural search query whose parameters are typed wildcards:
+
+	func before(s string) error { return fmt.Errorf(s) }
+
+finds the calls of fmt.Errorf with a single argument, of type string.
+
 Identifiers, including qualified identifiers (p.X) are considered to
 match only if they denote the same object.  This allows correct
 matching even in the presence of dot imports, named imports and
@@ -158,20 +167,36 @@ type Transformer struct {
 // in the package documentation.
 //
 func NewTransformer(fset *token.FileSet, template *loader.PackageInfo, verbose bool) (*Transformer, error) {
+	return newTransformer(fset, template, verbose, true)
+}
+
+// NewMatcher returns a transformer that only finds the occurrences of
+// the pattern of the specified template, a package containing a
+// "before" function as described in the package documentation; an
+// "after" function is not required.  Use its Find method; its
+// Transform method makes no replacements.
+//
+func NewMatcher(fset *token.FileSet, template *loader.PackageInfo, verbose bool) (*Transformer, error) {
+	return newTransformer(fset, template, verbose, false)
+}
+
+func newTransformer(fset *token.FileSet, template *loader.PackageInfo, verbose, replace bool) (*Transformer, error) {
 	// Check the template.
 	beforeSig := funcSig(template.Pkg, "before")
 	if beforeSig == nil {
 		return nil, fmt.Errorf("no 'before' func found in template")
 	}
-	afterSig := funcSig(template.Pkg, "after")
-	if afterSig == nil {
-		return nil, fmt.Errorf("no 'after' func found in template")
-	}
+	if replace {
+		afterSig := funcSig(template.Pkg, "after")
+		if afterSig == nil {
+			return nil, fmt.Errorf("no 'after' func found in template")
+		}
 
-	// TODO(adonovan): should we also check the names of the params match?
-	if !types.Identical(afterSig, beforeSig) {
-		return nil, fmt.Errorf("before %s and after %s functions have different signatures",
-			beforeSig, afterSig)
+		// TODO(adonovan): should we also check the names of the params match?
+		if !types.Identical(afterSig, beforeSig) {
+			return nil, fmt.Errorf("before %s and after %s functions have different signatures",
+				beforeSig, afterSig)
+		}
 	}
 
 	templateFile := template.Files[0]
@@ -200,16 +225,24 @@ func NewTransformer(fset *token.FileSet, template *loader.PackageInfo, verbose b
 	if err != nil {
 		return nil, fmt.Errorf("before: %s", err)
 	}
+	if !replace {
+		tr := &Transformer{
+			fset:           fset,
+			verbose:        verbose,
+			wildcards:      wildcards(beforeSig),
+			allowWildcards: true,
+			seenInfos:      make(map[*types.Info]bool),
+			before:         before,
+		}
+		tr.info.Info = newInfo()
+		mergeTypeInfo(&tr.info.Info, &template.Info)
+		return tr, nil
+	}
 	after, err := soleExpr(afterDecl)
 	if err != nil {
 		return nil, fmt.Errorf("after: %s", err)
 	}
 
-	wildcards := make(map[*types.Var]bool)
-	for i := 0; i < beforeSig.Params().Len(); i++ {
-		wildcards[beforeSig.Params().At(i)] = true
-	}
-
 	// checkExprTypes returns an error if Tb (type of before()) is not
 	// safe to replace with Ta (type of after()).
 	//
@@ -238,7 +271,7 @@ func NewTransformer(fset *token.FileSet, template *loader.PackageInfo, verbose b
 	tr := &Transformer{
 		fset:           fset,
 		verbose:        verbose,
-		wildcards:      wildcards,
+		wildcards:      wildcards(beforeSig),
 		allowWildcards: true,
 		seenInfos:      make(map[*types.Info]bool),
 		importedObjs:   make(map[types.Object]*ast.SelectorExpr),
@@ -253,12 +286,7 @@ func NewTransformer(fset *token.FileSet, template *loader.PackageInfo, verbose b
 	//
 	// TODO(adonovan): move type utility methods of PackageInfo to
 	// types.Info, or at least into go/types.typeutil.
-	tr.info.Info = types.Info{
-		Types:      make(map[ast.Expr]types.TypeAndValue),
-		Defs:       make(map[*ast.Ident]types.Object),
-		Uses:       make(map[*ast.Ident]types.Object),
-		Selections: make(map[*ast.SelectorExpr]*types.Selection),
-	}
+	tr.info.Info = newInfo()
 	mergeTypeInfo(&tr.info.Info, &template.Info)
 
 	// Compute set of imported objects required by after().
@@ -294,6 +322,25 @@ func WriteAST(fset *token.FileSet, filename string, f *ast.File) (err error) {
 
 // -- utilities --------------------------------------------------------
 
+// wildcards returns the set of parameters of the before function.
+func wildcards(beforeSig *types.Signature) map[*types.Var]bool {
+	wildcards := make(map[*types.Var]bool)
+	for i := 0; i < beforeSig.Params().Len(); i++ {
+		wildcards[beforeSig.Params().At(i)] = true
+	}
+	return wildcards
+}
+
+// newInfo returns a types.Info with the maps used by the transformer.
+func newInfo() types.Info {
+	return types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+}
+
 // funcSig returns the signature of the specified package-level function.
 func funcSig(pkg *types.Package, name string) *types.Signature {
 	if f, ok := pkg.Scope().Lookup(name).(*types.Func); ok {
@@ -0,0 +1,52 @@
+package eg
+
+// This file defines the read-only matching pass.
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A Match is an occurrence of the pattern of a transformer.
+type Match struct {
+	Expr ast.Expr            // the matching expression
+	Env  map[string]ast.Expr // the expression matched by each wildcard, by parameter name
+}
+
+// Pos returns the position of the matching expression.
+func (m *Match) Pos() token.Pos { return m.Expr.Pos() }
+
+// Find returns the occurrences of the pattern in the specified parsed
+// file, whose type information is supplied in info, in order of
+// position.  A match may contain others.  Unlike Transform, Find does
+// not modify the file, so it may be used to search a program for the
+// expressions that match a pattern with typed wildcards.
+//
+func (tr *Transformer) Find(info *types.Info, pkg *types.Package, file *ast.File) []*Match {
+	if !tr.seenInfos[info] {
+		tr.seenInfos[info] = true
+		mergeTypeInfo(&tr.info.Info, info)
+	}
+	tr.currentPkg = pkg
+
+	var matches []*Match
+	ast.Inspect(file, func(n ast.Node) bool {
+		e, ok := n.(ast.Expr)
+		if !ok {
+			return true
+		}
+		if _, ok := e.(*ast.ParenExpr); ok {
+			return true // matched as its operand
+		}
+		savedEnv := tr.env
+		tr.env = make(map[string]ast.Expr)
+		if tr.matchExpr(tr.before, e) {
+			matches = append(matches, &Match{Expr: e, Env: tr.env})
+		}
+		tr.env = savedEnv
+		return true
+	})
+	return matches
+}
@@ -0,0 +1,79 @@
+package eg_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/printer"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/refactor/eg"
+)
+
+func TestFind(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"log": {"log.go": `package log; func Printf(format string, args ...interface{}) {}`},
+	})
+	conf := loader.Config{Build: ctxt}
+	for name, src := range map[string]string{
+		"template": `package template
+import "log"
+func before(s string) { log.Printf(s) }
+`,
+		"input": `package input
+import (
+	L "log"
+	"log"
+)
+type T string
+func f(s string, n int, v T) {
+	log.Printf(s)
+	L.Printf("constant")
+	log.Printf(s, n)
+	log.Printf(string(v))
+	Printf(s)
+}
+func Printf(s string) {}
+`,
+	} {
+		f, err := conf.ParseFile(name+".go", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf.CreateFromFiles(name, f)
+	}
+	iprog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var template, input *loader.PackageInfo
+	for _, info := range iprog.Created {
+		if info.Pkg.Path() == "template" {
+			template = info
+		} else {
+			input = info
+		}
+	}
+
+	m, err := eg.NewMatcher(iprog.Fset, template, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, match := range m.Find(&input.Info, input.Pkg, input.Files[0]) {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, iprog.Fset, match.Env["s"])
+		got = append(got, fmt.Sprintf("%d: s=%s", iprog.Fset.Position(match.Pos()).Line, buf.String()))
+	}
+	want := []string{`8: s=s`, `9: s="constant"`, `11: s=string(v)`}
+	if g, w := strings.Join(got, "; "), strings.Join(want, "; "); g != w {
+		t.Errorf("Find: got %s, want %s", g, w)
+	}
+
+	// A matcher makes no replacements.
+	if n := m.Transform(&input.Info, input.Pkg, input.Files[0]); n != 0 {
+		t.Errorf("Transform made %d replacements, want 0", n)
+	}
+}
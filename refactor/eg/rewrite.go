@@ -29,6 +29,9 @@ import (
 // Derived from rewriteFile in $GOROOT/src/cmd/gofmt/rewrite.go.
 //
 func (tr *Transformer) Transform(info *types.Info, pkg *types.Package, file *ast.File) int {
+	if tr.after == nil {
+		return 0 // a matcher
+	}
 	if !tr.seenInfos[info] {
 		tr.seenInfos[info] = true
 		mergeTypeInfo(&tr.info.Info, info)
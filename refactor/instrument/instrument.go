@@ -0,0 +1,299 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package instrument injects statements, such as calls for tracing,
+// metrics, or the checking of assertions, into the source of a
+// program: at the entry and exit of selected functions, and around
+// selected call sites.
+//
+// Functions are selected by their type-checked objects, so that a
+// client may select, for example, all the methods of the types that
+// implement http.Handler:
+//
+//	handler := prog.Package("net/http").Pkg.Scope().Lookup("Handler")
+//	conf := instrument.Config{
+//		Funcs:   instrument.Implementing(handler.Type().Underlying().(*types.Interface)),
+//		Entry:   func(fn *types.Func) string { return fmt.Sprintf("trace.Enter(%q)", fn.FullName()) },
+//		Exit:    func(fn *types.Func) string { return fmt.Sprintf("trace.Exit(%q)", fn.FullName()) },
+//		Imports: []string{"example.com/trace"},
+//	}
+//
+// The statements are given as Go source and inserted without regard
+// to their types; a statement that does not type-check, or whose
+// names are shadowed at the point of insertion, makes the rewritten
+// source invalid.
+package instrument // import "golang.org/x/tools/refactor/instrument"
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+)
+
+// A Selector selects functions and methods.
+type Selector func(fn *types.Func) bool
+
+// InPackage selects the functions and methods of the package whose
+// import path is path.
+func InPackage(path string) Selector {
+	return func(fn *types.Func) bool {
+		return fn.Pkg() != nil && fn.Pkg().Path() == path
+	}
+}
+
+// Implementing selects the methods, whether or not of iface, of the
+// named types T for which T or *T implements iface.
+func Implementing(iface *types.Interface) Selector {
+	return func(fn *types.Func) bool {
+		recv := fn.Type().(*types.Signature).Recv()
+		if recv == nil {
+			return false
+		}
+		T := recv.Type()
+		if ptr, ok := T.(*types.Pointer); ok {
+			T = ptr.Elem()
+		}
+		if _, ok := T.Underlying().(*types.Interface); ok {
+			return false // an interface method
+		}
+		return types.Implements(types.NewPointer(T), iface)
+	}
+}
+
+// And selects the functions that all the selectors select.
+func And(selectors ...Selector) Selector {
+	return func(fn *types.Func) bool {
+		for _, s := range selectors {
+			if !s(fn) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or selects the functions that any of the selectors selects.
+func Or(selectors ...Selector) Selector {
+	return func(fn *types.Func) bool {
+		for _, s := range selectors {
+			if s(fn) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// A Hook returns the source of the statement to inject for the
+// function fn, or "" for none.
+type Hook func(fn *types.Func) string
+
+// A Config specifies the statements to inject.
+type Config struct {
+	// Funcs selects the declared functions and methods whose
+	// entries are instrumented: at the start of the body of each, a
+	// statement from Entry is inserted, and then a deferred call
+	// from Exit, which must be a call expression, so that it runs
+	// on every return, and on panics.
+	Funcs       Selector
+	Entry, Exit Hook
+
+	// Calls selects the static callees whose call sites are
+	// instrumented: the statements from Before and After are
+	// inserted before and after each statement that calls one of
+	// them, the hooks being given the callee.  Only calls in simple
+	// statements of a block—expression statements, assignments,
+	// declarations, and returns—are instrumented, and After is not
+	// applied to returns.
+	Calls         Selector
+	Before, After Hook
+
+	// Imports are the import paths of the packages to which the
+	// statements refer, which are imported by each changed file.
+	Imports []string
+}
+
+// Instrument rewrites, in place, the files of the initial packages of
+// prog to inject the statements specified by conf, and returns the
+// changed files, in order of position.  The types.Info of prog is not
+// updated.
+//
+// The files should then be printed, such as with go/format.Node.
+//
+func (conf *Config) Instrument(prog *loader.Program) ([]*ast.File, error) {
+	var changed []*ast.File
+	for _, info := range prog.InitialPackages() {
+		for _, f := range info.Files {
+			n, err := conf.file(&info.Info, f)
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				for _, path := range conf.Imports {
+					astutil.AddImport(prog.Fset, f, path)
+				}
+				changed = append(changed, f)
+			}
+		}
+	}
+	sort.Sort(byFilePos(changed))
+	return changed, nil
+}
+
+type byFilePos []*ast.File
+
+func (b byFilePos) Len() int           { return len(b) }
+func (b byFilePos) Less(i, j int) bool { return b[i].Pos() < b[j].Pos() }
+func (b byFilePos) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// file instruments the file f and returns the number of statements
+// it injected.
+func (conf *Config) file(info *types.Info, f *ast.File) (int, error) {
+	n := 0
+	var err error
+	inject := func(hook Hook, fn *types.Func, pos token.Pos, defer_ bool) []ast.Stmt {
+		if hook == nil || err != nil {
+			return nil
+		}
+		src := hook(fn)
+		if src == "" {
+			return nil
+		}
+		if defer_ {
+			src = "defer " + src
+		}
+		var stmt ast.Stmt
+		if stmt, err = parseStmt(src, pos); err != nil {
+			err = fmt.Errorf("instrument: invalid statement for %s: %v", fn.FullName(), err)
+			return nil
+		}
+		n++
+		return []ast.Stmt{stmt}
+	}
+
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil {
+			continue
+		}
+
+		// Instrument the call sites.  The injected statements,
+		// which have no type information, call no selected
+		// callee.
+		if conf.Calls != nil {
+			ast.Inspect(fd.Body, func(node ast.Node) bool {
+				list := stmtList(node)
+				if list == nil {
+					return true
+				}
+				var stmts []ast.Stmt
+				for _, stmt := range *list {
+					callee := conf.callee(info, stmt)
+					if callee == nil {
+						stmts = append(stmts, stmt)
+						continue
+					}
+					stmts = append(stmts, inject(conf.Before, callee, stmt.Pos(), false)...)
+					stmts = append(stmts, stmt)
+					if _, ok := stmt.(*ast.ReturnStmt); !ok {
+						stmts = append(stmts, inject(conf.After, callee, stmt.End(), false)...)
+					}
+				}
+				*list = stmts
+				return true
+			})
+		}
+
+		// Instrument the entry.
+		if conf.Funcs != nil {
+			fn, ok := info.Defs[fd.Name].(*types.Func)
+			if ok && conf.Funcs(fn) {
+				pos := fd.Body.Lbrace
+				entry := inject(conf.Entry, fn, pos, false)
+				entry = append(entry, inject(conf.Exit, fn, pos, true)...)
+				fd.Body.List = append(entry, fd.Body.List...)
+			}
+		}
+	}
+	return n, err
+}
+
+// callee returns the first selected static callee of the calls of the
+// simple statement stmt, or nil.
+func (conf *Config) callee(info *types.Info, stmt ast.Stmt) *types.Func {
+	switch stmt.(type) {
+	case *ast.ExprStmt, *ast.AssignStmt, *ast.DeclStmt, *ast.ReturnStmt:
+	default:
+		return nil
+	}
+	var callee *types.Func
+	ast.Inspect(stmt, func(node ast.Node) bool {
+		if _, ok := node.(*ast.FuncLit); ok || callee != nil {
+			return false // calls in a function literal are not executed by stmt
+		}
+		if call, ok := node.(*ast.CallExpr); ok {
+			var id *ast.Ident
+			switch fun := astutil.Unparen(call.Fun).(type) {
+			case *ast.Ident:
+				id = fun
+			case *ast.SelectorExpr:
+				id = fun.Sel
+			}
+			if fn, ok := info.Uses[id].(*types.Func); ok && conf.Calls(fn) {
+				callee = fn
+			}
+		}
+		return true
+	})
+	return callee
+}
+
+// stmtList returns the address of the list of statements of the block
+// node, or nil if it is not a block.
+func stmtList(node ast.Node) *[]ast.Stmt {
+	switch node := node.(type) {
+	case *ast.BlockStmt:
+		return &node.List
+	case *ast.CaseClause:
+		return &node.Body
+	case *ast.CommClause:
+		return &node.Body
+	}
+	return nil
+}
+
+// parseStmt parses the statement src, and sets all its positions to
+// pos, to place it among the comments of the file.
+func parseStmt(src string, pos token.Pos) (ast.Stmt, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", "package p; func _() {\n"+src+"\n}", 0)
+	if err != nil {
+		return nil, err
+	}
+	body := f.Decls[0].(*ast.FuncDecl).Body.List
+	if len(body) != 1 {
+		return nil, fmt.Errorf("got %d statements, want 1", len(body))
+	}
+	ast.Inspect(body[0], func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		v := reflect.ValueOf(node).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.Type() == posType && field.Interface().(token.Pos).IsValid() {
+				field.Set(reflect.ValueOf(pos))
+			}
+		}
+		return true
+	})
+	return body[0], nil
+}
+
+var posType = reflect.TypeOf(token.NoPos)
@@ -0,0 +1,147 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package instrument_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/refactor/instrument"
+)
+
+var sources = map[string]map[string]string{
+	"http": {"http.go": `package http
+
+type Handler interface{ ServeHTTP(path string) }
+`},
+	"trace": {"trace.go": `package trace
+
+func Enter(name string) {}
+func Exit(name string)  {}
+func Call(name string)  {}
+`},
+	"p": {"p.go": `package p
+
+import "http"
+
+var _ http.Handler = (*server)(nil)
+
+type server struct{}
+
+func (s *server) ServeHTTP(path string) { s.log(path) }
+
+func (s *server) log(msg string) {}
+
+func helper() int {
+	if n := compute(); n > 0 {
+		return compute()
+	}
+	x := compute()
+	go compute()
+	return x
+}
+
+func compute() int { return 1 }
+`},
+}
+
+const want = `package p
+
+import (
+	"http"
+	"trace"
+)
+
+var _ http.Handler = (*server)(nil)
+
+type server struct{}
+
+func (s *server) ServeHTTP(path string) {
+	trace.Enter("(*p.server).ServeHTTP")
+	defer trace.Exit("(*p.server).ServeHTTP")
+	s.log(path)
+}
+
+func (s *server) log(msg string) { trace.Enter("(*p.server).log"); defer trace.Exit("(*p.server).log") }
+
+func helper() int {
+	if n := compute(); n > 0 {
+		trace.Call("p.compute")
+		return compute()
+	}
+	trace.Call("p.compute")
+	x := compute()
+	go compute()
+	return x
+}
+
+func compute() int { return 1 }
+`
+
+func load(t *testing.T, files map[string]map[string]string) *loader.Program {
+	conf := loader.Config{Build: buildutil.FakeContext(files)}
+	conf.Import("p")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prog
+}
+
+func TestInstrument(t *testing.T) {
+	prog := load(t, sources)
+	handler := prog.Package("http").Pkg.Scope().Lookup("Handler")
+	call := func(fn *types.Func) string { return fmt.Sprintf("trace.Call(%q)", fn.FullName()) }
+	conf := instrument.Config{
+		Funcs:   instrument.Implementing(handler.Type().Underlying().(*types.Interface)),
+		Entry:   func(fn *types.Func) string { return fmt.Sprintf("trace.Enter(%q)", fn.FullName()) },
+		Exit:    func(fn *types.Func) string { return fmt.Sprintf("trace.Exit(%q)", fn.FullName()) },
+		Calls:   instrument.And(instrument.InPackage("p"), func(fn *types.Func) bool { return fn.Name() == "compute" }),
+		Before:  call,
+		After:   func(fn *types.Func) string { return "" },
+		Imports: []string{"trace"},
+	}
+	changed, err := conf.Instrument(prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 {
+		t.Fatalf("got %d changed files, want 1", len(changed))
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, prog.Fset, changed[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The result must still type-check.
+	files := map[string]map[string]string{"p": {"p.go": buf.String()}}
+	for path, pkg := range sources {
+		if path != "p" {
+			files[path] = pkg
+		}
+	}
+	load(t, files)
+}
+
+func TestInvalidHook(t *testing.T) {
+	prog := load(t, sources)
+	conf := instrument.Config{
+		Funcs: instrument.InPackage("p"),
+		Entry: func(fn *types.Func) string { return "if {" },
+	}
+	_, err := conf.Instrument(prog)
+	if err == nil || !strings.HasPrefix(err.Error(), "instrument: invalid statement for ") {
+		t.Errorf("got error %v, want invalid statement", err)
+	}
+}
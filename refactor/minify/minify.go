@@ -0,0 +1,224 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package minify renames the unexported identifiers of a program to
+// short or obscure names, for the distribution of compact or opaque
+// source trees that still compile.
+//
+// The renaming is applied to each package independently: within a
+// package, all the objects of the same unexported name, be they
+// package members, local variables, labels, or fields and methods, are
+// given the same new name, and distinct names are given distinct new
+// names.  The new names are chosen to differ from the names that are
+// not renamed: exported names, the names of imported packages, the
+// predeclared identifiers, and those kept at the request of the
+// client.  The renaming therefore preserves the resolution of every
+// identifier, whatever its scope, the matching of methods with the
+// methods of interfaces, and the selection of promoted fields and
+// methods, which for unexported names depend on the package too.
+//
+// The functions init and main are never renamed.  Neither are names
+// visible through reflection, type strings excepted, as reflection
+// only reveals exported fields and methods; code that depends on the
+// names of unexported objects, such as a //go:linkname directive, or a
+// test that inspects %T or %#v output, needs them kept.
+package minify // import "golang.org/x/tools/refactor/minify"
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+)
+
+// A Config specifies a renaming.
+type Config struct {
+	// Obfuscate causes the new names to be chosen to be hard to
+	// tell apart, such as l1Il, instead of as short as possible.
+	Obfuscate bool
+
+	// If Keep is non-nil, it is called for each unexported object
+	// of the packages to be renamed, and if it returns true, the
+	// name of the object is kept; so is that of all objects of the
+	// same package and name.  It serves as an allowlist of names
+	// that are needed at run time.
+	Keep func(obj types.Object) bool
+}
+
+// Minify renames the unexported identifiers of the files of the
+// initial packages of prog, in place, as described in the package
+// documentation, and returns the renaming: a map from each package to
+// a map from original to new names.  Names used more often are given
+// shorter names.  The types.Info of prog is not updated.
+//
+// The files should then be printed, such as with go/format.Node.
+//
+func (conf *Config) Minify(prog *loader.Program) map[*types.Package]map[string]string {
+	renaming := make(map[*types.Package]map[string]string)
+	for _, info := range prog.InitialPackages() {
+		renaming[info.Pkg] = conf.minify(info)
+	}
+	for _, info := range prog.InitialPackages() {
+		for _, f := range info.Files {
+			for _, id := range renamable(&info.Info, f) {
+				if name, ok := renaming[objectOf(&info.Info, id).Pkg()][id.Name]; ok {
+					id.Name = name
+				}
+			}
+		}
+	}
+	return renaming
+}
+
+// minify returns the renaming of the unexported names of the objects
+// of the package of info.
+func (conf *Config) minify(info *loader.PackageInfo) map[string]string {
+	// Count the uses of each name, and find those that are kept.
+	counts := make(map[string]int)
+	reserved := map[string]bool{"_": true, "init": true, "main": true}
+	for _, name := range types.Universe.Names() {
+		reserved[name] = true
+	}
+	for _, f := range info.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj := objectOf(&info.Info, id)
+			switch {
+			case obj == nil:
+				// e.g. the package name
+			case obj.Pkg() != info.Pkg, obj.Exported():
+				// Names from other packages are renamed
+				// according to those packages, but an
+				// unexported one can only be a field or
+				// method, in a selector, which cannot
+				// conflict with names of this package.
+				if obj.Pkg() == nil || obj.Exported() {
+					reserved[id.Name] = true
+				}
+			case isPkgName(obj), conf.Keep != nil && conf.Keep(obj):
+				reserved[id.Name] = true
+			default:
+				counts[id.Name]++
+			}
+			return true
+		})
+	}
+	var names []string
+	for name := range counts {
+		if !reserved[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Sort(byCount{names, counts})
+
+	renaming := make(map[string]string)
+	gen := &generator{obfuscate: conf.Obfuscate}
+	for _, name := range names {
+		newName := gen.next()
+		for reserved[newName] || token.Lookup(newName).IsKeyword() {
+			newName = gen.next()
+		}
+		renaming[name] = newName
+	}
+	return renaming
+}
+
+type byCount struct {
+	names  []string
+	counts map[string]int
+}
+
+func (b byCount) Len() int { return len(b.names) }
+func (b byCount) Less(i, j int) bool {
+	x, y := b.names[i], b.names[j]
+	if b.counts[x] != b.counts[y] {
+		return b.counts[x] > b.counts[y]
+	}
+	return x < y
+}
+func (b byCount) Swap(i, j int) { b.names[i], b.names[j] = b.names[j], b.names[i] }
+
+// renamable returns the identifiers of f that denote unexported
+// objects, other than imported package names.
+func renamable(info *types.Info, f *ast.File) []*ast.Ident {
+	var ids []*ast.Ident
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if obj := objectOf(info, id); obj != nil && obj.Pkg() != nil && !obj.Exported() && !isPkgName(obj) {
+				ids = append(ids, id)
+			}
+		}
+		return true
+	})
+	return ids
+}
+
+// objectOf returns the object denoted by id, or, for the symbol of a
+// type switch, which has one object per clause, one of them, or nil.
+func objectOf(info *types.Info, id *ast.Ident) types.Object {
+	if obj := info.ObjectOf(id); obj != nil {
+		return obj
+	}
+	for node, obj := range info.Implicits {
+		if _, ok := node.(*ast.CaseClause); ok && obj.Pos() == id.Pos() {
+			return obj
+		}
+	}
+	return nil
+}
+
+func isPkgName(obj types.Object) bool {
+	_, ok := obj.(*types.PkgName)
+	return ok
+}
+
+// A generator generates the new names, in order of length.
+type generator struct {
+	obfuscate bool
+	n         []int // the digits of the next name, in the alphabets
+}
+
+const (
+	shortFirst = "abcdefghijklmnopqrstuvwxyz"
+	shortRest  = shortFirst + "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+	obfusFirst = "l"
+	obfusRest  = "lI1"
+)
+
+func (g *generator) next() string {
+	first, rest := shortFirst, shortRest
+	if g.obfuscate {
+		first, rest = obfusFirst, obfusRest
+	}
+	if g.n == nil {
+		g.n = []int{0}
+	}
+	name := make([]byte, len(g.n))
+	name[0] = first[g.n[0]]
+	for i := 1; i < len(g.n); i++ {
+		name[i] = rest[g.n[i]]
+	}
+
+	// Increment the digits, the last fastest.
+	for i := len(g.n) - 1; ; i-- {
+		if i < 0 {
+			g.n = make([]int, len(g.n)+1)
+			break
+		}
+		base := len(rest)
+		if i == 0 {
+			base = len(first)
+		}
+		if g.n[i]++; g.n[i] < base {
+			break
+		}
+		g.n[i] = 0
+	}
+	return string(name)
+}
@@ -0,0 +1,145 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package minify_test
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/refactor/minify"
+)
+
+var sources = map[string]map[string]string{
+	"q": {"q.go": `package q
+
+type Counter struct{ count int }
+
+func (c *Counter) Inc() { c.count++ }
+
+func (c *Counter) Count() int { return c.count }
+
+type shape interface{ area() int }
+
+type square struct{ side int }
+
+func (s square) area() int { return s.side * s.side }
+
+func Total(xs ...int) (sum int) {
+	var shapes []shape
+	for _, x := range xs {
+		shapes = append(shapes, square{side: x})
+	}
+	for _, s := range shapes {
+		sum += s.area()
+	}
+	return
+}
+`},
+	"p": {"p.go": `package p
+
+import "q"
+
+type wrapper struct {
+	*q.Counter
+	label string
+}
+
+const a = 1
+
+var registry = map[string]int{}
+
+func describe(v interface{}) string {
+	switch x := v.(type) {
+	case int:
+		if x > a {
+			return "big"
+		}
+	case string:
+		return x
+	}
+	return "?"
+}
+
+func Run(name string) int {
+	w := wrapper{new(q.Counter), name}
+	w.Inc()
+loop:
+	for i := 0; i < 3; i++ {
+		if describe(i) == "?" {
+			break loop
+		}
+		w.Inc()
+	}
+	registry[w.label] = w.Count() + q.Total(1, 2)
+	return registry[describe(w.label)]
+}
+`},
+}
+
+// load loads the packages p and q from the files, or from sources.
+func load(t *testing.T, files map[string]map[string]string) *loader.Program {
+	conf := loader.Config{Build: buildutil.FakeContext(files)}
+	conf.Import("p")
+	conf.Import("q")
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prog
+}
+
+func TestMinify(t *testing.T) {
+	for _, conf := range []minify.Config{
+		{},
+		{Obfuscate: true},
+		{Keep: func(obj types.Object) bool { return obj.Name() == "registry" }},
+	} {
+		prog := load(t, sources)
+		renaming := conf.Minify(prog)
+
+		// Print the renamed files, and check them again.
+		printed := make(map[string]map[string]string)
+		for _, info := range prog.InitialPackages() {
+			var buf bytes.Buffer
+			if err := format.Node(&buf, prog.Fset, info.Files[0]); err != nil {
+				t.Fatal(err)
+			}
+			path := info.Pkg.Path()
+			printed[path] = map[string]string{path + ".go": buf.String()}
+
+			for old, name := range renaming[info.Pkg] {
+				if len(name) > len(old) && !conf.Obfuscate {
+					t.Errorf("%s: %s renamed to longer %s", path, old, name)
+				}
+				if conf.Obfuscate && strings.Trim(name, "lI1") != "" {
+					t.Errorf("%s: %s renamed to unobfuscated %s", path, old, name)
+				}
+			}
+		}
+		p := printed["p"]["p.go"]
+		for _, name := range []string{"wrapper", "describe", "label", "loop"} {
+			if strings.Contains(p, name) {
+				t.Errorf("%+v: %s not renamed:\n%s", conf, name, p)
+			}
+		}
+		for _, name := range []string{"Run", "Inc", "Counter", "q.Total", "string"} {
+			if !strings.Contains(p, name) {
+				t.Errorf("%+v: %s renamed:\n%s", conf, name, p)
+			}
+		}
+		if kept := strings.Contains(p, "registry"); kept != (conf.Keep != nil) {
+			t.Errorf("%+v: registry kept = %t, want %t", conf, kept, conf.Keep != nil)
+		}
+		if strings.Contains(printed["q"]["q.go"], "area") {
+			t.Errorf("%+v: area not renamed:\n%s", conf, printed["q"]["q.go"])
+		}
+		load(t, printed)
+	}
+}
@@ -419,7 +419,25 @@ func (r *renamer) update() error {
 		}
 	}
 
-	// TODO(adonovan): don't rewrite cgo + generated files.
+	// Refuse to edit generated files, whose changes would be lost
+	// the next time they are generated, unless forced.
+	// TODO(adonovan): don't rewrite cgo files.
+	if !Force {
+		var ngen int
+		for _, info := range r.packages {
+			for _, f := range info.Files {
+				if tokenFile := r.iprog.Fset.File(f.Pos()); filesToUpdate[tokenFile] && info.IsGenerated(f) {
+					reportError(r.iprog.Fset.Position(f.Pos()),
+						"renaming would modify this generated file")
+					ngen++
+				}
+			}
+		}
+		if ngen > 0 {
+			return fmt.Errorf("renaming would modify %d generated file%s; use -force to proceed", ngen, plural(ngen))
+		}
+	}
+
 	var nerrs, npkgs int
 	for _, info := range r.packages {
 		first := true
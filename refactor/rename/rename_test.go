@@ -1022,6 +1022,40 @@ var _ = I(C(0)).(J)
 // Simplifying wrapper around buildutil.FakeContext for packages whose
 // filenames are sequentially numbered (%d.go).  pkgs maps a package
 // import path to its list of file contents.
+func TestGeneratedFiles(t *testing.T) {
+	defer func(savedRewriteFile func(*token.FileSet, *ast.File, string) error, savedReportError func(token.Position, string)) {
+		rewriteFile = savedRewriteFile
+		reportError = savedReportError
+	}(rewriteFile, reportError)
+
+	var rewritten []string
+	rewriteFile = func(fset *token.FileSet, f *ast.File, filename string) error {
+		rewritten = append(rewritten, filename)
+		return nil
+	}
+	var reported []string
+	reportError = func(posn token.Position, message string) {
+		reported = append(reported, fmt.Sprintf("%s: %s", posn.Filename, message))
+	}
+
+	ctxt := fakeContext(map[string][]string{
+		"main": {
+			"package main\n\nvar x int\n",
+			"// Code generated by test; DO NOT EDIT.\n\npackage main\n\nvar _ = x\n",
+		},
+	})
+	err := Main(ctxt, "", "main.x", "y")
+	if err == nil || !strings.Contains(err.Error(), "generated file") {
+		t.Errorf("Main: got error %v, want generated file error", err)
+	}
+	if want := "/go/src/main/1.go: renaming would modify this generated file"; len(reported) != 1 || reported[0] != want {
+		t.Errorf("reported %q, want %q", reported, want)
+	}
+	if rewritten != nil {
+		t.Errorf("files were rewritten despite error: %s", rewritten)
+	}
+}
+
 func fakeContext(pkgs map[string][]string) *build.Context {
 	pkgs2 := make(map[string]map[string]string)
 	for path, files := range pkgs {